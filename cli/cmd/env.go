@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/deploy"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	envCmd.AddCommand(envListCmd)
+
+	rootCmd.AddCommand(envCmd)
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Allows to inspect the environments created by the test runs",
+	Long:  "Allows to inspect the environments created by the test runs, identified by the run ID in the labels of their Docker containers",
+	Run: func(cmd *cobra.Command, args []string) {
+		// NOOP
+	},
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the environments created by the test runs",
+	Long:  "Lists the environments created by the test runs in the Docker host, printing their run ID, profile, age and the state of their containers",
+	Run: func(cmd *cobra.Command, args []string) {
+		environments, err := deploy.ListEnvironments()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("Could not list the environments")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RUN ID\tPROFILE\tAGE\tCONTAINERS")
+		for _, env := range environments {
+			states := []string{}
+			for _, container := range env.Containers {
+				states = append(states, container.Name+" ("+container.State+")")
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", env.RunID, env.Profile, time.Since(env.Created).Round(time.Second), strings.Join(states, ", "))
+		}
+		w.Flush()
+	},
+}
@@ -0,0 +1,72 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/elastic/e2e-testing/internal/deploy"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/spf13/cobra"
+)
+
+// shellCommand the command opening bash in the container, or sh in the images not including it
+const shellCommand = "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"
+
+var shellIndex int
+var shellUser string
+
+func init() {
+	shellCmd.Flags().IntVarP(&shellIndex, "index", "i", 1, "Sets the index of the container, for scaled services")
+	shellCmd.Flags().StringVarP(&shellUser, "user", "u", "", "Sets the user running the shell. Default: the user of the image")
+
+	rootCmd.AddCommand(shellCmd)
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell <profile> <service>",
+	Short: "Opens a shell in the container of a service",
+	Long:  "Opens an interactive shell in the running container of a service from a profile, resolving the name of the container",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		profile := args[0]
+		service := args[1]
+
+		containerName, err := deploy.GetServiceContainerName(profile, service, shellIndex)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"profile": profile,
+				"service": service,
+			}).Fatal("Could not find the container of the service")
+		}
+
+		dockerArgs := []string{"exec", "-it"}
+		if shellUser != "" {
+			dockerArgs = append(dockerArgs, "--user", shellUser)
+		}
+		dockerArgs = append(dockerArgs, containerName, "sh", "-c", shellCommand)
+
+		docker := exec.Command("docker", dockerArgs...)
+		docker.Stdin = os.Stdin
+		docker.Stdout = os.Stdout
+		docker.Stderr = os.Stderr
+
+		err = docker.Run()
+		if err != nil {
+			// the exit code of the last command in the shell is propagated
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				os.Exit(exitErr.ExitCode())
+			}
+
+			log.WithFields(log.Fields{
+				"container": containerName,
+				"error":     err,
+			}).Fatal("Could not open a shell in the container")
+		}
+	},
+}
@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/elastic/e2e-testing/internal/deploy"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/spf13/cobra"
+)
+
+var toBundle string
+var fromBundle string
+
+func init() {
+	stackExportCmd.Flags().StringVarP(&toBundle, "to-bundle", "b", "", "Sets the directory where to export the bundle. (Required)")
+	_ = stackExportCmd.MarkFlagRequired("to-bundle")
+
+	stackUpCmd.Flags().StringVarP(&fromBundle, "from-bundle", "b", "", "Sets the directory of the bundle to start. (Required)")
+	_ = stackUpCmd.MarkFlagRequired("from-bundle")
+
+	stackCmd.AddCommand(stackExportCmd)
+	stackCmd.AddCommand(stackUpCmd)
+
+	rootCmd.AddCommand(stackCmd)
+}
+
+var stackCmd = &cobra.Command{
+	Use:   "stack",
+	Short: "Allows to export and reproduce the environment of a run",
+	Long:  "Allows to export the environment of a run into a bundle, and to start it again from the bundle, in the same or in another Docker host",
+	Run: func(cmd *cobra.Command, args []string) {
+		// NOOP
+	},
+}
+
+var stackExportCmd = &cobra.Command{
+	Use:   "export <profile>",
+	Short: "Exports the environment of a running profile into a bundle",
+	Long: `Exports the environment of a running profile into a bundle, including the compose files, the environment values
+and the digests of the images of its containers
+
+Example:
+  go run main.go stack export fleet --to-bundle /tmp/failure-bundle
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, err := deploy.ExportBundle(context.Background(), args[0], toBundle)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"bundle":  toBundle,
+				"error":   err,
+				"profile": args[0],
+			}).Fatal("Could not export the environment of the profile")
+		}
+	},
+}
+
+var stackUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Starts the environment exported into a bundle",
+	Long: `Starts the environment exported into a bundle, using the same images, compose files and environment values
+
+Example:
+  go run main.go stack up --from-bundle /tmp/failure-bundle
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, err := deploy.UpBundle(context.Background(), fromBundle)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"bundle": fromBundle,
+				"error":  err,
+			}).Fatal("Could not start the environment from the bundle")
+		}
+	},
+}
@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+func (fts *FleetTestSuite) theLogLevelOfTheAgentIsSetTo(logLevel string) error {
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+
+	fts.AgentActionDate = time.Now().UTC()
+	action, err := fts.kibanaClient.SetAgentLogLevel(fts.currentContext, agentID, logLevel)
+	if err != nil {
+		return err
+	}
+	fts.AgentActionID = action.ID
+
+	log.WithFields(log.Fields{
+		"action":   action.ID,
+		"agentID":  agentID,
+		"logLevel": logLevel,
+	}).Info("The log level of the agent was changed")
+
+	return nil
+}
+
+// theAgentAcknowledgesTheAction waits for the agent to acknowledge the last action sent to it in the scenario
+func (fts *FleetTestSuite) theAgentAcknowledgesTheAction() error {
+	if fts.AgentActionID == "" {
+		return fmt.Errorf("no actions were sent to the agent in the scenario")
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	actionAckFn := func() error {
+		status, err := fts.kibanaClient.GetActionStatus(fts.currentContext, fts.AgentActionID)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		if status.Status == kibana.ActionStatusFailed || status.Status == kibana.ActionStatusExpired {
+			return backoff.Permanent(fmt.Errorf("the %s action is %s", fts.AgentActionID, status.Status))
+		}
+
+		if status.Status != kibana.ActionStatusComplete {
+			err := fmt.Errorf("the %s action is not acknowledged yet", fts.AgentActionID)
+
+			log.WithFields(log.Fields{
+				"acks":        status.NbAgentsAck,
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+				"status":      status.Status,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"action":      fts.AgentActionID,
+			"elapsedTime": exp.GetElapsedTime(),
+			"retries":     retryCount,
+		}).Info("The agent acknowledged the action")
+
+		return nil
+	}
+
+	return backoff.Retry(actionAckFn, exp)
+}
+
+// theAgentReportsTheLogLevel checks the log level in the metadata that the agent reports to Fleet
+func (fts *FleetTestSuite) theAgentReportsTheLogLevel(logLevel string) error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+
+	logLevelFn := func() error {
+		agent, err := fts.kibanaClient.GetAgentByHostname(fts.currentContext, manifest.Hostname)
+		if err != nil {
+			return err
+		}
+
+		reported := agent.LocalMetadata.Elastic.Agent.LogLevel
+		if !strings.EqualFold(reported, logLevel) {
+			return fmt.Errorf("the agent reports the %s log level, expected %s", reported, logLevel)
+		}
+
+		return nil
+	}
+
+	return backoff.Retry(logLevelFn, exp)
+}
+
+// theAgentLogsContainMessagesWithLevel checks the logs shipped by the agent since the last action sent to it
+func (fts *FleetTestSuite) theAgentLogsContainMessagesWithLevel(logLevel string) error {
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+
+	since := fts.ScenarioStartDate
+	if fts.AgentActionDate.After(since) {
+		since = fts.AgentActionDate
+	}
+
+	query := agentMonitoringQuery(agentID, since).MatchPhrase("log.level", logLevel).Map()
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	_, err = elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), agentLogsIndexPattern, query, 1, maxTimeout)
+
+	return err
+}
@@ -0,0 +1,127 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/installer"
+	log "github.com/sirupsen/logrus"
+)
+
+// theFileInTheAgentContainerContains checks the content of a file in the agent host, such as its configuration
+// or state files, includes the text
+func (fts *FleetTestSuite) theFileInTheAgentContainerContains(path string, text string) error {
+	content, err := installer.ReadFile(fts.currentContext, fts.agentInstaller(), path)
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(content, text) {
+		return fmt.Errorf("the '%s' file does not contain '%s': %s", path, text, content)
+	}
+
+	return nil
+}
+
+// theFileInTheAgentContainerDoesNotContain checks the content of a file in the agent host does not include
+// the text, i.e. because it was redacted
+func (fts *FleetTestSuite) theFileInTheAgentContainerDoesNotContain(path string, text string) error {
+	content, err := installer.ReadFile(fts.currentContext, fts.agentInstaller(), path)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(content, text) {
+		return fmt.Errorf("the '%s' file contains '%s'", path, text)
+	}
+
+	return nil
+}
+
+func (fts *FleetTestSuite) theFileExistsInTheAgentContainer(path string, state string) error {
+	if state != "exists" && state != "does not exist" {
+		return fmt.Errorf("'%s' is not a valid state for the file. Valid values are: exists, does not exist", state)
+	}
+
+	exists, err := installer.FileExists(fts.currentContext, fts.agentInstaller(), path)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"exists": exists,
+		"path":   path,
+	}).Trace("File checked in the agent container")
+
+	if exists && state == "does not exist" {
+		return fmt.Errorf("the '%s' file exists in the agent container", path)
+	}
+	if !exists && state == "exists" {
+		return fmt.Errorf("the '%s' file does not exist in the agent container", path)
+	}
+
+	return nil
+}
+
+// theAgentFilesHaveThesePermissions checks the permissions and ownership of the installed files of the agent,
+// as declared by a table with the path, mode, owner and group columns. Empty cells are not checked
+func (fts *FleetTestSuite) theAgentFilesHaveThesePermissions(table *godog.Table) error {
+	if len(table.Rows) == 0 {
+		return fmt.Errorf("the table of permissions is empty")
+	}
+
+	columns := []string{}
+	for _, cell := range table.Rows[0].Cells {
+		columns = append(columns, strings.TrimSpace(cell.Value))
+	}
+	if strings.Join(columns, ",") != "path,mode,owner,group" {
+		return fmt.Errorf("the table of permissions must have the path, mode, owner and group columns")
+	}
+
+	agentInstaller := fts.agentInstaller()
+
+	mismatches := []string{}
+	for _, row := range table.Rows[1:] {
+		path := strings.TrimSpace(row.Cells[0].Value)
+		expected := installer.FileStat{
+			Mode:  strings.TrimSpace(row.Cells[1].Value),
+			Owner: strings.TrimSpace(row.Cells[2].Value),
+			Group: strings.TrimSpace(row.Cells[3].Value),
+		}
+		if expected.Mode != "" {
+			expected.Mode = installer.NormalizeMode(expected.Mode)
+		}
+
+		actual, err := installer.StatFile(fts.currentContext, agentInstaller, path)
+		if err != nil {
+			return err
+		}
+
+		if (expected.Mode != "" && expected.Mode != actual.Mode) ||
+			(expected.Owner != "" && expected.Owner != actual.Owner) ||
+			(expected.Group != "" && expected.Group != actual.Group) {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected %+v, got %+v", path, expected, actual))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("the permissions of %d agent files are not the expected ones:\n%s", len(mismatches), strings.Join(mismatches, "\n"))
+	}
+
+	return nil
+}
+
+// agentInstaller returns the installer of the agent deployed in the scenario
+func (fts *FleetTestSuite) agentInstaller() deploy.ServiceOperator {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	return agentInstaller
+}
@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/agentlogs"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// agentLogsAllowlist the benign messages of the agent logs, read from the AGENT_LOGS_ALLOWLIST_FILE env var
+var agentLogsAllowlist *agentlogs.Allowlist
+
+func initAgentLogsAllowlist() {
+	path := shell.GetEnv("AGENT_LOGS_ALLOWLIST_FILE", "agent_logs_allowlist.yml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+
+	allowlist, err := agentlogs.Load(path)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  path,
+		}).Fatal("Could not load the allowlist of the agent logs")
+	}
+
+	agentLogsAllowlist = allowlist
+}
+
+// theAgentLogsContainNoEntries checks the logs shipped by the agent since the scenario started have no entries
+// with the level, i.e. ERROR, except the ones in the allowlist, surfacing the failures which do not change
+// the status of the agent
+func (fts *FleetTestSuite) theAgentLogsContainNoEntries(logLevel string) error {
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+
+	// the agent must be shipping its logs, otherwise there would be no entries because of a different failure
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	_, err = elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), agentLogsIndexPattern, agentMonitoringQuery(agentID, fts.ScenarioStartDate).Map(), 1, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	query := agentMonitoringQuery(agentID, fts.ScenarioStartDate).MatchPhrase("log.level", strings.ToLower(logLevel)).Map()
+	result, err := elasticsearch.Search(fts.currentContext, agentLogsIndexPattern, query)
+	if err != nil {
+		return err
+	}
+
+	entries := []string{}
+	for _, hit := range result["hits"].(map[string]interface{})["hits"].([]interface{}) {
+		source, _ := hit.(map[string]interface{})["_source"].(map[string]interface{})
+		message, _ := source["message"].(string)
+
+		if agentLogsAllowlist.Allows(message) {
+			log.WithFields(log.Fields{
+				"level":   logLevel,
+				"message": message,
+			}).Debug("Allowed entry in the agent logs")
+			continue
+		}
+
+		entries = append(entries, message)
+	}
+
+	if len(entries) > 0 {
+		return fmt.Errorf("the agent logs contain %d %s entries: %s", len(entries), logLevel, strings.Join(entries, "; "))
+	}
+
+	return nil
+}
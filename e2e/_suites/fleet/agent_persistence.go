@@ -0,0 +1,100 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// theAgentKeepsItsEnrollmentAfterTheHostRestart checks the agent started again with the host, checking in
+// to Fleet with the ID it had before the restart. A different agent for the host means that it was enrolled again
+func (fts *FleetTestSuite) theAgentKeepsItsEnrollmentAfterTheHostRestart() error {
+	if fts.HostRestartedDate.IsZero() {
+		return fmt.Errorf("the host was not restarted in the scenario")
+	}
+
+	return fts.waitForAgentToReconnect(fts.AgentIDBeforeRestart, fts.HostRestartedDate, "the restart of the host")
+}
+
+// theAgentResumesShippingItsLogsAfterTheHostRestart checks the agent sends its own logs again once the host
+// is restarted, as they are written as soon as the agent starts
+func (fts *FleetTestSuite) theAgentResumesShippingItsLogsAfterTheHostRestart() error {
+	if fts.HostRestartedDate.IsZero() {
+		return fmt.Errorf("the host was not restarted in the scenario")
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	query := agentMonitoringQuery(fts.AgentIDBeforeRestart, fts.HostRestartedDate).Map()
+
+	count, err := elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), monitoringIndexPattern("logs"), query, 1, maxTimeout)
+	if err != nil {
+		return fmt.Errorf("the agent did not ship its logs after the restart of the host: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"agentID": fts.AgentIDBeforeRestart,
+		"count":   count,
+	}).Debug("The agent ships its logs after the restart of the host")
+
+	return nil
+}
+
+// waitForAgentToReconnect waits for the agent of the host to check in to Fleet after an event that disconnected it,
+// failing if the agent for the host has a different ID than before the event, as it was enrolled again
+func (fts *FleetTestSuite) waitForAgentToReconnect(previousID string, since time.Time, event string) error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 3
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	agentReconnectedFn := func() error {
+		agent, err := fts.kibanaClient.GetAgentByHostnameFromList(fts.currentContext, manifest.Hostname)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		if agent.ID != previousID {
+			return backoff.Permanent(fmt.Errorf("the agent was enrolled again: its ID was %s and now is %s", previousID, agent.ID))
+		}
+
+		lastCheckin, _ := time.Parse(time.RFC3339, agent.LastCheckin)
+		if agent.Status != "online" || lastCheckin.Before(since) {
+			err := fmt.Errorf("the agent did not check in after %s yet", event)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"lastCheckin": agent.LastCheckin,
+				"retry":       retryCount,
+				"status":      agent.Status,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"agentID":     agent.ID,
+			"elapsedTime": exp.GetElapsedTime(),
+			"event":       event,
+			"lastCheckin": agent.LastCheckin,
+			"retries":     retryCount,
+		}).Info("The agent reconnected to Fleet without enrolling again")
+
+		return nil
+	}
+
+	return backoff.Retry(agentReconnectedFn, exp)
+}
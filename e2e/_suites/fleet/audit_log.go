@@ -0,0 +1,77 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/auth"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+func (fts *FleetTestSuite) theAuditLogContainsEvents(action string) error {
+	return fts.waitForAuditEvents(action, "")
+}
+
+func (fts *FleetTestSuite) theAuditLogContainsEventsByUser(action string, user string) error {
+	return fts.waitForAuditEvents(action, user)
+}
+
+// waitForAuditEvents waits for the audit log of Elasticsearch to contain events with the action, performed
+// by the user, since the current scenario started. An empty user matches any user
+func (fts *FleetTestSuite) waitForAuditEvents(action string, user string) error {
+	if common.Provider == "remote" {
+		return fmt.Errorf("the audit log is read from the logs of the Elasticsearch container, which is not deployed by the remote provider")
+	}
+
+	if auth.SecurityDisabled() {
+		return fmt.Errorf("the audit log is not enabled when the security of the stack is disabled")
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	auditEventsFn := func() error {
+		logs, err := deploy.GetContainerLogs(fts.currentContext, deploy.NewServiceRequest("elasticsearch"), fts.ScenarioStartDate)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		events := elasticsearch.FilterAuditEvents(elasticsearch.ParseAuditEvents(logs), action, user)
+		if len(events) == 0 {
+			err := fmt.Errorf("there aren't '%s' events in the audit log yet", action)
+
+			log.WithFields(log.Fields{
+				"action":      action,
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+				"user":        user,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"action":      action,
+			"elapsedTime": exp.GetElapsedTime(),
+			"events":      len(events),
+			"retries":     retryCount,
+			"user":        user,
+		}).Info("The audit log contains the events")
+
+		return nil
+	}
+
+	return backoff.Retry(auditEventsFn, exp)
+}
@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/elastic/e2e-testing/internal/stack"
+	log "github.com/sirupsen/logrus"
+)
+
+// getCapabilities returns the capabilities of the target stack, which are detected once per run
+func (fts *FleetTestSuite) getCapabilities(ctx context.Context) (stack.CapabilitySet, error) {
+	if fts.capabilities != nil {
+		return *fts.capabilities, nil
+	}
+
+	capabilities, err := stack.Capabilities(ctx, fts.kibanaClient)
+	if err != nil {
+		return stack.CapabilitySet{}, err
+	}
+	fts.capabilities = &capabilities
+
+	return capabilities, nil
+}
+
+// missingCapabilityReason returns why the scenario with the tags must be skipped because the stack lacks a
+// capability it requires. The stack is only probed for scenarios requiring capabilities
+func (fts *FleetTestSuite) missingCapabilityReason(ctx context.Context, tags []string) (string, error) {
+	requires := false
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, stack.RequireTagPrefix) {
+			requires = true
+			break
+		}
+	}
+	if !requires {
+		return "", nil
+	}
+
+	capabilities, err := fts.getCapabilities(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return capabilities.MissingReason(tags)
+}
+
+func (fts *FleetTestSuite) theStackSupportsTheCapability(capability string) error {
+	capabilities, err := fts.getCapabilities(fts.currentContext)
+	if err != nil {
+		return err
+	}
+
+	if !capabilities.Has(capability) {
+		log.WithFields(log.Fields{
+			"capability":    capability,
+			"kibanaVersion": capabilities.KibanaVersion,
+		}).Warn("The stack does not support the capability")
+		return godog.ErrPending
+	}
+
+	return nil
+}
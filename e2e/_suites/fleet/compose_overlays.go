@@ -0,0 +1,44 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// theStackUsesTheComposeOverlay bootstraps the runtime dependencies again, merging the compose overlay into
+// the profile, so that a scenario can override its services without a new profile
+func (fts *FleetTestSuite) theStackUsesTheComposeOverlay(overlay string) error {
+	fts.ComposeOverlays = append(fts.ComposeOverlays, overlay)
+
+	log.WithFields(log.Fields{
+		"overlays": fts.ComposeOverlays,
+	}).Info("Merging compose overlays into the profile")
+
+	return bootstrapFleet(context.Background(), fts.fleetProfile(), fts.getProfileEnv())
+}
+
+// restoreComposeOverlays bootstraps the runtime dependencies again without the compose overlays, if the
+// scenario used any. The services only defined by the overlays are not removed until the profile is stopped
+func (fts *FleetTestSuite) restoreComposeOverlays() {
+	if len(fts.ComposeOverlays) == 0 {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"overlays": fts.ComposeOverlays,
+	}).Info("Restoring the profile without compose overlays")
+
+	fts.ComposeOverlays = []string{}
+
+	err := bootstrapFleet(context.Background(), fts.fleetProfile(), fts.getProfileEnv())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not restore the profile without compose overlays")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/openapi"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// contractViolationsAtStart the number of violations of the Fleet OpenAPI specification found before
+// the current scenario started
+var contractViolationsAtStart int
+
+// contractValidation if the requests sent to the Fleet API are validated against its specification
+var contractValidation bool
+
+func initContractValidation() {
+	specPath := shell.GetEnv("FLEET_OPENAPI_SPEC", "")
+	if specPath == "" {
+		return
+	}
+
+	spec, err := openapi.Load(specPath)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"spec":  specPath,
+		}).Fatal("Could not load the Fleet OpenAPI specification")
+	}
+
+	kibana.EnableContractValidation(spec)
+	contractValidation = true
+
+	log.WithFields(log.Fields{
+		"spec": specPath,
+	}).Info("The requests sent to the Fleet API, and their responses, will be validated against the specification")
+}
+
+// startContractValidation marks the violations found from now on as the ones of the scenario
+func startContractValidation() {
+	contractViolationsAtStart = len(kibana.ContractViolations())
+}
+
+// checkContractValidation returns an error if the requests of the scenario, including its clean up, violated
+// the Fleet OpenAPI specification
+func checkContractValidation() error {
+	if !contractValidation {
+		return nil
+	}
+
+	violations := kibana.ContractViolations()[contractViolationsAtStart:]
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("the requests to the Fleet API violated its OpenAPI specification %d times: %s", len(violations), strings.Join(violations, "; "))
+}
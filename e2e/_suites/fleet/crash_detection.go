@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/agentlogs"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/systemd"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+const (
+	// crashDetectionOff the logs of the agent are not scanned
+	crashDetectionOff = "off"
+	// crashDetectionAnnotate the crashes are logged and added to the APM transaction of the scenario
+	crashDetectionAnnotate = "annotate"
+	// crashDetectionFail the crashes fail the scenario, even if its steps passed
+	crashDetectionFail = "fail"
+)
+
+// crashDetectionTimeout the max time reading the logs of the agent can take
+const crashDetectionTimeout = 2 * time.Minute
+
+// crashDetection what to do with the crashes found in the logs of the agent after each scenario.
+// It can be overriden by AGENT_CRASH_DETECTION env var
+var crashDetection = crashDetectionAnnotate
+
+func initCrashDetection() {
+	crashDetection = strings.ToLower(shell.GetEnv("AGENT_CRASH_DETECTION", crashDetectionAnnotate))
+
+	switch crashDetection {
+	case crashDetectionOff, crashDetectionAnnotate, crashDetectionFail:
+	default:
+		log.WithField("mode", crashDetection).Fatal("The crash detection mode is not valid. Valid values are: off, annotate, fail")
+	}
+}
+
+// checkAgentCrashes scans the logs of the agent since the scenario started for panics, fatal errors and
+// restart loops, before the agent is uninstalled. The logs are read with their own context, as the one of the
+// scenario is already cancelled. Only in the fail mode an error is returned, also when the logs cannot be read
+func (fts *FleetTestSuite) checkAgentCrashes() error {
+	if crashDetection == crashDetectionOff || fts.InstallerType == "" || fts.SkipReason != "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(apm.ContextWithTransaction(context.Background(), tx), crashDetectionTimeout)
+	defer cancel()
+
+	logs, err := fts.getAgentLogsSinceScenarioStart(ctx)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"scenario": fts.CurrentScenario,
+		}).Error("Could not get the agent logs to detect crashes")
+		if tx != nil {
+			tx.Context.SetLabel("agent_crashes_unknown", true)
+		}
+
+		if crashDetection != crashDetectionFail {
+			return nil
+		}
+
+		return fmt.Errorf("could not get the agent logs to detect crashes: %w", err)
+	}
+
+	crashes := agentlogs.ScanForCrashes(logs)
+	if len(crashes) == 0 {
+		return nil
+	}
+
+	descriptions := []string{}
+	for _, crash := range crashes {
+		descriptions = append(descriptions, crash.String())
+	}
+
+	log.WithFields(log.Fields{
+		"crashes":  descriptions,
+		"scenario": fts.CurrentScenario,
+	}).Warn("The agent crashed during the scenario")
+	if tx != nil {
+		tx.Context.SetLabel("agent_crashes", len(crashes))
+	}
+
+	if crashDetection != crashDetectionFail {
+		return nil
+	}
+
+	return fmt.Errorf("the agent crashed %d times during the scenario: %s", len(crashes), strings.Join(descriptions, "; "))
+}
+
+// getAgentLogsSinceScenarioStart returns the logs of the agent, including the output of the processes it runs,
+// from the container for the Docker image, or from the journal for the rest of the installers
+func (fts *FleetTestSuite) getAgentLogsSinceScenarioStart(ctx context.Context) (string, error) {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+
+	if fts.StandAlone || fts.InstallerType == "docker" {
+		return deploy.GetContainerLogs(ctx, agentService, fts.ScenarioStartDate)
+	}
+
+	agentInstaller, err := installer.Attach(ctx, fts.getDeployer(), agentService, fts.InstallerType)
+	if err != nil {
+		return "", err
+	}
+
+	cmds := append(systemd.LogCmds(common.ElasticAgentServiceName), "--no-pager", "--since", fmt.Sprintf("@%d", fts.ScenarioStartDate.Unix()))
+	return agentInstaller.Exec(ctx, cmds)
+}
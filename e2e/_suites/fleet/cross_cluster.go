@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// remoteClusterSeed the transport address of the remote cluster of the "ccs" compose overlay, as seen from
+// the main cluster
+const remoteClusterSeed = "elasticsearch-remote:9300"
+
+// matchAllQuery the query matching every document of an index
+var matchAllQuery = map[string]interface{}{
+	"query": map[string]interface{}{
+		"match_all": map[string]interface{}{},
+	},
+}
+
+// aDocumentIsIndexedInTheRemoteCluster indexes a document in the remote cluster, waiting for it to be started
+func (fts *FleetTestSuite) aDocumentIsIndexedInTheRemoteCluster(index string) error {
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	exp := utils.GetExponentialBackOff(maxTimeout)
+
+	document := map[string]interface{}{
+		"@timestamp": time.Now().UTC().Format(time.RFC3339),
+		"message":    "cross-cluster document of the " + naming.RunID() + " run",
+	}
+
+	retryCount := 1
+
+	indexFn := func() error {
+		err := elasticsearch.IndexDocument(fts.currentContext, elasticsearch.GetRemoteElasticSearchEndpoint(), index, document)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"index":       index,
+				"retry":       retryCount,
+			}).Warn("Could not index the document in the remote cluster")
+
+			retryCount++
+			return err
+		}
+
+		return nil
+	}
+
+	return backoff.Retry(indexFn, exp)
+}
+
+// theRemoteClusterIsConnected registers the remote cluster in the main one, waiting for them to be connected
+func (fts *FleetTestSuite) theRemoteClusterIsConnected() error {
+	esEndpoint := elasticsearch.GetElasticSearchEndpoint()
+
+	err := elasticsearch.ConfigureRemoteCluster(fts.currentContext, esEndpoint, elasticsearch.RemoteClusterAlias, []string{remoteClusterSeed})
+	if err != nil {
+		return err
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+
+	return elasticsearch.WaitForRemoteClusterConnected(fts.currentContext, esEndpoint, elasticsearch.RemoteClusterAlias, maxTimeout)
+}
+
+// theDocumentIsFoundSearchingAcrossClusters searches the index of the remote cluster from the main one
+func (fts *FleetTestSuite) theDocumentIsFoundSearchingAcrossClusters(index string) error {
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	remoteIndex := elasticsearch.RemoteIndexName(elasticsearch.RemoteClusterAlias, index)
+
+	hits, err := elasticsearch.WaitForNumberOfHits(fts.currentContext, remoteIndex, matchAllQuery, 1, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	return elasticsearch.AssertHitsComeFromCluster(hits, elasticsearch.RemoteClusterAlias)
+}
+
+// theIndexIsReplicatedFromTheRemoteCluster follows the index of the remote cluster from the main one, waiting
+// for the follower index to have the documents of the leader. A previous follower index is deleted first
+func (fts *FleetTestSuite) theIndexIsReplicatedFromTheRemoteCluster(index string) error {
+	esEndpoint := elasticsearch.GetElasticSearchEndpoint()
+	followerIndex := fmt.Sprintf("%s-follower", index)
+
+	err := elasticsearch.DeleteIndex(fts.currentContext, followerIndex)
+	if err != nil {
+		return err
+	}
+
+	err = elasticsearch.FollowIndex(fts.currentContext, esEndpoint, elasticsearch.RemoteClusterAlias, index, followerIndex)
+	if err != nil {
+		return err
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+
+	hits, err := elasticsearch.WaitForNumberOfHits(fts.currentContext, followerIndex, matchAllQuery, 1, maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	return elasticsearch.AssertHitsComeFromCluster(hits, "")
+}
@@ -0,0 +1,169 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// customLogsPackageName name of the package for the "Custom Logs" integration
+const customLogsPackageName = "log"
+
+func (fts *FleetTestSuite) theCustomLogsIntegrationIsAddedForTheFile(logFile string) error {
+	ctx := fts.currentContext
+
+	integration, err := fts.kibanaClient.GetIntegrationByPackageName(ctx, customLogsPackageName)
+	if err != nil {
+		return err
+	}
+
+	// the dataset determines the data stream the lines will be ingested into,
+	// so it must be unique per scenario to avoid reading documents from previous runs. Datasets cannot contain dashes
+	dataset := strings.ReplaceAll(naming.Name("e2e_custom_logs"), "-", "_")
+
+	packageDataStream := kibana.PackageDataStream{
+		Name:        naming.Name(integration.Name),
+		Description: integration.Title,
+		Namespace:   "default",
+		PolicyID:    fts.Policy.ID,
+		Enabled:     true,
+		Package:     integration,
+		Inputs:      customLogsInputs(logFile, dataset),
+	}
+
+	fts.policyChanged()
+	err = fts.kibanaClient.AddIntegrationToPolicy(ctx, packageDataStream)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":       err,
+			"file":      logFile,
+			"packageDS": packageDataStream,
+		}).Error("Unable to add the custom logs integration to policy")
+		return err
+	}
+
+	err = fts.kibanaClient.WaitForIntegrationInstalled(ctx, integration)
+	if err != nil {
+		return err
+	}
+
+	fts.CustomLogsDataset = dataset
+	fts.CustomLogsFile = logFile
+
+	log.WithFields(log.Fields{
+		"dataset": dataset,
+		"file":    logFile,
+		"policy":  fts.Policy.ID,
+	}).Info("Custom logs integration added to the policy")
+
+	return nil
+}
+
+func (fts *FleetTestSuite) linesAreWrittenToTheCustomLogsFile(lines int) error {
+	if fts.CustomLogsFile == "" {
+		return fmt.Errorf("the custom logs integration has not been added to the policy")
+	}
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, err := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < lines; i++ {
+		line := fmt.Sprintf("%s e2e custom log line %d", time.Now().UTC().Format(time.RFC3339), i)
+		cmd := []string{"sh", "-c", fmt.Sprintf("echo '%s' >> %s", line, fts.CustomLogsFile)}
+
+		_, err := agentInstaller.Exec(fts.currentContext, cmd)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"file":  fts.CustomLogsFile,
+				"line":  i,
+			}).Error("Could not write line to the custom logs file")
+			return err
+		}
+	}
+
+	fts.CustomLogsLines += lines
+
+	log.WithFields(log.Fields{
+		"file":  fts.CustomLogsFile,
+		"lines": lines,
+	}).Debug("Lines written to the custom logs file")
+
+	return nil
+}
+
+func (fts *FleetTestSuite) theCustomLogsLinesArePresentInTheDataStream() error {
+	if fts.CustomLogsDataset == "" {
+		return fmt.Errorf("the custom logs integration has not been added to the policy")
+	}
+
+	query := elasticsearch.NewBoolQuery().
+		Dataset(fts.CustomLogsDataset).
+		Term("log.file.path", fts.CustomLogsFile).
+		MatchPhrase("message", "e2e custom log line").
+		Map()
+
+	indexName := fmt.Sprintf("logs-%s-default", fts.CustomLogsDataset)
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	_, err := elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), indexName, query, fts.CustomLogsLines, maxTimeout)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn(elasticsearch.WaitForIndices())
+		return err
+	}
+
+	return nil
+}
+
+// customLogsInputs returns the logfile input for the "Custom Logs" package, reading from the file
+// passed as parameter and sending the lines to the given dataset
+func customLogsInputs(logFile string, dataset string) []kibana.Input {
+	return []kibana.Input{
+		{
+			Type:    "logfile",
+			Enabled: true,
+			Streams: []kibana.Stream{
+				{
+					ID:      naming.Name("logfile-log.log"),
+					Enabled: true,
+					DS: kibana.DataStream{
+						Dataset: "log.log",
+						Type:    "logs",
+					},
+					Vars: kibana.Vars{
+						"paths": {
+							Value: []string{logFile},
+							Type:  "text",
+						},
+						"data_stream.dataset": {
+							Value: dataset,
+							Type:  "text",
+						},
+						"custom": {
+							Value: "",
+							Type:  "yaml",
+						},
+					},
+				},
+			},
+		},
+	}
+}
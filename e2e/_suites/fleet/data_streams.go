@@ -0,0 +1,156 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+func (fts *FleetTestSuite) theBackingIndicesOfTheDataStreamAreInHealthStatus(dataStream string, status string) error {
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	_, err := elasticsearch.WaitForDataStreamHealth(fts.currentContext, dataStream, status, maxTimeout)
+	return err
+}
+
+func (fts *FleetTestSuite) theBackingIndicesOfTheDataStreamHavePrimaryShards(dataStream string, primaryShards int) error {
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	// all the primary shards must be active before counting them
+	healths, err := elasticsearch.WaitForDataStreamHealth(fts.currentContext, dataStream, "green", maxTimeout)
+	if err != nil {
+		return err
+	}
+
+	return elasticsearch.AssertIndicesHavePrimaryShards(healths, primaryShards)
+}
+
+// thereAreAtLeastDocumentsInTheIndexWithin waits for the index to have a number of documents, whatever they are
+func (fts *FleetTestSuite) thereAreAtLeastDocumentsInTheIndexWithin(minCount int, indexName string, timeout string) error {
+	return fts.waitForDocs(minCount, indexName, timeout, "")
+}
+
+// thereAreAtLeastDocumentsInTheIndexWithinMatching waits for the index to have a number of documents
+// matching the query in the doc string, which is either a query clause or a whole search body
+func (fts *FleetTestSuite) thereAreAtLeastDocumentsInTheIndexWithinMatching(minCount int, indexName string, timeout string, query *godog.DocString) error {
+	return fts.waitForDocs(minCount, indexName, timeout, query.Content)
+}
+
+func (fts *FleetTestSuite) waitForDocs(minCount int, indexName string, timeout string, queryJSON string) error {
+	maxTimeout, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid duration: %v", timeout, err)
+	}
+
+	count, err := elasticsearch.WaitForDocs(fts.currentContext, indexName, queryJSON, minCount, maxTimeout*time.Duration(utils.TimeoutFactor))
+	if err != nil {
+		return fmt.Errorf("expected at least %d documents in the %s index, found %d: %v", minCount, indexName, count, err)
+	}
+
+	return nil
+}
+
+// theDocumentsInTheIndexHaveDifferentValuesOf counts the documents ingested during the scenario per value of
+// the field, i.e. the agent.id, waiting for a number of different values
+func (fts *FleetTestSuite) theDocumentsInTheIndexHaveDifferentValuesOf(indexName string, minValues int, field string) error {
+	assertFn := func(buckets []elasticsearch.Bucket) error {
+		return elasticsearch.AssertNumberOfBuckets(buckets, minValues)
+	}
+
+	return fts.waitForBuckets(indexName, field, assertFn)
+}
+
+// theDocumentsInTheIndexHaveTheCountsBy counts the documents ingested during the scenario per value of the field,
+// waiting for the values in the table, which columns are the value and its minimum number of documents
+func (fts *FleetTestSuite) theDocumentsInTheIndexHaveTheCountsBy(indexName string, field string, table *godog.Table) error {
+	expected, err := parseBucketsTable(table)
+	if err != nil {
+		return err
+	}
+
+	assertFn := func(buckets []elasticsearch.Bucket) error {
+		return elasticsearch.AssertBucketCounts(buckets, expected)
+	}
+
+	return fts.waitForBuckets(indexName, field, assertFn)
+}
+
+func (fts *FleetTestSuite) waitForBuckets(indexName string, field string, assertFn func([]elasticsearch.Bucket) error) error {
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	query := elasticsearch.NewBoolQuery().Since(fts.ScenarioStartDate).Map()
+
+	buckets, err := elasticsearch.WaitForBuckets(fts.currentContext, indexName, query, field, assertFn, maxTimeout)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"buckets": buckets,
+			"field":   field,
+			"index":   indexName,
+		}).Error("The document counts do not match the expected ones")
+		return err
+	}
+
+	return nil
+}
+
+func parseBucketsTable(table *godog.Table) ([]elasticsearch.Bucket, error) {
+	if len(table.Rows) == 0 {
+		return nil, fmt.Errorf("the table of counts is empty")
+	}
+
+	header := table.Rows[0].Cells
+	if len(header) != 2 || strings.TrimSpace(header[0].Value) != "value" || strings.TrimSpace(header[1].Value) != "count" {
+		return nil, fmt.Errorf("the table of counts must have the value and count columns")
+	}
+
+	expected := []elasticsearch.Bucket{}
+	for _, row := range table.Rows[1:] {
+		count, err := strconv.Atoi(strings.TrimSpace(row.Cells[1].Value))
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid count of documents: %v", row.Cells[1].Value, err)
+		}
+
+		expected = append(expected, elasticsearch.Bucket{Key: strings.TrimSpace(row.Cells[0].Value), Count: count})
+	}
+
+	return expected, nil
+}
+
+// thereAreNoDuplicatedEventsInTheIndex checks the events ingested during the scenario were not indexed more
+// than once, i.e. resent after a restart or a network fault, comparing the whole events
+func (fts *FleetTestSuite) thereAreNoDuplicatedEventsInTheIndex(indexName string) error {
+	return fts.assertNoDuplicatedEvents(indexName, nil)
+}
+
+// thereAreNoDuplicatedEventsInTheIndexBy checks the events ingested during the scenario were not indexed more
+// than once, comparing the values of a comma-separated list of fields
+func (fts *FleetTestSuite) thereAreNoDuplicatedEventsInTheIndexBy(indexName string, fieldList string) error {
+	fields := []string{}
+	for _, field := range strings.Split(fieldList, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fts.assertNoDuplicatedEvents(indexName, fields)
+}
+
+func (fts *FleetTestSuite) assertNoDuplicatedEvents(indexName string, fields []string) error {
+	query := elasticsearch.NewBoolQuery().Since(fts.ScenarioStartDate).Map()
+
+	result, err := elasticsearch.Search(fts.currentContext, indexName, query)
+	if err != nil {
+		return err
+	}
+
+	return elasticsearch.AssertNoDuplicates(result, fields)
+}
@@ -0,0 +1,23 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"github.com/elastic/e2e-testing/internal/kibana"
+	log "github.com/sirupsen/logrus"
+)
+
+// reportKibanaDeprecations logs the deprecated Kibana API endpoints used by the run, which must be migrated
+// before they are removed from Kibana
+func reportKibanaDeprecations() {
+	for _, d := range kibana.Deprecations() {
+		log.WithFields(log.Fields{
+			"count":    d.Count,
+			"endpoint": d.Endpoint,
+			"message":  d.Message,
+			"method":   d.Method,
+		}).Warn("Deprecated Kibana API endpoint used by the run")
+	}
+}
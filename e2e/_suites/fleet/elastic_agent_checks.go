@@ -10,7 +10,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/deploy"
 	"github.com/elastic/e2e-testing/internal/elasticsearch"
@@ -85,7 +84,7 @@ func (fts *FleetTestSuite) checkDataStream() error {
 
 	indexName := "metrics-linux.memory-default"
 
-	_, err := elasticsearch.WaitForNumberOfHits(context.Background(), indexName, query, 1, 3*time.Minute)
+	_, err := elasticsearch.WaitForDocsInCluster(context.Background(), elasticsearch.GetElasticSearchEndpoint(), indexName, query, 1, 3*time.Minute)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
@@ -102,7 +101,7 @@ func (fts *FleetTestSuite) systemPackageDashboardsAreListedInFleet() error {
 	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
 	retryCount := 1
 
-	exp := utils.GetExponentialBackOff(maxTimeout)
+	exp := utils.GetExponentialBackOffForCheck("data streams", maxTimeout)
 
 	countDataStreamsFn := func() error {
 		dataStreams, err := fts.kibanaClient.GetDataStreams(fts.currentContext)
@@ -141,7 +140,7 @@ func (fts *FleetTestSuite) systemPackageDashboardsAreListedInFleet() error {
 		return nil
 	}
 
-	err := backoff.Retry(countDataStreamsFn, exp)
+	err := utils.RetryWithHeartbeat("the data streams to be listed in Fleet", countDataStreamsFn, exp)
 	if err != nil {
 		return err
 	}
@@ -187,7 +186,7 @@ func (fts *FleetTestSuite) tagsAreInTheElasticAgentIndex() error {
 
 	indexName := ".fleet-agents"
 
-	_, err := elasticsearch.WaitForNumberOfHits(context.Background(), indexName, query, 1, 3*time.Minute)
+	_, err := elasticsearch.WaitForDocsInCluster(context.Background(), elasticsearch.GetElasticSearchEndpoint(), indexName, query, 1, 3*time.Minute)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
@@ -204,6 +203,8 @@ func (fts *FleetTestSuite) theAgentIsListedInFleetWithStatus(desiredStatus strin
 		return err
 	}
 	if desiredStatus == "online" {
+		fts.recordEnrollment()
+
 		//get Agent Default Key
 		err := fts.theAgentGetDefaultAPIKey()
 		if err != nil {
@@ -353,7 +354,7 @@ func theAgentIsListedInFleetWithStatus(ctx context.Context, desiredStatus string
 	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
 	retryCount := 1
 
-	exp := utils.GetExponentialBackOff(maxTimeout)
+	exp := utils.GetExponentialBackOffForCheck("agent status", maxTimeout)
 
 	agentOnlineFn := func() error {
 		agentID, err := kibanaClient.GetAgentIDByHostname(ctx, hostname)
@@ -408,7 +409,7 @@ func theAgentIsListedInFleetWithStatus(ctx context.Context, desiredStatus string
 		return nil
 	}
 
-	err = backoff.Retry(agentOnlineFn, exp)
+	err = utils.RetryWithHeartbeat("the agent to be listed in Fleet as "+desiredStatus, agentOnlineFn, exp)
 	if err != nil {
 		return err
 	}
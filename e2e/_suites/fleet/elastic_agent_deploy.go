@@ -6,24 +6,34 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"runtime"
 
+	"github.com/elastic/e2e-testing/internal/benchmark"
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/deploy"
 	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/naming"
 	log "github.com/sirupsen/logrus"
 )
 
 var deployedAgentsCount = 0
 
-// this step infers the installer type from the underlying OS image
-// supported Docker images: centos and debian
+// this step infers the installer type from the underlying OS image, as declared in the OS images file.
+// Otherwise, the deb installer is used for debian and ubuntu, and the rpm installer for the rest, such as centos
 func (fts *FleetTestSuite) anAgentIsDeployedToFleet(image string) error {
 	installerType := "rpm"
-	if image == "debian" {
+	if osImage, found := osImages.Find(image); found {
+		installerType = osImage.Installer
+	} else if image == "debian" || image == "ubuntu" {
 		installerType = "deb"
 	}
 
+	if installerType == "zip" && (common.Provider != "remote" || runtime.GOOS != "windows") {
+		return fmt.Errorf("the %s agents can only be deployed with the remote provider on a Windows host, current provider is %s on %s", image, common.Provider, runtime.GOOS)
+	}
+
 	return fts.deployAgentToFleet(InstallerType(installerType))
 }
 
@@ -60,6 +70,7 @@ func (fts *FleetTestSuite) deployAgentToFleet(opts ...DeploymentOpt) error {
 	}).Trace("Deploying an agent to Fleet with base image using an already bootstrapped Fleet Server")
 
 	deployedAgentsCount++
+	fts.enrollmentTimer = benchmark.NewTimer()
 
 	fts.InstallerType = args.installerType
 	fts.BeatsProcess = args.beatsProcess
@@ -77,17 +88,32 @@ func (fts *FleetTestSuite) deployAgentToFleet(opts ...DeploymentOpt) error {
 		agentService,
 	}
 	env := fts.getProfileEnv()
-	err := fts.getDeployer().Add(fts.currentContext, deploy.NewServiceRequest(common.FleetProfileName), services, env)
+	// the hostname carries the run ID, identifying the agents of the run in Fleet
+	env["elasticAgentHostname"] = naming.Name("elastic-agent")
+	err := fts.getDeployer().Add(fts.currentContext, fts.fleetProfile(), services, env)
 	if err != nil {
 		return err
 	}
+	fts.enrollmentTimer.Lap(benchmark.PhaseDeploy)
+
+	if len(args.files) > 0 {
+		manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+		for _, file := range args.files {
+			err := deploy.CopyFileToContainer(fts.currentContext, manifest.Name, file, "/", false)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
-	err = deploymentLifecycle(fts.currentContext, agentInstaller, fts.CurrentToken, fts.ElasticAgentFlags)
+	err = deploymentLifecycle(fts.currentContext, agentInstaller, fts.CurrentToken, fts.ElasticAgentFlags, fts.enrollmentTimer)
 	if err != nil {
 		return err
 	}
-	return err
+
+	fts.recordDeployedAgent(agentService)
+	return nil
 }
 
 // DeploymentOpts options to be applied to a deployment of the elastic-agent
@@ -96,6 +122,7 @@ type DeploymentOpts struct {
 	boostrapFleetServer bool
 	installerType       string
 	flags               string
+	files               []string
 }
 
 // DeploymentOpt an option to be applied to a deployment of the elastic-agent
@@ -139,7 +166,27 @@ func Flags(flags string) DeploymentOpt {
 	}
 }
 
-func deploymentLifecycle(ctx context.Context, agentInstaller deploy.ServiceOperator, token string, flags string) error {
+// ProxyURL option to enroll the agent through a proxy, which is used for the communication with Fleet Server.
+// It overrides the Flags option. Default is empty
+func ProxyURL(proxyURL string) DeploymentOpt {
+	return func(args *DeploymentOpts) {
+		log.Tracef(">>> applying configuration to agent deployment [ProxyURL]: %s", proxyURL)
+		args.flags = "--proxy-url=" + proxyURL
+	}
+}
+
+// FleetServerTLS option to enroll the agent in a Fleet Server using TLS, verifying its certificate with the
+// certificate authority file, which is copied to the root of the agent host. It overrides the Flags option.
+// Default is the insecure enrollment in the Fleet Server of the stack
+func FleetServerTLS(fleetServerURL string, caFile string) DeploymentOpt {
+	return func(args *DeploymentOpts) {
+		log.Tracef(">>> applying configuration to agent deployment [FleetServerTLS]: %s", fleetServerURL)
+		args.flags = "--url=" + fleetServerURL + " --certificate-authorities=/" + filepath.Base(caFile)
+		args.files = append(args.files, caFile)
+	}
+}
+
+func deploymentLifecycle(ctx context.Context, agentInstaller deploy.ServiceOperator, token string, flags string, timer *benchmark.Timer) error {
 	err := agentInstaller.Preinstall(ctx)
 	if err != nil {
 		return err
@@ -149,11 +196,18 @@ func deploymentLifecycle(ctx context.Context, agentInstaller deploy.ServiceOpera
 	if err != nil {
 		return err
 	}
+	timer.Lap(benchmark.PhaseInstall)
+
+	err = enrollWithTimeout(ctx, agentInstaller, token, flags)
+	if err != nil {
+		return err
+	}
 
-	err = agentInstaller.Enroll(ctx, token, flags)
+	err = agentInstaller.Postinstall(ctx)
 	if err != nil {
 		return err
 	}
+	timer.Lap(benchmark.PhaseEnroll)
 
-	return agentInstaller.Postinstall(ctx)
+	return nil
 }
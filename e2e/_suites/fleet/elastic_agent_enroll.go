@@ -5,19 +5,104 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
 	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/shell"
 	"github.com/elastic/e2e-testing/internal/utils"
 	log "github.com/sirupsen/logrus"
 	"go.elastic.co/apm"
 )
 
+// enrollTimeout the max time the enrollment of an agent can take before the command is killed.
+// It can be overriden by ENROLL_TIMEOUT env var (i.e. 5m)
+var enrollTimeout time.Duration
+
+func initEnrollTimeout() {
+	enrollTimeout = shell.GetEnvDuration("ENROLL_TIMEOUT", time.Duration(utils.TimeoutFactor)*time.Minute*5)
+}
+
+// enrollWithTimeout enrolls the agent, killing the enroll command if it exceeds the enroll timeout, i.e. because
+// it's waiting for a Fleet Server which never replies. The diagnostics are collected in that case, as the agent
+// is left in an unknown state
+func enrollWithTimeout(ctx context.Context, agentInstaller deploy.ServiceOperator, token string, flags string) error {
+	enrollCtx, cancel := context.WithTimeout(ctx, enrollTimeout)
+	defer cancel()
+
+	err := agentInstaller.Enroll(enrollCtx, token, flags)
+	if err == nil || !(shell.TimedOut(err) || enrollCtx.Err() == context.DeadlineExceeded) {
+		return err
+	}
+
+	// the scenario could have been aborted, being its diagnostics already collected
+	if ctx.Err() == nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"timeout": enrollTimeout,
+		}).Error("The enrollment of the agent exceeded its timeout")
+
+		collectScenarioDiagnostics("enroll timeout")
+	}
+
+	return fmt.Errorf("the enrollment of the agent did not finish in %s: %w", enrollTimeout, err)
+}
+
+// expectEnrollmentFailure checks the error returned by the deployment of an agent comes from its enrollment,
+// keeping the output of the failed enroll command, which is checked by the next steps
+func (fts *FleetTestSuite) expectEnrollmentFailure(err error) error {
+	if err == nil {
+		return fmt.Errorf("the agent was enrolled although its enrollment was expected to fail")
+	}
+
+	// the enroll command must exit with a non-zero code, to distinguish it from the commands which could not be run
+	exitCode := shell.ExitCode(err)
+	if exitCode <= 0 {
+		return fmt.Errorf("the enrollment did not exit with a non-zero code (%d): %v", exitCode, err)
+	}
+
+	stdout, stderr := shell.CommandOutput(err)
+	fts.EnrollmentFailureOutput = stdout + "\n" + stderr
+
+	log.WithFields(log.Fields{
+		"err":      err,
+		"exitCode": exitCode,
+		"stderr":   stderr,
+		"stdout":   stdout,
+	}).Debug("As expected, the enrollment of the agent failed")
+
+	return nil
+}
+
 func (fts *FleetTestSuite) anAttemptToEnrollANewAgentFails() error {
+	_, err := fts.enrollNewAgentWithRevokedToken()
+	return err
+}
+
+// anAttemptToEnrollANewAgentFailsWithTheMessage checks the output of the failed enroll command, which is
+// what the users see when enrolling with a revoked token
+func (fts *FleetTestSuite) anAttemptToEnrollANewAgentFailsWithTheMessage(message string) error {
+	output, err := fts.enrollNewAgentWithRevokedToken()
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(output, message) {
+		return fmt.Errorf("the output of the failed enrollment does not contain the '%s' message: %s", message, output)
+	}
+
+	return nil
+}
+
+// enrollNewAgentWithRevokedToken deploys a new agent with the revoked token, returning the output of the enroll
+// command, and an error if it did not fail as expected
+func (fts *FleetTestSuite) enrollNewAgentWithRevokedToken() (string, error) {
 	log.Trace("Enrolling a new agent with an revoked token")
 
 	serviceName := common.ElasticAgentServiceName
@@ -26,51 +111,239 @@ func (fts *FleetTestSuite) anAttemptToEnrollANewAgentFails() error {
 	err := agentInstaller.Uninstall(fts.currentContext)
 	if err != nil {
 		log.Errorf("could not uninstall the current agent: %v", err)
-		return err
+		return "", err
 	}
 
-	err = fts.deployAgentToFleet(InstallerType(fts.InstallerType))
-	if err == nil {
-		err = fmt.Errorf("the agent was enrolled although the token was previously revoked")
-
+	err = fts.expectEnrollmentFailure(fts.deployAgentToFleet(InstallerType(fts.InstallerType)))
+	if err != nil {
 		log.WithFields(log.Fields{
 			"tokenID": fts.CurrentTokenID,
 			"error":   err,
-		}).Error(err.Error())
-		return err
+		}).Error("The enrollment with a revoked token did not fail as expected")
+		return "", err
 	}
 
-	// checking the error message produced by the install command in TAR installer
-	// to distinguish from other install errors
-	if err != nil && strings.Contains(err.Error(), "Error: enroll command failed") {
-		log.WithFields(log.Fields{
-			"err":   err,
-			"token": fts.CurrentToken,
-		}).Debug("As expected, it's not possible to enroll an agent with a revoked token")
-		return nil
-	}
-
-	return nil
+	return fts.EnrollmentFailureOutput, nil
 }
 
 func (fts *FleetTestSuite) theAgentIsUnenrolled() error {
+	// the unenrolled agents are not listed anymore, so the identity of the agent is recorded before
+	fts.recordAgentBeforeReenroll()
+
 	return fts.unenrollHostname()
 }
 
 func (fts *FleetTestSuite) theAgentIsReenrolledOnTheHost() error {
 	log.Trace("Re-enrolling the agent on the host with same token")
 
+	if fts.AgentIDBeforeReenroll == "" {
+		fts.recordAgentBeforeReenroll()
+	}
+
 	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
 	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
 
-	err := agentInstaller.Enroll(fts.currentContext, fts.CurrentToken, fts.ElasticAgentFlags)
+	err := enrollWithTimeout(fts.currentContext, agentInstaller, fts.CurrentToken, fts.ElasticAgentFlags)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// recordAgentBeforeReenroll keeps the ID and the output API keys of the agent listed in Fleet for the host, so that
+// the identity of the agent can be checked after it's re-enrolled
+func (fts *FleetTestSuite) recordAgentBeforeReenroll() {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	agent, err := fts.kibanaClient.GetAgentByHostnameFromList(fts.currentContext, manifest.Hostname)
+	if err != nil || agent.ID == "" {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"hostname": manifest.Hostname,
+		}).Warn("Could not record the agent before the re-enrollment")
+		return
+	}
+
+	fts.AgentIDBeforeReenroll = agent.ID
+	fts.APIKeyIDsBeforeReenroll = []string{}
+	for _, output := range agent.Outputs {
+		fts.APIKeyIDsBeforeReenroll = append(fts.APIKeyIDsBeforeReenroll, output.APIKeyID)
+	}
+}
+
+// theReenrolledAgentIsAnAgentInFleet checks whether Fleet created a new agent for the re-enrolled one, or it reused
+// the agent enrolled before
+func (fts *FleetTestSuite) theReenrolledAgentIsAnAgentInFleet(identity string) error {
+	if fts.AgentIDBeforeReenroll == "" {
+		return fmt.Errorf("the agent was not recorded before the re-enrollment")
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	agentID := ""
+	reenrolledFn := func() error {
+		id, err := fts.getAgentID()
+		if err == nil && id == "" {
+			err = fmt.Errorf("the re-enrolled agent is not listed in Fleet yet")
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"retry":       retryCount,
+			}).Warn("Could not get the re-enrolled agent")
+
+			retryCount++
+			return err
+		}
+
+		agentID = id
+		return nil
+	}
+
+	err := backoff.Retry(reenrolledFn, exp)
+	if err != nil {
+		return err
+	}
+
+	if identity == "new" && agentID == fts.AgentIDBeforeReenroll {
+		return fmt.Errorf("the re-enrolled agent reuses the ID of the agent enrolled before: %s", agentID)
+	}
+	if identity == "reused" && agentID != fts.AgentIDBeforeReenroll {
+		return fmt.Errorf("the re-enrolled agent was enrolled with the %s ID, expected %s", agentID, fts.AgentIDBeforeReenroll)
+	}
+
+	log.WithFields(log.Fields{
+		"agentID":         agentID,
+		"previousAgentID": fts.AgentIDBeforeReenroll,
+	}).Debugf("The re-enrolled agent is a %s agent in Fleet", identity)
+
+	return nil
+}
+
+// theAPIKeysBeforeTheReenrollmentAreInvalidated checks the output API keys of the agent enrolled before cannot be
+// used anymore, as Fleet invalidates them asynchronously
+func (fts *FleetTestSuite) theAPIKeysBeforeTheReenrollmentAreInvalidated() error {
+	if len(fts.APIKeyIDsBeforeReenroll) == 0 {
+		return fmt.Errorf("the API keys of the agent were not recorded before the re-enrollment")
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	invalidatedFn := func() error {
+		for _, ID := range fts.APIKeyIDsBeforeReenroll {
+			key, err := elasticsearch.GetAPIKey(fts.currentContext, ID)
+			if err == nil && !key.Invalidated {
+				err = fmt.Errorf("the %s API key is not invalidated", ID)
+			}
+			if err != nil {
+				log.WithFields(log.Fields{
+					"elapsedTime": exp.GetElapsedTime(),
+					"error":       err,
+					"retry":       retryCount,
+				}).Warn("The API keys of the agent are not invalidated yet")
+
+				retryCount++
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return backoff.Retry(invalidatedFn, exp)
+}
+
+// useDefaultEnrollmentToken if enabled, the scenarios enroll the agents with the policy's default enrollment
+// token instead of creating a new one. It can be enabled with the FLEET_USE_DEFAULT_ENROLLMENT_TOKEN env var
+var useDefaultEnrollmentToken = false
+
+// thePolicysDefaultEnrollmentTokenIsUsed replaces the enrollment token created for the scenario with the
+// default one, created by Fleet together with the policy, as most users do
+func (fts *FleetTestSuite) thePolicysDefaultEnrollmentTokenIsUsed() error {
+	if fts.CurrentTokenID != "" && !fts.UsingDefaultToken {
+		err := fts.kibanaClient.DeleteEnrollmentAPIKey(fts.currentContext, fts.CurrentTokenID)
+		if err != nil {
+			return err
+		}
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	defaultTokenFn := func() error {
+		enrollmentKey, err := fts.kibanaClient.GetDefaultEnrollmentAPIKey(fts.currentContext, fts.Policy)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"policy":      fts.Policy.ID,
+				"retry":       retryCount,
+			}).Warn("The default enrollment token is not available yet")
+
+			retryCount++
+			return err
+		}
+
+		fts.CurrentToken = enrollmentKey.APIKey
+		fts.CurrentTokenID = enrollmentKey.ID
+		fts.UsingDefaultToken = true
+		return nil
+	}
+
+	err := backoff.Retry(defaultTokenFn, exp)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"policy":  fts.Policy.ID,
+		"tokenID": fts.CurrentTokenID,
+	}).Debug("Using the policy's default enrollment token")
+
+	return nil
+}
+
+func (fts *FleetTestSuite) aNewEnrollmentTokenIsCreated() error {
+	enrollmentKey, err := fts.kibanaClient.CreateEnrollmentAPIKey(fts.currentContext, fts.Policy)
 	if err != nil {
 		return err
 	}
 
+	fts.CurrentToken = enrollmentKey.APIKey
+	fts.CurrentTokenID = enrollmentKey.ID
+	fts.UsingDefaultToken = false
+
+	log.WithFields(log.Fields{
+		"policy":  fts.Policy.ID,
+		"tokenID": fts.CurrentTokenID,
+	}).Debug("New enrollment token created")
+
 	return nil
 }
 
+// thePreviouslyBlockedAgentIsEnrolledWithTheNewToken enrolls the agent that could not be enrolled with the
+// revoked token, which is the last one deployed, using the current token
+func (fts *FleetTestSuite) thePreviouslyBlockedAgentIsEnrolledWithTheNewToken() error {
+	log.WithFields(log.Fields{
+		"tokenID": fts.CurrentTokenID,
+	}).Trace("Enrolling the previously blocked agent with the new token")
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName).
+		WithScale(deployedAgentsCount).
+		WithVersion(fts.Version)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	return enrollWithTimeout(fts.currentContext, agentInstaller, fts.CurrentToken, fts.ElasticAgentFlags)
+}
+
 func (fts *FleetTestSuite) theEnrollmentTokenIsRevoked() error {
 	log.WithFields(log.Fields{
 		"token":   fts.CurrentToken,
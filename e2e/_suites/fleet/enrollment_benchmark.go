@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/benchmark"
+	"github.com/elastic/e2e-testing/internal/config"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// enrollmentRecorder stores how long the agents take to be online in Fleet, per OS, architecture and installer
+var enrollmentRecorder *benchmark.Recorder
+
+// benchmarkResultsDir the directory where the results of the benchmarks of the run are stored
+var benchmarkResultsDir string
+
+// benchmarkRunID identifies the files with the results of the benchmarks of the run
+var benchmarkRunID string
+
+func initEnrollmentBenchmark() {
+	benchmarkResultsDir = shell.GetEnv("BENCHMARK_RESULTS_DIR", filepath.Join(config.OpDir(), "benchmarks"))
+	benchmarkRunID = shell.GetEnv("BENCHMARK_RUN_ID", time.Now().UTC().Format("20060102T150405"))
+
+	recorder, err := benchmark.NewRecorder(benchmarkResultsDir, benchmarkRunID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"dir":   benchmarkResultsDir,
+			"error": err,
+		}).Warn("Could not initialise the enrollment benchmark. Its results won't be stored")
+		return
+	}
+
+	enrollmentRecorder = recorder
+}
+
+// recordEnrollment stores the phases of the enrollment of the current agent, once it is online
+func (fts *FleetTestSuite) recordEnrollment() {
+	if enrollmentRecorder == nil || fts.enrollmentTimer == nil {
+		return
+	}
+
+	defer func() {
+		fts.enrollmentTimer = nil
+	}()
+
+	fts.enrollmentTimer.Lap(benchmark.PhaseOnline)
+
+	result := fts.enrollmentTimer.Result(fts.CurrentScenario, fts.InstallerType, runtime.GOOS, utils.GetArchitecture(), fts.Version)
+
+	err := enrollmentRecorder.Record(result)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  enrollmentRecorder.Path(),
+		}).Warn("Could not store the enrollment benchmark")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"arch":      result.Arch,
+		"installer": result.Installer,
+		"os":        result.OS,
+		"phases":    result.Phases,
+		"total":     fmt.Sprintf("%.1fs", result.Total),
+	}).Info("Enrollment benchmark recorded")
+}
+
+// reportEnrollmentBenchmark logs the summary of the enrollments of the run, by OS, architecture and installer
+func reportEnrollmentBenchmark() {
+	if enrollmentRecorder == nil {
+		return
+	}
+
+	for _, s := range enrollmentRecorder.Summary() {
+		log.WithFields(log.Fields{
+			"arch":      s.Arch,
+			"count":     s.Count,
+			"installer": s.Installer,
+			"max":       fmt.Sprintf("%.1fs", s.Max),
+			"mean":      fmt.Sprintf("%.1fs", s.Mean),
+			"min":       fmt.Sprintf("%.1fs", s.Min),
+			"os":        s.OS,
+		}).Info("Enrollment benchmark summary")
+	}
+
+	log.WithField("path", enrollmentRecorder.Path()).Info("Enrollment benchmark results stored")
+}
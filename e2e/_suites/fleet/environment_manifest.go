@@ -0,0 +1,128 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/config"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/manifest"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/pkg/downloads"
+	log "github.com/sirupsen/logrus"
+)
+
+// environmentManifestFileName the name of the file of the manifest, when copied next to other reports
+const environmentManifestFileName = "environment-manifest.json"
+
+// environmentManifest the environment where the run is executed, captured when the suite starts
+var environmentManifest *manifest.Manifest
+
+// environmentManifestDir the directory where the manifests of the runs are stored, by run ID.
+// It can be overriden by ENVIRONMENT_MANIFEST_DIR env var
+var environmentManifestDir string
+
+// dockerProbe retrieves the details of the environment from Docker and Docker Compose, which run the services
+type dockerProbe struct{}
+
+// ToolVersions returns the versions of the Docker server and Docker Compose, empty if they cannot be retrieved
+func (p dockerProbe) ToolVersions(ctx context.Context) map[string]string {
+	return map[string]string{
+		"docker":         toolVersion(ctx, "docker", "version", "--format", "{{.Server.Version}}"),
+		"docker-compose": toolVersion(ctx, "docker-compose", "version", "--short"),
+	}
+}
+
+// ImageDigests returns the digests of the images present in the Docker host
+func (p dockerProbe) ImageDigests(ctx context.Context, images []string) map[string]string {
+	return deploy.GetImageDigests(ctx, images)
+}
+
+func toolVersion(ctx context.Context, command string, args ...string) string {
+	output, err := shell.Execute(ctx, ".", command, args...)
+	if err != nil {
+		return ""
+	}
+
+	return output
+}
+
+// captureEnvironmentManifest captures the manifest of the environment once the images of the stack are pulled,
+// storing it for the run
+func captureEnvironmentManifest(ctx context.Context, images []string) {
+	environmentManifestDir = shell.GetEnv("ENVIRONMENT_MANIFEST_DIR", filepath.Join(config.OpDir(), "manifests"))
+
+	versions := map[string]string{
+		"agent":  common.ElasticAgentVersion,
+		"beats":  common.BeatVersion,
+		"kibana": common.KibanaVersion,
+		"stack":  common.StackVersion,
+	}
+	environmentManifest = manifest.Capture(ctx, "fleet", versions, images, dockerProbe{})
+
+	writeEnvironmentManifest(environmentManifestPath())
+}
+
+// environmentManifestLabels returns the environment details including the ones in the manifest, if captured
+func environmentManifestLabels(env map[string]string) map[string]string {
+	labels := map[string]string{}
+	for k, v := range env {
+		labels[k] = v
+	}
+
+	if environmentManifest == nil {
+		return labels
+	}
+
+	for k, v := range environmentManifest.Labels() {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// reportEnvironmentManifest updates the manifest with the artifacts downloaded by the run, storing it again,
+// together with the results of the benchmarks, so that they can be compared across runs
+func reportEnvironmentManifest() {
+	if environmentManifest == nil {
+		return
+	}
+
+	environmentManifest.AddArtifacts(downloads.DownloadedArtifacts())
+	writeEnvironmentManifest(environmentManifestPath())
+
+	if enrollmentRecorder != nil {
+		writeEnvironmentManifest(filepath.Join(benchmarkResultsDir, benchmarkRunID+"-"+environmentManifestFileName))
+	}
+
+	log.WithFields(log.Fields{
+		"artifacts": len(environmentManifest.Artifacts),
+		"path":      environmentManifestPath(),
+	}).Info("Environment manifest stored")
+}
+
+// writeEnvironmentManifest stores the manifest in the file, if captured. Errors are logged, as the manifest
+// must not break the run
+func writeEnvironmentManifest(file string) {
+	if environmentManifest == nil {
+		return
+	}
+
+	err := environmentManifest.Write(file)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  file,
+		}).Warn("Could not store the environment manifest")
+	}
+}
+
+func environmentManifestPath() string {
+	return filepath.Join(environmentManifestDir, naming.RunID()+".json")
+}
@@ -0,0 +1,54 @@
+@agent_monitoring
+Feature: Agent Monitoring
+  Scenarios for the monitoring settings of the policy, which decide if the Agent sends its own logs and metrics.
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+
+@monitoring-enabled
+Scenario Outline: The agent sends its logs and metrics
+  Given an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the "logs" monitoring data streams of the agent have data
+    And the "metrics" monitoring data streams of the agent have data
+
+@disable-logs-monitoring
+Scenario Outline: Disabling the logs monitoring in the policy
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the agent monitoring of "logs" is "disabled" in the policy
+  Then the agent applies the latest revision of the policy
+    And the "logs" monitoring data streams of the agent stop receiving data
+    And the "metrics" monitoring data streams of the agent have data
+
+@disable-metrics-monitoring
+Scenario Outline: Disabling the metrics monitoring in the policy
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the agent monitoring of "metrics" is "disabled" in the policy
+  Then the agent applies the latest revision of the policy
+    And the "metrics" monitoring data streams of the agent stop receiving data
+    And the "logs" monitoring data streams of the agent have data
+
+@enable-metrics-monitoring
+Scenario Outline: Enabling the metrics monitoring again in the policy
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+    And the agent monitoring of "metrics" is "disabled" in the policy
+    And the "metrics" monitoring data streams of the agent stop receiving data
+  When the agent monitoring of "metrics" is "enabled" in the policy
+  Then the "metrics" monitoring data streams of the agent have data
+
+@agent-logs
+Scenario Outline: The agent ships its own lifecycle logs
+  Given an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the agent logs contain the "Elastic Agent started" message
+
+@agent-logs-restart
+Scenario Outline: The agent ships its lifecycle logs after a restart
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the "elastic-agent" process is "restarted" on the host
+  Then the agent is listed in Fleet as "online"
+    And the agent logs contain the "Shutting down Elastic Agent and sending last events" message
@@ -0,0 +1,23 @@
+@audit_log
+Feature: Audit Log
+  Scenarios checking that the Fleet flows leave the expected security trail in the audit log of Elasticsearch.
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+
+@enroll
+Scenario Outline: Enrolling the agent creates its API keys
+  Given an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the audit log contains "create_apikey" events by "elastic/fleet-server"
+
+@create-token
+Scenario Outline: Creating an enrollment token creates its API key
+  When a new enrollment token is created
+  Then the audit log contains "create_apikey" events
+
+@revoke-token @destructive
+Scenario Outline: Revoking the enrollment token invalidates its API key
+  Given an agent is deployed to Fleet with "tar" installer
+  When the enrollment token is revoked
+  Then the audit log contains "invalidate_apikeys" events
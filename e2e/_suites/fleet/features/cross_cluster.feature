@@ -0,0 +1,17 @@
+@cross_cluster
+Feature: Cross-cluster search and replication
+  Scenarios for a main Elasticsearch cluster connected to a remote cluster, using the "ccs" compose overlay
+
+@cross-cluster-search
+Scenario: Searching the documents of a remote cluster
+  Given the stack uses the "ccs" compose overlay
+    And a document is indexed in the "e2e-ccs" index of the remote cluster
+  When the remote cluster is connected
+  Then the document is found searching the "e2e-ccs" index across clusters
+
+@cross-cluster-replication
+Scenario: Replicating an index of a remote cluster
+  Given the stack uses the "ccs" compose overlay
+    And a document is indexed in the "e2e-ccs" index of the remote cluster
+  When the remote cluster is connected
+  Then the "e2e-ccs" index is replicated from the remote cluster
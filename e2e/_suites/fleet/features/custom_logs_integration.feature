@@ -0,0 +1,10 @@
+@custom_logs_integration
+Feature: Custom Logs Integration
+  Scenarios for the Custom Logs integration, reading log files from the host
+
+Scenario Outline: Adding the Custom Logs Integration to an Agent ...
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the "Custom Logs" integration is added in the policy for the "/var/log/e2e-custom.log" file
+    And "10" lines are written to the custom log file
+  Then the custom log lines are present in the data stream
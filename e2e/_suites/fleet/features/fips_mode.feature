@@ -0,0 +1,22 @@
+@fips_mode
+Feature: FIPS Mode
+  Scenarios for the Agent in Fleet mode connecting to a stack running in FIPS 140-2 mode. The stack runs in
+  FIPS mode when the FIPS_MODE environment variable is enabled.
+
+Background: Setting up the stack in FIPS mode
+  Given kibana uses "default" profile
+    And the stack runs in FIPS mode
+
+@enroll
+Scenario Outline: Enrolling the agent in a stack in FIPS mode
+  Given an agent is deployed to Fleet with "tar" installer
+  When the "elastic-agent" process is in the "started" state on the host
+  Then the agent is listed in Fleet as "online"
+    And system package dashboards are listed in Fleet
+
+@ship-data
+Scenario Outline: Shipping data to a stack in FIPS mode
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the "Linux" integration is "added" in the policy
+  Then a Linux data stream exists with some data
@@ -4,6 +4,8 @@ Feature: Fleet Mode
 
 Background: Setting up kibana instance with the default profile
   Given kibana uses "default" profile
+    And the Fleet setup is complete
+    And no agents are enrolled
 
 @install
 Scenario Outline: Deploying the agent
@@ -11,13 +13,48 @@ Scenario Outline: Deploying the agent
   When the "elastic-agent" process is in the "started" state on the host
   Then the agent is listed in Fleet as "online"
     And system package dashboards are listed in Fleet
+    And the agent logs contain no "ERROR" entries
+    And the file "/opt/Elastic/Agent/elastic-agent.yml" in the agent container contains "fleet"
+    And the agent files have these permissions:
+      | path                                 | mode | owner | group |
+      | /opt/Elastic/Agent                   |      | root  | root  |
+      | /opt/Elastic/Agent/data              |      | root  | root  |
+      | /opt/Elastic/Agent/elastic-agent     | 755  | root  | root  |
+      | /opt/Elastic/Agent/elastic-agent.yml | 600  | root  | root  |
 
 @install-including-tags
 Scenario Outline: Deploying the agent including command line --tag for tags
+  Given the stack supports the "agent-tags" capability
   When an agent is deployed to Fleet with "tar" installer and "--tag=production,linux" flags
   Then the agent is listed in Fleet as "online"
     And the elastic agent index contains the tags
 
+@deploy-os
+Scenario Outline: Deploying the agent on <os>
+  Given a "<os>" agent is deployed to Fleet
+  When the "elastic-agent" process is in the "started" state on the host
+  Then the agent is listed in Fleet as "online"
+    And the agent logs contain no "ERROR" entries
+
+@os_images
+Examples: OS images
+| os |
+
+@systemd-unit
+Scenario Outline: Keeping the agent unit active on <os>
+  Given a "<os>" agent is deployed to Fleet
+  Then the "elastic-agent" systemd unit is enabled
+    And the "elastic-agent" systemd unit is active
+  When the "elastic-agent" systemd unit is restarted
+  Then the "elastic-agent" systemd unit is active
+  When the agent container is rebooted
+  Then the "elastic-agent" systemd unit is active
+    And the agent is listed in Fleet as "online"
+
+@os_images
+Examples: OS images
+| os |
+
 # @enroll
 # Scenario Outline: Deploying the agent with enroll and then run on rpm and deb
 #   Given an agent is deployed to Fleet
@@ -35,19 +72,59 @@ Scenario Outline: Deploying the agent including command line --tag for tags
 # | os     |
 # | debian |
 
+@default-token
+Scenario Outline: Deploying the agent with the policy's default enrollment token
+  Given the policy's default enrollment token is used
+  When an agent is deployed to Fleet with "tar" installer
+  Then the agent is listed in Fleet as "online"
+
+@multiple-agents
+Scenario: Deploying several agents with the same enrollment token
+  When "2" agents are deployed to Fleet
+  Then all the deployed agents are listed in Fleet as "online"
+    And the deployed agents are enrolled as different agents
+    And all the deployed agents run the policy
+
+@reassign-policy
+Scenario: Reassigning the agent to a different policy
+  Given an agent is deployed to Fleet with "tar" installer
+  When the agent is assigned to the "second-policy" policy
+  Then the agent is listed in Fleet as "online"
+
+@compose-overlay
+Scenario: Deploying an agent to a stack with a compose overlay
+  Given the stack uses the "kibana-debug-logs" compose overlay
+  When an agent is deployed to Fleet with "tar" installer
+  Then the agent is listed in Fleet as "online"
+
 @restart-agent
 Scenario Outline: Restarting the installed agent
   Given an agent is deployed to Fleet with "tar" installer
   When the "elastic-agent" process is "restarted" on the host
   Then the agent is listed in Fleet as "online"
 
-@unenroll
+@restart-host-persistence
+Scenario Outline: Restarting the host keeps the agent enrolled
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the host is restarted
+  Then the "elastic-agent" process is in the "started" state on the host
+    And the agent keeps its enrollment after the host restart
+    And the agent resumes shipping its logs after the host restart
+
+@restart-agent-unprivileged
+Scenario Outline: Restarting the installed agent requires privileges
+  Given an agent is deployed to Fleet with "tar" installer
+  When the "elastic-agent restart" command fails for the "nobody" user
+  Then the agent is listed in Fleet as "online"
+
+@unenroll @destructive
 Scenario Outline: Un-enrolling the agent deactivates the agent
   Given an agent is deployed to Fleet with "tar" installer
   When the agent is un-enrolled
   Then the agent is listed in Fleet as "inactive"
 
-@reenroll
+@reenroll @destructive
 Scenario Outline: Re-enrolling the agent activates the agent in Fleet
   Given an agent is deployed to Fleet with "tar" installer
     And the agent is un-enrolled
@@ -55,15 +132,36 @@ Scenario Outline: Re-enrolling the agent activates the agent in Fleet
     And the agent is re-enrolled on the host
   When the "elastic-agent" process is "started" on the host
   Then the agent is listed in Fleet as "online"
+    And the re-enrolled agent is a "new" agent in Fleet
+    And the API keys of the agent before the re-enrollment are invalidated
 
-@revoke-token
+@revoke-token @destructive
 Scenario Outline: Revoking the enrollment token for the agent
   Given an agent is deployed to Fleet with "tar" installer
   When the enrollment token is revoked
-  Then an attempt to enroll a new agent fails
+  Then an attempt to enroll a new agent fails with the "fail to enroll" message
+
+@revoke-token-recovery @destructive
+Scenario Outline: Re-enrolling the blocked agent with a new enrollment token
+  Given an agent is deployed to Fleet with "tar" installer
+    And the enrollment token is revoked
+    And an attempt to enroll a new agent fails
+  When a new enrollment token is created
+    And the previously blocked agent is enrolled with the new token
+  Then the agent is listed in Fleet as "online"
 
 @uninstall-host
 Scenario Outline: Un-installing the installed agent
   Given an agent is deployed to Fleet with "tar" installer
   When the "elastic-agent" process is "uninstalled" on the host
   Then the file system Agent folder is empty
+    And the file "/usr/bin/elastic-agent" does not exist in the agent container
+
+@log-level
+Scenario Outline: Changing the log level of the agent
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the log level of the agent is set to "debug"
+  Then the agent acknowledges the action
+    And the agent reports the "debug" log level
+    And the agent logs contain "debug" messages
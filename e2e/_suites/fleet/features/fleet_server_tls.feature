@@ -0,0 +1,29 @@
+@fleet_server_tls @skip:<8.5
+Feature: Fleet Server TLS
+  Scenarios for agents connected to a Fleet Server using TLS, which verify its certificate with the CA
+  that issued it
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+
+@certificate-rotation
+Scenario Outline: Rotating the certificate of the Fleet Server
+  Given a Fleet Server using TLS is deployed
+    And an agent is deployed to Fleet with "tar" installer using TLS
+    And the agent is listed in Fleet as "online"
+  When the certificate of the Fleet Server is rotated
+  Then the agent reconnects to Fleet without enrolling again
+
+@expired-certificate
+Scenario Outline: Enrolling in a Fleet Server with an expired certificate
+  Given a Fleet Server using TLS with an expired certificate is deployed
+  When an attempt to deploy an agent to Fleet with "tar" installer using TLS fails
+  Then the enrollment fails with an "expired" certificate error
+    And the agent is not enrolled in Fleet
+
+@wrong-ca
+Scenario Outline: Enrolling in a Fleet Server trusting a different CA
+  Given a Fleet Server using TLS is deployed
+  When an attempt to deploy an agent to Fleet with "tar" installer trusting a different CA fails
+  Then the enrollment fails with an "unknown authority" certificate error
+    And the agent is not enrolled in Fleet
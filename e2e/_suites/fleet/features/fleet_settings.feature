@@ -0,0 +1,20 @@
+@fleet_settings
+Feature: Fleet Settings
+  Scenarios for enrolled agents reacting to changes in the default output and Fleet Server host of Fleet
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+    And an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+
+@default-output-change
+Scenario Outline: Changing the configuration of the default output
+  When the default output is configured with "bulk_max_size: 100"
+  Then the agent applies the latest revision of the policy
+    And the agent is listed in Fleet as "online"
+
+@default-fleet-server-host-change
+Scenario Outline: Adding an unreachable URL to the default Fleet Server host
+  When the "http://unreachable-fleet-server:8220" URL is added to the default Fleet Server host
+  Then the agent applies the latest revision of the policy
+    And the agent is listed in Fleet as "online"
@@ -7,3 +7,29 @@ Scenario Outline: Adding the Linux Integration to an Agent ...
     And the agent is listed in Fleet as "online"
   When the "Linux" integration is "added" in the policy
   Then a Linux data stream exists with some data
+
+Scenario Outline: The Linux Integration data streams are healthy ...
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the "Linux" integration is "added" in the policy
+    And a Linux data stream exists with some data
+  Then the backing indices of the "metrics-linux.memory-default" data stream are "green"
+    And the backing indices of the "metrics-linux.memory-default" data stream have "1" primary shards
+
+@integration-settings
+Scenario Outline: Adding the Linux Integration with custom settings ...
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the "Linux" integration is added in the policy with the settings:
+    | input         | dataset      | variable | value |
+    | linux/metrics | linux.memory | period   | 10s   |
+    | linux/metrics | linux.load   | period   | 10s   |
+  Then a Linux data stream exists with some data
+    And there are at least "10" documents in the "metrics-linux.memory-default" index within "2m" matching:
+      """
+      {"term": {"data_stream.dataset": "linux.memory"}}
+      """
+    And the documents in the "metrics-linux.*" index have at least these counts by "data_stream.dataset":
+      | value        | count |
+      | linux.memory | 1     |
+      | linux.load   | 1     |
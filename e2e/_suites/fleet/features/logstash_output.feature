@@ -0,0 +1,9 @@
+@logstash_output @requires:outputs
+Feature: Logstash Output
+  Scenarios for agents sending data to Elasticsearch through Logstash
+
+Scenario Outline: Sending data through a Logstash output
+  Given the policy sends data through Logstash
+    And an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then there is new data in the index from agent through Logstash
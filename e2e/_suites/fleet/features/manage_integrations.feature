@@ -11,3 +11,19 @@ Examples:
   | Elastic APM |
   | Endpoint    |
   | Linux       |
+
+@remove
+Scenario Outline: Removing an Integration from a Policy
+  Given the "<integration>" integration is added to the policy
+  When the "<integration>" integration is removed from the policy
+  Then the "<integration>" datasource is not shown in the policy
+Examples:
+  | integration |
+  | Elastic APM |
+  | Linux       |
+
+@dashboards
+Scenario Outline: The dashboards of the Integrations reference existing objects
+  When the "Linux" integration is "added" in the policy
+  Then the "Linux" integration dashboards have no broken references
+    And the "System" integration dashboards have no broken references
@@ -0,0 +1,40 @@
+@multiple_outputs @requires:outputs
+Feature: Multiple Outputs
+  Scenarios for policies sending data and monitoring data to different Elasticsearch clusters
+
+@data-to-secondary
+Scenario Outline: Sending data to a secondary Elasticsearch cluster
+  Given the policy sends "data" to the secondary Elasticsearch cluster
+    And an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the "metrics-system.*" data streams have data in the "secondary" Elasticsearch cluster
+    And the "metrics-elastic_agent*" data streams have data in the "main" Elasticsearch cluster
+    And the "metrics-system.*" data streams have no data in the "main" Elasticsearch cluster
+
+@monitoring-to-secondary
+Scenario Outline: Sending monitoring data to a secondary Elasticsearch cluster
+  Given the policy sends "monitoring" to the secondary Elasticsearch cluster
+    And an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the "metrics-elastic_agent*" data streams have data in the "secondary" Elasticsearch cluster
+    And the "metrics-system.*" data streams have data in the "main" Elasticsearch cluster
+    And the "metrics-elastic_agent*" data streams have no data in the "main" Elasticsearch cluster
+
+@monitoring-cluster
+Scenario Outline: Sending monitoring data to a dedicated monitoring cluster
+  Given the policy sends monitoring data to the monitoring Elasticsearch cluster
+    And an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the agent monitoring data is only in the monitoring Elasticsearch cluster
+    And the "metrics-system.*" data streams have data in the "main" Elasticsearch cluster
+    And the "metrics-system.*" data streams have no data in the "monitoring" Elasticsearch cluster
+
+@data-and-monitoring-clusters
+Scenario Outline: Sending data and monitoring data to different clusters than the main one
+  Given the policy sends "data" to the secondary Elasticsearch cluster
+    And the policy sends monitoring data to the monitoring Elasticsearch cluster
+    And an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the "metrics-system.*" data streams have data in the "secondary" Elasticsearch cluster
+    And the agent monitoring data is only in the monitoring Elasticsearch cluster
+    And the "metrics-elastic_agent*" data streams have no data in the "secondary" Elasticsearch cluster
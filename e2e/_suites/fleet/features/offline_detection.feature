@@ -0,0 +1,24 @@
+@offline_detection
+Feature: Offline Detection
+  Scenarios for the liveness of the Agent in Fleet, which changes the status of the agents that stop checking in
+  once the inactivity and unenrollment timeouts of their policy are exceeded.
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+
+@inactivity-timeout
+Scenario Outline: A stopped agent becomes offline and then inactive
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+    And the policy has an "inactivity" timeout of "600" seconds
+  When the "elastic-agent" process is "stopped" on the host
+  Then the agent is listed in Fleet as "offline" within the "inactivity" timeout
+    And the agent is listed in Fleet as "inactive" within the "inactivity" timeout
+
+@unenrollment-timeout @destructive
+Scenario Outline: A stopped agent is unenrolled
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+    And the policy has an "unenrollment" timeout of "300" seconds
+  When the "elastic-agent" process is "stopped" on the host
+  Then the agent is listed in Fleet as "inactive" within the "unenrollment" timeout
@@ -0,0 +1,13 @@
+@package_registry
+Feature: Package Registry
+  Scenarios for Fleet when the package registry fails, which are pending unless the mock package registry is
+  enabled with PACKAGE_REGISTRY_MOCK=true
+
+@package-registry-outage
+Scenario: Adding an integration while the package registry is not available
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When the package registry fails with "503" status
+  Then the "e2e_mock" integration cannot be added to the policy
+  When the package registry is available
+  Then the "e2e_mock" integration is added to the policy
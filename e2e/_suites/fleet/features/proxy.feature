@@ -0,0 +1,38 @@
+@proxy
+Feature: Proxy
+  Scenarios for agents behind an HTTP proxy, which is used to enroll in Fleet and to send data to Elasticsearch
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+    And the proxy is deployed
+
+@enroll-through-proxy
+Scenario Outline: Enrolling an agent through the proxy
+  Given an agent is deployed to Fleet with "tar" installer through the proxy
+  When the agent is listed in Fleet as "online"
+  Then the proxy forwards the requests to "fleet-server:8220"
+
+@data-through-proxy
+Scenario Outline: Sending data through the proxy
+  Given the policy sends data through the proxy
+    And an agent is deployed to Fleet with "tar" installer through the proxy
+  When the agent is listed in Fleet as "online"
+  Then the proxy forwards the requests to "elasticsearch:9200"
+    And the "metrics-system.*" data streams have data in the "main" Elasticsearch cluster
+
+@proxy-down
+Scenario Outline: Enrolling an agent when the proxy is down
+  Given the proxy is stopped
+  When an attempt to deploy an agent to Fleet with "tar" installer through the proxy fails
+  Then the agent is not enrolled in Fleet
+
+@proxy-outage
+Scenario Outline: Recovering from a proxy outage
+  Given the policy sends data through the proxy
+    And an agent is deployed to Fleet with "tar" installer through the proxy
+    And the agent is listed in Fleet as "online"
+  When the proxy is stopped
+    And the proxy is started again after "120" seconds
+  Then the agent is listed in Fleet as "online"
+    And the "metrics-system.*" events collected during the proxy outage are delivered
+    And there are no duplicated events in the "metrics-system.*" index
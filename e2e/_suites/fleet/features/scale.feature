@@ -0,0 +1,22 @@
+@scale @long_running
+Feature: Scale
+  Scenarios to validate the scalability of Fleet, enrolling many agents at once with Docker Compose scaling.
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+
+@scale-enroll
+Scenario Outline: Enrolling <agents> agents at once
+  Given "<agents>" agents are deployed to Fleet at scale
+  Then all the agents are listed in Fleet as "online"
+    And the documents in the "metrics-system.*" index have at least "<agents>" different values of "agent.id"
+
+@10
+Examples: 10 agents
+  | agents |
+  | 10     |
+
+@100
+Examples: 100 agents
+  | agents |
+  | 100    |
@@ -0,0 +1,13 @@
+@soak @long_running
+Feature: Soak
+  Scenarios to verify that an enrolled agent keeps running healthy and shipping data for a long period,
+  configured with the SOAK_DURATION and SOAK_CHECK_INTERVAL env vars.
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+
+@soak-tar
+Scenario: Keeping an agent in steady state for the soak period
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  Then the agent stays healthy during the soak period
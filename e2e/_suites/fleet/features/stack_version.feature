@@ -0,0 +1,13 @@
+@stack_version
+Feature: Stack Version
+  Scenarios for features pinning the version of the stack they run against, which the agents follow
+
+Background: Setting up kibana instance with the default profile in a previous version of the stack
+  Given kibana uses "default" profile
+    And the stack version is "8.5-SNAPSHOT"
+
+@agent-in-pinned-version
+Scenario Outline: Deploying an agent in the pinned version of the stack
+  Given an agent is deployed to Fleet with "tar" installer
+  When the agent is listed in Fleet as "online"
+  Then the agent is listed in Fleet with version "8.5-SNAPSHOT"
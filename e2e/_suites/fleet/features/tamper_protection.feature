@@ -0,0 +1,29 @@
+@tamper_protection
+Feature: Tamper Protection
+  Scenarios for the agents of a protected policy, which can only be uninstalled with the uninstall token
+  of the policy, retrieved from Fleet. Only stacks supporting tamper protection run these scenarios.
+
+Background: Setting up kibana instance with the default profile
+  Given kibana uses "default" profile
+    And the stack supports tamper protection
+
+@uninstall-without-token
+Scenario Outline: A protected agent is not uninstalled without the uninstall token
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+    And the "Endpoint Security" integration is "added" in the policy
+    And the policy is protected against tampering
+    And the agent applies the latest revision of the policy
+  When the agent is uninstalled without the uninstall token
+  Then the "elastic-agent" process is in the "started" state on the host
+    And the agent is listed in Fleet as "online"
+
+@uninstall-with-token
+Scenario Outline: A protected agent is uninstalled with the uninstall token
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+    And the "Endpoint Security" integration is "added" in the policy
+    And the policy is protected against tampering
+    And the agent applies the latest revision of the policy
+  When the agent is uninstalled with the uninstall token
+  Then the file system Agent folder is empty
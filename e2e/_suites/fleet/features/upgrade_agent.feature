@@ -18,3 +18,47 @@ Examples: Stale versions
 | 8.1.3 |
 | 8.1.0 |
 | 7.17-SNAPSHOT |
+
+@fleet-upgrade
+Scenario Outline: Tracking the progress of the upgrade of an agent from <stale-version>
+  Given a "<stale-version>" stale agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+    And certs are installed
+    And the "elastic-agent" process is "restarted" on the host
+  When agent is upgraded to "latest" version
+  Then the agent is listed in Fleet as "updating"
+    And the upgrade action is completed
+    And the agent downloaded the "latest" version
+    And the agent was restarted by the upgrade
+    And agent is in "latest" version
+    And the agent is listed in Fleet as "online"
+Examples: Stale versions
+| stale-version |
+| 8.4-SNAPSHOT |
+
+@upgrade-to-version
+Scenario Outline: Upgrading an installed agent from <stale-version> to <version>
+  Given a "<stale-version>" stale agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+    And certs are installed
+    And the "elastic-agent" process is "restarted" on the host
+  When the agent is upgraded to version "<version>"
+  Then the upgrade action is completed
+    And the agent is listed in Fleet with version "<version>"
+    And the agent is listed in Fleet as "online"
+Examples: Versions
+| stale-version | version      |
+| 8.3.0         | 8.4-SNAPSHOT |
+| 8.3.0         | latest       |
+
+@downgrade
+Scenario Outline: Downgrading an installed agent to <older-version> is rejected
+  Given an agent is deployed to Fleet with "tar" installer
+    And the agent is listed in Fleet as "online"
+  When an attempt to downgrade the agent to "<older-version>" version is rejected
+  Then agent is in "latest" version
+    And the agent is listed in Fleet as "online"
+Examples: Older versions
+| older-version |
+| 8.3.0 |
+| 7.17.0 |
@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/shell"
+)
+
+// fipsProfileEnv runs Elasticsearch in FIPS 140-2 mode if the FIPS_MODE env var is enabled. The users of
+// the stack are hashed with PBKDF2, as bcrypt is not approved by FIPS
+func fipsProfileEnv(env map[string]string) map[string]string {
+	if shell.GetEnvBool("FIPS_MODE") {
+		env["elasticsearchUsersFile"] = "elasticsearch-users-fips"
+		env["fipsMode"] = "true"
+		env["passwordHashingAlgorithm"] = "pbkdf2_stretch"
+	}
+
+	return env
+}
+
+func (fts *FleetTestSuite) theStackRunsInFIPSMode() error {
+	enabled, err := elasticsearch.IsFIPSModeEnabled(fts.currentContext)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		return fmt.Errorf("elasticsearch does not run in FIPS mode. Set FIPS_MODE=true to run the stack in FIPS mode")
+	}
+
+	return nil
+}
@@ -8,9 +8,12 @@ import (
 	"context"
 	"time"
 
+	"github.com/elastic/e2e-testing/internal/benchmark"
+	"github.com/elastic/e2e-testing/internal/certs"
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/deploy"
 	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/stack"
 	"github.com/elastic/e2e-testing/internal/utils"
 
 	log "github.com/sirupsen/logrus"
@@ -20,9 +23,11 @@ import (
 type FleetTestSuite struct {
 	// integrations
 	KibanaProfile       string
+	ComposeOverlays     []string // compose overlays merged into the profile in the current scenario
 	StandAlone          bool
 	CurrentToken        string // current enrollment token
 	CurrentTokenID      string // current enrollment tokenID
+	UsingDefaultToken   bool   // the current enrollment token is the policy's default one, so it must not be deleted
 	ElasticAgentStopped bool   // will be used to signal when the agent process can be called again in the tear-down stage
 	Image               string // base image used to install the agent
 	InstallerType       string
@@ -34,9 +39,71 @@ type FleetTestSuite struct {
 	deployer            deploy.Deployment
 	dockerDeployer      deploy.Deployment // used for docker related deployents, such as the stand-alone containers
 	BeatsProcess        string            // (optional) name of the Beats that must be present before installing the elastic-agent
+	// custom logs integration
+	CustomLogsDataset string // dataset where the lines of the custom log file are sent
+	CustomLogsFile    string // path to the log file within the agent container
+	CustomLogsLines   int    // number of lines written to the custom log file
+	// outputs
+	LogstashOutputID                string // ID of the Logstash output in Fleet, if the policy sends data through Logstash
+	SecondaryElasticsearchOutputID  string // ID of the output for the secondary Elasticsearch cluster, if used by the policy
+	MonitoringElasticsearchOutputID string // ID of the output for the monitoring Elasticsearch cluster, if used by the policy
+	// proxy
+	ProxyDeployed    bool      // the proxy service was deployed in the current scenario, so it must be removed
+	ProxyOutputID    string    // ID of the output sending data through the proxy, if used by the policy
+	ProxyStoppedDate time.Time // the moment the proxy was stopped, starting an outage
+	ProxyStartedDate time.Time // the moment the proxy was started again, ending the outage
+	// Fleet Server using TLS
+	FleetServerTLSCA        *certs.CA // certificate authority issuing the certificates of the Fleet Server using TLS
+	FleetServerTLSCertsDir  string    // directory with the certificates, mounted in the Fleet Server using TLS
+	FleetServerTLSHostID    string    // ID of the Fleet Server host using TLS, if used by the policy
+	CertificateRotatedDate  time.Time // the moment the certificate of the Fleet Server using TLS was rotated
+	AgentIDBeforeRotation   string    // ID of the agent before the certificate of the Fleet Server was rotated
+	EnrollmentFailureOutput string    // output of the enrollment of the agent, when it was expected to fail
+	// host restarts
+	HostRestartedDate    time.Time // the moment the host of the agent was started again after a restart
+	AgentIDBeforeRestart string    // ID of the agent before the host was restarted
+	// Fleet settings changed in the current scenario, restored after it
+	DefaultOutputBeforeChange          *kibana.Output
+	DefaultFleetServerHostBeforeChange *kibana.FleetServerHost
+	// re-enrollment
+	AgentIDBeforeReenroll   string   // ID of the agent before it was re-enrolled on the host
+	APIKeyIDsBeforeReenroll []string // IDs of the output API keys of the agent before it was re-enrolled
+	// agents deployed in the current scenario, by the name of their containers
+	DeployedAgents map[string]DeployedAgent
+	// scale
+	ScaledAgents   int       // number of agents deployed at scale in the current scenario
+	ScaleStartDate time.Time // the moment the agents started to be deployed at scale
 	// date controls for queries
 	AgentStoppedDate             time.Time
 	RuntimeDependenciesStartDate time.Time
+	ScenarioStartDate            time.Time // the moment the current scenario started, scoping the audit events
+	MonitoringChangedDate        time.Time // the moment the agent monitoring was last changed in the policy
+	PolicyChangedDate            time.Time // the moment the policy was last changed, to measure its propagation to the agent
+	AgentActionDate              time.Time // the moment the last action was sent to the agent
+	// name of the snapshot of Fleet's state, taken before destructive scenarios
+	FleetStateSnapshot string
+	// the feature file of the current scenario, used to reset Fleet's state between feature files
+	CurrentFeature      string
+	CurrentScenario     string
+	CurrentScenarioTags []string
+	// version of the stack declared by the current feature, if it differs from the default one
+	PinnedStackVersion string
+	// why the current scenario is skipped for the version or the capabilities of the stack, if it is
+	SkipReason string
+	// features available in the target stack, detected the first time a scenario requires them
+	capabilities *stack.CapabilitySet
+	// ID of the last action sent to the agent in the current scenario
+	AgentActionID string
+	// pid of the agent before it was upgraded in the current scenario
+	AgentPIDBeforeUpgrade string
+	// measures the phases of the enrollment of the agent deployed in the current scenario
+	enrollmentTimer *benchmark.Timer
+	// context of the run, cancelled when it exceeds its deadline
+	runContext context.Context
+	cancelRun  context.CancelFunc
+	// context of the steps of the current scenario, cancelled when it exceeds its timeout or the run is aborted
+	scenarioContext context.Context
+	cancelScenario  context.CancelFunc
 	// instrumentation
 	currentContext    context.Context
 	DefaultAPIKey     string
@@ -44,6 +111,11 @@ type FleetTestSuite struct {
 	ElasticAgentFlags string
 }
 
+// DeployedAgent an agent deployed to Fleet in the current scenario
+type DeployedAgent struct {
+	Hostname string
+}
+
 func (fts *FleetTestSuite) getDeployer() deploy.Deployment {
 	if fts.StandAlone {
 		return fts.dockerDeployer
@@ -51,6 +123,12 @@ func (fts *FleetTestSuite) getDeployer() deploy.Deployment {
 	return fts.deployer
 }
 
+// fleetProfile returns the profile of the runtime dependencies, including the compose overlays of the current
+// scenario, so that the services are not recreated without them
+func (fts *FleetTestSuite) fleetProfile() deploy.ServiceRequest {
+	return deploy.NewServiceRequest(common.FleetProfileName).WithOverlays(fts.ComposeOverlays...)
+}
+
 func (fts *FleetTestSuite) getProfileEnv() map[string]string {
 	env := map[string]string{}
 
@@ -62,14 +140,31 @@ func (fts *FleetTestSuite) getProfileEnv() map[string]string {
 		env["kibanaProfile"] = fts.KibanaProfile
 	}
 
+	if fts.PinnedStackVersion != "" {
+		env["stackVersion"] = fts.PinnedStackVersion
+		env["kibanaVersion"] = fts.PinnedStackVersion
+		env["kibanaDockerNamespace"] = "kibana"
+		env["fleetServerVersion"] = fts.PinnedStackVersion
+		delete(env, "KIBANA_IMAGE_REF_CUSTOM")
+	}
+
 	return env
 }
 
+// theHostIsRestarted stops and starts the host of the agent, recording the ID of the agent before the restart,
+// so that it's possible to check the agent was not enrolled again
 func (fts *FleetTestSuite) theHostIsRestarted() error {
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+	fts.AgentIDBeforeRestart = agentID
+
 	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
-	err := fts.getDeployer().Stop(fts.currentContext, agentService)
+	err = fts.getDeployer().Stop(fts.currentContext, agentService)
 	if err != nil {
 		log.WithField("err", err).Error("Could not stop the service")
+		return err
 	}
 
 	utils.Sleep(time.Duration(utils.TimeoutFactor) * 10 * time.Second)
@@ -77,8 +172,12 @@ func (fts *FleetTestSuite) theHostIsRestarted() error {
 	err = fts.getDeployer().Start(fts.currentContext, agentService)
 	if err != nil {
 		log.WithField("err", err).Error("Could not start the service")
+		return err
 	}
+	fts.HostRestartedDate = time.Now().UTC()
 
-	log.Debug("The elastic-agent service has been restarted")
+	log.WithFields(log.Fields{
+		"agentID": agentID,
+	}).Debug("The elastic-agent service has been restarted")
 	return nil
 }
@@ -0,0 +1,301 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/certs"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
+	log "github.com/sirupsen/logrus"
+)
+
+// fleetServerTLSFlavour the flavour of the elastic-agent service running a Fleet Server using TLS, which is also
+// the name of its service in the compose files
+const fleetServerTLSFlavour = "fleet-tls"
+
+// fleetServerTLSURL the URL of the Fleet Server using TLS within the Docker network of the profile
+const fleetServerTLSURL = "https://" + fleetServerTLSFlavour + ":8220"
+
+// certificateErrors the errors reported by the agent when it cannot verify the certificate of the server, by reason
+var certificateErrors = map[string]string{
+	"expired":           "x509: certificate has expired or is not yet valid",
+	"unknown authority": "x509: certificate signed by unknown authority",
+}
+
+// fleetServerTLSCertName the name of the files of the certificate and key of the Fleet Server using TLS
+const fleetServerTLSCertName = "fleet-server"
+
+func (fts *FleetTestSuite) aFleetServerUsingTLSIsDeployed() error {
+	return fts.deployFleetServerTLS(false)
+}
+
+func (fts *FleetTestSuite) aFleetServerUsingTLSWithAnExpiredCertificateIsDeployed() error {
+	return fts.deployFleetServerTLS(true)
+}
+
+// deployFleetServerTLS deploys a Fleet Server using a certificate issued by a new CA, which can be expired,
+// and makes the agents in the policy connect to it
+func (fts *FleetTestSuite) deployFleetServerTLS(expired bool) error {
+	now := time.Now()
+	ca, err := certs.NewCA("e2e-fleet-ca", now.Add(-24*time.Hour), now.Add(24*time.Hour))
+	if err != nil {
+		return err
+	}
+	fts.FleetServerTLSCA = ca
+
+	fts.FleetServerTLSCertsDir = common.GetElasticAgentWorkingPath("fleet-tls-certs-" + naming.RunID())
+	err = io.MkdirAll(fts.FleetServerTLSCertsDir)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = ca.Write(fts.FleetServerTLSCertsDir, "ca")
+	if err != nil {
+		return err
+	}
+
+	err = fts.issueFleetServerTLSCertificate(expired)
+	if err != nil {
+		return err
+	}
+
+	serviceToken, err := createFleetServerToken(fts.currentContext, "e2e-fleet-tls-"+naming.RunID())
+	if err != nil {
+		return err
+	}
+
+	env := fts.getProfileEnv()
+	env["elasticAgentTag"] = fts.agentVersion()
+	env["fleetServerCertsDir"] = fts.FleetServerTLSCertsDir
+	env["fleetServerServiceToken"] = serviceToken.Value
+	env["fleetServerPolicyId"] = kibana.FleetServicePolicy.ID
+	if expired {
+		env["fleetServerTLSInsecure"] = "1"
+	}
+
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceRequest(common.ElasticAgentServiceName).WithFlavour(fleetServerTLSFlavour),
+	}
+	err = fts.getDeployer().Add(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not deploy the Fleet Server using TLS")
+		return err
+	}
+
+	host, err := fts.kibanaClient.CreateFleetServerHost(fts.currentContext, kibana.FleetServerHost{
+		Name:     naming.Name("fleet-tls"),
+		HostURLs: []string{fleetServerTLSURL},
+	})
+	if err != nil {
+		return err
+	}
+	fts.FleetServerTLSHostID = host.ID
+
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyFleetServerHost(fts.currentContext, fts.Policy, host.ID)
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+
+	log.WithFields(log.Fields{
+		"expired": expired,
+		"host":    host.ID,
+		"policy":  policy.ID,
+	}).Info("The Fleet Server using TLS was deployed")
+
+	return nil
+}
+
+// issueFleetServerTLSCertificate issues a certificate for the Fleet Server using TLS, valid for a day unless it is
+// expired, replacing the existing one in the certificates directory
+func (fts *FleetTestSuite) issueFleetServerTLSCertificate(expired bool) error {
+	hosts := []string{fleetServerTLSFlavour, "localhost"}
+
+	var keyPair certs.KeyPair
+	var err error
+	if expired {
+		keyPair, err = fts.FleetServerTLSCA.IssueExpiredServerCertificate(hosts)
+	} else {
+		now := time.Now()
+		keyPair, err = fts.FleetServerTLSCA.IssueServerCertificate(hosts, now.Add(-time.Minute), now.Add(24*time.Hour))
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _, err = keyPair.Write(fts.FleetServerTLSCertsDir, fleetServerTLSCertName)
+	return err
+}
+
+func (fts *FleetTestSuite) anAgentIsDeployedToFleetWithInstallerUsingTLS(installerType string) error {
+	if fts.FleetServerTLSCA == nil {
+		return fmt.Errorf("the Fleet Server using TLS was not deployed in the scenario")
+	}
+
+	caFile := filepath.Join(fts.FleetServerTLSCertsDir, "ca.crt")
+	return fts.deployAgentToFleet(InstallerType(installerType), FleetServerTLS(fleetServerTLSURL, caFile))
+}
+
+// anAttemptToDeployAnAgentToFleetWithInstallerUsingTLSFails deploys an agent to the Fleet Server using TLS,
+// expecting its enrollment to fail, i.e. because the certificate of the server is expired
+func (fts *FleetTestSuite) anAttemptToDeployAnAgentToFleetWithInstallerUsingTLSFails(installerType string) error {
+	return fts.expectEnrollmentFailure(fts.anAgentIsDeployedToFleetWithInstallerUsingTLS(installerType))
+}
+
+// anAgentIsDeployedToFleetWithInstallerTrustingADifferentCA deploys an agent verifying the certificate of the
+// Fleet Server using TLS with a CA that did not issue it
+func (fts *FleetTestSuite) anAgentIsDeployedToFleetWithInstallerTrustingADifferentCA(installerType string) error {
+	if fts.FleetServerTLSCA == nil {
+		return fmt.Errorf("the Fleet Server using TLS was not deployed in the scenario")
+	}
+
+	now := time.Now()
+	otherCA, err := certs.NewCA("e2e-other-ca", now.Add(-24*time.Hour), now.Add(24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	caFile, _, err := otherCA.Write(fts.FleetServerTLSCertsDir, "other-ca")
+	if err != nil {
+		return err
+	}
+
+	return fts.deployAgentToFleet(InstallerType(installerType), FleetServerTLS(fleetServerTLSURL, caFile))
+}
+
+// anAttemptToDeployAnAgentToFleetWithInstallerTrustingADifferentCAFails deploys an agent verifying the certificate of
+// the Fleet Server using TLS with a CA that did not issue it, expecting its enrollment to fail
+func (fts *FleetTestSuite) anAttemptToDeployAnAgentToFleetWithInstallerTrustingADifferentCAFails(installerType string) error {
+	return fts.expectEnrollmentFailure(fts.anAgentIsDeployedToFleetWithInstallerTrustingADifferentCA(installerType))
+}
+
+// theCertificateOfTheFleetServerIsRotated issues a new certificate for the Fleet Server using TLS, signed by the
+// same CA, and restarts it to load the certificate
+func (fts *FleetTestSuite) theCertificateOfTheFleetServerIsRotated() error {
+	if fts.FleetServerTLSCA == nil {
+		return fmt.Errorf("the Fleet Server using TLS was not deployed in the scenario")
+	}
+
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+	fts.AgentIDBeforeRotation = agentID
+
+	err = fts.issueFleetServerTLSCertificate(false)
+	if err != nil {
+		return err
+	}
+
+	fleetServerTLSService := deploy.NewServiceContainerRequest(fleetServerTLSFlavour)
+	err = fts.getDeployer().Stop(fts.currentContext, fleetServerTLSService)
+	if err != nil {
+		return err
+	}
+
+	err = fts.getDeployer().Start(fts.currentContext, fleetServerTLSService)
+	if err != nil {
+		return err
+	}
+	fts.CertificateRotatedDate = time.Now().UTC()
+
+	log.WithFields(log.Fields{
+		"agentID": agentID,
+	}).Info("The certificate of the Fleet Server was rotated")
+
+	return nil
+}
+
+// theAgentReconnectsToFleetWithoutEnrollingAgain waits for the agent enrolled before the rotation of the certificate
+// to check in again. A different agent for the host means that it was enrolled again
+func (fts *FleetTestSuite) theAgentReconnectsToFleetWithoutEnrollingAgain() error {
+	if fts.CertificateRotatedDate.IsZero() {
+		return fmt.Errorf("the certificate of the Fleet Server was not rotated in the scenario")
+	}
+
+	return fts.waitForAgentToReconnect(fts.AgentIDBeforeRotation, fts.CertificateRotatedDate, "the rotation of the certificate")
+}
+
+// theEnrollmentFailsWithACertificateError checks the output of the failed enrollment, which must not verify the
+// certificate of the Fleet Server for the reason
+func (fts *FleetTestSuite) theEnrollmentFailsWithACertificateError(reason string) error {
+	certificateError, exists := certificateErrors[reason]
+	if !exists {
+		return fmt.Errorf("'%s' is not a supported reason of certificate errors. Valid values are: expired, unknown authority", reason)
+	}
+
+	if fts.EnrollmentFailureOutput == "" {
+		return fmt.Errorf("no enrollment failed in the scenario")
+	}
+
+	if !strings.Contains(fts.EnrollmentFailureOutput, certificateError) {
+		log.WithFields(log.Fields{
+			"output": fts.EnrollmentFailureOutput,
+			"reason": reason,
+		}).Error("The enrollment did not fail because of the certificate")
+		return fmt.Errorf("the output of the enrollment does not contain the '%s' error", certificateError)
+	}
+
+	log.WithFields(log.Fields{
+		"error":  certificateError,
+		"reason": reason,
+	}).Info("The enrollment failed because of the certificate")
+
+	return nil
+}
+
+// removeFleetServerTLS deletes the Fleet Server host using TLS from Fleet, removes its service and certificates,
+// if they were created
+func (fts *FleetTestSuite) removeFleetServerTLS() {
+	if fts.FleetServerTLSCA == nil {
+		return
+	}
+
+	if fts.FleetServerTLSHostID != "" {
+		err := fts.kibanaClient.DeleteFleetServerHost(fts.currentContext, fts.FleetServerTLSHostID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"err":  err,
+				"host": fts.FleetServerTLSHostID,
+			}).Warn("The Fleet Server host using TLS could not be deleted")
+		}
+	}
+
+	env := fts.getProfileEnv()
+	env["fleetServerCertsDir"] = fts.FleetServerTLSCertsDir
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceRequest(common.ElasticAgentServiceName).WithFlavour(fleetServerTLSFlavour),
+	}
+	err := fts.getDeployer().Remove(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err": err,
+		}).Warn("The Fleet Server using TLS could not be removed")
+	}
+
+	err = os.RemoveAll(fts.FleetServerTLSCertsDir)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"dir": fts.FleetServerTLSCertsDir,
+			"err": err,
+		}).Warn("The certificates of the Fleet Server using TLS could not be removed")
+	}
+
+	fts.FleetServerTLSCA = nil
+	fts.FleetServerTLSCertsDir = ""
+	fts.FleetServerTLSHostID = ""
+}
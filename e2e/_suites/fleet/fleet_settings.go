@@ -0,0 +1,148 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/elastic/e2e-testing/internal/kibana"
+	log "github.com/sirupsen/logrus"
+)
+
+// the URLs the agents use to reach the runtime dependencies, configured as Fleet's defaults when bootstrapping
+const (
+	defaultOutputHost         = "http://elasticsearch:9200"
+	defaultFleetServerHostURL = "http://fleet-server:8220"
+)
+
+// configureFleetDefaults configures the default output and Fleet Server host through the API, instead of
+// preconfiguring them in kibana.yml, so that the scenarios are able to change them
+func configureFleetDefaults(ctx context.Context, kibanaClient *kibana.Client) error {
+	output, err := kibanaClient.GetDefaultOutput(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !output.IsPreconfigured {
+		output.Hosts = []string{defaultOutputHost}
+
+		_, err = kibanaClient.UpdateOutput(ctx, output)
+		if err != nil {
+			return err
+		}
+	}
+
+	host, err := kibanaClient.GetDefaultFleetServerHost(ctx)
+	if err != nil {
+		// there is no default Fleet Server host until one is configured
+		host, err = kibanaClient.CreateFleetServerHost(ctx, kibana.FleetServerHost{
+			Name:      "Default",
+			HostURLs:  []string{defaultFleetServerHostURL},
+			IsDefault: true,
+		})
+		if err != nil {
+			return err
+		}
+	} else if !host.IsPreconfigured {
+		host.HostURLs = []string{defaultFleetServerHostURL}
+
+		_, err = kibanaClient.UpdateFleetServerHost(ctx, host)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"host":   host.ID,
+		"output": output.ID,
+	}).Debug("Fleet defaults configured")
+
+	return nil
+}
+
+// theDefaultOutputIsConfiguredWith changes the advanced YAML configuration of the default output, which changes all
+// the policies without a data output of their own. The default output is restored after the scenario
+func (fts *FleetTestSuite) theDefaultOutputIsConfiguredWith(configYaml string) error {
+	output, err := fts.kibanaClient.GetDefaultOutput(fts.currentContext)
+	if err != nil {
+		return err
+	}
+
+	if fts.DefaultOutputBeforeChange == nil {
+		original := output
+		fts.DefaultOutputBeforeChange = &original
+	}
+
+	output.ConfigYaml = configYaml
+
+	fts.policyChanged()
+	_, err = fts.kibanaClient.UpdateOutput(fts.currentContext, output)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"config": configYaml,
+		"output": output.ID,
+	}).Debug("Default output configured")
+
+	return nil
+}
+
+// theURLIsAddedToTheDefaultFleetServerHost adds a URL to the default Fleet Server host, which changes the hosts of
+// all the policies without a Fleet Server host of their own. The default host is restored after the scenario
+func (fts *FleetTestSuite) theURLIsAddedToTheDefaultFleetServerHost(URL string) error {
+	host, err := fts.kibanaClient.GetDefaultFleetServerHost(fts.currentContext)
+	if err != nil {
+		return err
+	}
+
+	if fts.DefaultFleetServerHostBeforeChange == nil {
+		original := host
+		original.HostURLs = append([]string{}, host.HostURLs...)
+		fts.DefaultFleetServerHostBeforeChange = &original
+	}
+
+	host.HostURLs = append(host.HostURLs, URL)
+
+	fts.policyChanged()
+	_, err = fts.kibanaClient.UpdateFleetServerHost(fts.currentContext, host)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"host": host.ID,
+		"urls": host.HostURLs,
+	}).Debug("URL added to the default Fleet Server host")
+
+	return nil
+}
+
+// restoreFleetSettings restores the default output and Fleet Server host changed in the scenario, if any
+func (fts *FleetTestSuite) restoreFleetSettings() {
+	if fts.DefaultOutputBeforeChange != nil {
+		_, err := fts.kibanaClient.UpdateOutput(fts.currentContext, *fts.DefaultOutputBeforeChange)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"err":    err,
+				"output": fts.DefaultOutputBeforeChange.ID,
+			}).Warn("The default output could not be restored")
+		}
+	}
+
+	if fts.DefaultFleetServerHostBeforeChange != nil {
+		_, err := fts.kibanaClient.UpdateFleetServerHost(fts.currentContext, *fts.DefaultFleetServerHostBeforeChange)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"err":  err,
+				"host": fts.DefaultFleetServerHostBeforeChange.ID,
+			}).Warn("The default Fleet Server host could not be restored")
+		}
+	}
+
+	fts.DefaultOutputBeforeChange = nil
+	fts.DefaultFleetServerHostBeforeChange = nil
+}
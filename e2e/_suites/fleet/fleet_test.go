@@ -7,7 +7,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,15 +18,20 @@ import (
 	"github.com/cucumber/godog/colors"
 	"github.com/docker/go-connections/nat"
 	apme2e "github.com/elastic/e2e-testing/internal"
+	"github.com/elastic/e2e-testing/internal/auth"
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/config"
 	"github.com/elastic/e2e-testing/internal/deploy"
 	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	_ "github.com/elastic/e2e-testing/internal/formatters"
 	"github.com/elastic/e2e-testing/internal/installer"
 	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/metrics"
+	"github.com/elastic/e2e-testing/internal/naming"
 	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/stack"
+	"github.com/elastic/e2e-testing/internal/status"
 	"github.com/elastic/e2e-testing/internal/utils"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	flag "github.com/spf13/pflag"
@@ -40,6 +44,7 @@ var fts *FleetTestSuite
 
 var tx *apm.Transaction
 var stepSpan *apm.Span
+var stepStartedAt time.Time
 
 // afterScenario destroys the state created by a scenario
 func afterScenario(fts *FleetTestSuite) {
@@ -48,6 +53,9 @@ func afterScenario(fts *FleetTestSuite) {
 		// Reset Kibana Profile to default
 		fts.KibanaProfile = ""
 		deployedAgentsCount = 0
+		fts.DeployedAgents = map[string]DeployedAgent{}
+		fts.enrollmentTimer = nil
+		resetPackageRegistry()
 	}()
 
 	span := tx.StartSpan("Clean up", "test.scenario.clean", nil)
@@ -73,7 +81,12 @@ func afterScenario(fts *FleetTestSuite) {
 			}
 			// only call it when the elastic-agent is present
 			if !fts.ElasticAgentStopped {
-				err := agentInstaller.Uninstall(fts.currentContext)
+				var err error
+				if fts.Policy.IsProtected {
+					err = fts.uninstallProtectedAgent(agentInstaller)
+				} else {
+					err = agentInstaller.Uninstall(fts.currentContext)
+				}
 				if err != nil {
 					log.Warnf("Could not uninstall the agent after the scenario: %v", err)
 				}
@@ -93,11 +106,22 @@ func afterScenario(fts *FleetTestSuite) {
 		}
 	}
 
+	fts.unenrollDeployedAgents()
+	fts.unenrollScaledAgents()
+
 	env := fts.getProfileEnv()
-	_ = fts.getDeployer().Remove(fts.currentContext, deploy.NewServiceRequest(common.FleetProfileName), []deploy.ServiceRequest{deploy.NewServiceRequest(serviceName)}, env)
+	_ = fts.getDeployer().Remove(fts.currentContext, fts.fleetProfile(), []deploy.ServiceRequest{deploy.NewServiceRequest(serviceName)}, env)
+
+	fts.removeLogstash()
+	fts.removeSecondaryElasticsearch()
+	fts.removeMonitoringElasticsearch()
+	fts.removeProxy()
+	fts.removeFleetServerTLS()
+	fts.restoreFleetSettings()
+	fts.restoreComposeOverlays()
 
 	// TODO: Determine why this may be empty here before being cleared out
-	if fts.CurrentTokenID != "" {
+	if fts.CurrentTokenID != "" && !fts.UsingDefaultToken {
 		err := fts.kibanaClient.DeleteEnrollmentAPIKey(fts.currentContext, fts.CurrentTokenID)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -113,11 +137,15 @@ func afterScenario(fts *FleetTestSuite) {
 	// clean up fields
 	fts.CurrentTokenID = ""
 	fts.CurrentToken = ""
+	fts.UsingDefaultToken = false
 	fts.InstallerType = ""
 	fts.Image = ""
 	fts.StandAlone = false
 	fts.BeatsProcess = ""
 	fts.ElasticAgentFlags = ""
+	fts.CustomLogsDataset = ""
+	fts.CustomLogsFile = ""
+	fts.CustomLogsLines = 0
 }
 
 // beforeScenario creates the state needed by a scenario
@@ -127,8 +155,23 @@ func beforeScenario(fts *FleetTestSuite) {
 
 	fts.StandAlone = false
 	fts.ElasticAgentStopped = false
-
-	fts.Version = common.ElasticAgentVersion
+	fts.MonitoringChangedDate = time.Time{}
+	fts.PolicyChangedDate = time.Time{}
+	fts.AgentStoppedDate = time.Time{}
+	fts.AgentActionDate = time.Time{}
+	fts.ProxyStoppedDate = time.Time{}
+	fts.ProxyStartedDate = time.Time{}
+	fts.CertificateRotatedDate = time.Time{}
+	fts.AgentIDBeforeRotation = ""
+	fts.EnrollmentFailureOutput = ""
+	fts.HostRestartedDate = time.Time{}
+	fts.AgentIDBeforeRestart = ""
+	fts.AgentIDBeforeReenroll = ""
+	fts.APIKeyIDsBeforeReenroll = []string{}
+	fts.AgentActionID = ""
+	fts.AgentPIDBeforeUpgrade = ""
+
+	fts.Version = fts.agentVersion()
 
 	waitForPolicy := func() error {
 		policy, err := fts.kibanaClient.CreatePolicy(fts.currentContext)
@@ -152,7 +195,7 @@ func beforeScenario(fts *FleetTestSuite) {
 		}
 
 		packageDataStream := kibana.PackageDataStream{
-			Name:        fmt.Sprintf("%s-%s", integration.Name, uuid.New().String()),
+			Name:        naming.Name(integration.Name),
 			Description: integration.Title,
 			Namespace:   "default",
 			PolicyID:    fts.Policy.ID,
@@ -208,6 +251,14 @@ func beforeScenario(fts *FleetTestSuite) {
 		log.Fatal(err)
 	}
 
+	if useDefaultEnrollmentToken {
+		err = fts.thePolicysDefaultEnrollmentTokenIsUsed()
+		if err != nil {
+			log.WithError(err).Fatal("Unable to get the default enrollment token for agent")
+		}
+		return
+	}
+
 	// Grab a new enrollment key for new agent
 	enrollmentKey, err := fts.kibanaClient.CreateEnrollmentAPIKey(fts.currentContext, fts.Policy)
 
@@ -220,8 +271,9 @@ func beforeScenario(fts *FleetTestSuite) {
 }
 
 // bootstrapFleet this method creates the runtime dependencies for the Fleet test suite, being of special
-// interest kibana profile passed as part of the environment variables to bootstrap the dependencies.
-func bootstrapFleet(ctx context.Context, env map[string]string) error {
+// interest kibana profile passed as part of the environment variables to bootstrap the dependencies, and
+// the compose overlays of the profile.
+func bootstrapFleet(ctx context.Context, profile deploy.ServiceRequest, env map[string]string) error {
 	deployer := deploy.New(common.Provider)
 
 	if profile, ok := env["kibanaProfile"]; ok {
@@ -229,7 +281,7 @@ func bootstrapFleet(ctx context.Context, env map[string]string) error {
 	}
 
 	// the runtime dependencies must be started only in non-remote executions
-	return deployer.Bootstrap(ctx, deploy.NewServiceRequest(common.FleetProfileName), env, func() error {
+	return deployer.Bootstrap(ctx, profile, env, func() error {
 		kibanaClient, err := kibana.NewClient()
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -245,6 +297,12 @@ func bootstrapFleet(ctx context.Context, env map[string]string) error {
 			}).Fatal("Elasticsearch Cluster is not healthy")
 		}
 
+		if auth.SecurityDisabled() {
+			// Fleet and Fleet Server need the security of the stack, so only Elasticsearch and Kibana are started
+			_, err = kibanaClient.WaitForReady(ctx, 10)
+			return err
+		}
+
 		err = kibanaClient.RecreateFleet(ctx)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -253,6 +311,13 @@ func bootstrapFleet(ctx context.Context, env map[string]string) error {
 			}).Fatal("Fleet could not be recreated")
 		}
 
+		err = configureFleetDefaults(ctx, kibanaClient)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Fatal("Fleet defaults could not be configured")
+		}
+
 		fleetServicePolicy := kibana.FleetServicePolicy
 
 		log.WithFields(log.Fields{
@@ -266,14 +331,14 @@ func bootstrapFleet(ctx context.Context, env map[string]string) error {
 		retryCount := 1
 
 		fleetServerBootstrapFn := func() error {
-			serviceToken, err := elasticsearch.GetAPIToken(ctx)
+			serviceToken, err := createFleetServerToken(ctx, "e2e-fleet-server-"+naming.RunID())
 			if err != nil {
 				retryCount++
 				log.WithFields(log.Fields{
 					"error":       err,
 					"retries":     retryCount,
 					"elapsedTime": exp.GetElapsedTime(),
-				}).Warn("Could not get API Token from Elasticsearch.")
+				}).Warn("Could not create the service account token of Fleet Server.")
 				return err
 			}
 
@@ -294,10 +359,14 @@ func bootstrapFleet(ctx context.Context, env map[string]string) error {
 			}
 
 			fleetServerEnv["elasticAgentTag"] = common.ElasticAgentVersion
+			if version, ok := env["fleetServerVersion"]; ok {
+				// Fleet Server must not be newer than the stack it connects to
+				fleetServerEnv["elasticAgentTag"] = version
+			}
 			fleetServerEnv["fleetServerMode"] = "1"
 			fleetServerEnv["fleetServerPort"] = fleetServerPort.Port()
 			fleetServerEnv["fleetInsecure"] = "1"
-			fleetServerEnv["fleetServerServiceToken"] = serviceToken.AccessToken
+			fleetServerEnv["fleetServerServiceToken"] = serviceToken.Value
 			fleetServerEnv["fleetServerPolicyId"] = fleetServicePolicy.ID
 
 			fleetServerSrv := deploy.ServiceRequest{
@@ -305,7 +374,7 @@ func bootstrapFleet(ctx context.Context, env map[string]string) error {
 				Flavour: "fleet-server",
 			}
 
-			err = deployer.Add(ctx, deploy.NewServiceRequest(common.FleetProfileName), []deploy.ServiceRequest{fleetServerSrv}, fleetServerEnv)
+			err = deployer.Add(ctx, profile, []deploy.ServiceRequest{fleetServerSrv}, fleetServerEnv)
 			if err != nil {
 				retryCount++
 				log.WithFields(log.Fields{
@@ -353,11 +422,37 @@ func setUpSuite() {
 
 	common.InitVersions()
 
+	useDefaultEnrollmentToken = shell.GetEnvBool("FLEET_USE_DEFAULT_ENROLLMENT_TOKEN")
+	if auth.SecurityDisabled() {
+		// Fleet enrolls the agents with API keys, which need the security of Elasticsearch
+		log.Warn("The stack runs with security disabled: only the stand-alone scenarios are supported")
+	}
+	initLogStreaming()
+	initScenarioTimeout()
+	initEnrollTimeout()
+	initEnrollmentBenchmark()
+	initPolicyPropagationBenchmark()
+	initStepBudgets()
+	initAgentLogsAllowlist()
+	initCrashDetection()
+	initKibanaJournal()
+	initContractValidation()
+	initPackageRegistryMock()
+
+	if addr := shell.GetEnv("STATUS_ENDPOINT_ADDR", ""); addr != "" {
+		status.Serve(addr)
+	}
+
+	if addr := shell.GetEnv("METRICS_ENDPOINT_ADDR", ""); addr != "" {
+		metrics.Serve(addr)
+	}
+
 	fts = &FleetTestSuite{
 		kibanaClient:   kibanaClient,
 		deployer:       deploy.New(common.Provider),
 		dockerDeployer: deploy.New("docker"),
 	}
+	fts.startRunDeadline()
 }
 
 func InitializeFleetTestScenario(ctx *godog.ScenarioContext) {
@@ -369,7 +464,56 @@ func InitializeFleetTestScenario(ctx *godog.ScenarioContext) {
 
 		// context is initialised at the step hook, we are initialising it here to prevent panics
 		fts.currentContext = context.Background()
+		if fts.CurrentFeature != "" && fts.CurrentFeature != sc.Uri {
+			fts.restoreStackVersion()
+			fts.resetFleetState()
+		}
+		fts.CurrentFeature = sc.Uri
+		fts.CurrentScenario = sc.Name
+		fts.CurrentScenarioTags = []string{}
+		for _, tag := range sc.Tags {
+			fts.CurrentScenarioTags = append(fts.CurrentScenarioTags, tag.Name)
+		}
+		fts.ScenarioStartDate = time.Now().UTC()
+
+		skipReason, err := stack.SkipReason(fts.CurrentScenarioTags, fts.stackVersion())
+		if err != nil {
+			return ctx, err
+		}
+		if skipReason == "" {
+			skipReason, err = fts.missingCapabilityReason(ctx, fts.CurrentScenarioTags)
+			if err != nil {
+				return ctx, err
+			}
+		}
+		fts.SkipReason = skipReason
+
+		status.ScenarioStarted(sc.Uri, sc.Name)
+		startContractValidation()
+		if fts.SkipReason != "" {
+			// the steps of the scenario are reported as pending, without being executed
+			log.WithFields(log.Fields{
+				"reason":   fts.SkipReason,
+				"scenario": sc.Name,
+			}).Warn("The scenario is not supported by the stack. Skipping it")
+			fts.scenarioContext, fts.cancelScenario = context.Background(), func() {}
+			return ctx, nil
+		}
+		if fts.runAborted() {
+			// no new scenarios start once the deadline is exceeded: its steps will fail without being executed
+			log.WithField("scenario", sc.Name).Warn("The run exceeded its deadline. Aborting the scenario")
+			fts.scenarioContext, fts.cancelScenario = fts.runContext, func() {}
+			return ctx, nil
+		}
+
+		startLogStreaming(sc.Name)
+		startKibanaJournal(sc.Name)
+
+		if isDestructiveScenario(sc) {
+			fts.snapshotFleetState()
+		}
 		beforeScenario(fts)
+		fts.startScenarioDeadline(sc.Name)
 
 		return ctx, nil
 	})
@@ -380,6 +524,9 @@ func InitializeFleetTestScenario(ctx *godog.ScenarioContext) {
 			e := apm.DefaultTracer.NewError(err)
 			e.Context.SetLabel("scenario", sc.Name)
 			e.Context.SetLabel("gherkin_type", "scenario")
+			if kibanaJournalPath != "" {
+				e.Context.SetLabel("journal", kibanaJournalPath)
+			}
 			e.Send()
 		}
 
@@ -390,20 +537,55 @@ func InitializeFleetTestScenario(ctx *godog.ScenarioContext) {
 		}
 		defer f()
 
+		fts.stopScenarioDeadline()
+
+		// the logs are scanned before the agent is uninstalled
+		crashErr := fts.checkAgentCrashes()
 		afterScenario(fts)
+		fts.restoreFleetState()
+
+		// the scenario fails if its requests violated the Fleet API specification, or if the agent crashed,
+		// even if its steps passed
+		hookErr := checkContractValidation()
+		if hookErr == nil {
+			hookErr = crashErr
+		}
+		if err == nil {
+			err = hookErr
+		}
+
+		stopLogStreaming()
+		stopKibanaJournal(sc.Name, err)
+		if err != nil && fts.runAborted() {
+			status.ScenarioAborted()
+		} else {
+			status.ScenarioFinished(err)
+		}
 
 		log.Tracef("After Fleet scenario: %s", sc.Name)
-		return ctx, nil
+		return ctx, hookErr
 	})
 
 	ctx.StepContext().Before(func(ctx context.Context, step *godog.Step) (context.Context, error) {
 		log.Tracef("Before step: %s", step.Text)
+		status.StepStarted(step.Text)
+		stepStartedAt = time.Now()
 		stepSpan = tx.StartSpan(step.Text, "test.scenario.step", nil)
-		fts.currentContext = apm.ContextWithSpan(context.Background(), stepSpan)
+		fts.currentContext = apm.ContextWithSpan(fts.scenarioContext, stepSpan)
 
-		return ctx, nil
+		if fts.SkipReason != "" {
+			return ctx, godog.ErrPending
+		}
+
+		return ctx, fts.checkScenarioDeadline()
 	})
 	ctx.StepContext().After(func(ctx context.Context, step *godog.Step, status godog.StepResultStatus, err error) (context.Context, error) {
+		// a step exceeding its budget fails, even if it eventually succeeded
+		var budgetErr error
+		if err == nil && status == godog.StepPassed {
+			budgetErr = fts.checkStepBudget(step.Text, stepStartedAt)
+		}
+
 		if err != nil {
 			e := apm.DefaultTracer.NewError(err)
 			e.Context.SetLabel("step", step.Text)
@@ -412,32 +594,64 @@ func InitializeFleetTestScenario(ctx *godog.ScenarioContext) {
 			e.Send()
 		}
 
+		metrics.StepsTotal.Inc(status.String())
+
 		if stepSpan != nil {
 			stepSpan.End()
 		}
 
 		log.Tracef("After step (%s): %s", status.String(), step.Text)
-		return ctx, nil
+		return ctx, budgetErr
 	})
 
 	ctx.Step(`^a "([^"]*)" agent is deployed to Fleet$`, fts.anAgentIsDeployedToFleet)
 	ctx.Step(`^an agent is deployed to Fleet on top of "([^"]*)"$`, fts.anAgentIsDeployedToFleetOnTopOfBeat)
 	ctx.Step(`^an agent is deployed to Fleet with "([^"]*)" installer$`, fts.anAgentIsDeployedToFleetWithInstaller)
 	ctx.Step(`^an agent is deployed to Fleet with "([^"]*)" installer and "([^"]*)" flags$`, fts.anAgentIsDeployedToFleetWithInstallerAndTags)
+	ctx.Step(`^"(\d+)" agents are deployed to Fleet$`, fts.agentsAreDeployedToFleet)
+	ctx.Step(`^all the deployed agents are listed in Fleet as "([^"]*)"$`, fts.allTheDeployedAgentsAreListedInFleetWithStatus)
+	ctx.Step(`^the deployed agents are enrolled as different agents$`, fts.theDeployedAgentsAreEnrolledAsDifferentAgents)
+	ctx.Step(`^all the deployed agents run the policy$`, fts.allTheDeployedAgentsRunThePolicy)
+	ctx.Step(`^the agent is assigned to the "([^"]*)" policy$`, fts.theAgentIsAssignedToThePolicy)
 	ctx.Step(`^the agent is listed in Fleet as "([^"]*)"$`, fts.theAgentIsListedInFleetWithStatus)
 	ctx.Step(`^the output permissions has "([^"]*)"$`, fts.verifyPermissionHashStatus)
 	ctx.Step(`^the host is restarted$`, fts.theHostIsRestarted)
 	ctx.Step(`^system package dashboards are listed in Fleet$`, fts.systemPackageDashboardsAreListedInFleet)
 	ctx.Step(`^the agent is un-enrolled$`, fts.theAgentIsUnenrolled)
 	ctx.Step(`^the agent is re-enrolled on the host$`, fts.theAgentIsReenrolledOnTheHost)
+	ctx.Step(`^the re-enrolled agent is a "(new|reused)" agent in Fleet$`, fts.theReenrolledAgentIsAnAgentInFleet)
+	ctx.Step(`^the API keys of the agent before the re-enrollment are invalidated$`, fts.theAPIKeysBeforeTheReenrollmentAreInvalidated)
 	ctx.Step(`^the enrollment token is revoked$`, fts.theEnrollmentTokenIsRevoked)
 	ctx.Step(`^an attempt to enroll a new agent fails$`, fts.anAttemptToEnrollANewAgentFails)
+	ctx.Step(`^an attempt to enroll a new agent fails with the "([^"]*)" message$`, fts.anAttemptToEnrollANewAgentFailsWithTheMessage)
+	ctx.Step(`^the "([^"]*)" command fails for the "([^"]*)" user$`, fts.theCommandFailsForTheUser)
+	ctx.Step(`^the file "([^"]*)" in the agent container contains "([^"]*)"$`, fts.theFileInTheAgentContainerContains)
+	ctx.Step(`^the file "([^"]*)" in the agent container does not contain "([^"]*)"$`, fts.theFileInTheAgentContainerDoesNotContain)
+	ctx.Step(`^the file "([^"]*)" (exists|does not exist) in the agent container$`, fts.theFileExistsInTheAgentContainer)
+	ctx.Step(`^the agent files have these permissions:$`, fts.theAgentFilesHaveThesePermissions)
+	ctx.Step(`^the "([^"]*)" systemd unit is (enabled|active)$`, fts.theSystemdUnitIs)
+	ctx.Step(`^the "([^"]*)" systemd unit is restarted$`, fts.theSystemdUnitIsRestarted)
+	ctx.Step(`^the agent container is rebooted$`, fts.theAgentContainerIsRebooted)
+	ctx.Step(`^a new enrollment token is created$`, fts.aNewEnrollmentTokenIsCreated)
+	ctx.Step(`^the policy's default enrollment token is used$`, fts.thePolicysDefaultEnrollmentTokenIsUsed)
+	ctx.Step(`^the previously blocked agent is enrolled with the new token$`, fts.thePreviouslyBlockedAgentIsEnrolledWithTheNewToken)
 	ctx.Step(`^the "([^"]*)" process is "([^"]*)" on the host$`, fts.processStateChangedOnTheHost)
 	ctx.Step(`^the file system Agent folder is empty$`, fts.theFileSystemAgentFolderIsEmpty)
 	ctx.Step(`^a Linux data stream exists with some data$`, fts.checkDataStream)
 
+	// background steps
+	ctx.Step(`^the Fleet setup is complete$`, fts.theFleetSetupIsComplete)
+	ctx.Step(`^no agents are enrolled$`, fts.noAgentsAreEnrolled)
+
 	// preconfigured policies steps
 	ctx.Step(`^kibana uses "([^"]*)" profile$`, fts.kibanaUsesProfile)
+	ctx.Step(`^the stack uses the "([^"]*)" compose overlay$`, fts.theStackUsesTheComposeOverlay)
+
+	// cross-cluster search and replication steps
+	ctx.Step(`^a document is indexed in the "([^"]*)" index of the remote cluster$`, fts.aDocumentIsIndexedInTheRemoteCluster)
+	ctx.Step(`^the remote cluster is connected$`, fts.theRemoteClusterIsConnected)
+	ctx.Step(`^the document is found searching the "([^"]*)" index across clusters$`, fts.theDocumentIsFoundSearchingAcrossClusters)
+	ctx.Step(`^the "([^"]*)" index is replicated from the remote cluster$`, fts.theIndexIsReplicatedFromTheRemoteCluster)
 	ctx.Step(`^agent uses enrollment token from "([^"]*)" policy$`, fts.agentUsesPolicy)
 	ctx.Step(`^the agent is enrolled into "([^"]*)" policy$`, fts.agentRunPolicy)
 
@@ -446,15 +660,121 @@ func InitializeFleetTestScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^certs are installed$`, fts.installCerts)
 	ctx.Step(`^agent is in "([^"]*)" version$`, fts.agentInVersion)
 	ctx.Step(`^agent is upgraded to "([^"]*)" version$`, fts.anAgentIsUpgradedToVersion)
+	ctx.Step(`^the agent is upgraded to version "([^"]*)"$`, fts.theAgentIsUpgradedToVersion)
+	ctx.Step(`^the agent is listed in Fleet with version "([^"]*)"$`, fts.theAgentIsListedInFleetWithVersion)
+	ctx.Step(`^the upgrade action is completed$`, fts.theUpgradeActionIsCompleted)
+	ctx.Step(`^the agent downloaded the "([^"]*)" version$`, fts.theAgentDownloadedTheVersion)
+	ctx.Step(`^the agent was restarted by the upgrade$`, fts.theAgentWasRestartedByTheUpgrade)
+	ctx.Step(`^an attempt to downgrade the agent to "([^"]*)" version is rejected$`, fts.anAttemptToDowngradeTheAgentIsRejected)
 
 	//flags steps
 	ctx.Step(`^the elastic agent index contains the tags$`, fts.tagsAreInTheElasticAgentIndex)
 
 	// integrations steps
 	ctx.Step(`^the "([^"]*)" integration is "([^"]*)" in the policy$`, fts.theIntegrationIsOperatedInThePolicy)
+	ctx.Step(`^the "([^"]*)" integration is added in the policy with the settings:$`, fts.theIntegrationIsAddedInThePolicyWithTheSettings)
+	ctx.Step(`^the "([^"]*)" integration is added to the policy$`, fts.theIntegrationIsAddedToThePolicy)
+	ctx.Step(`^the "([^"]*)" integration is removed from the policy$`, fts.theIntegrationIsRemovedFromThePolicy)
 	ctx.Step(`^the "([^"]*)" datasource is shown in the policy as added$`, fts.thePolicyShowsTheDatasourceAdded)
+	ctx.Step(`^the "([^"]*)" datasource is not shown in the policy$`, fts.thePolicyDoesNotShowTheDatasource)
+	ctx.Step(`^the "([^"]*)" integration dashboards have no broken references$`, fts.theIntegrationDashboardsHaveNoBrokenReferences)
 	ctx.Step(`^an "([^"]*)" is successfully deployed with an Agent using "([^"]*)" installer$`, fts.anIntegrationIsSuccessfullyDeployedWithAgentAndInstaller)
 
+	// custom logs steps
+	ctx.Step(`^the "Custom Logs" integration is added in the policy for the "([^"]*)" file$`, fts.theCustomLogsIntegrationIsAddedForTheFile)
+	ctx.Step(`^"(\d+)" lines are written to the custom log file$`, fts.linesAreWrittenToTheCustomLogsFile)
+	ctx.Step(`^the custom log lines are present in the data stream$`, fts.theCustomLogsLinesArePresentInTheDataStream)
+
+	// FIPS steps
+	ctx.Step(`^the stack runs in FIPS mode$`, fts.theStackRunsInFIPSMode)
+
+	// package registry steps
+	ctx.Step(`^the package registry fails with "([^"]*)" status$`, fts.thePackageRegistryFailsWithStatus)
+	ctx.Step(`^the package registry is available$`, fts.thePackageRegistryIsAvailable)
+	ctx.Step(`^the "([^"]*)" integration cannot be added to the policy$`, fts.theIntegrationCannotBeAddedToThePolicy)
+
+	// agent monitoring steps
+	ctx.Step(`^the agent monitoring of "([^"]*)" is "([^"]*)" in the policy$`, fts.theAgentMonitoringIsOperatedInThePolicy)
+	ctx.Step(`^the "([^"]*)" monitoring data streams of the agent have data$`, fts.theMonitoringDataStreamsHaveData)
+	ctx.Step(`^the "([^"]*)" monitoring data streams of the agent stop receiving data$`, fts.theMonitoringDataStreamsStopReceivingData)
+	ctx.Step(`^the agent logs contain the "([^"]*)" message$`, fts.theAgentLogsContainTheMessage)
+
+	// agent actions steps
+	ctx.Step(`^the log level of the agent is set to "([^"]*)"$`, fts.theLogLevelOfTheAgentIsSetTo)
+	ctx.Step(`^the agent acknowledges the action$`, fts.theAgentAcknowledgesTheAction)
+	ctx.Step(`^the agent reports the "([^"]*)" log level$`, fts.theAgentReportsTheLogLevel)
+	ctx.Step(`^the agent logs contain "([^"]*)" messages$`, fts.theAgentLogsContainMessagesWithLevel)
+	ctx.Step(`^the agent logs contain no "([^"]*)" entries$`, fts.theAgentLogsContainNoEntries)
+
+	// Fleet settings steps
+	ctx.Step(`^the default output is configured with "([^"]*)"$`, fts.theDefaultOutputIsConfiguredWith)
+	ctx.Step(`^the "([^"]*)" URL is added to the default Fleet Server host$`, fts.theURLIsAddedToTheDefaultFleetServerHost)
+
+	// offline detection steps
+	ctx.Step(`^the policy has an "([^"]*)" timeout of "(\d+)" seconds$`, fts.thePolicyHasATimeoutOfSeconds)
+	ctx.Step(`^the agent is listed in Fleet as "([^"]*)" within the "([^"]*)" timeout$`, fts.theAgentIsListedInFleetWithStatusWithinTheTimeout)
+
+	// policy propagation steps
+	ctx.Step(`^the agent applies the latest revision of the policy$`, fts.theAgentAppliesTheLatestRevisionOfThePolicy)
+
+	// stack steps
+	ctx.Step(`^the stack supports the "([^"]*)" capability$`, fts.theStackSupportsTheCapability)
+	ctx.Step(`^the stack version is "([^"]*)"$`, fts.theStackVersionIs)
+
+	// tamper protection steps
+	ctx.Step(`^the stack supports tamper protection$`, fts.theStackSupportsTamperProtection)
+	ctx.Step(`^the policy is protected against tampering$`, fts.thePolicyIsProtectedAgainstTampering)
+	ctx.Step(`^the agent is uninstalled without the uninstall token$`, fts.theAgentIsUninstalledWithoutTheUninstallToken)
+	ctx.Step(`^the agent is uninstalled with the uninstall token$`, fts.theAgentIsUninstalledWithTheUninstallToken)
+
+	// audit log steps
+	ctx.Step(`^the audit log contains "([^"]*)" events$`, fts.theAuditLogContainsEvents)
+	ctx.Step(`^the audit log contains "([^"]*)" events by "([^"]*)"$`, fts.theAuditLogContainsEventsByUser)
+
+	// logstash steps
+	ctx.Step(`^the policy sends data through Logstash$`, fts.thePolicySendsDataThroughLogstash)
+	ctx.Step(`^there is new data in the index from agent through Logstash$`, fts.thereIsNewDataInTheIndexFromAgentThroughLogstash)
+
+	// proxy steps
+	ctx.Step(`^the proxy is deployed$`, fts.theProxyIsDeployed)
+	ctx.Step(`^the proxy is stopped$`, fts.theProxyIsStopped)
+	ctx.Step(`^the proxy is started again after "(\d+)" seconds$`, fts.theProxyIsStartedAgainAfterSeconds)
+	ctx.Step(`^the "([^"]*)" events collected during the proxy outage are delivered$`, fts.theEventsCollectedDuringTheProxyOutageAreDelivered)
+	ctx.Step(`^an agent is deployed to Fleet with "([^"]*)" installer through the proxy$`, fts.anAgentIsDeployedToFleetWithInstallerThroughTheProxy)
+	ctx.Step(`^an attempt to deploy an agent to Fleet with "([^"]*)" installer through the proxy fails$`, fts.anAttemptToDeployAnAgentToFleetWithInstallerThroughTheProxyFails)
+	ctx.Step(`^the policy sends data through the proxy$`, fts.thePolicySendsDataThroughTheProxy)
+	ctx.Step(`^the proxy forwards the requests to "([^"]*)"$`, fts.theProxyForwardsTheRequestsTo)
+	ctx.Step(`^the agent is not enrolled in Fleet$`, fts.theAgentIsNotEnrolledInFleet)
+
+	// Fleet Server TLS steps
+	ctx.Step(`^a Fleet Server using TLS is deployed$`, fts.aFleetServerUsingTLSIsDeployed)
+	ctx.Step(`^a Fleet Server using TLS with an expired certificate is deployed$`, fts.aFleetServerUsingTLSWithAnExpiredCertificateIsDeployed)
+	ctx.Step(`^an agent is deployed to Fleet with "([^"]*)" installer using TLS$`, fts.anAgentIsDeployedToFleetWithInstallerUsingTLS)
+	ctx.Step(`^the certificate of the Fleet Server is rotated$`, fts.theCertificateOfTheFleetServerIsRotated)
+	ctx.Step(`^the agent reconnects to Fleet without enrolling again$`, fts.theAgentReconnectsToFleetWithoutEnrollingAgain)
+	ctx.Step(`^the agent keeps its enrollment after the host restart$`, fts.theAgentKeepsItsEnrollmentAfterTheHostRestart)
+	ctx.Step(`^the agent resumes shipping its logs after the host restart$`, fts.theAgentResumesShippingItsLogsAfterTheHostRestart)
+	ctx.Step(`^an attempt to deploy an agent to Fleet with "([^"]*)" installer using TLS fails$`, fts.anAttemptToDeployAnAgentToFleetWithInstallerUsingTLSFails)
+	ctx.Step(`^an attempt to deploy an agent to Fleet with "([^"]*)" installer trusting a different CA fails$`, fts.anAttemptToDeployAnAgentToFleetWithInstallerTrustingADifferentCAFails)
+	ctx.Step(`^the enrollment fails with an? "([^"]*)" certificate error$`, fts.theEnrollmentFailsWithACertificateError)
+
+	// outputs steps
+	ctx.Step(`^the policy sends "([^"]*)" to the secondary Elasticsearch cluster$`, fts.thePolicySendsToTheSecondaryElasticsearchCluster)
+	ctx.Step(`^the policy sends monitoring data to the monitoring Elasticsearch cluster$`, fts.thePolicySendsMonitoringDataToTheMonitoringCluster)
+	ctx.Step(`^the agent monitoring data is only in the monitoring Elasticsearch cluster$`, fts.theAgentMonitoringDataIsOnlyInTheMonitoringCluster)
+	ctx.Step(`^the "([^"]*)" data streams have data in the "([^"]*)" Elasticsearch cluster$`, fts.theDataStreamsHaveDataInTheCluster)
+	ctx.Step(`^the "([^"]*)" data streams have no data in the "([^"]*)" Elasticsearch cluster$`, fts.theDataStreamsHaveNoDataInTheCluster)
+
+	// data streams steps
+	ctx.Step(`^the backing indices of the "([^"]*)" data stream are "([^"]*)"$`, fts.theBackingIndicesOfTheDataStreamAreInHealthStatus)
+	ctx.Step(`^the backing indices of the "([^"]*)" data stream have "(\d+)" primary shards$`, fts.theBackingIndicesOfTheDataStreamHavePrimaryShards)
+	ctx.Step(`^there are at least "(\d+)" documents in the "([^"]*)" index within "([^"]*)"$`, fts.thereAreAtLeastDocumentsInTheIndexWithin)
+	ctx.Step(`^there are at least "(\d+)" documents in the "([^"]*)" index within "([^"]*)" matching:$`, fts.thereAreAtLeastDocumentsInTheIndexWithinMatching)
+	ctx.Step(`^the documents in the "([^"]*)" index have at least "(\d+)" different values of "([^"]*)"$`, fts.theDocumentsInTheIndexHaveDifferentValuesOf)
+	ctx.Step(`^the documents in the "([^"]*)" index have at least these counts by "([^"]*)":$`, fts.theDocumentsInTheIndexHaveTheCountsBy)
+	ctx.Step(`^there are no duplicated events in the "([^"]*)" index$`, fts.thereAreNoDuplicatedEventsInTheIndex)
+	ctx.Step(`^there are no duplicated events in the "([^"]*)" index by "([^"]*)"$`, fts.thereAreNoDuplicatedEventsInTheIndexBy)
+
 	// endpoint steps
 	ctx.Step(`^the host name is shown in the Administration view in the Security App as "([^"]*)"$`, fts.theHostNameIsShownInTheAdminViewInTheSecurityApp)
 	ctx.Step(`^the host name is not shown in the Administration view in the Security App$`, fts.theHostNameIsNotShownInTheAdminViewInTheSecurityApp)
@@ -474,6 +794,13 @@ func InitializeFleetTestScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^there is no new data in the index after agent shuts down$`, fts.thereIsNoNewDataInTheIndexAfterAgentShutsDown)
 	ctx.Step(`^the stand-alone agent is listed in Fleet as "([^"]*)"$`, fts.theStandaloneAgentIsListedInFleetWithStatus)
 
+	// scale steps
+	ctx.Step(`^"(\d+)" agents are deployed to Fleet at scale$`, fts.agentsAreDeployedToFleetAtScale)
+	ctx.Step(`^all the agents are listed in Fleet as "([^"]*)"$`, fts.allTheAgentsAreListedInFleetWithStatus)
+
+	// soak steps
+	ctx.Step(`^the agent stays healthy during the soak period$`, fts.theAgentStaysHealthyDuringTheSoakPeriod)
+
 	// process steps
 	ctx.Step(`^the "([^"]*)" process is in the "([^"]*)" state on the host$`, fts.processStateOnTheHost)
 	ctx.Step(`^there are "([^"]*)" instances of the "([^"]*)" process in the "([^"]*)" state$`, fts.thereAreInstancesOfTheProcessInTheState)
@@ -504,8 +831,9 @@ func InitializeFleetTestSuite(ctx *godog.TestSuiteContext) {
 
 		// FIXME: This needs to go into deployer code for docker somehow. Must resolve
 		// cyclic imports since common.defaults now imports deploy module
-		if !shell.GetEnvBool("SKIP_PULL") && common.Provider != "remote" {
-			images := []string{
+		images := []string{}
+		if common.Provider != "remote" {
+			images = []string{
 				"docker.elastic.co/beats/elastic-agent:" + common.ElasticAgentVersion,
 				"docker.elastic.co/beats/elastic-agent-ubi8:" + common.ElasticAgentVersion,
 				"docker.elastic.co/elasticsearch/elasticsearch:" + common.StackVersion,
@@ -518,7 +846,10 @@ func InitializeFleetTestSuite(ctx *godog.TestSuiteContext) {
 			if !strings.HasPrefix(common.KibanaVersion, "pr") {
 				images = append(images, "docker.elastic.co/kibana/kibana:"+common.KibanaVersion)
 			}
+		}
 
+		// the images are also listed in the environment manifest, with their digests
+		if !shell.GetEnvBool("SKIP_PULL") && len(images) > 0 {
 			deploy.PullImages(suiteContext, images)
 		}
 
@@ -535,9 +866,18 @@ func InitializeFleetTestSuite(ctx *godog.TestSuiteContext) {
 			common.ProfileEnv["kibanaDockerNamespace"] = "observability-ci"
 			common.ProfileEnv["KIBANA_IMAGE_REF_CUSTOM"] = "docker.elastic.co/observability-ci/kibana:" + common.KibanaVersion
 		}
+		common.ProfileEnv = packageRegistryEnv(common.ProfileEnv)
+		common.ProfileEnv = fipsProfileEnv(common.ProfileEnv)
+		if auth.SecurityDisabled() {
+			common.ProfileEnv["kibanaHealthPath"] = "app/home"
+			common.ProfileEnv["stackSecurityEnabled"] = "false"
+		}
+
+		captureEnvironmentManifest(suiteContext, images)
+		status.SuiteStarted("fleet", environmentManifestLabels(common.ProfileEnv))
 
 		if common.Provider != "remote" {
-			err := bootstrapFleet(suiteContext, common.ProfileEnv)
+			err := bootstrapFleet(suiteContext, deploy.NewServiceRequest(common.FleetProfileName), common.ProfileEnv)
 			if err != nil {
 				log.WithError(err).Fatal("Could not bootstrap Fleet runtime dependencies")
 			}
@@ -548,6 +888,12 @@ func InitializeFleetTestSuite(ctx *godog.TestSuiteContext) {
 			}
 		}
 
+		initServiceAccountAuth(suiteContext)
+
+		if common.DeveloperMode && common.Provider != "remote" {
+			fts.cleanupPreviousRuns(suiteContext)
+		}
+
 		fts.Version = common.BeatVersionBase
 		fts.RuntimeDependenciesStartDate = time.Now().UTC()
 	})
@@ -558,6 +904,14 @@ func InitializeFleetTestSuite(ctx *godog.TestSuiteContext) {
 		}
 		defer f()
 
+		fts.stopRunDeadline()
+		reportEnrollmentBenchmark()
+		reportPolicyPropagationBenchmark()
+		reportHTTPLatencies()
+		reportKibanaDeprecations()
+		reportEnvironmentManifest()
+		stopPackageRegistry()
+
 		// instrumentation
 		var suiteTx *apm.Transaction
 		var suiteParentSpan *apm.Span
@@ -569,10 +923,18 @@ func InitializeFleetTestSuite(ctx *godog.TestSuiteContext) {
 		suiteContext = apm.ContextWithSpan(suiteContext, suiteParentSpan)
 		defer suiteParentSpan.End()
 
+		// the runtime dependencies outlive the run, so its resources must not accumulate
+		if common.DeveloperMode || common.Provider == "remote" {
+			fts.cleanupRunResources(suiteContext, naming.NamePattern(naming.RunID()))
+			revokeServiceAccountAuth(suiteContext)
+			deleteFleetServerTokens(suiteContext)
+		}
+
 		if !common.DeveloperMode && common.Provider != "remote" {
 			log.Debug("Destroying Fleet runtime dependencies")
 			deployer := deploy.New(common.Provider)
 			deployer.Destroy(suiteContext, deploy.NewServiceRequest(common.FleetProfileName))
+			removeRunDockerResources(naming.RunID())
 		}
 	})
 }
@@ -588,7 +950,7 @@ func init() {
 
 func TestMain(m *testing.M) {
 	flag.Parse()
-	opts.Paths = flag.Args()
+	opts.Paths = expandFeaturePaths(flag.Args())
 
 	status := godog.TestSuite{
 		Name:                 "fleet",
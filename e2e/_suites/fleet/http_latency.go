@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/elastic/e2e-testing/internal/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// httpLatencyReportedEndpoints the number of endpoints with the highest total latency included in the logs.
+// All of them are stored in the results file
+const httpLatencyReportedEndpoints = 10
+
+// reportHTTPLatencies logs the endpoints of the Kibana and Elasticsearch APIs where the run spent more time,
+// and stores the latencies of all of them with the results of the benchmarks
+func reportHTTPLatencies() {
+	summary := metrics.HTTPLatencies.Summary()
+	if len(summary) == 0 {
+		return
+	}
+
+	for i, s := range summary {
+		if i == httpLatencyReportedEndpoints {
+			break
+		}
+
+		log.WithFields(log.Fields{
+			"count":    s.Count,
+			"endpoint": s.Endpoint,
+			"max":      fmt.Sprintf("%.2fs", s.Max),
+			"method":   s.Method,
+			"p50":      fmt.Sprintf("%.2fs", s.P50),
+			"p95":      fmt.Sprintf("%.2fs", s.P95),
+			"target":   s.Target,
+			"total":    fmt.Sprintf("%.1fs", s.Total),
+		}).Info("HTTP latency summary")
+	}
+
+	if benchmarkResultsDir == "" {
+		return
+	}
+
+	path := filepath.Join(benchmarkResultsDir, fmt.Sprintf("http-latency-%s.json", benchmarkRunID))
+
+	bytes, err := json.MarshalIndent(summary, "", "  ")
+	if err == nil {
+		err = io.WriteFile(bytes, path)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  path,
+		}).Warn("Could not store the HTTP latencies")
+		return
+	}
+
+	log.WithField("path", path).Info("HTTP latencies stored")
+}
@@ -10,9 +10,10 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/cucumber/godog"
 	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
 	"github.com/elastic/e2e-testing/internal/utils"
-	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -45,36 +46,138 @@ func (fts *FleetTestSuite) theIntegrationIsOperatedInThePolicy(packageName strin
 	}
 
 	if strings.ToLower(action) == actionADDED {
-		packageDataStream := kibana.PackageDataStream{
-			Name:        fmt.Sprintf("%s-%s", integration.Name, uuid.New().String()),
-			Description: integration.Title,
-			Namespace:   "default",
-			PolicyID:    policy.ID,
-			Enabled:     true,
-			Package:     integration,
-			Inputs:      []kibana.Input{},
-		}
-		packageDataStream.Inputs = inputs(integration.Name)
-
-		err = client.AddIntegrationToPolicy(ctx, packageDataStream)
-		if err != nil {
-			log.WithFields(log.Fields{
-				"err":       err,
-				"packageDS": packageDataStream,
-			}).Error("Unable to add integration to policy")
-			return err
-		}
+		return fts.addIntegrationToPolicy(integration, []kibana.PackagePolicySetting{})
 	} else if strings.ToLower(action) == actionREMOVED {
 		packageDataStream, err := client.GetIntegrationFromAgentPolicy(ctx, integration.Name, policy)
 		if err != nil {
 			return err
 		}
+		fts.policyChanged()
 		return client.DeleteIntegrationFromPolicy(ctx, packageDataStream)
 	}
 
 	return nil
 }
 
+// theIntegrationIsAddedToThePolicy adds the integration to the policy of the scenario, through the package policies API
+func (fts *FleetTestSuite) theIntegrationIsAddedToThePolicy(packageName string) error {
+	return fts.theIntegrationIsOperatedInThePolicy(packageName, actionADDED)
+}
+
+// theIntegrationIsRemovedFromThePolicy removes the integration from the policy of the scenario, through the package
+// policies API
+func (fts *FleetTestSuite) theIntegrationIsRemovedFromThePolicy(packageName string) error {
+	return fts.theIntegrationIsOperatedInThePolicy(packageName, actionREMOVED)
+}
+
+// theIntegrationIsAddedInThePolicyWithTheSettings adds the integration to the policy, setting the variables of its
+// inputs and streams from a table with the input, dataset, variable, value and type columns
+func (fts *FleetTestSuite) theIntegrationIsAddedInThePolicyWithTheSettings(packageName string, table *godog.Table) error {
+	rows := [][]string{}
+	for _, row := range table.Rows {
+		cells := []string{}
+		for _, cell := range row.Cells {
+			cells = append(cells, cell.Value)
+		}
+		rows = append(rows, cells)
+	}
+
+	settings, err := kibana.ParsePackagePolicySettings(rows)
+	if err != nil {
+		return err
+	}
+
+	integration, err := fts.kibanaClient.GetIntegrationByPackageName(fts.currentContext, packageName)
+	if err != nil {
+		return err
+	}
+
+	return fts.addIntegrationToPolicy(integration, settings)
+}
+
+// addIntegrationToPolicy adds the integration to the policy with its default inputs, overridden by the settings
+func (fts *FleetTestSuite) addIntegrationToPolicy(integration kibana.IntegrationPackage, settings []kibana.PackagePolicySetting) error {
+	packageDataStream := kibana.PackageDataStream{
+		Name:        naming.Name(integration.Name),
+		Description: integration.Title,
+		Namespace:   "default",
+		PolicyID:    fts.Policy.ID,
+		Enabled:     true,
+		Package:     integration,
+		Inputs:      inputs(integration.Name),
+	}
+	kibana.ApplyPackagePolicySettings(&packageDataStream, settings)
+
+	fts.policyChanged()
+	err := fts.kibanaClient.AddIntegrationToPolicy(fts.currentContext, packageDataStream)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":       err,
+			"packageDS": packageDataStream,
+		}).Error("Unable to add integration to policy")
+		return err
+	}
+
+	// the data assertions would retry until the data streams exist if the package was not installed yet
+	err = fts.kibanaClient.WaitForIntegrationInstalled(fts.currentContext, integration)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"package":  integration.Name,
+		"policy":   fts.Policy.ID,
+		"settings": len(settings),
+	}).Debug("Integration added to the policy")
+
+	return nil
+}
+
+// theIntegrationDashboardsHaveNoBrokenReferences resolves the visualizations, searches and index patterns
+// referenced by the dashboards installed by the integration, failing if any of them does not exist
+func (fts *FleetTestSuite) theIntegrationDashboardsHaveNoBrokenReferences(packageName string) error {
+	integration, err := fts.kibanaClient.GetIntegrationByPackageName(fts.currentContext, packageName)
+	if err != nil {
+		return err
+	}
+
+	assets, err := fts.kibanaClient.GetInstalledKibanaAssets(fts.currentContext, integration)
+	if err != nil {
+		return err
+	}
+
+	dashboards := []kibana.SavedObjectReference{}
+	for _, asset := range assets {
+		if asset.Type == "dashboard" {
+			dashboards = append(dashboards, asset)
+		}
+	}
+	if len(dashboards) == 0 {
+		return fmt.Errorf("the %s integration has not installed any dashboard", integration.Name)
+	}
+
+	broken, err := fts.kibanaClient.FindBrokenReferences(fts.currentContext, dashboards)
+	if err != nil {
+		return err
+	}
+
+	if len(broken) > 0 {
+		references := []string{}
+		for _, b := range broken {
+			references = append(references, b.String())
+		}
+
+		return fmt.Errorf("the dashboards of the %s integration have %d broken references: %s", integration.Name, len(broken), strings.Join(references, ", "))
+	}
+
+	log.WithFields(log.Fields{
+		"dashboards": len(dashboards),
+		"package":    integration.Name,
+	}).Info("The references of the dashboards are resolved")
+
+	return nil
+}
+
 func (fts *FleetTestSuite) thePolicyShowsTheDatasourceAdded(packageName string) error {
 	log.WithFields(log.Fields{
 		"policyID": fts.Policy.ID,
@@ -111,6 +214,23 @@ func (fts *FleetTestSuite) thePolicyShowsTheDatasourceAdded(packageName string)
 	return nil
 }
 
+// thePolicyDoesNotShowTheDatasource checks the policy of the scenario does not include the integration. Removing a
+// package policy is synchronous, so the check is not retried
+func (fts *FleetTestSuite) thePolicyDoesNotShowTheDatasource(packageName string) error {
+	packagePolicies, err := fts.kibanaClient.ListPackagePoliciesByPolicy(fts.currentContext, fts.Policy.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, packagePolicy := range packagePolicies {
+		if strings.EqualFold(packageName, packagePolicy.Package.Title) || strings.EqualFold(packageName, packagePolicy.Package.Name) {
+			return fmt.Errorf("the %s integration is still in the %s policy: %s", packageName, fts.Policy.ID, packagePolicy.ID)
+		}
+	}
+
+	return nil
+}
+
 func inputs(integration string) []kibana.Input {
 	switch integration {
 	case "apm":
@@ -134,7 +254,7 @@ func inputs(integration string) []kibana.Input {
 				Enabled: true,
 				Streams: []kibana.Stream{
 					{
-						ID:      "linux/metrics-linux.memory-" + uuid.New().String(),
+						ID:      naming.Name("linux/metrics-linux.memory"),
 						Enabled: true,
 						DS: kibana.DataStream{
 							Dataset: "linux.memory",
@@ -0,0 +1,71 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/config"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// kibanaJournalDir the directory where the journals of the requests sent to the Kibana API during each
+// scenario are written. It is empty if the KIBANA_JOURNAL env var is not enabled
+var kibanaJournalDir string
+
+// kibanaJournalPath the journal of the current scenario
+var kibanaJournalPath string
+
+func initKibanaJournal() {
+	if !shell.GetEnvBool("KIBANA_JOURNAL") {
+		return
+	}
+
+	kibanaJournalDir = shell.GetEnv("KIBANA_JOURNAL_DIR", filepath.Join(config.OpDir(), "journals"))
+
+	log.WithFields(log.Fields{
+		"dir": kibanaJournalDir,
+	}).Info("The requests sent to the Kibana API will be recorded into a journal per scenario")
+}
+
+// startKibanaJournal records the requests sent to the Kibana API since the scenario starts, including
+// the ones of its clean up
+func startKibanaJournal(scenario string) {
+	if kibanaJournalDir == "" {
+		return
+	}
+
+	path := filepath.Join(kibanaJournalDir, fmt.Sprintf("%s-%d.ndjson", scenarioFileName(scenario), time.Now().Unix()))
+	err := kibana.StartJournal(path)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"scenario": scenario,
+		}).Warn("Could not record the requests sent to the Kibana API")
+		return
+	}
+
+	kibanaJournalPath = path
+}
+
+// stopKibanaJournal stops recording the requests once the scenario has been cleaned up, pointing to the
+// journal when the scenario failed
+func stopKibanaJournal(scenario string, err error) {
+	path := kibana.StopJournal()
+	kibanaJournalPath = ""
+
+	if path == "" || err == nil {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"journal":  path,
+		"scenario": scenario,
+	}).Error("The scenario failed. Check the requests sent to the Kibana API in the journal")
+}
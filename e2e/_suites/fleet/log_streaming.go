@@ -0,0 +1,76 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/config"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// logStreamer streams the logs of the selected services during each scenario. It is nil if the
+// LOG_STREAMING_SERVICES env var is empty
+var logStreamer *deploy.LogStreamer
+
+// logStreamingServices the services which logs are streamed, read from the LOG_STREAMING_SERVICES env var
+// as a comma-separated list (i.e. kibana,elasticsearch,fleet-server)
+var logStreamingServices = []string{}
+
+func initLogStreaming() {
+	services := shell.GetEnv("LOG_STREAMING_SERVICES", "")
+	if services == "" {
+		return
+	}
+
+	for _, service := range strings.Split(services, ",") {
+		if s := strings.TrimSpace(service); s != "" {
+			logStreamingServices = append(logStreamingServices, s)
+		}
+	}
+
+	dir := shell.GetEnv("LOG_STREAMING_DIR", filepath.Join(config.OpDir(), "logs"))
+	maxSizeMB := shell.GetEnvInteger("LOG_STREAMING_MAX_SIZE_MB", 10)
+	maxBackups := shell.GetEnvInteger("LOG_STREAMING_MAX_BACKUPS", 5)
+
+	logStreamer = deploy.NewLogStreamer(dir, int64(maxSizeMB)*1024*1024, maxBackups)
+
+	log.WithFields(log.Fields{
+		"dir":      dir,
+		"services": logStreamingServices,
+	}).Info("The logs of the services will be streamed to files during the scenarios")
+}
+
+// startLogStreaming follows the logs of the selected services since the scenario starts
+func startLogStreaming(scenario string) {
+	if logStreamer == nil {
+		return
+	}
+
+	services := []deploy.ServiceRequest{}
+	for _, service := range logStreamingServices {
+		services = append(services, deploy.NewServiceRequest(service))
+	}
+
+	err := logStreamer.Start(fts.currentContext, scenario, services...)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"scenario": scenario,
+		}).Warn("Could not stream the logs of the services")
+	}
+}
+
+// stopLogStreaming stops following the logs, once the scenario has been cleaned up
+func stopLogStreaming() {
+	if logStreamer == nil {
+		return
+	}
+
+	logStreamer.Stop()
+}
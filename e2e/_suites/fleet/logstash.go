@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/auth"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// logstashServiceName the name of the Logstash service in the compose files
+const logstashServiceName = "logstash"
+
+// logstashTag tag added by the Logstash pipeline to every event going through it
+const logstashTag = "e2e_logstash"
+
+func (fts *FleetTestSuite) thePolicySendsDataThroughLogstash() error {
+	env := fts.getProfileEnv()
+
+	// the pipeline sends the events to Elasticsearch with the credentials of the test framework
+	esCredentials := auth.ForService(auth.ElasticsearchService)
+	env["elasticsearchUsername"] = esCredentials.Username
+	env["elasticsearchPassword"] = esCredentials.Password
+
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceContainerRequest(logstashServiceName),
+	}
+	err := fts.getDeployer().Add(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not deploy Logstash")
+		return err
+	}
+
+	output, err := fts.kibanaClient.CreateOutput(fts.currentContext, kibana.Output{
+		Name:  naming.Name("logstash"),
+		Type:  "logstash",
+		Hosts: []string{logstashServiceName + ":5044"},
+	})
+	if err != nil {
+		return err
+	}
+	fts.LogstashOutputID = output.ID
+
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyOutputs(fts.currentContext, fts.Policy, output.ID, "")
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+
+	log.WithFields(log.Fields{
+		"output": output.ID,
+		"policy": policy.ID,
+	}).Info("The policy sends data through Logstash")
+
+	return nil
+}
+
+func (fts *FleetTestSuite) thereIsNewDataInTheIndexFromAgentThroughLogstash() error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	query := elasticsearch.NewBoolQuery().
+		Term("tags", logstashTag).
+		MatchPhrase("host.name", manifest.Hostname).
+		Since(fts.RuntimeDependenciesStartDate).
+		Map()
+
+	indexName := "logs-*,metrics-*"
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	_, err := elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), indexName, query, 1, maxTimeout)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn(elasticsearch.WaitForIndices())
+	}
+
+	return err
+}
+
+// removeLogstash deletes the Logstash output from Fleet and removes the Logstash service, if they were created
+func (fts *FleetTestSuite) removeLogstash() {
+	if fts.LogstashOutputID == "" {
+		return
+	}
+
+	err := fts.kibanaClient.DeleteOutput(fts.currentContext, fts.LogstashOutputID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":    err,
+			"output": fts.LogstashOutputID,
+		}).Warn("The Logstash output could not be deleted")
+	}
+
+	env := fts.getProfileEnv()
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceContainerRequest(logstashServiceName),
+	}
+	err = fts.getDeployer().Remove(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err": err,
+		}).Warn("The Logstash service could not be removed")
+	}
+
+	fts.LogstashOutputID = ""
+}
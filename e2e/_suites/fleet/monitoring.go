@@ -0,0 +1,193 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// agentLogsIndexPattern the data streams where the agent sends its own logs, excluding the logs of the processes
+// it runs, which use their own datasets
+const agentLogsIndexPattern = "logs-elastic_agent-*"
+
+// monitoringQuietPeriod the time without new monitoring documents after which the agent is considered to have
+// stopped sending them. The agent collects its metrics every 10 seconds, and ships its logs continuously
+const monitoringQuietPeriod = time.Minute
+
+func (fts *FleetTestSuite) theAgentMonitoringIsOperatedInThePolicy(monitoringType string, state string) error {
+	if err := validateMonitoringType(monitoringType); err != nil {
+		return err
+	}
+
+	if state != "enabled" && state != "disabled" {
+		return fmt.Errorf("'%s' is not a valid state for the monitoring. Valid values are: enabled, disabled", state)
+	}
+
+	monitoringEnabled := []string{}
+	for _, t := range fts.Policy.MonitoringEnabled {
+		if t != monitoringType {
+			monitoringEnabled = append(monitoringEnabled, t)
+		}
+	}
+	if state == "enabled" {
+		monitoringEnabled = append(monitoringEnabled, monitoringType)
+	}
+
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyMonitoring(fts.currentContext, fts.Policy, monitoringEnabled)
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+	fts.MonitoringChangedDate = time.Now().UTC()
+
+	log.WithFields(log.Fields{
+		"monitoring": policy.MonitoringEnabled,
+		"policy":     policy.ID,
+		"type":       monitoringType,
+	}).Infof("The agent monitoring is %s in the policy", state)
+
+	return nil
+}
+
+func (fts *FleetTestSuite) theMonitoringDataStreamsHaveData(monitoringType string) error {
+	if err := validateMonitoringType(monitoringType); err != nil {
+		return err
+	}
+
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+
+	// documents collected before the monitoring was enabled do not count
+	since := fts.RuntimeDependenciesStartDate
+	if fts.MonitoringChangedDate.After(since) {
+		since = fts.MonitoringChangedDate
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	_, err = elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), monitoringIndexPattern(monitoringType), agentMonitoringQuery(agentID, since).Map(), 1, maxTimeout)
+
+	return err
+}
+
+// theMonitoringDataStreamsStopReceivingData waits for a quiet period, after the monitoring was changed in the policy,
+// without new monitoring documents from the agent, as the agent keeps sending them until it applies the policy
+func (fts *FleetTestSuite) theMonitoringDataStreamsStopReceivingData(monitoringType string) error {
+	if err := validateMonitoringType(monitoringType); err != nil {
+		return err
+	}
+
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 5
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	indexPattern := monitoringIndexPattern(monitoringType)
+
+	noNewDataFn := func() error {
+		since := time.Now().UTC().Add(-monitoringQuietPeriod)
+		if since.Before(fts.MonitoringChangedDate) {
+			err := fmt.Errorf("the %s monitoring was changed in the policy less than %s ago", monitoringType, monitoringQuietPeriod)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+			}).Debug(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		result, err := elasticsearch.Search(fts.currentContext, indexPattern, agentMonitoringQuery(agentID, since).Map())
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		err = elasticsearch.AssertHitsAreNotPresent(result)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime":  exp.GetElapsedTime(),
+				"indexPattern": indexPattern,
+				"retry":        retryCount,
+				"since":        since,
+			}).Warn("The agent is still sending monitoring data")
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"elapsedTime":  exp.GetElapsedTime(),
+			"indexPattern": indexPattern,
+			"retries":      retryCount,
+		}).Info("The agent stopped sending monitoring data")
+
+		return nil
+	}
+
+	return backoff.Retry(noNewDataFn, exp)
+}
+
+// theAgentLogsContainTheMessage checks that the agent ships its own logs through Fleet, instead of reading them
+// from the host, looking for a message logged since the scenario started
+func (fts *FleetTestSuite) theAgentLogsContainTheMessage(message string) error {
+	agentID, err := fts.getAgentID()
+	if err != nil {
+		return err
+	}
+
+	query := agentMonitoringQuery(agentID, fts.ScenarioStartDate).MatchPhrase("message", message).Map()
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	_, err = elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), agentLogsIndexPattern, query, 1, maxTimeout)
+
+	return err
+}
+
+// getAgentID returns the ID in Fleet of the agent deployed in the current scenario
+func (fts *FleetTestSuite) getAgentID() (string, error) {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	return fts.kibanaClient.GetAgentIDByHostname(fts.currentContext, manifest.Hostname)
+}
+
+// agentMonitoringQuery query to retrieve the monitoring documents of the agent since the given date,
+// which can be narrowed with additional filters
+func agentMonitoringQuery(agentID string, since time.Time) *elasticsearch.BoolQuery {
+	return elasticsearch.NewBoolQuery().
+		MatchPhrase("elastic_agent.id", agentID).
+		Since(since)
+}
+
+// monitoringIndexPattern the data streams where the agent sends its own logs or metrics
+func monitoringIndexPattern(monitoringType string) string {
+	return monitoringType + "-elastic_agent*"
+}
+
+func validateMonitoringType(monitoringType string) error {
+	if monitoringType != "logs" && monitoringType != "metrics" {
+		return fmt.Errorf("'%s' is not a valid type of monitoring. Valid values are: logs, metrics", monitoringType)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	log "github.com/sirupsen/logrus"
+)
+
+// agentsAreDeployedToFleet deploys the agents one after the other, enrolling all of them with the current
+// enrollment token
+func (fts *FleetTestSuite) agentsAreDeployedToFleet(count string) error {
+	agents, err := strconv.Atoi(count)
+	if err != nil {
+		return err
+	}
+	if agents < 1 {
+		return fmt.Errorf("the number of agents must be greater than zero: %d", agents)
+	}
+
+	for i := 0; i < agents; i++ {
+		err := fts.deployAgentToFleet(InstallerType("tar"))
+		if err != nil {
+			return fmt.Errorf("could not deploy the agent %d of %d: %w", i+1, agents, err)
+		}
+	}
+
+	if len(fts.DeployedAgents) != agents {
+		return fmt.Errorf("%d agents were deployed, but %d hosts were found", agents, len(fts.DeployedAgents))
+	}
+
+	return nil
+}
+
+// allTheDeployedAgentsAreListedInFleetWithStatus checks the status of each agent deployed in the scenario
+func (fts *FleetTestSuite) allTheDeployedAgentsAreListedInFleetWithStatus(desiredStatus string) error {
+	if len(fts.DeployedAgents) == 0 {
+		return fmt.Errorf("no agents were deployed in the scenario")
+	}
+
+	for name, agent := range fts.DeployedAgents {
+		err := theAgentIsListedInFleetWithStatus(fts.currentContext, desiredStatus, agent.Hostname)
+		if err != nil {
+			return fmt.Errorf("the agent in %s is not %s: %w", name, desiredStatus, err)
+		}
+	}
+
+	return nil
+}
+
+// theDeployedAgentsAreEnrolledAsDifferentAgents checks Fleet does not mix up the agents enrolled with the
+// same enrollment token, listing each one with its own ID
+func (fts *FleetTestSuite) theDeployedAgentsAreEnrolledAsDifferentAgents() error {
+	hosts := map[string]string{}
+	for name, agent := range fts.DeployedAgents {
+		fleetAgent, err := fts.kibanaClient.GetAgentByHostname(fts.currentContext, agent.Hostname)
+		if err != nil {
+			return err
+		}
+
+		if other, exists := hosts[fleetAgent.ID]; exists {
+			return fmt.Errorf("the agents in %s and %s were enrolled with the same ID: %s", other, name, fleetAgent.ID)
+		}
+		hosts[fleetAgent.ID] = name
+	}
+
+	return nil
+}
+
+// allTheDeployedAgentsRunThePolicy checks each agent deployed in the scenario is assigned to the policy of
+// the scenario
+func (fts *FleetTestSuite) allTheDeployedAgentsRunThePolicy() error {
+	for name, agent := range fts.DeployedAgents {
+		fleetAgent, err := fts.kibanaClient.GetAgentByHostname(fts.currentContext, agent.Hostname)
+		if err != nil {
+			return err
+		}
+
+		if fleetAgent.PolicyID != fts.Policy.ID {
+			return fmt.Errorf("the agent in %s runs the %s policy, expected %s", name, fleetAgent.PolicyID, fts.Policy.ID)
+		}
+	}
+
+	return nil
+}
+
+// recordDeployedAgent keeps the host of the last deployed agent, which is the most recent container of the
+// elastic-agent service
+func (fts *FleetTestSuite) recordDeployedAgent(agentService deploy.ServiceRequest) {
+	manifest, err := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"service": agentService.Name,
+		}).Warn("Could not record the deployed agent")
+		return
+	}
+
+	if fts.DeployedAgents == nil {
+		fts.DeployedAgents = map[string]DeployedAgent{}
+	}
+
+	name := manifest.Name
+	if name == "" {
+		name = manifest.Hostname
+	}
+
+	fts.DeployedAgents[name] = DeployedAgent{
+		Hostname: manifest.Hostname,
+	}
+}
+
+// unenrollDeployedAgents unenrolls the agents deployed in the scenario but the last one, which is unenrolled
+// with the hostname of the elastic-agent service. Their containers are removed with the elastic-agent service
+func (fts *FleetTestSuite) unenrollDeployedAgents() {
+	if len(fts.DeployedAgents) < 2 {
+		return
+	}
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	for name, agent := range fts.DeployedAgents {
+		if agent.Hostname == manifest.Hostname {
+			continue
+		}
+
+		err := fts.kibanaClient.UnEnrollAgent(fts.currentContext, agent.Hostname)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"err":      err,
+				"hostname": agent.Hostname,
+				"name":     name,
+			}).Warn("The deployed agent could not be unenrolled")
+		}
+	}
+}
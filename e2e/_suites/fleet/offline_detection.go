@@ -0,0 +1,133 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// agentStatusGracePeriod the time, on top of a timeout of the policy, that Fleet may take to change the status
+// of an agent, as Fleet Server updates the last check-in of the agents in bulk, and checks the timeouts periodically
+const agentStatusGracePeriod = 2 * time.Minute
+
+func (fts *FleetTestSuite) thePolicyHasATimeoutOfSeconds(timeoutType string, seconds int) error {
+	inactivityTimeout := 0
+	unenrollTimeout := 0
+	switch timeoutType {
+	case "inactivity":
+		inactivityTimeout = seconds
+	case "unenrollment":
+		unenrollTimeout = seconds
+	default:
+		return fmt.Errorf("'%s' is not a valid timeout of the policy. Valid values are: inactivity, unenrollment", timeoutType)
+	}
+
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyTimeouts(fts.currentContext, fts.Policy, inactivityTimeout, unenrollTimeout)
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+
+	log.WithFields(log.Fields{
+		"policy":  policy.ID,
+		"seconds": seconds,
+		"timeout": timeoutType,
+	}).Info("The timeout is set in the policy")
+
+	return nil
+}
+
+// theAgentIsListedInFleetWithStatusWithinTheTimeout checks that Fleet changes the status of the stopped agent
+// before the timeout of the policy is exceeded, allowing a grace period. The agent must not be inactive before
+// the timeout, as the timeouts of the policy are the ones making it inactive, while it can be offline earlier
+func (fts *FleetTestSuite) theAgentIsListedInFleetWithStatusWithinTheTimeout(desiredStatus string, timeoutType string) error {
+	var seconds int
+	switch timeoutType {
+	case "inactivity":
+		seconds = fts.Policy.InactivityTimeout
+	case "unenrollment":
+		seconds = fts.Policy.UnenrollTimeout
+	default:
+		return fmt.Errorf("'%s' is not a valid timeout of the policy. Valid values are: inactivity, unenrollment", timeoutType)
+	}
+
+	if seconds == 0 {
+		return fmt.Errorf("the %s timeout is not set in the %s policy", timeoutType, fts.Policy.ID)
+	}
+
+	if fts.AgentStoppedDate.IsZero() {
+		return fmt.Errorf("the agent was not stopped in the scenario")
+	}
+
+	timeout := time.Duration(seconds) * time.Second
+	gracePeriod := time.Duration(utils.TimeoutFactor) * agentStatusGracePeriod
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	maxTimeout := time.Until(fts.AgentStoppedDate.Add(timeout + gracePeriod))
+	exp := utils.GetExponentialBackOffForCheck("agent status", maxTimeout)
+	retryCount := 1
+
+	agentStatusFn := func() error {
+		agentID, err := fts.kibanaClient.GetAgentIDByHostname(fts.currentContext, manifest.Hostname)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		// unenrolled agents are not listed in Fleet
+		agentStatus := "inactive"
+		if agentID != "" {
+			agentStatus, err = fts.kibanaClient.GetAgentStatusByHostname(fts.currentContext, manifest.Hostname)
+			if err != nil {
+				retryCount++
+				return err
+			}
+		}
+
+		if !strings.EqualFold(agentStatus, desiredStatus) {
+			err := fmt.Errorf("the agent is %s, expected %s", agentStatus, desiredStatus)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+				"timeout":     timeout,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		return nil
+	}
+
+	err := backoff.Retry(agentStatusFn, exp)
+	if err != nil {
+		return fmt.Errorf("the agent was not listed in Fleet as %s within the %s timeout of %s (plus %s): %v", desiredStatus, timeoutType, timeout, gracePeriod, err)
+	}
+
+	elapsed := time.Since(fts.AgentStoppedDate)
+	if strings.EqualFold(desiredStatus, "inactive") && elapsed < timeout-gracePeriod {
+		return fmt.Errorf("the agent was listed in Fleet as %s after %s, before the %s timeout of %s", desiredStatus, elapsed.Round(time.Second), timeoutType, timeout)
+	}
+
+	log.WithFields(log.Fields{
+		"elapsed": elapsed.Round(time.Second),
+		"status":  desiredStatus,
+		"timeout": timeout,
+	}).Infof("The agent is listed in Fleet within the %s timeout", timeoutType)
+
+	return nil
+}
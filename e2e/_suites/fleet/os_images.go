@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/outline"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// osImages the OS images where the agents are deployed, read from the OS_IMAGES_FILE env var
+var osImages *outline.Images
+
+// expandFeaturePaths generates the examples of the scenario outlines tagged with @os_images from the enabled OS
+// images. The expanded features are written to a temporary directory, replacing the original ones in the paths
+func expandFeaturePaths(paths []string) []string {
+	path := shell.GetEnv("OS_IMAGES_FILE", "os_images.yml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return paths
+	}
+
+	images, err := outline.Load(path)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  path,
+		}).Fatal("Could not load the OS images")
+	}
+	osImages = images
+
+	if len(paths) == 0 {
+		// default path of the features in godog
+		paths = []string{"features"}
+	}
+
+	outputDir, err := ioutil.TempDir("", "fleet-features")
+	if err != nil {
+		log.WithField("error", err).Fatal("Could not create the directory for the expanded features")
+	}
+
+	expanded := []string{}
+	for _, p := range paths {
+		// paths with line filters are not expanded
+		if strings.Contains(p, ":") {
+			expanded = append(expanded, p)
+			continue
+		}
+
+		features, err := filepath.Glob(filepath.Join(p, "*.feature"))
+		if err != nil || len(features) == 0 {
+			features = []string{p}
+		}
+
+		for _, feature := range features {
+			expanded = append(expanded, expandFeature(feature, outputDir, images.Enabled()))
+		}
+	}
+
+	return expanded
+}
+
+// expandFeature returns the path of the feature with its examples generated, or the original one
+// if it does not have generated examples
+func expandFeature(feature string, outputDir string, images []outline.Image) string {
+	content, err := ioutil.ReadFile(feature)
+	if err != nil {
+		return feature
+	}
+
+	result, generated, err := outline.Expand(string(content), images)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"feature": feature,
+		}).Fatal("Could not generate the examples of the feature")
+	}
+	if !generated {
+		return feature
+	}
+
+	target := filepath.Join(outputDir, filepath.Base(feature))
+	err = ioutil.WriteFile(target, []byte(result), 0644)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"feature": feature,
+		}).Fatal("Could not write the feature with the generated examples")
+	}
+
+	log.WithFields(log.Fields{
+		"feature": feature,
+		"images":  len(images),
+		"path":    target,
+	}).Debug("Examples generated from the OS images")
+
+	return target
+}
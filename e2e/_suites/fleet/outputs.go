@@ -0,0 +1,218 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// secondaryElasticsearchServiceName the name of the secondary Elasticsearch service in the compose files
+const secondaryElasticsearchServiceName = "elasticsearch-secondary"
+
+// monitoringElasticsearchServiceName the name of the Elasticsearch service dedicated to the monitoring data
+// of the agents in the compose files
+const monitoringElasticsearchServiceName = "elasticsearch-monitoring"
+
+func (fts *FleetTestSuite) thePolicySendsToTheSecondaryElasticsearchCluster(dataType string) error {
+	if dataType != "data" && dataType != "monitoring" {
+		return fmt.Errorf("'%s' is not a valid type of data for the policy. Valid values are: data, monitoring", dataType)
+	}
+
+	output, err := fts.deployElasticsearchOutput(secondaryElasticsearchServiceName)
+	if err != nil {
+		return err
+	}
+	fts.SecondaryElasticsearchOutputID = output.ID
+
+	dataOutputID := ""
+	monitoringOutputID := ""
+	if dataType == "data" {
+		dataOutputID = output.ID
+	} else {
+		monitoringOutputID = output.ID
+	}
+
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyOutputs(fts.currentContext, fts.Policy, dataOutputID, monitoringOutputID)
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+
+	log.WithFields(log.Fields{
+		"output": output.ID,
+		"policy": policy.ID,
+		"type":   dataType,
+	}).Info("The policy sends data to the secondary Elasticsearch cluster")
+
+	return nil
+}
+
+// thePolicySendsMonitoringDataToTheMonitoringCluster uses a dedicated Elasticsearch cluster as the monitoring
+// output of the policy, keeping the output of its data, which could be the secondary cluster
+func (fts *FleetTestSuite) thePolicySendsMonitoringDataToTheMonitoringCluster() error {
+	output, err := fts.deployElasticsearchOutput(monitoringElasticsearchServiceName)
+	if err != nil {
+		return err
+	}
+	fts.MonitoringElasticsearchOutputID = output.ID
+
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyOutputs(fts.currentContext, fts.Policy, fts.Policy.DataOutputID, output.ID)
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+
+	log.WithFields(log.Fields{
+		"output": output.ID,
+		"policy": policy.ID,
+	}).Info("The policy sends monitoring data to the monitoring Elasticsearch cluster")
+
+	return nil
+}
+
+// theAgentMonitoringDataIsOnlyInTheMonitoringCluster checks the logs and metrics of the agent itself are
+// present in the monitoring cluster, and absent from the main one
+func (fts *FleetTestSuite) theAgentMonitoringDataIsOnlyInTheMonitoringCluster() error {
+	for _, indexPattern := range []string{"logs-elastic_agent*", "metrics-elastic_agent*"} {
+		err := fts.theDataStreamsHaveDataInTheCluster(indexPattern, "monitoring")
+		if err != nil {
+			return err
+		}
+
+		err = fts.theDataStreamsHaveNoDataInTheCluster(indexPattern, "main")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deployElasticsearchOutput deploys an additional Elasticsearch cluster, creating an output for it in Fleet
+func (fts *FleetTestSuite) deployElasticsearchOutput(serviceName string) (kibana.Output, error) {
+	env := fts.getProfileEnv()
+
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceContainerRequest(serviceName),
+	}
+	err := fts.getDeployer().Add(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"service": serviceName,
+		}).Error("Could not deploy the Elasticsearch cluster")
+		return kibana.Output{}, err
+	}
+
+	return fts.kibanaClient.CreateOutput(fts.currentContext, kibana.Output{
+		Name:  naming.Name(serviceName),
+		Type:  "elasticsearch",
+		Hosts: []string{"http://" + serviceName + ":9200"},
+	})
+}
+
+func (fts *FleetTestSuite) theDataStreamsHaveDataInTheCluster(indexPattern string, cluster string) error {
+	esEndpoint, err := elasticsearchEndpointForCluster(cluster)
+	if err != nil {
+		return err
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+
+	_, err = elasticsearch.WaitForDocsInCluster(fts.currentContext, esEndpoint, indexPattern, fts.agentDataQuery(), 1, maxTimeout)
+
+	return err
+}
+
+func (fts *FleetTestSuite) theDataStreamsHaveNoDataInTheCluster(indexPattern string, cluster string) error {
+	esEndpoint, err := elasticsearchEndpointForCluster(cluster)
+	if err != nil {
+		return err
+	}
+
+	result, err := elasticsearch.SearchInCluster(fts.currentContext, esEndpoint, indexPattern, fts.agentDataQuery())
+	if err != nil {
+		return err
+	}
+
+	return elasticsearch.AssertHitsAreNotPresent(result)
+}
+
+// agentDataQuery query to retrieve the documents sent by the agent in the current scenario
+func (fts *FleetTestSuite) agentDataQuery() map[string]interface{} {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	return elasticsearch.NewBoolQuery().
+		MatchPhrase("host.name", manifest.Hostname).
+		Since(fts.RuntimeDependenciesStartDate).
+		Map()
+}
+
+// removeSecondaryElasticsearch deletes the output for the secondary Elasticsearch cluster from Fleet,
+// and removes the cluster, if they were created
+func (fts *FleetTestSuite) removeSecondaryElasticsearch() {
+	fts.removeElasticsearchOutput(secondaryElasticsearchServiceName, fts.SecondaryElasticsearchOutputID)
+	fts.SecondaryElasticsearchOutputID = ""
+}
+
+// removeMonitoringElasticsearch deletes the output for the monitoring Elasticsearch cluster from Fleet,
+// and removes the cluster, if they were created
+func (fts *FleetTestSuite) removeMonitoringElasticsearch() {
+	fts.removeElasticsearchOutput(monitoringElasticsearchServiceName, fts.MonitoringElasticsearchOutputID)
+	fts.MonitoringElasticsearchOutputID = ""
+}
+
+func (fts *FleetTestSuite) removeElasticsearchOutput(serviceName string, outputID string) {
+	if outputID == "" {
+		return
+	}
+
+	err := fts.kibanaClient.DeleteOutput(fts.currentContext, outputID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":     err,
+			"output":  outputID,
+			"service": serviceName,
+		}).Warn("The output for the Elasticsearch cluster could not be deleted")
+	}
+
+	env := fts.getProfileEnv()
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceContainerRequest(serviceName),
+	}
+	err = fts.getDeployer().Remove(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":     err,
+			"service": serviceName,
+		}).Warn("The Elasticsearch cluster could not be removed")
+	}
+}
+
+func elasticsearchEndpointForCluster(cluster string) (*elasticsearch.Endpoint, error) {
+	switch strings.ToLower(cluster) {
+	case "main":
+		return elasticsearch.GetElasticSearchEndpoint(), nil
+	case "secondary":
+		return elasticsearch.GetSecondaryElasticSearchEndpoint(), nil
+	case "monitoring":
+		return elasticsearch.GetMonitoringElasticSearchEndpoint(), nil
+	}
+
+	return nil, fmt.Errorf("'%s' is not a valid Elasticsearch cluster. Valid values are: main, secondary, monitoring", cluster)
+}
@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/epr"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// packageRegistry the mock package registry used by Kibana instead of the real one. It is nil if the
+// PACKAGE_REGISTRY_MOCK env var is not enabled
+var packageRegistry *epr.MockRegistry
+
+func initPackageRegistryMock() {
+	if !shell.GetEnvBool("PACKAGE_REGISTRY_MOCK") {
+		return
+	}
+
+	if common.Provider == "remote" {
+		log.Warn("The mock package registry is not supported by the remote provider, as its Kibana is not deployed by the test suite. Using the real registry")
+		return
+	}
+
+	fixturesDir := shell.GetEnv("PACKAGE_REGISTRY_MOCK_FIXTURES", "testresources/packages")
+	registry, err := epr.NewMockRegistry(fixturesDir)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"fixtures": fixturesDir,
+		}).Fatal("Could not load the fixture packages of the mock package registry")
+	}
+
+	addr := ":" + shell.GetEnv("PACKAGE_REGISTRY_MOCK_PORT", "8480")
+	err = registry.Start(addr)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"addr":  addr,
+			"error": err,
+		}).Fatal("Could not start the mock package registry")
+	}
+
+	packageRegistry = registry
+}
+
+// packageRegistryEnv points Kibana to the mock package registry, if enabled, which is reached from
+// the containers through the host
+func packageRegistryEnv(env map[string]string) map[string]string {
+	if packageRegistry != nil {
+		env["fleetRegistryURL"] = fmt.Sprintf("http://host.docker.internal:%d", packageRegistry.Port())
+	}
+
+	return env
+}
+
+// resetPackageRegistry makes the mock package registry available again after a scenario
+func resetPackageRegistry() {
+	if packageRegistry != nil {
+		packageRegistry.ClearFailures()
+	}
+}
+
+// stopPackageRegistry stops the mock package registry at the end of the run
+func stopPackageRegistry() {
+	if packageRegistry == nil {
+		return
+	}
+
+	err := packageRegistry.Stop(context.Background())
+	if err != nil {
+		log.WithField("error", err).Warn("Could not stop the mock package registry")
+	}
+}
+
+// thePackageRegistryFailsWithStatus makes the mock package registry answer every request with the status code.
+// The scenario is pending without the mock package registry, as the real one cannot fail on demand
+func (fts *FleetTestSuite) thePackageRegistryFailsWithStatus(statusCode string) error {
+	if packageRegistry == nil {
+		log.Warn("The package registry can't fail without the mock package registry. Set PACKAGE_REGISTRY_MOCK=true")
+		return godog.ErrPending
+	}
+
+	code, err := strconv.Atoi(statusCode)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid status code", statusCode)
+	}
+
+	return packageRegistry.SimulateFailure("", code, 0)
+}
+
+func (fts *FleetTestSuite) thePackageRegistryIsAvailable() error {
+	resetPackageRegistry()
+	return nil
+}
+
+// theIntegrationCannotBeAddedToThePolicy checks that adding the integration to the policy fails, i.e. because
+// the package registry is not available
+func (fts *FleetTestSuite) theIntegrationCannotBeAddedToThePolicy(packageName string) error {
+	err := fts.theIntegrationIsAddedToThePolicy(packageName)
+	if err == nil {
+		return fmt.Errorf("the %s integration was added to the policy, although the package registry is not available", packageName)
+	}
+
+	log.WithFields(log.Fields{
+		"error":   err,
+		"package": packageName,
+	}).Debug("The integration could not be added to the policy, as expected")
+
+	return nil
+}
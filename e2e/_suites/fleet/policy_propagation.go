@@ -0,0 +1,137 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/benchmark"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// propagationRecorder stores how long the agents take to apply the changes in their policies
+var propagationRecorder *benchmark.PropagationRecorder
+
+// initPolicyPropagationBenchmark stores the results next to the ones of the enrollment benchmark
+func initPolicyPropagationBenchmark() {
+	recorder, err := benchmark.NewPropagationRecorder(benchmarkResultsDir, benchmarkRunID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"dir":   benchmarkResultsDir,
+			"error": err,
+		}).Warn("Could not initialise the policy propagation benchmark. Its results won't be stored")
+		return
+	}
+
+	propagationRecorder = recorder
+}
+
+// policyChanged marks the moment the policy of the current scenario is changed, right before calling the API
+func (fts *FleetTestSuite) policyChanged() {
+	fts.PolicyChangedDate = time.Now()
+}
+
+// theAgentAppliesTheLatestRevisionOfThePolicy waits for the agent to acknowledge the current revision of its policy,
+// recording the time since the last change of the policy
+func (fts *FleetTestSuite) theAgentAppliesTheLatestRevisionOfThePolicy() error {
+	if fts.PolicyChangedDate.IsZero() {
+		return fmt.Errorf("the %s policy was not changed in the scenario", fts.Policy.ID)
+	}
+
+	policy, err := fts.kibanaClient.GetPolicy(fts.currentContext, fts.Policy.ID)
+	if err != nil {
+		return err
+	}
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	policyAppliedFn := func() error {
+		agent, err := fts.kibanaClient.GetAgentByHostname(fts.currentContext, manifest.Hostname)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		if agent.PolicyRevision < policy.Revision {
+			err := fmt.Errorf("the agent runs the revision %d of the %s policy, expected %d", agent.PolicyRevision, policy.ID, policy.Revision)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		return nil
+	}
+
+	// the agents API is polled, so the propagation includes up to one interval of the backoff
+	err = backoff.Retry(policyAppliedFn, exp)
+	if err != nil {
+		return err
+	}
+
+	fts.recordPolicyPropagation(policy.ID, policy.Revision, time.Since(fts.PolicyChangedDate))
+	return nil
+}
+
+func (fts *FleetTestSuite) recordPolicyPropagation(policyID string, revision int, duration time.Duration) {
+	log.WithFields(log.Fields{
+		"duration": fmt.Sprintf("%.1fs", duration.Seconds()),
+		"policy":   policyID,
+		"revision": revision,
+	}).Info("The agent applied the latest revision of the policy")
+
+	if propagationRecorder == nil {
+		return
+	}
+
+	err := propagationRecorder.Record(benchmark.PropagationResult{
+		Scenario:  fts.CurrentScenario,
+		Policy:    policyID,
+		Revision:  revision,
+		Version:   fts.Version,
+		Duration:  duration.Seconds(),
+		Timestamp: fts.PolicyChangedDate.UTC(),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  propagationRecorder.Path(),
+		}).Warn("Could not store the policy propagation benchmark")
+	}
+}
+
+// reportPolicyPropagationBenchmark logs the summary of the policy propagations of the run
+func reportPolicyPropagationBenchmark() {
+	if propagationRecorder == nil {
+		return
+	}
+
+	s := propagationRecorder.Summary()
+	if s.Count == 0 {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"count": s.Count,
+		"max":   fmt.Sprintf("%.1fs", s.Max),
+		"mean":  fmt.Sprintf("%.1fs", s.Mean),
+		"min":   fmt.Sprintf("%.1fs", s.Min),
+		"path":  propagationRecorder.Path(),
+	}).Info("Policy propagation benchmark summary")
+}
@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// theAgentIsAssignedToThePolicy creates a second policy, reassigning the agent to it, and waits for the agent to
+// acknowledge the new policy. The new policy becomes the policy of the scenario for the following steps
+func (fts *FleetTestSuite) theAgentIsAssignedToThePolicy(name string) error {
+	policy, err := fts.kibanaClient.CreatePolicyWithName(fts.currentContext, naming.Name(name))
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"id":       policy.ID,
+		"name":     policy.Name,
+		"previous": fts.Policy.ID,
+	}).Info("Policy created to reassign the agent")
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	agentID, err := fts.kibanaClient.GetAgentIDByHostname(fts.currentContext, manifest.Hostname)
+	if err != nil {
+		return err
+	}
+
+	fts.policyChanged()
+	err = fts.kibanaClient.ReassignAgent(fts.currentContext, agentID, policy.ID)
+	if err != nil {
+		return err
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	policyAcknowledgedFn := func() error {
+		agent, err := fts.kibanaClient.GetAgentByHostname(fts.currentContext, manifest.Hostname)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		// Fleet sets the policy of the agent on reassignment, but the revision only when the agent acknowledges it
+		if agent.PolicyID != policy.ID || agent.PolicyRevision < policy.Revision {
+			err := fmt.Errorf("the agent runs the revision %d of the %s policy, expected the revision %d of the %s policy", agent.PolicyRevision, agent.PolicyID, policy.Revision, policy.ID)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		return nil
+	}
+
+	err = backoff.Retry(policyAcknowledgedFn, exp)
+	if err != nil {
+		return err
+	}
+
+	fts.recordPolicyPropagation(policy.ID, policy.Revision, time.Since(fts.PolicyChangedDate))
+	fts.Policy = policy
+
+	return nil
+}
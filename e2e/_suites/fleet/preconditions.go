@@ -0,0 +1,59 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// theFleetSetupIsComplete waits for Fleet to be set up, so that a feature declares it in its background instead of
+// relying on the bootstrap of the suite. Fleet is already set up most of the times, so it's a quick check
+func (fts *FleetTestSuite) theFleetSetupIsComplete() error {
+	return fts.kibanaClient.WaitForFleet(fts.currentContext)
+}
+
+// noAgentsAreEnrolled checks that none of the agents of the run are enrolled, waiting for the ones unenrolled by
+// the tear-down of the previous scenario to disappear. It does not unenroll any agent, so that the agents of
+// other runs sharing the stack are left alone
+func (fts *FleetTestSuite) noAgentsAreEnrolled() error {
+	namePattern := naming.NamePattern(naming.RunID())
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	noAgentsFn := func() error {
+		policies, err := fts.kibanaClient.ListPoliciesByName(fts.currentContext, namePattern)
+		if err != nil {
+			return err
+		}
+
+		agents, err := fts.listRunAgents(fts.currentContext, namePattern, policies)
+		if err == nil && len(agents) > 0 {
+			err = fmt.Errorf("there are still %d agents of the run enrolled in Fleet", len(agents))
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"retry":       retryCount,
+				"runID":       naming.RunID(),
+			}).Warn("The agents of the run are still enrolled")
+
+			retryCount++
+			return err
+		}
+
+		return nil
+	}
+
+	return backoff.Retry(noAgentsFn, exp)
+}
@@ -92,5 +92,5 @@ func (fts *FleetTestSuite) kibanaUsesProfile(profile string) error {
 
 	env := fts.getProfileEnv()
 
-	return bootstrapFleet(context.Background(), env)
+	return bootstrapFleet(context.Background(), fts.fleetProfile(), env)
 }
@@ -6,6 +6,7 @@ package main
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/elastic/e2e-testing/internal/common"
@@ -60,6 +61,7 @@ func (fts *FleetTestSuite) processStateChangedOnTheHost(pr string, state string)
 
 		return err
 	}
+	fts.AgentStoppedDate = time.Now().UTC()
 
 	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
 
@@ -0,0 +1,291 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// proxyServiceName the name of the HTTP proxy service in the compose files
+const proxyServiceName = "proxy"
+
+// proxyURL the URL of the HTTP proxy within the Docker network of the profile
+const proxyURL = "http://" + proxyServiceName + ":3128"
+
+func (fts *FleetTestSuite) theProxyIsDeployed() error {
+	env := fts.getProfileEnv()
+
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceContainerRequest(proxyServiceName),
+	}
+	err := fts.getDeployer().Add(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not deploy the proxy")
+		return err
+	}
+	fts.ProxyDeployed = true
+
+	return nil
+}
+
+func (fts *FleetTestSuite) theProxyIsStopped() error {
+	err := fts.getDeployer().Stop(fts.currentContext, deploy.NewServiceContainerRequest(proxyServiceName))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not stop the proxy")
+		return err
+	}
+
+	fts.ProxyStoppedDate = time.Now().UTC()
+
+	log.Debug("The proxy has been stopped")
+	return nil
+}
+
+// theProxyIsStartedAgainAfterSeconds keeps the proxy down for the given seconds, so the agent has to queue the
+// events and retry the requests, and then starts it again
+func (fts *FleetTestSuite) theProxyIsStartedAgainAfterSeconds(seconds int) error {
+	if fts.ProxyStoppedDate.IsZero() {
+		return fmt.Errorf("the proxy was not stopped in the scenario")
+	}
+
+	outage := time.Duration(seconds) * time.Second
+	utils.Sleep(time.Until(fts.ProxyStoppedDate.Add(outage)))
+
+	err := fts.getDeployer().Start(fts.currentContext, deploy.NewServiceContainerRequest(proxyServiceName))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not start the proxy")
+		return err
+	}
+	fts.ProxyStartedDate = time.Now().UTC()
+
+	log.WithFields(log.Fields{
+		"outage": fts.ProxyStartedDate.Sub(fts.ProxyStoppedDate),
+	}).Info("The proxy has been started again")
+
+	return nil
+}
+
+func (fts *FleetTestSuite) anAgentIsDeployedToFleetWithInstallerThroughTheProxy(installerType string) error {
+	return fts.deployAgentToFleet(InstallerType(installerType), ProxyURL(proxyURL))
+}
+
+// anAttemptToDeployAnAgentToFleetWithInstallerThroughTheProxyFails deploys an agent enrolling through the proxy,
+// expecting its enrollment to fail, i.e. because the proxy is down
+func (fts *FleetTestSuite) anAttemptToDeployAnAgentToFleetWithInstallerThroughTheProxyFails(installerType string) error {
+	return fts.expectEnrollmentFailure(fts.anAgentIsDeployedToFleetWithInstallerThroughTheProxy(installerType))
+}
+
+func (fts *FleetTestSuite) thePolicySendsDataThroughTheProxy() error {
+	output, err := fts.kibanaClient.CreateOutput(fts.currentContext, kibana.Output{
+		Name:       naming.Name("elasticsearch-proxy"),
+		Type:       "elasticsearch",
+		Hosts:      []string{"http://elasticsearch:9200"},
+		ConfigYaml: "proxy_url: " + proxyURL,
+	})
+	if err != nil {
+		return err
+	}
+	fts.ProxyOutputID = output.ID
+
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyOutputs(fts.currentContext, fts.Policy, output.ID, "")
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+
+	log.WithFields(log.Fields{
+		"output": output.ID,
+		"policy": policy.ID,
+	}).Info("The policy sends data through the proxy")
+
+	return nil
+}
+
+// theProxyForwardsTheRequestsTo waits for the access log of the proxy to contain requests to the host,
+// since the current scenario started
+func (fts *FleetTestSuite) theProxyForwardsTheRequestsTo(host string) error {
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	proxyRequestsFn := func() error {
+		logs, err := deploy.GetContainerLogs(fts.currentContext, deploy.NewServiceContainerRequest(proxyServiceName), fts.ScenarioStartDate)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		requests := countProxyRequests(logs, host)
+		if requests == 0 {
+			err := fmt.Errorf("the proxy did not forward requests to %s yet", host)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"host":        host,
+				"retry":       retryCount,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"elapsedTime": exp.GetElapsedTime(),
+			"host":        host,
+			"requests":    requests,
+			"retries":     retryCount,
+		}).Info("The proxy forwards the requests")
+
+		return nil
+	}
+
+	return backoff.Retry(proxyRequestsFn, exp)
+}
+
+// theEventsCollectedDuringTheProxyOutageAreDelivered waits for the events collected by the agent while the proxy
+// was down, which must be retried once the proxy is back, to be present in the index pattern
+func (fts *FleetTestSuite) theEventsCollectedDuringTheProxyOutageAreDelivered(indexPattern string) error {
+	if fts.ProxyStoppedDate.IsZero() || fts.ProxyStartedDate.IsZero() {
+		return fmt.Errorf("there was no proxy outage in the scenario")
+	}
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{
+						"match_phrase": map[string]interface{}{
+							"host.name": manifest.Hostname,
+						},
+					},
+					map[string]interface{}{
+						"range": map[string]interface{}{
+							"@timestamp": map[string]interface{}{
+								"gte":    fts.ProxyStoppedDate,
+								"lte":    fts.ProxyStartedDate,
+								"format": "strict_date_optional_time",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 3
+
+	_, err := elasticsearch.WaitForDocsInCluster(fts.currentContext, elasticsearch.GetElasticSearchEndpoint(), indexPattern, query, 1, maxTimeout)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn(elasticsearch.WaitForIndices())
+	}
+
+	return err
+}
+
+// theAgentIsNotEnrolledInFleet checks that the agent does not show up in Fleet for a period of time,
+// as its enrollment cannot reach Fleet Server
+func (fts *FleetTestSuite) theAgentIsNotEnrolledInFleet() error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+
+	quietPeriod := time.Duration(utils.TimeoutFactor) * time.Minute
+	deadline := time.Now().Add(quietPeriod)
+
+	for time.Now().Before(deadline) {
+		agent, err := fts.kibanaClient.GetAgentByHostnameFromList(fts.currentContext, manifest.Hostname)
+		if err != nil {
+			return err
+		}
+
+		if agent.ID != "" {
+			return fmt.Errorf("the agent %s was enrolled in Fleet with status %s", agent.ID, agent.Status)
+		}
+
+		utils.Sleep(10 * time.Second)
+	}
+
+	log.WithFields(log.Fields{
+		"hostname":    manifest.Hostname,
+		"quietPeriod": quietPeriod,
+	}).Info("The agent is not enrolled in Fleet")
+
+	return nil
+}
+
+// removeProxy deletes the output sending data through the proxy from Fleet, and removes the proxy service,
+// if they were created
+func (fts *FleetTestSuite) removeProxy() {
+	if fts.ProxyOutputID != "" {
+		err := fts.kibanaClient.DeleteOutput(fts.currentContext, fts.ProxyOutputID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"err":    err,
+				"output": fts.ProxyOutputID,
+			}).Warn("The output sending data through the proxy could not be deleted")
+		}
+
+		fts.ProxyOutputID = ""
+	}
+
+	if !fts.ProxyDeployed {
+		return
+	}
+
+	env := fts.getProfileEnv()
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceContainerRequest(proxyServiceName),
+	}
+	err := fts.getDeployer().Remove(fts.currentContext, fts.fleetProfile(), services, env)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err": err,
+		}).Warn("The proxy could not be removed")
+	}
+
+	fts.ProxyDeployed = false
+}
+
+// countProxyRequests counts the requests to the host in the access log of the proxy, either tunneled
+// with the CONNECT method (host:port) or forwarded as plain HTTP (http://host:port/path)
+func countProxyRequests(accessLog string, host string) int {
+	count := 0
+	for _, line := range strings.Split(accessLog, "\n") {
+		fields := strings.Fields(line)
+		// squid native format: time elapsed client code/status bytes method URL ...
+		if len(fields) < 7 {
+			continue
+		}
+
+		url := strings.TrimPrefix(strings.TrimPrefix(fields[6], "http://"), "https://")
+		if url == host || strings.HasPrefix(url, host+"/") {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,74 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/naming"
+	log "github.com/sirupsen/logrus"
+)
+
+// agentDataStreams the data streams written by the agents, which are wiped between feature files
+var agentDataStreams = []string{"logs-*-*", "metrics-*-*", "traces-*-*"}
+
+// composeServiceLabel the label set by Docker Compose with the name of the service of a container
+const composeServiceLabel = "com.docker.compose.service"
+
+// resetFleetState brings Fleet back to the state it had after bootstrapping the runtime dependencies,
+// so that feature files do not depend on the order they are run, without restarting the stack.
+// It unenrolls the agents, and deletes the policies and enrollment tokens created by the current run, leaving
+// alone the ones of other runs. The stacks of the remote provider and the developer mode are shared or
+// reused, so their state is never reset
+func (fts *FleetTestSuite) resetFleetState() {
+	if common.Provider == "remote" || common.DeveloperMode {
+		log.WithFields(log.Fields{
+			"developerMode": common.DeveloperMode,
+			"provider":      common.Provider,
+		}).Debug("Fleet's state is not reset, as the stack could be shared with other runs")
+		return
+	}
+
+	log.Debug("Resetting Fleet's state")
+
+	fts.cleanupRunResources(fts.currentContext, naming.NamePattern(naming.RunID()))
+
+	// data streams cannot be labelled with the run ID, so they are only wiped in the Elasticsearch of the run
+	if !isStartedByRun("elasticsearch", naming.RunID()) {
+		log.WithFields(log.Fields{
+			"runID": naming.RunID(),
+		}).Debug("Elasticsearch was not started by the run. Its data streams won't be wiped")
+		return
+	}
+
+	for _, dataStream := range agentDataStreams {
+		err := elasticsearch.DeleteDataStreams(fts.currentContext, dataStream)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"dataStream": dataStream,
+				"error":      err,
+			}).Warn("Could not delete the data streams")
+		}
+	}
+
+	log.Info("Fleet's state was reset")
+}
+
+// isStartedByRun checks if the container of the service is labelled with the run ID
+func isStartedByRun(serviceName string, runID string) bool {
+	containers, err := deploy.ListContainersByRunID(runID)
+	if err != nil {
+		return false
+	}
+
+	for _, container := range containers {
+		if container.Labels[composeServiceLabel] == serviceName {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
+	log "github.com/sirupsen/logrus"
+)
+
+// cleanupRunResources removes the agents, enrollment tokens and policies which names match the pattern,
+// identifying the runs that created them, and leaving alone the ones of any other run sharing the same stack.
+// Agents are matched by their hostnames or by their policies. Errors are logged, as a partial cleanup is
+// better than none
+func (fts *FleetTestSuite) cleanupRunResources(ctx context.Context, namePattern string) {
+	policies, err := fts.kibanaClient.ListPoliciesByName(ctx, namePattern)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":       err,
+			"namePattern": namePattern,
+		}).Warn("Could not list the policies of the run")
+	}
+
+	agents, err := fts.listRunAgents(ctx, namePattern, policies)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("Could not list the agents of the run")
+	}
+
+	unenrolled := 0
+	for _, agent := range agents {
+		err := fts.kibanaClient.UnEnrollAgentByID(ctx, agent.ID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"agentID": agent.ID,
+				"error":   err,
+			}).Warn("Could not unenroll the agent")
+			continue
+		}
+		unenrolled++
+	}
+
+	revoked, err := fts.kibanaClient.RevokeEnrollmentAPIKeysByName(ctx, "^"+kibana.TestTokenNamePrefix+".*"+namePattern)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":       err,
+			"namePattern": namePattern,
+		}).Warn("Could not revoke the enrollment tokens of the run")
+	}
+
+	deleted := 0
+	for _, policy := range policies {
+		err := fts.kibanaClient.DeletePolicy(ctx, policy.ID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":  err,
+				"policy": policy.ID,
+			}).Warn("Could not delete the policy")
+			continue
+		}
+		deleted++
+	}
+
+	log.WithFields(log.Fields{
+		"agents":         unenrolled,
+		"enrollmentKeys": revoked,
+		"namePattern":    namePattern,
+		"policies":       deleted,
+	}).Info("Resources of the run cleaned up")
+}
+
+// listRunAgents lists the agents enrolled by the runs which names match the pattern, either because of their
+// hostnames or because of their policies
+func (fts *FleetTestSuite) listRunAgents(ctx context.Context, namePattern string, policies []kibana.Policy) ([]kibana.Agent, error) {
+	re, err := regexp.Compile(namePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	runPolicies := map[string]bool{}
+	for _, policy := range policies {
+		runPolicies[policy.ID] = true
+	}
+
+	agents, err := fts.kibanaClient.ListAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runAgents := []kibana.Agent{}
+	for _, agent := range agents {
+		if runPolicies[agent.PolicyID] || re.MatchString(agent.LocalMetadata.Host.HostName) {
+			runAgents = append(runAgents, agent)
+		}
+	}
+
+	return runAgents, nil
+}
+
+// cleanupPreviousRuns removes the resources left behind by the previous runs, i.e. because they crashed before
+// tearing down. It's only safe for the runtime dependencies reused in developer mode, which are not shared
+// with other users, so the resources of any run but the current one can be removed
+func (fts *FleetTestSuite) cleanupPreviousRuns(ctx context.Context) {
+	log.Debug("Cleaning up the resources of the previous runs")
+
+	fts.cleanupRunResources(ctx, naming.AnyRunNamePattern())
+}
+
+// removeRunDockerResources removes the containers and networks labelled with the run ID which survived the
+// destruction of the runtime dependencies, such as the ones of crashed scenarios
+func removeRunDockerResources(runID string) {
+	containers, err := deploy.ListContainersByRunID(runID)
+	if err != nil {
+		return
+	}
+
+	for _, container := range containers {
+		_ = deploy.RemoveContainer(container.ID)
+	}
+
+	networks, err := deploy.ListNetworksByRunID(runID)
+	if err != nil {
+		return
+	}
+
+	for _, network := range networks {
+		_ = deploy.RemoveNetwork(network.ID)
+	}
+
+	log.WithFields(log.Fields{
+		"containers": len(containers),
+		"networks":   len(networks),
+		"runID":      runID,
+	}).Debug("Docker resources of the run removed")
+}
@@ -0,0 +1,46 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// runTimeout the max duration of the whole run, since the suite starts. Once exceeded, no new scenarios
+// start and the running one is aborted, although their clean up and the suite's tear down are executed.
+// Zero disables it. It can be overriden by RUN_TIMEOUT env var (i.e. 2h)
+var runTimeout time.Duration
+
+// startRunDeadline creates the context of the run, from which the context of each scenario derives
+func (fts *FleetTestSuite) startRunDeadline() {
+	runTimeout = shell.GetEnvDuration("RUN_TIMEOUT", 0)
+	if runTimeout <= 0 {
+		fts.runContext, fts.cancelRun = context.WithCancel(context.Background())
+		return
+	}
+
+	fts.runContext, fts.cancelRun = context.WithTimeout(context.Background(), runTimeout)
+
+	log.WithFields(log.Fields{
+		"deadline": time.Now().Add(runTimeout).Format(time.RFC3339),
+		"timeout":  runTimeout,
+	}).Info("The run will be aborted if it exceeds the deadline")
+}
+
+// stopRunDeadline releases the context of the run, once all the scenarios have been executed
+func (fts *FleetTestSuite) stopRunDeadline() {
+	if fts.cancelRun != nil {
+		fts.cancelRun()
+	}
+}
+
+// runAborted returns true if the run exceeded its deadline
+func (fts *FleetTestSuite) runAborted() bool {
+	return fts.runContext != nil && fts.runContext.Err() == context.DeadlineExceeded
+}
@@ -0,0 +1,159 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// scaleFlavour the flavour of the elastic-agent service for the scale scenarios: a container enrolling
+// itself on start, without published ports, so that it can be scaled with Docker Compose. Each replica
+// uses its container ID as hostname, so that they are listed as different hosts in Fleet
+const scaleFlavour = "scale"
+
+func (fts *FleetTestSuite) agentsAreDeployedToFleetAtScale(count string) error {
+	replicas, err := strconv.Atoi(count)
+	if err != nil {
+		return err
+	}
+	if replicas < 1 {
+		return fmt.Errorf("the number of agents must be greater than zero: %d", replicas)
+	}
+
+	enrollmentKey, err := fts.kibanaClient.CreateEnrollmentAPIKey(fts.currentContext, fts.Policy)
+	if err != nil {
+		return err
+	}
+	fts.CurrentToken = enrollmentKey.APIKey
+	fts.CurrentTokenID = enrollmentKey.ID
+
+	cfg, err := kibana.NewFleetConfig(fts.CurrentToken)
+	if err != nil {
+		return err
+	}
+
+	env := fts.getProfileEnv()
+	env["elasticAgentDockerNamespace"] = deploy.GetDockerNamespaceEnvVar("beats")
+	env["elasticAgentTag"] = fts.agentVersion()
+	env["fleetEnrollmentToken"] = cfg.EnrollmentToken
+	env["fleetUrl"] = cfg.FleetServerURL()
+
+	agentService := deploy.NewServiceContainerRequest(common.ElasticAgentServiceName).
+		WithFlavour(scaleFlavour).
+		WithScale(replicas)
+
+	log.WithFields(log.Fields{
+		"agents": replicas,
+		"policy": fts.Policy.ID,
+	}).Info("Deploying the agents to Fleet at scale")
+
+	fts.ScaleStartDate = time.Now()
+	fts.ScaledAgents = replicas
+
+	err = fts.getDeployer().Add(fts.currentContext, fts.fleetProfile(), []deploy.ServiceRequest{agentService}, env)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"agents":  replicas,
+		"elapsed": time.Since(fts.ScaleStartDate).Round(time.Second),
+	}).Info("The agents were deployed at scale")
+
+	return nil
+}
+
+func (fts *FleetTestSuite) allTheAgentsAreListedInFleetWithStatus(desiredStatus string) error {
+	// enrolling hundreds of agents takes longer than a single one
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 5
+	exp := utils.GetExponentialBackOffForCheck("agent status", maxTimeout)
+	retryCount := 1
+
+	allAgentsInStatusFn := func() error {
+		agents, err := fts.kibanaClient.ListAgentsByPolicy(fts.currentContext, fts.Policy.ID)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		inStatus := 0
+		for _, agent := range agents {
+			if strings.EqualFold(agent.Status, desiredStatus) {
+				inStatus++
+			}
+		}
+
+		if inStatus < fts.ScaledAgents {
+			err := fmt.Errorf("%d of %d agents are %s in Fleet", inStatus, fts.ScaledAgents, desiredStatus)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"enrolled":    len(agents),
+				"retry":       retryCount,
+				"status":      desiredStatus,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		return nil
+	}
+
+	err := utils.RetryWithHeartbeat(fmt.Sprintf("%d agents to be listed in Fleet as %s", fts.ScaledAgents, desiredStatus), allAgentsInStatusFn, exp)
+	if err != nil {
+		return err
+	}
+
+	elapsed := time.Since(fts.ScaleStartDate)
+	log.WithFields(log.Fields{
+		"agents":          fts.ScaledAgents,
+		"agentsPerMinute": fmt.Sprintf("%.2f", float64(fts.ScaledAgents)/elapsed.Minutes()),
+		"elapsed":         elapsed.Round(time.Second),
+		"status":          desiredStatus,
+	}).Info("Enrollment throughput at scale")
+
+	return nil
+}
+
+// unenrollScaledAgents unenrolls the agents deployed at scale, if any. Their containers are removed
+// with the elastic-agent service
+func (fts *FleetTestSuite) unenrollScaledAgents() {
+	if fts.ScaledAgents == 0 {
+		return
+	}
+
+	defer func() {
+		fts.ScaledAgents = 0
+	}()
+
+	agents, err := fts.kibanaClient.ListAgentsByPolicy(fts.currentContext, fts.Policy.ID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":    err,
+			"policy": fts.Policy.ID,
+		}).Warn("The agents deployed at scale could not be listed")
+		return
+	}
+
+	for _, agent := range agents {
+		err := fts.kibanaClient.UnEnrollAgentByID(fts.currentContext, agent.ID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"agentID": agent.ID,
+				"err":     err,
+			}).Warn("The agent deployed at scale could not be unenrolled")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/config"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// diagnosticsTimeout the max time to collect the diagnostics of a scenario exceeding its timeout
+const diagnosticsTimeout = 2 * time.Minute
+
+// scenarioTimeout the max duration of a scenario, excluding its clean up. Zero disables it.
+// It can be overriden by SCENARIO_TIMEOUT env var (i.e. 20m)
+var scenarioTimeout time.Duration
+
+// diagnosticsDir the directory where the diagnostics of the scenarios exceeding their timeout are written.
+// It can be overriden by DIAGNOSTICS_DIR env var
+var diagnosticsDir string
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func initScenarioTimeout() {
+	scenarioTimeout = shell.GetEnvDuration("SCENARIO_TIMEOUT", 0)
+	diagnosticsDir = shell.GetEnv("DIAGNOSTICS_DIR", filepath.Join(config.OpDir(), "diagnostics"))
+
+	if scenarioTimeout > 0 {
+		log.WithFields(log.Fields{
+			"timeout": scenarioTimeout,
+		}).Info("The scenarios will be aborted if they exceed the timeout")
+	}
+}
+
+// startScenarioDeadline creates the context for the steps of the scenario, which is cancelled when the
+// scenario exceeds its timeout or the run exceeds its deadline, killing any running command. The diagnostics
+// are collected at that moment, as the step could still be stuck in code not honouring the context
+func (fts *FleetTestSuite) startScenarioDeadline(scenario string) {
+	parent := fts.runContext
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	if scenarioTimeout <= 0 {
+		fts.scenarioContext, fts.cancelScenario = context.WithCancel(parent)
+	} else {
+		fts.scenarioContext, fts.cancelScenario = context.WithTimeout(parent, scenarioTimeout)
+	}
+
+	ctx := fts.scenarioContext
+	go func() {
+		<-ctx.Done()
+		if ctx.Err() != context.DeadlineExceeded {
+			return
+		}
+
+		if fts.runAborted() {
+			log.WithFields(log.Fields{
+				"scenario": scenario,
+				"timeout":  runTimeout,
+			}).Error("The run exceeded its deadline and the scenario will be aborted")
+		} else {
+			log.WithFields(log.Fields{
+				"scenario": scenario,
+				"timeout":  scenarioTimeout,
+			}).Error("The scenario exceeded its timeout and it will be aborted")
+		}
+
+		collectScenarioDiagnostics(scenario)
+	}()
+}
+
+// stopScenarioDeadline releases the context of the scenario, once all its steps have been executed
+func (fts *FleetTestSuite) stopScenarioDeadline() {
+	if fts.cancelScenario != nil {
+		fts.cancelScenario()
+	}
+}
+
+// checkScenarioDeadline returns an error if the scenario exceeded its timeout or the run was aborted,
+// so that its remaining steps fail
+func (fts *FleetTestSuite) checkScenarioDeadline() error {
+	if fts.runAborted() {
+		return fmt.Errorf("the run exceeded its %s deadline, so the scenario was aborted", runTimeout)
+	}
+
+	if fts.scenarioContext == nil || fts.scenarioContext.Err() != context.DeadlineExceeded {
+		return nil
+	}
+
+	return fmt.Errorf("the scenario exceeded its %s timeout", scenarioTimeout)
+}
+
+func collectScenarioDiagnostics(scenario string) {
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsTimeout)
+	defer cancel()
+
+	dir := filepath.Join(diagnosticsDir, fmt.Sprintf("%s-%d", scenarioFileName(scenario), time.Now().Unix()))
+
+	services := []deploy.ServiceRequest{
+		deploy.NewServiceRequest(common.ElasticAgentServiceName),
+		deploy.NewServiceRequest(common.FleetServerAgentServiceName),
+		deploy.NewServiceRequest("kibana"),
+		deploy.NewServiceRequest("elasticsearch"),
+	}
+
+	err := deploy.CollectDiagnostics(ctx, dir, services...)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"scenario": scenario,
+		}).Warn("Could not collect the diagnostics of the scenario")
+	}
+
+	writeEnvironmentManifest(filepath.Join(dir, environmentManifestFileName))
+}
+
+// scenarioFileName returns the name of the scenario to be used in the names of files, such as the ones of its
+// diagnostics
+func scenarioFileName(scenario string) string {
+	return strings.Trim(nonAlphanumericRegex.ReplaceAllString(strings.ToLower(scenario), "-"), "-")
+}
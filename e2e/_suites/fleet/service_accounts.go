@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/elastic/e2e-testing/internal/auth"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// serviceAccount the service account which token authenticates the requests sent to Elasticsearch by
+// the test suite, instead of basic auth. It is empty if the ELASTICSEARCH_SERVICE_ACCOUNT env var is not set
+var serviceAccount string
+
+// serviceAccountTokenName the name of the token created for the service account in the current run
+var serviceAccountTokenName string
+
+// initServiceAccountAuth creates a token of the service account, once the runtime dependencies are up,
+// so that the requests sent to Elasticsearch authenticate with it
+func initServiceAccountAuth(ctx context.Context) {
+	serviceAccount = shell.GetEnv("ELASTICSEARCH_SERVICE_ACCOUNT", "")
+	if serviceAccount == "" {
+		return
+	}
+
+	serviceAccountTokenName = "e2e-helpers-" + naming.RunID()
+	token, err := elasticsearch.CreateServiceAccountToken(ctx, serviceAccount, serviceAccountTokenName)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":          err,
+			"serviceAccount": serviceAccount,
+		}).Fatal("Could not create the service account token to access Elasticsearch")
+	}
+
+	auth.SetServiceToken(auth.ElasticsearchService, token.Value)
+
+	log.WithFields(log.Fields{
+		"serviceAccount": serviceAccount,
+		"token":          token.Name,
+	}).Info("The requests sent to Elasticsearch will authenticate with a service account token")
+}
+
+// revokeServiceAccountAuth deletes the token of the service account created in the current run, when the
+// runtime dependencies outlive it
+func revokeServiceAccountAuth(ctx context.Context) {
+	if serviceAccountTokenName == "" {
+		return
+	}
+
+	// the token is deleted with the user and password, as service accounts can't manage their tokens
+	auth.SetServiceToken(auth.ElasticsearchService, "")
+
+	err := elasticsearch.DeleteServiceAccountToken(ctx, serviceAccount, serviceAccountTokenName)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":          err,
+			"serviceAccount": serviceAccount,
+			"token":          serviceAccountTokenName,
+		}).Warn("Could not delete the service account token")
+	}
+}
+
+// fleetServerTokenNames the names of the tokens of the Fleet Server service account created in the current run
+var fleetServerTokenNames []string
+
+// createFleetServerToken creates a token of the Fleet Server service account, which Fleet Server uses to connect
+// to Elasticsearch instead of a user and password. The token is deleted when the run ends
+func createFleetServerToken(ctx context.Context, name string) (elasticsearch.ServiceAccountToken, error) {
+	token, err := elasticsearch.CreateServiceAccountToken(ctx, elasticsearch.FleetServerServiceAccount, name)
+	if err != nil {
+		return elasticsearch.ServiceAccountToken{}, err
+	}
+
+	for _, tokenName := range fleetServerTokenNames {
+		if tokenName == name {
+			return token, nil
+		}
+	}
+	fleetServerTokenNames = append(fleetServerTokenNames, name)
+
+	return token, nil
+}
+
+// deleteFleetServerTokens deletes the tokens of the Fleet Server service account created in the current run,
+// when the runtime dependencies outlive it
+func deleteFleetServerTokens(ctx context.Context) {
+	for _, name := range fleetServerTokenNames {
+		err := elasticsearch.DeleteServiceAccountToken(ctx, elasticsearch.FleetServerServiceAccount, name)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"token": name,
+			}).Warn("Could not delete the Fleet Server token")
+		}
+	}
+
+	fleetServerTokenNames = nil
+}
@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"github.com/cucumber/godog"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/naming"
+	log "github.com/sirupsen/logrus"
+)
+
+// destructiveTag scenarios tagged with it modify Fleet's state in a way that could affect the scenarios
+// running after them, so Fleet's state is snapshotted before them and restored afterwards
+const destructiveTag = "@destructive"
+
+func isDestructiveScenario(sc *godog.Scenario) bool {
+	for _, tag := range sc.Tags {
+		if tag.Name == destructiveTag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// snapshotFleetState takes a snapshot of Fleet's system indices, to be restored after the scenario
+func (fts *FleetTestSuite) snapshotFleetState() {
+	err := elasticsearch.CreateSnapshotsRepository(fts.currentContext)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("The snapshots repository could not be created. Fleet's state won't be restored after the scenario")
+		return
+	}
+
+	snapshot := naming.Name("fleet")
+
+	err = elasticsearch.CreateSnapshot(fts.currentContext, snapshot, elasticsearch.NewFleetSnapshotRequest())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"snapshot": snapshot,
+		}).Warn("Fleet's state could not be snapshotted. It won't be restored after the scenario")
+		return
+	}
+
+	fts.FleetStateSnapshot = snapshot
+}
+
+// restoreFleetState restores Fleet's system indices from the snapshot taken before the scenario, if any
+func (fts *FleetTestSuite) restoreFleetState() {
+	if fts.FleetStateSnapshot == "" {
+		return
+	}
+
+	snapshot := fts.FleetStateSnapshot
+	fts.FleetStateSnapshot = ""
+
+	err := elasticsearch.RestoreSnapshot(fts.currentContext, snapshot, elasticsearch.NewFleetSnapshotRequest())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"snapshot": snapshot,
+		}).Error("Fleet's state could not be restored from the snapshot")
+		return
+	}
+
+	err = elasticsearch.DeleteSnapshot(fts.currentContext, snapshot)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"snapshot": snapshot,
+		}).Warn("The snapshot could not be deleted")
+	}
+
+	log.WithField("snapshot", snapshot).Debug("Fleet's state restored from the snapshot")
+}
@@ -0,0 +1,135 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/elasticsearch"
+	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// soakDataStream the data stream where the agent ships its own logs, used to check the freshness of its data
+const soakDataStream = "logs-elastic_agent-default"
+
+func (fts *FleetTestSuite) theAgentStaysHealthyDuringTheSoakPeriod() error {
+	duration := shell.GetEnvDuration("SOAK_DURATION", 10*time.Minute)
+	interval := shell.GetEnvDuration("SOAK_CHECK_INTERVAL", 5*time.Minute)
+	if interval <= 0 || interval > duration {
+		interval = duration
+	}
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	initialPID, err := fts.agentPID(agentInstaller)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"duration": duration,
+		"hostname": manifest.Hostname,
+		"interval": interval,
+		"pid":      initialPID,
+	}).Info("Starting the soak period of the agent")
+
+	started := time.Now()
+	lastCheck := started.UTC()
+	checks := 0
+
+	for time.Since(started) < duration {
+		select {
+		case <-fts.currentContext.Done():
+			return fmt.Errorf("the soak period was interrupted after %s: %v", time.Since(started).Round(time.Second), fts.currentContext.Err())
+		case <-time.After(interval):
+		}
+
+		checks++
+		elapsed := time.Since(started).Round(time.Second)
+
+		agentStatus, err := fts.kibanaClient.GetAgentStatusByHostname(fts.currentContext, manifest.Hostname)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(agentStatus, "online") {
+			return fmt.Errorf("the agent is %s in Fleet after %s of soak period", agentStatus, elapsed)
+		}
+
+		result, err := elasticsearch.Search(fts.currentContext, soakDataStream, soakDataQuery(manifest.Hostname, lastCheck))
+		if err != nil {
+			return err
+		}
+		if elasticsearch.AssertHitsArePresent(result) != nil {
+			return fmt.Errorf("the agent did not ship data to the %s data stream since %s, after %s of soak period", soakDataStream, lastCheck.Format(time.RFC3339), elapsed)
+		}
+
+		pid, err := fts.agentPID(agentInstaller)
+		if err != nil {
+			return err
+		}
+		if pid != initialPID {
+			return fmt.Errorf("the agent was restarted during the soak period, after %s: its pid changed from %s to %s", elapsed, initialPID, pid)
+		}
+
+		log.WithFields(log.Fields{
+			"checks":    checks,
+			"elapsed":   elapsed,
+			"remaining": (duration - time.Since(started)).Round(time.Second),
+		}).Info("The agent is healthy, shipping data and was not restarted")
+
+		lastCheck = time.Now().UTC()
+	}
+
+	return nil
+}
+
+// agentPID returns the pid of the oldest elastic-agent process in the host, which changes if the agent restarts
+func (fts *FleetTestSuite) agentPID(agentInstaller deploy.ServiceOperator) (string, error) {
+	pid, err := agentInstaller.Exec(fts.currentContext, []string{"pgrep", "-o", common.ElasticAgentProcessName})
+	if err != nil {
+		return "", err
+	}
+
+	pid = strings.TrimSpace(pid)
+	if pid == "" {
+		return "", fmt.Errorf("the %s process is not running", common.ElasticAgentProcessName)
+	}
+
+	return pid, nil
+}
+
+// soakDataQuery query to retrieve the documents sent by the agent since a date
+func soakDataQuery(hostname string, since time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"size": 1,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{
+						"match_phrase": map[string]interface{}{
+							"host.name": hostname,
+						},
+					},
+					map[string]interface{}{
+						"range": map[string]interface{}{
+							"@timestamp": map[string]interface{}{
+								"gte":    since,
+								"format": "strict_date_optional_time",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/stack"
+	"github.com/elastic/e2e-testing/pkg/downloads"
+	log "github.com/sirupsen/logrus"
+)
+
+// stackVersion returns the version of the stack the current scenario runs against
+func (fts *FleetTestSuite) stackVersion() string {
+	if fts.PinnedStackVersion != "" {
+		return fts.PinnedStackVersion
+	}
+
+	return common.StackVersion
+}
+
+// agentVersion returns the version of the agents deployed in the current scenario, which is the version of the
+// stack when a feature pins it
+func (fts *FleetTestSuite) agentVersion() string {
+	if fts.PinnedStackVersion != "" {
+		return fts.PinnedStackVersion
+	}
+
+	return common.ElasticAgentVersion
+}
+
+// theStackVersionIs pins the version of the stack for the scenarios of the feature, so it should be called
+// from its Background. The runtime dependencies are bootstrapped again with the images of that version,
+// unless they already run it. Remote stacks cannot be switched, so their version is only validated
+func (fts *FleetTestSuite) theStackVersionIs(version string) error {
+	if common.Provider == "remote" {
+		capabilities, err := fts.getCapabilities(fts.currentContext)
+		if err != nil {
+			return err
+		}
+
+		if !stack.VersionMatches(capabilities.KibanaVersion, version) {
+			return fmt.Errorf("the remote stack runs the %s version, but the feature requires %s", capabilities.KibanaVersion, version)
+		}
+
+		return nil
+	}
+
+	if stack.VersionMatches(fts.stackVersion(), version) {
+		return nil
+	}
+
+	resolvedVersion, err := downloads.GetElasticArtifactVersion(version)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"currentVersion": fts.stackVersion(),
+		"version":        resolvedVersion,
+	}).Info("Switching the version of the stack")
+
+	fts.PinnedStackVersion = resolvedVersion
+	fts.Version = fts.agentVersion()
+	fts.capabilities = nil
+
+	return bootstrapFleet(context.Background(), fts.fleetProfile(), fts.getProfileEnv())
+}
+
+// restoreStackVersion bootstraps the runtime dependencies again with the default version of the stack,
+// if a feature pinned a different one
+func (fts *FleetTestSuite) restoreStackVersion() {
+	if fts.PinnedStackVersion == "" {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"pinnedVersion": fts.PinnedStackVersion,
+		"version":       common.StackVersion,
+	}).Info("Restoring the default version of the stack")
+
+	fts.PinnedStackVersion = ""
+	fts.capabilities = nil
+
+	err := bootstrapFleet(context.Background(), fts.fleetProfile(), fts.getProfileEnv())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"version": common.StackVersion,
+		}).Error("Could not restore the default version of the stack")
+	}
+}
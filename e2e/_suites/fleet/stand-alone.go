@@ -9,10 +9,12 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/auth"
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/deploy"
 	"github.com/elastic/e2e-testing/internal/installer"
 	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
 	"github.com/elastic/e2e-testing/internal/utils"
 	"github.com/elastic/e2e-testing/pkg/downloads"
 	"github.com/pkg/errors"
@@ -110,7 +112,7 @@ func (fts *FleetTestSuite) startStandAloneAgent(image string, bootstrapFleetServ
 	fts.StandAlone = true
 	log.Trace("Deploying an agent to Fleet")
 
-	dockerImageTag := common.ElasticAgentVersion
+	dockerImageTag := fts.agentVersion()
 
 	common.ProfileEnv["elasticAgentDockerNamespace"] = deploy.GetDockerNamespaceEnvVar("beats")
 	common.ProfileEnv["elasticAgentDockerImageSuffix"] = ""
@@ -154,9 +156,21 @@ func (fts *FleetTestSuite) startStandAloneAgent(image string, bootstrapFleetServ
 	common.ProfileEnv["fleetServerPort"] = "8221" // fixed port to avoid collitions with the stack's fleet-server
 
 	common.ProfileEnv["elasticAgentTag"] = dockerImageTag
+	common.ProfileEnv["elasticAgentHostname"] = naming.Name("elastic-agent")
+
+	// the default output of the stand-alone agent authenticates with the credentials of the test framework
+	esCredentials := auth.ForService(auth.ElasticsearchService)
+	common.ProfileEnv["elasticsearchUsername"] = esCredentials.Username
+	common.ProfileEnv["elasticsearchPassword"] = esCredentials.Password
 
 	if bootstrapFleetServer {
+		serviceToken, err := createFleetServerToken(fts.currentContext, "e2e-standalone-fleet-server-"+naming.RunID())
+		if err != nil {
+			return err
+		}
+
 		common.ProfileEnv["fleetServerMode"] = "1"
+		common.ProfileEnv["fleetServerServiceToken"] = serviceToken.Value
 	} else {
 		common.ProfileEnv["fleetServerMode"] = "0"
 	}
@@ -0,0 +1,62 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/budget"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// stepBudgets the duration budgets of the steps, read from the STEP_BUDGETS_FILE env var and multiplied by the
+// timeout factor. Steps exceeding their budget fail, even if they eventually succeeded
+var stepBudgets *budget.Budgets
+
+func initStepBudgets() {
+	path := shell.GetEnv("STEP_BUDGETS_FILE", "budgets.yml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return
+	}
+
+	budgets, err := budget.Load(path)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  path,
+		}).Fatal("Could not load the duration budgets of the steps")
+	}
+
+	budgets.Scale(utils.TimeoutFactor)
+	stepBudgets = budgets
+
+	log.WithFields(log.Fields{
+		"budgets":       len(budgets.Budgets),
+		"path":          path,
+		"timeoutFactor": utils.TimeoutFactor,
+	}).Info("The steps exceeding their duration budget will fail")
+}
+
+// checkStepBudget returns an error if the step, which started at the given time, exceeded its duration budget
+func (fts *FleetTestSuite) checkStepBudget(step string, started time.Time) error {
+	b, found := stepBudgets.Find(step, fts.CurrentScenarioTags)
+	if !found {
+		return nil
+	}
+
+	err := b.Check(time.Since(started))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"budget":        b.Duration,
+			"step":          step,
+			"timeoutFactor": utils.TimeoutFactor,
+		}).Warn(err.Error())
+	}
+
+	return err
+}
@@ -13,11 +13,10 @@ import (
 	"time"
 
 	"github.com/Jeffail/gabs/v2"
-	"github.com/cenkalti/backoff/v4"
 	"github.com/cucumber/godog"
 	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/internal/naming"
 	"github.com/elastic/e2e-testing/internal/utils"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
@@ -71,7 +70,7 @@ func (fts *FleetTestSuite) theMetricsInTheDataStream(name string, set string) er
 		return err
 	}
 
-	err := backoff.Retry(waitForDataStreams, exp)
+	err := utils.RetryWithHeartbeat("the data streams of the system integration", waitForDataStreams, exp)
 	if err != nil {
 		return err
 	}
@@ -183,7 +182,7 @@ func parseJSONMetrics(data *gabs.Container, integration string, set string, metr
 				dataSet, _ := stream.Path("data_stream.dataset").Data().(string)
 				if dataSet == metrics+"."+set {
 					data.SetP(
-						integration+"-"+metrics+"."+set+"-"+uuid.New().String(),
+						naming.Name(integration+"-"+metrics+"."+set),
 						fmt.Sprintf("inputs.%d.streams.%d.id", i, idx),
 					)
 					data.SetP(
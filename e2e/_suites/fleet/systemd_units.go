@@ -0,0 +1,127 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/systemd"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// theSystemdUnitIs checks the unit is enabled at boot, or active, waiting for it to become active as it
+// could be still activating, i.e. right after a restart
+func (fts *FleetTestSuite) theSystemdUnitIs(unit string, state string) error {
+	agentInstaller, err := fts.systemdAgentInstaller()
+	if err != nil {
+		return err
+	}
+
+	cmds := systemd.IsActiveCmds(unit)
+	if state == "enabled" {
+		cmds = systemd.IsEnabledCmds(unit)
+	}
+
+	// the state is printed even if the command exits with a non-zero code, because it's not the expected one
+	unitStateFn := func() (string, error) {
+		output, err := agentInstaller.Exec(fts.currentContext, cmds)
+		if err != nil && shell.ExitCode(err) <= 0 {
+			return "", err
+		}
+		if err != nil {
+			output, _ = shell.CommandOutput(err)
+		}
+
+		return strings.TrimSpace(output), nil
+	}
+
+	if state == "enabled" {
+		unitState, err := unitStateFn()
+		if err != nil {
+			return err
+		}
+
+		if unitState != state {
+			return fmt.Errorf("the '%s' unit is not enabled: %s", unit, unitState)
+		}
+
+		return nil
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOffForCheck("systemd unit", maxTimeout)
+	retryCount := 1
+
+	unitActiveFn := func() error {
+		unitState, err := unitStateFn()
+		if err != nil {
+			return err
+		}
+
+		if unitState != state {
+			err = fmt.Errorf("the '%s' unit is not active yet: %s", unit, unitState)
+
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+				"state":       unitState,
+				"unit":        unit,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"elapsedTime": exp.GetElapsedTime(),
+			"retries":     retryCount,
+			"unit":        unit,
+		}).Info("The systemd unit is active")
+		return nil
+	}
+
+	return utils.RetryWithHeartbeat(fmt.Sprintf("the '%s' unit to be active", unit), unitActiveFn, exp)
+}
+
+func (fts *FleetTestSuite) theSystemdUnitIsRestarted(unit string) error {
+	agentInstaller, err := fts.systemdAgentInstaller()
+	if err != nil {
+		return err
+	}
+
+	_, err = agentInstaller.Exec(fts.currentContext, systemd.RestartCmds(unit))
+	return err
+}
+
+// theAgentContainerIsRebooted restarts the host of the agent, so that systemd starts again the units enabled at boot
+func (fts *FleetTestSuite) theAgentContainerIsRebooted() error {
+	_, err := fts.systemdAgentInstaller()
+	if err != nil {
+		return err
+	}
+
+	return fts.theHostIsRestarted()
+}
+
+// systemdAgentInstaller returns the installer of the agent deployed in the scenario, which must run it under
+// systemd: the agent runs as the main process of the container with the docker installer
+func (fts *FleetTestSuite) systemdAgentInstaller() (deploy.ServiceOperator, error) {
+	agentInstaller := fts.agentInstaller()
+	if agentInstaller == nil {
+		return nil, fmt.Errorf("the '%s' installer is not supported", fts.InstallerType)
+	}
+
+	metadata := agentInstaller.PkgMetadata()
+	if metadata.Docker || metadata.Os != "linux" {
+		return nil, fmt.Errorf("the agent is not run under systemd with the '%s' installer", fts.InstallerType)
+	}
+
+	return agentInstaller, nil
+}
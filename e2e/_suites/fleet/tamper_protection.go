@@ -0,0 +1,121 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/pkg/downloads"
+	log "github.com/sirupsen/logrus"
+)
+
+// tamperProtectionMinVersion the first version of the stack where Fleet protects the agents against tampering
+const tamperProtectionMinVersion = "8.11.0"
+
+func (fts *FleetTestSuite) theStackSupportsTamperProtection() error {
+	if !downloads.IsVersionAtLeast(fts.stackVersion(), tamperProtectionMinVersion) {
+		log.WithFields(log.Fields{
+			"minVersion":   tamperProtectionMinVersion,
+			"stackVersion": fts.stackVersion(),
+		}).Warn("The stack does not support tamper protection")
+		return godog.ErrPending
+	}
+
+	return nil
+}
+
+func (fts *FleetTestSuite) thePolicyIsProtectedAgainstTampering() error {
+	fts.policyChanged()
+	policy, err := fts.kibanaClient.UpdatePolicyProtection(fts.currentContext, fts.Policy, true)
+	if err != nil {
+		return err
+	}
+	fts.Policy = policy
+
+	log.WithFields(log.Fields{
+		"policy":   policy.ID,
+		"revision": policy.Revision,
+	}).Info("The policy is protected against tampering")
+
+	return nil
+}
+
+// uninstallRefusedMessage part of the error the agent prints when it refuses to be uninstalled without the token
+const uninstallRefusedMessage = "uninstall token"
+
+// theAgentIsUninstalledWithoutTheUninstallToken tries to uninstall the agent, which must be refused by a
+// protected agent, exiting with a non-zero code and an error about the missing uninstall token
+func (fts *FleetTestSuite) theAgentIsUninstalledWithoutTheUninstallToken() error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	output, err := agentInstaller.Exec(fts.currentContext, []string{"elastic-agent", "uninstall", "-f"})
+	if err == nil {
+		// signal that the elastic-agent was uninstalled
+		fts.ElasticAgentStopped = true
+
+		return fmt.Errorf("the protected agent was uninstalled without the uninstall token: %s", output)
+	}
+
+	// the uninstall command must exit with a non-zero code, to distinguish it from the commands which could not be run
+	exitCode := shell.ExitCode(err)
+	if exitCode <= 0 {
+		return fmt.Errorf("the uninstall without the uninstall token did not exit with a non-zero code (%d): %v", exitCode, err)
+	}
+
+	stdout, stderr := shell.CommandOutput(err)
+	if !strings.Contains(strings.ToLower(stdout+stderr), uninstallRefusedMessage) {
+		return fmt.Errorf("the uninstall was not refused because of the missing uninstall token (exit code %d): %s %s", exitCode, stdout, stderr)
+	}
+
+	log.WithFields(log.Fields{
+		"exitCode": exitCode,
+		"stderr":   stderr,
+		"stdout":   stdout,
+	}).Debug("As expected, the protected agent refused to be uninstalled without the uninstall token")
+
+	return nil
+}
+
+func (fts *FleetTestSuite) theAgentIsUninstalledWithTheUninstallToken() error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	err := fts.uninstallProtectedAgent(agentInstaller)
+	if err != nil {
+		return err
+	}
+
+	// signal that the elastic-agent was uninstalled
+	fts.ElasticAgentStopped = true
+
+	return nil
+}
+
+// uninstallProtectedAgent uninstalls the agent using the uninstall token of the policy, retrieved from Fleet
+func (fts *FleetTestSuite) uninstallProtectedAgent(agentInstaller deploy.ServiceOperator) error {
+	token, err := fts.kibanaClient.GetUninstallToken(fts.currentContext, fts.Policy.ID)
+	if err != nil {
+		return err
+	}
+
+	output, err := agentInstaller.Exec(fts.currentContext, []string{"elastic-agent", "uninstall", "-f", "--uninstall-token", token})
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"output": output,
+		"policy": fts.Policy.ID,
+	}).Debug("Uninstall command with the uninstall token executed")
+
+	return nil
+}
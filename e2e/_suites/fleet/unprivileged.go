@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/common"
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// permissionDeniedMessage part of the error printed by the commands which are rejected for lack of privileges
+const permissionDeniedMessage = "permission denied"
+
+// theCommandFailsForTheUser runs a command in the agent host as another user than root, checking it's
+// rejected because it requires privileges the user does not have
+func (fts *FleetTestSuite) theCommandFailsForTheUser(command string, user string) error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName).WithUser(user)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	output, err := agentInstaller.Exec(fts.currentContext, strings.Fields(command))
+	if err == nil {
+		return fmt.Errorf("the '%s' command did not fail for the '%s' user: %s", command, user, output)
+	}
+
+	// a command which could not be run, i.e. because the user or the binary do not exist, does not prove anything.
+	// docker exec exits with 126 and 127 in those cases
+	exitCode := shell.ExitCode(err)
+	if exitCode <= 0 || exitCode == 126 || exitCode == 127 {
+		return fmt.Errorf("the '%s' command could not be run by the '%s' user (exit code %d): %v", command, user, exitCode, err)
+	}
+
+	stdout, stderr := shell.CommandOutput(err)
+	if !strings.Contains(strings.ToLower(stderr+stdout), permissionDeniedMessage) {
+		return fmt.Errorf("the '%s' command did not fail for lack of privileges of the '%s' user (exit code %d): %s %s", command, user, exitCode, stdout, stderr)
+	}
+
+	log.WithFields(log.Fields{
+		"command":  command,
+		"error":    err,
+		"exitCode": exitCode,
+		"stderr":   stderr,
+		"user":     user,
+	}).Debug("As expected, the command failed for the user")
+
+	return nil
+}
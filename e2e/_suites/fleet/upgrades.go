@@ -6,12 +6,15 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/deploy"
 	"github.com/elastic/e2e-testing/internal/installer"
+	"github.com/elastic/e2e-testing/internal/kibana"
 	"github.com/elastic/e2e-testing/internal/utils"
 	"github.com/elastic/e2e-testing/pkg/downloads"
 	log "github.com/sirupsen/logrus"
@@ -20,7 +23,13 @@ import (
 func (fts *FleetTestSuite) agentInVersion(version string) error {
 	switch version {
 	case "latest":
-		version = downloads.GetSnapshotVersion(common.ElasticAgentVersion)
+		version = downloads.GetSnapshotVersion(fts.agentVersion())
+	default:
+		v, err := fts.resolveAgentVersion(version)
+		if err != nil {
+			return err
+		}
+		version = downloads.RemoveCommitFromSnapshot(v)
 	}
 	log.Tracef("Checking if agent is in version %s. Current version: %s", version, fts.Version)
 
@@ -72,9 +81,9 @@ func (fts *FleetTestSuite) agentInVersion(version string) error {
 }
 
 func (fts *FleetTestSuite) anAgentIsUpgradedToVersion(desiredVersion string) error {
-	switch desiredVersion {
-	case "latest":
-		desiredVersion = common.ElasticAgentVersion
+	desiredVersion, err := fts.resolveAgentVersion(desiredVersion)
+	if err != nil {
+		return err
 	}
 	log.Tracef("Desired version is %s. Current version: %s", desiredVersion, fts.Version)
 
@@ -88,14 +97,236 @@ func (fts *FleetTestSuite) anAgentIsUpgradedToVersion(desiredVersion string) err
 		return agentInstaller.Upgrade(fts.currentContext, desiredVersion)
 	*/
 
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+	return fts.upgradeAgent(agentInstaller, desiredVersion, "")
+}
+
+// theAgentIsUpgradedToVersion installs the artifact of the version in the host of the agent, which Fleet
+// upgrades it from, so that the agent can be upgraded to versions it cannot download on its own, such as
+// the snapshots
+func (fts *FleetTestSuite) theAgentIsUpgradedToVersion(version string) error {
+	desiredVersion, err := fts.resolveAgentVersion(version)
+	if err != nil {
+		return err
+	}
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	sourceURI, err := installer.InstallUpgradeArtifact(fts.currentContext, agentInstaller, desiredVersion)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"currentVersion": fts.Version,
+		"sourceURI":      sourceURI,
+		"version":        desiredVersion,
+	}).Trace("Upgrading the agent from the installed artifact")
+
+	return fts.upgradeAgent(agentInstaller, desiredVersion, sourceURI)
+}
+
+// upgradeAgent requests Fleet to upgrade the agent to the version, from the source URI if not empty, recording
+// the pid of the agent and the date of the request, which tell if the upgrade happened
+func (fts *FleetTestSuite) upgradeAgent(agentInstaller deploy.ServiceOperator, version string, sourceURI string) error {
+	// the pid tells if the agent was restarted by the upgrade
+	pid, err := fts.agentPID(agentInstaller)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Warn("Could not get the pid of the agent before the upgrade")
+	}
+	fts.AgentPIDBeforeUpgrade = pid
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
+	fts.AgentActionDate = time.Now().UTC()
+	_, err = fts.kibanaClient.UpgradeAgentFromSource(fts.currentContext, manifest.Hostname, version, sourceURI)
+	return err
+}
+
+// theAgentIsListedInFleetWithVersion checks the version of the agent listed in Fleet, and that the agent in
+// the host runs the binary of that version, so the new build is the one reported after the upgrade
+func (fts *FleetTestSuite) theAgentIsListedInFleetWithVersion(version string) error {
+	err := fts.agentInVersion(version)
+	if err != nil {
+		return err
+	}
+
+	desiredVersion, err := fts.resolveAgentVersion(version)
+	if err != nil {
+		return err
+	}
+	desiredVersion = downloads.GetSnapshotVersion(desiredVersion)
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	output, err := agentInstaller.Exec(fts.currentContext, []string{common.ElasticAgentProcessName, "version", "--binary-only"})
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(output, desiredVersion) {
+		return fmt.Errorf("the agent in the host does not run the %s version: %s", desiredVersion, strings.TrimSpace(output))
+	}
+
+	return nil
+}
+
+// resolveAgentVersion returns the version the agent is upgraded to, resolving the latest version and
+// the aliases, such as 8.4-SNAPSHOT, which Fleet does not accept
+func (fts *FleetTestSuite) resolveAgentVersion(version string) (string, error) {
+	if version == "latest" {
+		return fts.agentVersion(), nil
+	}
+
+	if !downloads.IsAlias(version) {
+		return version, nil
+	}
+
+	v, err := downloads.GetElasticArtifactVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve the %s version of the agent: %w", version, err)
+	}
+
+	return v, nil
+}
+
+// theUpgradeActionIsCompleted waits for the upgrade action sent by Fleet to be acknowledged by the agent, which
+// happens once the agent runs the new version
+func (fts *FleetTestSuite) theUpgradeActionIsCompleted() error {
+	if fts.AgentActionDate.IsZero() {
+		return fmt.Errorf("the agent was not upgraded in the scenario")
+	}
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+
+	// Fleet does not return the ID of the upgrade action when it is requested
+	findActionFn := func() error {
+		status, err := fts.kibanaClient.GetLatestActionStatus(fts.currentContext, kibana.ActionTypeUpgrade, fts.AgentActionDate)
+		if err != nil {
+			return err
+		}
+
+		fts.AgentActionID = status.ActionID
+		return nil
+	}
+
+	err := backoff.Retry(findActionFn, exp)
+	if err != nil {
+		return err
+	}
+
+	return fts.theAgentAcknowledgesTheAction()
+}
+
+// theAgentDownloadedTheVersion checks that the artifact of the version is in the downloads of the agent
+func (fts *FleetTestSuite) theAgentDownloadedTheVersion(version string) error {
+	switch version {
+	case "latest":
+		version = fts.agentVersion()
+	}
+	version = downloads.RemoveCommitFromSnapshot(version)
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+	pkgManifest, _ := agentInstaller.Inspect()
+
+	// each version of the agent keeps the artifacts it downloads in its own data directory
+	cmd := []string{
+		"find", filepath.Join(pkgManifest.WorkDir, "data"), "-path", "*/downloads/*", "-name", common.ElasticAgentProcessName + "-" + version + "-*",
+	}
+
+	content, err := agentInstaller.Exec(fts.currentContext, cmd)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("the agent did not download the %s version", version)
+	}
+
+	log.WithFields(log.Fields{
+		"artifacts": strings.Fields(content),
+		"version":   version,
+	}).Debug("The agent downloaded the version")
+
+	return nil
+}
+
+// theAgentWasRestartedByTheUpgrade checks that the process of the agent is not the one running before the upgrade
+func (fts *FleetTestSuite) theAgentWasRestartedByTheUpgrade() error {
+	if fts.AgentPIDBeforeUpgrade == "" {
+		return fmt.Errorf("the pid of the agent before the upgrade is unknown")
+	}
+
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
+	agentInstaller, _ := installer.Attach(fts.currentContext, fts.getDeployer(), agentService, fts.InstallerType)
+
+	pid, err := fts.agentPID(agentInstaller)
+	if err != nil {
+		return err
+	}
+
+	if pid == fts.AgentPIDBeforeUpgrade {
+		return fmt.Errorf("the agent was not restarted by the upgrade: its pid is still %s", pid)
+	}
+
+	return nil
+}
+
+// downgradeRejectedMessages the messages of Fleet rejecting the upgrade of an agent to an older version,
+// which changed across the stack versions
+var downgradeRejectedMessages = []string{"is not upgradeable", "lower version", "downgrade"}
+
+// anAttemptToDowngradeTheAgentIsRejected checks that Fleet refuses to upgrade the agent to an older version,
+// with a client error explaining why. Any other failure, such as Kibana being unavailable, is not a rejection.
+// The version of the agent must be checked afterwards, as an accepted downgrade could still fail safely
+func (fts *FleetTestSuite) anAttemptToDowngradeTheAgentIsRejected(olderVersion string) error {
+	agentService := deploy.NewServiceRequest(common.ElasticAgentServiceName)
 	manifest, _ := fts.getDeployer().GetServiceManifest(fts.currentContext, agentService)
-	return fts.kibanaClient.UpgradeAgent(fts.currentContext, manifest.Hostname, desiredVersion)
+
+	statusCode, err := fts.kibanaClient.UpgradeAgent(fts.currentContext, manifest.Hostname, olderVersion)
+	if err == nil {
+		return fmt.Errorf("fleet accepted the downgrade of the agent from %s to %s", fts.Version, olderVersion)
+	}
+
+	if statusCode < 400 || statusCode > 499 {
+		return fmt.Errorf("the downgrade of the agent did not fail with a client error: %w", err)
+	}
+
+	if !containsAny(strings.ToLower(err.Error()), downgradeRejectedMessages) {
+		return fmt.Errorf("fleet did not reject the downgrade of the agent because of its version: %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"error":        err,
+		"olderVersion": olderVersion,
+		"statusCode":   statusCode,
+		"version":      fts.Version,
+	}).Info("Fleet rejected the downgrade of the agent")
+
+	return nil
+}
+
+// containsAny returns if the text contains any of the substrings
+func containsAny(text string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(text, substring) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (fts *FleetTestSuite) anStaleAgentIsDeployedToFleetWithInstaller(staleVersion string, installerType string) error {
 	switch staleVersion {
 	case "latest":
-		staleVersion = common.ElasticAgentVersion
+		staleVersion = fts.agentVersion()
 	}
 
 	fts.Version = staleVersion
@@ -112,7 +343,7 @@ func (fts *FleetTestSuite) installCerts() error {
 	err := agentInstaller.InstallCerts(fts.currentContext)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"agentVersion":      common.ElasticAgentVersion,
+			"agentVersion":      fts.agentVersion(),
 			"agentStaleVersion": fts.Version,
 			"error":             err,
 			"installer":         agentInstaller,
@@ -121,7 +352,7 @@ func (fts *FleetTestSuite) installCerts() error {
 	}
 
 	log.WithFields(log.Fields{
-		"agentVersion":      common.ElasticAgentVersion,
+		"agentVersion":      fts.agentVersion(),
 		"agentStaleVersion": fts.Version,
 		"error":             err,
 		"installer":         agentInstaller,
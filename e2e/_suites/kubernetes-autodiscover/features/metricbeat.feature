@@ -15,3 +15,9 @@ Scenario: Metrics collection configured with hints with named ports
   Given "metricbeat" is running with "hints enabled"
    When "redis" is running with "metrics annotations with named port"
    Then "metricbeat" collects events with "kubernetes.pod.name:redis"
+
+Scenario: Metrics of the hinted module are collected without module configuration
+  Given "metricbeat" is running with "hints enabled"
+   When "redis" is running with "metrics annotations"
+   Then "metricbeat" collects events with "event.module:redis"
+    And "metricbeat" collects events with "metricset.name:info"
@@ -12,6 +12,13 @@ STACK_VERSION?=
 
 ELASTIC_APM_ENVIRONMENT?=local
 
+# QUIET=true prints only the scenario names, the failures and a final summary, hiding the debug logs
+QUIET?=false
+ifeq ($(QUIET),true)
+FORMAT=quiet
+LOG_LEVEL=WARNING
+endif
+
 ifeq ($(ELASTIC_APM_ACTIVE),true)
 # If running in the CI then let's use the environment
 # variables from the withOtelEnv step.
@@ -48,9 +55,12 @@ endif
 # Double quote only if the tags are set
 TAGS_VALUE="$(TAGS)"
 else
-ifeq ($(SKIP_SCENARIOS),true)
+## The scenarios tagged with @long_running only run when their tags are set
 TAGS_FLAG=--godog.tags=
-TAGS_VALUE="~skip"
+ifeq ($(SKIP_SCENARIOS),true)
+TAGS_VALUE="~skip && ~long_running"
+else
+TAGS_VALUE="~long_running"
 endif
 endif
 
@@ -13,6 +13,7 @@ require (
 	github.com/elastic/elastic-package v0.36.0
 	github.com/elastic/go-elasticsearch/v8 v8.0.0-20210317102009-a9d74cec0186
 	github.com/elastic/go-windows v1.0.1 // indirect
+	github.com/getkin/kin-openapi v0.76.0
 	github.com/gobuffalo/packr/v2 v2.8.1
 	github.com/google/uuid v1.3.0
 	github.com/joho/godotenv v1.4.0 // indirect
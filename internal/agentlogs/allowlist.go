@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package agentlogs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Allowlist the messages of the agent logs which are known to be benign, as regular expressions, so that
+// they do not fail the scenarios checking the agent logs have no errors
+type Allowlist struct {
+	Messages []string `yaml:"messages"`
+
+	messageRes []*regexp.Regexp
+}
+
+// Load reads the allowlist from a YAML file, validating the expressions
+func Load(path string) (*Allowlist, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(bytes)
+}
+
+func parse(bytes []byte) (*Allowlist, error) {
+	allowlist := &Allowlist{}
+	err := yaml.Unmarshal(bytes, allowlist)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range allowlist.Messages {
+		re, err := regexp.Compile(message)
+		if err != nil {
+			return nil, fmt.Errorf("the '%s' message expression of the allowlist is not valid: %v", message, err)
+		}
+
+		allowlist.messageRes = append(allowlist.messageRes, re)
+	}
+
+	return allowlist, nil
+}
+
+// Allows returns if the message matches any of the expressions of the allowlist
+func (a *Allowlist) Allows(message string) bool {
+	if a == nil {
+		return false
+	}
+
+	for _, re := range a.messageRes {
+		if re.MatchString(message) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package agentlogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Valid allowlist", func(t *testing.T) {
+		allowlist, err := parse([]byte(`
+messages:
+  - 'connect: connection refused$'
+  - '^Could not communicate with fleet-server'
+`))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(allowlist.Messages))
+	})
+
+	t.Run("Invalid expression", func(t *testing.T) {
+		_, err := parse([]byte(`
+messages:
+  - '(unclosed'
+`))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestAllows(t *testing.T) {
+	allowlist, err := parse([]byte(`
+messages:
+  - 'connect: connection refused$'
+`))
+	assert.Nil(t, err)
+
+	assert.True(t, allowlist.Allows("Failed to connect to backoff(elasticsearch(http://elasticsearch:9200)): Get \"http://elasticsearch:9200\": dial tcp 172.18.0.2:9200: connect: connection refused"))
+	assert.False(t, allowlist.Allows("Failed to apply the policy"))
+
+	var missing *Allowlist
+	assert.False(t, missing.Allows("Failed to apply the policy"))
+}
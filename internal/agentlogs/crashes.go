@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package agentlogs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// PanicCrash a Go panic in the agent or in any of the processes it runs
+	PanicCrash = "panic"
+	// FatalCrash a fatal error, from the Go runtime or logged with the fatal level
+	FatalCrash = "fatal"
+	// RestartLoopCrash a process exiting again and again, being restarted by the agent each time
+	RestartLoopCrash = "restart-loop"
+)
+
+// restartLoopThreshold the number of exits of a process, in the same logs, considered a restart loop
+const restartLoopThreshold = 3
+
+// unknownComponent the component of the exits which do not name the process
+const unknownComponent = "unknown"
+
+// the Go runtime prints panics and fatal errors at the start of a line, which could be prefixed by the journal,
+// i.e. "elastic-agent[42]: ", or be the message of a JSON log entry
+var (
+	panicRe     = regexp.MustCompile(`(?:^|\]: |"message":\s*")panic: `)
+	fatalRe     = regexp.MustCompile(`(?:^|\]: |"message":\s*")fatal error: |"log\.level":\s*"fatal"`)
+	exitRe      = regexp.MustCompile(`exited with code`)
+	componentRe = regexp.MustCompile(`(?:Application: |Component state changed |"component":\s*\{"id":\s*")([\w.\-/]+)`)
+)
+
+// Crash a crash found in the logs of the agent. Restart loops are reported once per component, with the
+// number of exits, while panics and fatal errors are reported for each line
+type Crash struct {
+	Kind      string
+	Component string
+	Line      string
+	Count     int
+}
+
+// String returns a readable representation of the crash
+func (c Crash) String() string {
+	if c.Kind == RestartLoopCrash {
+		return fmt.Sprintf("%s: the %s component exited %d times", c.Kind, c.Component, c.Count)
+	}
+
+	return fmt.Sprintf("%s: %s", c.Kind, c.Line)
+}
+
+// ScanForCrashes returns the panics, fatal errors and restart loops in the logs of the agent, which include
+// the output of the processes it runs, such as the Beats
+func ScanForCrashes(logs string) []Crash {
+	crashes := []Crash{}
+
+	exits := map[string]int{}
+	components := []string{}
+
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case panicRe.MatchString(line):
+			crashes = append(crashes, Crash{Kind: PanicCrash, Component: componentOf(line), Line: line, Count: 1})
+		case fatalRe.MatchString(line):
+			crashes = append(crashes, Crash{Kind: FatalCrash, Component: componentOf(line), Line: line, Count: 1})
+		case exitRe.MatchString(line):
+			component := componentOf(line)
+			if _, exists := exits[component]; !exists {
+				components = append(components, component)
+			}
+			exits[component]++
+		}
+	}
+
+	for _, component := range components {
+		if exits[component] >= restartLoopThreshold {
+			crashes = append(crashes, Crash{Kind: RestartLoopCrash, Component: component, Count: exits[component]})
+		}
+	}
+
+	return crashes
+}
+
+func componentOf(line string) string {
+	matches := componentRe.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return unknownComponent
+	}
+
+	return matches[1]
+}
@@ -0,0 +1,51 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package agentlogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanForCrashes(t *testing.T) {
+	t.Run("Healthy logs", func(t *testing.T) {
+		logs := `Oct 15 10:00:00 centos elastic-agent[42]: Elastic Agent started
+Oct 15 10:00:01 centos elastic-agent[42]: Application: metricbeat--8.3.0[a1b2]: State changed to RUNNING: Running
+Oct 15 10:00:02 centos elastic-agent[42]: Application: filebeat--8.3.0[a1b2]: State changed to CRASHED: exited with code: 2`
+
+		assert.Equal(t, []Crash{}, ScanForCrashes(logs))
+	})
+
+	t.Run("Panics and fatal errors", func(t *testing.T) {
+		logs := `Oct 15 10:00:00 centos elastic-agent[42]: panic: runtime error: invalid memory address or nil pointer dereference
+Oct 15 10:00:00 centos elastic-agent[42]: goroutine 1 [running]:
+{"log.level":"fatal","message":"could not load the configuration"}
+Oct 15 10:00:01 centos elastic-agent[42]: fatal error: concurrent map writes
+Oct 15 10:00:02 centos elastic-agent[42]: the panic: button was not pressed`
+
+		crashes := ScanForCrashes(logs)
+
+		assert.Equal(t, 3, len(crashes))
+		assert.Equal(t, PanicCrash, crashes[0].Kind)
+		assert.Equal(t, FatalCrash, crashes[1].Kind)
+		assert.Equal(t, FatalCrash, crashes[2].Kind)
+	})
+
+	t.Run("Restart loops", func(t *testing.T) {
+		logs := `Application: filebeat--8.3.0[a1b2]: State changed to CRASHED: exited with code: 2
+Application: metricbeat--8.3.0[a1b2]: State changed to CRASHED: exited with code: 1
+Application: filebeat--8.3.0[a1b2]: State changed to CRASHED: exited with code: 2
+Component state changed filebeat-default (HEALTHY->FAILED): Failed: pid '1234' exited with code '-1'
+Application: filebeat--8.3.0[a1b2]: State changed to CRASHED: exited with code: 2`
+
+		crashes := ScanForCrashes(logs)
+
+		assert.Equal(t, []Crash{
+			{Kind: RestartLoopCrash, Component: "filebeat--8.3.0", Count: 3},
+		}, crashes)
+		assert.Equal(t, "restart-loop: the filebeat--8.3.0 component exited 3 times", crashes[0].String())
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/elastic/e2e-testing/internal/shell"
+)
+
+const (
+	// ElasticsearchService the credentials of the requests sent to Elasticsearch by the test framework
+	ElasticsearchService = "elasticsearch"
+
+	// FleetService the credentials of the requests sent to the Fleet API. They default to the Kibana ones,
+	// as the Fleet API is served by Kibana
+	FleetService = "fleet"
+
+	// FleetServerService the credentials used by Fleet Server to connect to Elasticsearch
+	FleetServerService = "fleet-server"
+
+	// KibanaService the credentials of the requests sent to Kibana by the test framework
+	KibanaService = "kibana"
+)
+
+// Credentials represents the user and password used to authenticate against a service, or the service
+// account token, which is used instead of them when present
+type Credentials struct {
+	Username     string
+	Password     string
+	ServiceToken string
+}
+
+// String returns the credentials in the user:password format
+func (c Credentials) String() string {
+	return c.Username + ":" + c.Password
+}
+
+// Apply sets the authorization header of the request, as a bearer token for service account tokens
+// or as basic auth otherwise. Empty credentials leave the request unauthenticated
+func (c Credentials) Apply(req *http.Request) {
+	if c.ServiceToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.ServiceToken)
+		return
+	}
+
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// SecurityDisabled returns if the stack runs with security disabled, which is enabled with the
+// STACK_SECURITY_DISABLED env var. The requests to its services are not authenticated then
+func SecurityDisabled() bool {
+	return shell.GetEnvBool("STACK_SECURITY_DISABLED")
+}
+
+// serviceTokens the service account tokens created at setup for the services
+var serviceTokens = map[string]string{}
+var serviceTokensMutex sync.RWMutex
+
+// SetServiceToken makes the requests to the service authenticate with the service account token, created
+// at setup, instead of the user and password. An empty token restores basic auth
+func SetServiceToken(service string, token string) {
+	serviceTokensMutex.Lock()
+	defer serviceTokensMutex.Unlock()
+
+	if token == "" {
+		delete(serviceTokens, service)
+		return
+	}
+
+	serviceTokens[service] = token
+}
+
+// defaultCredentials the credentials of the users created in the runtime dependencies of the test suites
+var defaultCredentials = map[string]Credentials{
+	ElasticsearchService: {Username: "admin", Password: "changeme"},
+	FleetServerService:   {Username: "elastic", Password: "changeme"},
+	KibanaService:        {Username: "admin", Password: "changeme"},
+}
+
+// fallbackServices the service which credentials are used when the ones of a service are not configured
+var fallbackServices = map[string]string{
+	FleetService:       KibanaService,
+	FleetServerService: ElasticsearchService,
+}
+
+// ForService returns the credentials of the service, read from the <SERVICE>_USERNAME and <SERVICE>_PASSWORD
+// env vars (i.e. FLEET_SERVER_USERNAME). If they are not set, the credentials of its fallback service are used
+// when configured, or the default ones of the runtime dependencies. The service account token is read from the
+// <SERVICE>_SERVICE_TOKEN env var, or it is the one set at setup, if any. There are no credentials when
+// the security of the stack is disabled
+func ForService(service string) Credentials {
+	if SecurityDisabled() {
+		return Credentials{}
+	}
+
+	credentials, hasDefault := defaultCredentials[service]
+	if fallback, hasFallback := fallbackServices[service]; hasFallback && (!hasDefault || isConfigured(fallback)) {
+		credentials = ForService(fallback)
+	}
+
+	serviceTokensMutex.RLock()
+	if token, exists := serviceTokens[service]; exists {
+		credentials.ServiceToken = token
+	}
+	serviceTokensMutex.RUnlock()
+
+	prefix := envPrefix(service)
+
+	return Credentials{
+		Username:     shell.GetEnv(prefix+"_USERNAME", credentials.Username),
+		Password:     shell.GetEnv(prefix+"_PASSWORD", credentials.Password),
+		ServiceToken: shell.GetEnv(prefix+"_SERVICE_TOKEN", credentials.ServiceToken),
+	}
+}
+
+// isConfigured returns if the user of the service is set with env vars
+func isConfigured(service string) bool {
+	return shell.GetEnv(envPrefix(service)+"_USERNAME", "") != ""
+}
+
+func envPrefix(service string) string {
+	return strings.ToUpper(strings.ReplaceAll(service, "-", "_"))
+}
@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package auth
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForService(t *testing.T) {
+	t.Run("Default credentials", func(t *testing.T) {
+		assert.Equal(t, Credentials{Username: "admin", Password: "changeme"}, ForService(ElasticsearchService))
+		assert.Equal(t, Credentials{Username: "elastic", Password: "changeme"}, ForService(FleetServerService))
+		assert.Equal(t, Credentials{Username: "admin", Password: "changeme"}, ForService(KibanaService))
+	})
+
+	t.Run("Fleet uses the Kibana credentials", func(t *testing.T) {
+		defer os.Unsetenv("KIBANA_USERNAME")
+		os.Setenv("KIBANA_USERNAME", "kibana_admin")
+
+		assert.Equal(t, Credentials{Username: "kibana_admin", Password: "changeme"}, ForService(FleetService))
+	})
+
+	t.Run("Fleet Server uses the configured Elasticsearch credentials", func(t *testing.T) {
+		defer os.Unsetenv("ELASTICSEARCH_USERNAME")
+		os.Setenv("ELASTICSEARCH_USERNAME", "superuser")
+
+		assert.Equal(t, Credentials{Username: "superuser", Password: "changeme"}, ForService(FleetServerService))
+	})
+
+	t.Run("Credentials of the service", func(t *testing.T) {
+		defer os.Unsetenv("FLEET_USERNAME")
+		defer os.Unsetenv("FLEET_PASSWORD")
+		os.Setenv("FLEET_USERNAME", "fleet_admin")
+		os.Setenv("FLEET_PASSWORD", "secret")
+
+		assert.Equal(t, Credentials{Username: "fleet_admin", Password: "secret"}, ForService(FleetService))
+		assert.Equal(t, "fleet_admin:secret", ForService(FleetService).String())
+	})
+}
+
+func TestServiceToken(t *testing.T) {
+	defer SetServiceToken(ElasticsearchService, "")
+	SetServiceToken(ElasticsearchService, "AAEAAWVsYXN0aWM")
+
+	credentials := ForService(ElasticsearchService)
+	assert.Equal(t, "AAEAAWVsYXN0aWM", credentials.ServiceToken)
+	assert.Equal(t, "", ForService(KibanaService).ServiceToken)
+
+	t.Run("Service account tokens are bearer tokens", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:9200", nil)
+		credentials.Apply(req)
+
+		assert.Equal(t, "Bearer AAEAAWVsYXN0aWM", req.Header.Get("Authorization"))
+	})
+
+	t.Run("Basic auth without service account token", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:5601", nil)
+		ForService(KibanaService).Apply(req)
+
+		username, password, ok := req.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "admin", username)
+		assert.Equal(t, "changeme", password)
+	})
+}
+
+func TestSecurityDisabled(t *testing.T) {
+	defer os.Unsetenv("STACK_SECURITY_DISABLED")
+	os.Setenv("STACK_SECURITY_DISABLED", "true")
+
+	credentials := ForService(ElasticsearchService)
+	assert.Equal(t, Credentials{}, credentials)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:9200", nil)
+	credentials.Apply(req)
+	assert.Equal(t, "", req.Header.Get("Authorization"))
+}
@@ -0,0 +1,195 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/io"
+)
+
+// Enrollment phases, in the order they happen
+const (
+	PhaseDeploy  = "deploy"
+	PhaseInstall = "install"
+	PhaseEnroll  = "enroll"
+	PhaseOnline  = "online"
+)
+
+// EnrollmentResult the durations of the phases needed to have an agent online in Fleet, in seconds
+type EnrollmentResult struct {
+	Scenario  string             `json:"scenario"`
+	Installer string             `json:"installer"`
+	OS        string             `json:"os"`
+	Arch      string             `json:"arch"`
+	Version   string             `json:"version"`
+	Phases    map[string]float64 `json:"phases"`
+	Total     float64            `json:"total"`
+	Timestamp time.Time          `json:"@timestamp"`
+}
+
+// EnrollmentSummary aggregates the total durations of the enrollments of an installer in an OS and architecture,
+// in seconds
+type EnrollmentSummary struct {
+	Installer string
+	OS        string
+	Arch      string
+	Count     int
+	Min       float64
+	Max       float64
+	Mean      float64
+}
+
+// Timer measures the phases of an enrollment, each one lasting since the previous one finished
+type Timer struct {
+	started time.Time
+	last    time.Time
+	phases  map[string]float64
+}
+
+// NewTimer returns a timer starting now
+func NewTimer() *Timer {
+	now := time.Now()
+
+	return &Timer{
+		started: now,
+		last:    now,
+		phases:  map[string]float64{},
+	}
+}
+
+// Lap records the duration of the phase, which finishes now. It's a no-op for a nil timer
+func (t *Timer) Lap(phase string) {
+	if t == nil {
+		return
+	}
+
+	now := time.Now()
+	t.phases[phase] = now.Sub(t.last).Seconds()
+	t.last = now
+}
+
+// Result returns the result of the enrollment with the phases recorded so far
+func (t *Timer) Result(scenario string, installer string, operatingSystem string, arch string, version string) EnrollmentResult {
+	phases := map[string]float64{}
+	for k, v := range t.phases {
+		phases[k] = v
+	}
+
+	return EnrollmentResult{
+		Scenario:  scenario,
+		Installer: installer,
+		OS:        operatingSystem,
+		Arch:      arch,
+		Version:   version,
+		Phases:    phases,
+		Total:     t.last.Sub(t.started).Seconds(),
+		Timestamp: t.started.UTC(),
+	}
+}
+
+// Recorder stores the results of the enrollments of a run in a file, one JSON document per line,
+// so that they can be compared across runs or ingested into Elasticsearch
+type Recorder struct {
+	path    string
+	results []EnrollmentResult
+	mutex   sync.Mutex
+}
+
+// NewRecorder returns a recorder writing into a file for the run in the directory
+func NewRecorder(dir string, runID string) (*Recorder, error) {
+	err := io.MkdirAll(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		path: filepath.Join(dir, fmt.Sprintf("enrollment-%s.ndjson", runID)),
+	}, nil
+}
+
+// Path returns the path of the file where the results are stored
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Record appends the result to the file of the run
+func (r *Recorder) Record(result EnrollmentResult) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := appendJSONLine(r.path, result)
+	if err != nil {
+		return err
+	}
+
+	r.results = append(r.results, result)
+	return nil
+}
+
+// Summary aggregates the results recorded so far by OS, architecture and installer, sorted by the three
+func (r *Recorder) Summary() []EnrollmentSummary {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	byKey := map[string]*EnrollmentSummary{}
+	for _, result := range r.results {
+		key := result.OS + "/" + result.Arch + "/" + result.Installer
+
+		s, exists := byKey[key]
+		if !exists {
+			s = &EnrollmentSummary{Installer: result.Installer, OS: result.OS, Arch: result.Arch, Min: result.Total, Max: result.Total}
+			byKey[key] = s
+		}
+
+		s.Mean = (s.Mean*float64(s.Count) + result.Total) / float64(s.Count+1)
+		s.Count++
+		if result.Total < s.Min {
+			s.Min = result.Total
+		}
+		if result.Total > s.Max {
+			s.Max = result.Total
+		}
+	}
+
+	summaries := []EnrollmentSummary{}
+	for _, s := range byKey {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].OS != summaries[j].OS {
+			return summaries[i].OS < summaries[j].OS
+		}
+		if summaries[i].Arch != summaries[j].Arch {
+			return summaries[i].Arch < summaries[j].Arch
+		}
+		return summaries[i].Installer < summaries[j].Installer
+	})
+
+	return summaries
+}
+
+// appendJSONLine appends the value to the file as a JSON document in its own line, creating the file if needed
+func appendJSONLine(path string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
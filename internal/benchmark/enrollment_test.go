@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package benchmark
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimer(t *testing.T) {
+	timer := NewTimer()
+	timer.Lap(PhaseDeploy)
+	timer.Lap(PhaseOnline)
+
+	result := timer.Result("Deploying the agent", "tar", "linux", "amd64", "8.6.0-SNAPSHOT")
+	assert.Equal(t, "tar", result.Installer)
+	assert.Equal(t, "linux", result.OS)
+	assert.Equal(t, "amd64", result.Arch)
+	assert.Contains(t, result.Phases, PhaseDeploy)
+	assert.Contains(t, result.Phases, PhaseOnline)
+	assert.NotContains(t, result.Phases, PhaseEnroll)
+	assert.GreaterOrEqual(t, result.Total, result.Phases[PhaseDeploy])
+
+	t.Run("Lap is a no-op for a nil timer", func(t *testing.T) {
+		var nilTimer *Timer
+		assert.NotPanics(t, func() { nilTimer.Lap(PhaseDeploy) })
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	recorder, err := NewRecorder(t.TempDir(), "1234")
+	assert.Nil(t, err)
+
+	results := []EnrollmentResult{
+		{Installer: "tar", OS: "linux", Arch: "amd64", Total: 30},
+		{Installer: "rpm", OS: "linux", Arch: "amd64", Total: 40},
+		{Installer: "tar", OS: "linux", Arch: "amd64", Total: 50},
+		{Installer: "tar", OS: "linux", Arch: "arm64", Total: 60},
+	}
+	for _, result := range results {
+		assert.Nil(t, recorder.Record(result))
+	}
+
+	t.Run("Results are stored one per line", func(t *testing.T) {
+		f, err := os.Open(recorder.Path())
+		assert.Nil(t, err)
+		defer f.Close()
+
+		lines := 0
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			result := EnrollmentResult{}
+			assert.Nil(t, json.Unmarshal(scanner.Bytes(), &result))
+			assert.Equal(t, results[lines].Installer, result.Installer)
+			lines++
+		}
+		assert.Equal(t, 4, lines)
+	})
+
+	t.Run("Results are summarised by OS, architecture and installer", func(t *testing.T) {
+		summaries := recorder.Summary()
+		assert.Len(t, summaries, 3)
+
+		assert.Equal(t, "rpm", summaries[0].Installer)
+		assert.Equal(t, 1, summaries[0].Count)
+
+		assert.Equal(t, "tar", summaries[1].Installer)
+		assert.Equal(t, 2, summaries[1].Count)
+		assert.Equal(t, float64(30), summaries[1].Min)
+		assert.Equal(t, float64(50), summaries[1].Max)
+		assert.Equal(t, float64(40), summaries[1].Mean)
+
+		assert.Equal(t, "arm64", summaries[2].Arch)
+		assert.Equal(t, 1, summaries[2].Count)
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package benchmark
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/io"
+)
+
+// PropagationResult the time, in seconds, between a change in a policy and the agent applying the revision
+// of the policy with the change
+type PropagationResult struct {
+	Scenario  string    `json:"scenario"`
+	Policy    string    `json:"policy"`
+	Revision  int       `json:"revision"`
+	Version   string    `json:"version"`
+	Duration  float64   `json:"duration"`
+	Timestamp time.Time `json:"@timestamp"`
+}
+
+// PropagationSummary aggregates the durations of the policy propagations of a run, in seconds
+type PropagationSummary struct {
+	Count int
+	Min   float64
+	Max   float64
+	Mean  float64
+}
+
+// PropagationRecorder stores the policy propagations of a run in a file, one JSON document per line
+type PropagationRecorder struct {
+	path    string
+	results []PropagationResult
+	mutex   sync.Mutex
+}
+
+// NewPropagationRecorder returns a recorder writing into a file for the run in the directory
+func NewPropagationRecorder(dir string, runID string) (*PropagationRecorder, error) {
+	err := io.MkdirAll(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PropagationRecorder{
+		path: filepath.Join(dir, fmt.Sprintf("policy-propagation-%s.ndjson", runID)),
+	}, nil
+}
+
+// Path returns the path of the file where the results are stored
+func (r *PropagationRecorder) Path() string {
+	return r.path
+}
+
+// Record appends the result to the file of the run
+func (r *PropagationRecorder) Record(result PropagationResult) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := appendJSONLine(r.path, result)
+	if err != nil {
+		return err
+	}
+
+	r.results = append(r.results, result)
+	return nil
+}
+
+// Summary aggregates the results recorded so far. The count is zero if there are no results
+func (r *PropagationRecorder) Summary() PropagationSummary {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s := PropagationSummary{}
+	for _, result := range r.results {
+		if s.Count == 0 || result.Duration < s.Min {
+			s.Min = result.Duration
+		}
+		if result.Duration > s.Max {
+			s.Max = result.Duration
+		}
+
+		s.Mean = (s.Mean*float64(s.Count) + result.Duration) / float64(s.Count+1)
+		s.Count++
+	}
+
+	return s
+}
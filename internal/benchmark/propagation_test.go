@@ -0,0 +1,57 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package benchmark
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPropagationRecorder(t *testing.T) {
+	recorder, err := NewPropagationRecorder(t.TempDir(), "1234")
+	assert.Nil(t, err)
+
+	t.Run("An empty recorder has no results", func(t *testing.T) {
+		assert.Equal(t, 0, recorder.Summary().Count)
+	})
+
+	results := []PropagationResult{
+		{Policy: "policy-1", Revision: 2, Duration: 4},
+		{Policy: "policy-1", Revision: 3, Duration: 8},
+		{Policy: "policy-2", Revision: 2, Duration: 6},
+	}
+	for _, result := range results {
+		assert.Nil(t, recorder.Record(result))
+	}
+
+	t.Run("Results are stored one per line", func(t *testing.T) {
+		f, err := os.Open(recorder.Path())
+		assert.Nil(t, err)
+		defer f.Close()
+
+		lines := 0
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			result := PropagationResult{}
+			assert.Nil(t, json.Unmarshal(scanner.Bytes(), &result))
+			assert.Equal(t, results[lines].Revision, result.Revision)
+			lines++
+		}
+		assert.Equal(t, 3, lines)
+	})
+
+	t.Run("Results are summarised", func(t *testing.T) {
+		summary := recorder.Summary()
+
+		assert.Equal(t, 3, summary.Count)
+		assert.Equal(t, float64(4), summary.Min)
+		assert.Equal(t, float64(8), summary.Max)
+		assert.Equal(t, float64(6), summary.Mean)
+	})
+}
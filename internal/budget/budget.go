@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package budget
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Budget the max duration of the steps matching a regular expression, only in the scenarios
+// including all the tags, if any
+type Budget struct {
+	Step     string   `yaml:"step"`
+	Duration string   `yaml:"duration"`
+	Tags     []string `yaml:"tags"`
+
+	duration time.Duration
+	stepRe   *regexp.Regexp
+}
+
+// Budgets the duration budgets of the steps, in the order they are declared
+type Budgets struct {
+	Budgets []*Budget `yaml:"budgets"`
+}
+
+// Load reads the budgets from a YAML file, validating the expressions and durations
+func Load(path string) (*Budgets, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(bytes)
+}
+
+func parse(bytes []byte) (*Budgets, error) {
+	budgets := &Budgets{}
+	err := yaml.Unmarshal(bytes, budgets)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range budgets.Budgets {
+		b.stepRe, err = regexp.Compile(b.Step)
+		if err != nil {
+			return nil, fmt.Errorf("the '%s' step expression of the budget is not valid: %v", b.Step, err)
+		}
+
+		b.duration, err = time.ParseDuration(b.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("the '%s' duration of the budget for the '%s' step is not valid: %v", b.Duration, b.Step, err)
+		}
+	}
+
+	return budgets, nil
+}
+
+// Find returns the first budget matching the step in a scenario with the tags
+func (bs *Budgets) Find(step string, tags []string) (*Budget, bool) {
+	if bs == nil {
+		return nil, false
+	}
+
+	for _, b := range bs.Budgets {
+		if b.stepRe.MatchString(step) && includesAll(tags, b.Tags) {
+			return b, true
+		}
+	}
+
+	return nil, false
+}
+
+// Scale multiplies the durations of the budgets by a factor, so that they grow with the timeouts of the retries
+// in slower environments
+func (bs *Budgets) Scale(factor int) {
+	for _, b := range bs.Budgets {
+		b.duration = b.duration * time.Duration(factor)
+	}
+}
+
+// Check returns an error if the elapsed time exceeds the budget
+func (b *Budget) Check(elapsed time.Duration) error {
+	if elapsed <= b.duration {
+		return nil
+	}
+
+	return fmt.Errorf("the step took %s, exceeding its %s budget", elapsed.Round(time.Millisecond), b.duration)
+}
+
+func includesAll(tags []string, required []string) bool {
+	for _, r := range required {
+		found := false
+		for _, t := range tags {
+			if t == r {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
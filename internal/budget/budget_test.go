@@ -0,0 +1,77 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testBudgets = `
+budgets:
+  - step: 'the agent is listed in Fleet as "online"'
+    duration: 90s
+    tags: ["@centos"]
+  - step: 'the agent is listed in Fleet as "online"'
+    duration: 3m
+`
+
+func TestFind(t *testing.T) {
+	budgets, err := parse([]byte(testBudgets))
+	assert.Nil(t, err)
+
+	t.Run("The first budget matching the step and the tags is returned", func(t *testing.T) {
+		b, found := budgets.Find(`the agent is listed in Fleet as "online"`, []string{"@fleet_mode", "@centos"})
+		assert.True(t, found)
+		assert.Equal(t, "90s", b.Duration)
+	})
+
+	t.Run("Budgets with tags are skipped if the scenario does not have them", func(t *testing.T) {
+		b, found := budgets.Find(`the agent is listed in Fleet as "online"`, []string{"@fleet_mode"})
+		assert.True(t, found)
+		assert.Equal(t, "3m", b.Duration)
+	})
+
+	t.Run("Steps without budget", func(t *testing.T) {
+		_, found := budgets.Find(`the agent is listed in Fleet as "offline"`, []string{})
+		assert.False(t, found)
+	})
+
+	t.Run("Nil budgets", func(t *testing.T) {
+		var nilBudgets *Budgets
+		_, found := nilBudgets.Find(`the agent is listed in Fleet as "online"`, []string{})
+		assert.False(t, found)
+	})
+}
+
+func TestCheck(t *testing.T) {
+	budgets, err := parse([]byte(testBudgets))
+	assert.Nil(t, err)
+
+	b := budgets.Budgets[0]
+	assert.Nil(t, b.Check(90*time.Second))
+	assert.Error(t, b.Check(91*time.Second))
+}
+
+func TestScale(t *testing.T) {
+	budgets, err := parse([]byte(testBudgets))
+	assert.Nil(t, err)
+
+	budgets.Scale(3)
+
+	b := budgets.Budgets[0]
+	assert.Nil(t, b.Check(270*time.Second))
+	assert.Error(t, b.Check(271*time.Second))
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := parse([]byte("budgets:\n  - step: '('\n    duration: 1m\n"))
+	assert.Error(t, err)
+
+	_, err = parse([]byte("budgets:\n  - step: 'online'\n    duration: one minute\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/pkg/errors"
+)
+
+// KeyPair represents a PEM-encoded certificate and its private key
+type KeyPair struct {
+	Cert []byte
+	Key  []byte
+}
+
+// Write writes the certificate and the private key of the key pair to the directory, as <name>.crt and <name>.key,
+// returning their paths
+func (kp KeyPair) Write(dir string, name string) (string, string, error) {
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+
+	err := io.WriteFile(kp.Cert, certPath)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "could not write the %s certificate", name)
+	}
+
+	err = io.WriteFile(kp.Key, keyPath)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "could not write the %s private key", name)
+	}
+
+	return certPath, keyPath, nil
+}
+
+// CA represents a certificate authority, which issues the certificates of the services of the stack
+type CA struct {
+	KeyPair
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// NewCA creates a self-signed certificate authority, valid between the two dates
+func NewCA(commonName string, notBefore time.Time, notAfter time.Time) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate the private key of the CA")
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the certificate of the CA")
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse the certificate of the CA")
+	}
+
+	keyPair, err := encodeKeyPair(der, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{
+		KeyPair: keyPair,
+		cert:    cert,
+		key:     key,
+	}, nil
+}
+
+// IssueServerCertificate issues a certificate for a server reachable at the hosts, which can be either DNS names
+// or IP addresses, valid between the two dates. The dates are not bound by the validity of the CA, so expired
+// certificates can be issued too
+func (ca *CA) IssueServerCertificate(hosts []string, notBefore time.Time, notAfter time.Time) (KeyPair, error) {
+	if len(hosts) == 0 {
+		return KeyPair{}, errors.New("at least one host is needed to issue a server certificate")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return KeyPair{}, errors.Wrap(err, "could not generate the private key of the server")
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return KeyPair{}, errors.Wrap(err, "could not create the certificate of the server")
+	}
+
+	return encodeKeyPair(der, key)
+}
+
+// IssueExpiredServerCertificate issues a certificate for a server reachable at the hosts, which expired a day ago
+func (ca *CA) IssueExpiredServerCertificate(hosts []string) (KeyPair, error) {
+	now := time.Now()
+	return ca.IssueServerCertificate(hosts, now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+}
+
+func encodeKeyPair(der []byte, key *ecdsa.PrivateKey) (KeyPair, error) {
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return KeyPair{}, errors.Wrap(err, "could not encode the private key")
+	}
+
+	return KeyPair{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}, nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate the serial number of the certificate")
+	}
+
+	return serialNumber, nil
+}
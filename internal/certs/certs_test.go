@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/Flaque/filet"
+	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueServerCertificate(t *testing.T) {
+	now := time.Now()
+	ca, err := NewCA("e2e-ca", now.Add(-time.Hour), now.Add(time.Hour))
+	assert.Nil(t, err)
+
+	t.Run("Valid certificate is verified by the CA", func(t *testing.T) {
+		kp, err := ca.IssueServerCertificate([]string{"fleet-server", "127.0.0.1"}, now.Add(-time.Hour), now.Add(time.Hour))
+		assert.Nil(t, err)
+
+		cert := parseCertificate(t, kp.Cert)
+		assert.Equal(t, []string{"fleet-server"}, cert.DNSNames)
+		assert.Equal(t, "127.0.0.1", cert.IPAddresses[0].String())
+
+		_, err = cert.Verify(x509.VerifyOptions{DNSName: "fleet-server", Roots: caPool(t, ca)})
+		assert.Nil(t, err)
+
+		_, err = tls.X509KeyPair(kp.Cert, kp.Key)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Expired certificate is not verified by the CA", func(t *testing.T) {
+		kp, err := ca.IssueExpiredServerCertificate([]string{"fleet-server"})
+		assert.Nil(t, err)
+
+		cert := parseCertificate(t, kp.Cert)
+		_, err = cert.Verify(x509.VerifyOptions{DNSName: "fleet-server", Roots: caPool(t, ca)})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Certificate without hosts is not issued", func(t *testing.T) {
+		_, err := ca.IssueServerCertificate([]string{}, now, now.Add(time.Hour))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestKeyPairWrite(t *testing.T) {
+	defer filet.CleanUp(t)
+
+	tmpDir := filet.TmpDir(t, "")
+
+	kp := KeyPair{Cert: []byte("cert"), Key: []byte("key")}
+	certPath, keyPath, err := kp.Write(tmpDir, "fleet-server")
+	assert.Nil(t, err)
+	assert.Equal(t, path.Join(tmpDir, "fleet-server.crt"), certPath)
+	assert.Equal(t, path.Join(tmpDir, "fleet-server.key"), keyPath)
+
+	cert, _ := io.ReadFile(certPath)
+	assert.Equal(t, "cert", string(cert))
+}
+
+func caPool(t *testing.T, ca *CA) *x509.CertPool {
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(ca.Cert))
+	return pool
+}
+
+func parseCertificate(t *testing.T, certPEM []byte) *x509.Certificate {
+	block, _ := pem.Decode(certPEM)
+	assert.NotNil(t, block)
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.Nil(t, err)
+	return cert
+}
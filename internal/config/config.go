@@ -229,6 +229,9 @@ func configureLogger() {
 	default:
 		log.SetLevel(log.InfoLevel)
 	}
+
+	// the loggers of the components inherit the global configuration
+	resetLoggers()
 }
 
 // newConfig returns a new configuration
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HTTPLogger the name of the logger for the requests sent to the Kibana and Elasticsearch APIs
+const HTTPLogger = "http"
+
+// ComposeLogger the name of the logger for the Docker Compose executions
+const ComposeLogger = "compose"
+
+var loggers = map[string]*log.Logger{}
+var loggersMutex sync.Mutex
+
+// Logger returns the logger for a component of the tool, which level can be configured independently
+// with the LOG_LEVEL_<COMPONENT> env var (i.e. LOG_LEVEL_HTTP=debug), also read from the .env file in
+// the tool's workspace. If not set, the global log level is used. The formatter and the output are
+// the ones of the global logger
+func Logger(component string) *log.Logger {
+	loggersMutex.Lock()
+	defer loggersMutex.Unlock()
+
+	if logger, exists := loggers[component]; exists {
+		return logger
+	}
+
+	std := log.StandardLogger()
+
+	logger := log.New()
+	logger.SetFormatter(std.Formatter)
+	logger.SetOutput(std.Out)
+	logger.SetLevel(componentLogLevel(component, std.GetLevel()))
+
+	loggers[component] = logger
+	return logger
+}
+
+// componentLogLevel returns the log level for the component, or the default level if it is not set or invalid
+func componentLogLevel(component string, defaultLevel log.Level) log.Level {
+	envVar := "LOG_LEVEL_" + strings.ToUpper(component)
+
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultLevel
+	}
+
+	level, err := log.ParseLevel(value)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"envVar": envVar,
+			"error":  err,
+			"value":  value,
+		}).Warn("Invalid log level for the component, using the global one")
+		return defaultLevel
+	}
+
+	return level
+}
+
+// resetLoggers discards the loggers of the components, so that they are configured again on next use
+func resetLoggers() {
+	loggersMutex.Lock()
+	defer loggersMutex.Unlock()
+
+	loggers = map[string]*log.Logger{}
+}
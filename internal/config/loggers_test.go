@@ -0,0 +1,47 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	defer resetLoggers()
+
+	t.Run("The global level is used by default", func(t *testing.T) {
+		resetLoggers()
+		defer logrus.SetLevel(logrus.GetLevel())
+		logrus.SetLevel(logrus.WarnLevel)
+
+		assert.Equal(t, logrus.WarnLevel, Logger(HTTPLogger).GetLevel())
+	})
+
+	t.Run("The level of the component is read from the environment", func(t *testing.T) {
+		resetLoggers()
+		os.Setenv("LOG_LEVEL_HTTP", "debug")
+		defer os.Unsetenv("LOG_LEVEL_HTTP")
+
+		assert.Equal(t, logrus.DebugLevel, Logger(HTTPLogger).GetLevel())
+	})
+
+	t.Run("An invalid level falls back to the global one", func(t *testing.T) {
+		resetLoggers()
+		os.Setenv("LOG_LEVEL_COMPOSE", "verbose")
+		defer os.Unsetenv("LOG_LEVEL_COMPOSE")
+
+		assert.Equal(t, logrus.GetLevel(), Logger(ComposeLogger).GetLevel())
+	})
+
+	t.Run("The logger is reused for the same component", func(t *testing.T) {
+		resetLoggers()
+
+		assert.Same(t, Logger(ComposeLogger), Logger(ComposeLogger))
+	})
+}
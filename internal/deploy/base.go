@@ -93,7 +93,9 @@ type ServiceRequest struct {
 	BackgroundProcesses []string // optional, configured using builder method to add processes that must be installed in the service
 	Flavour             string   // optional, configured using builder method
 	IsContainer         bool     // optional, set to true when the service is backed by a container
+	Overlays            []string // optional, configured using builder method to merge compose overlays into the profile
 	Scale               int      // default: 1
+	User                string   // optional, the user running the commands executed in the service. Default: root
 	Version             string
 	WaitStrategies      []WaitForServiceRequest // wait strategies for the service
 }
@@ -142,6 +144,16 @@ func (sr ServiceRequest) WithFlavour(f string) ServiceRequest {
 	return sr
 }
 
+// WithOverlays adds compose overlays to the profile, merged into its compose file at runtime, so that a scenario
+// can override the services of the profile without a new profile. Overlays are looked up in the overlays subdir
+// of the profile, by name and without the yml extension, unless they are absolute paths
+func (sr ServiceRequest) WithOverlays(o ...string) ServiceRequest {
+	overlays := []string{}
+	overlays = append(overlays, sr.Overlays...)
+	sr.Overlays = append(overlays, o...)
+	return sr
+}
+
 // WithScale adds the scale index to the service
 func (sr ServiceRequest) WithScale(s int) ServiceRequest {
 	if s < 1 {
@@ -152,6 +164,22 @@ func (sr ServiceRequest) WithScale(s int) ServiceRequest {
 	return sr
 }
 
+// WithUser sets the user running the commands executed in the service, i.e. to check the behaviour of
+// unprivileged users
+func (sr ServiceRequest) WithUser(u string) ServiceRequest {
+	sr.User = u
+	return sr
+}
+
+// execUser returns the user running the commands executed in the service
+func (sr ServiceRequest) execUser() string {
+	if sr.User == "" {
+		return "root"
+	}
+
+	return sr.User
+}
+
 // WithVersion adds a version for the service
 func (sr ServiceRequest) WithVersion(v string) ServiceRequest {
 	sr.Version = v
@@ -98,6 +98,20 @@ func Test_ServiceRequest_WithScale(t *testing.T) {
 	})
 }
 
+func Test_ServiceRequest_WithUser(t *testing.T) {
+	t.Run("ServiceRequest without user", func(t *testing.T) {
+		srv := NewServiceRequest("foo")
+
+		assert.Equal(t, "root", srv.execUser(), "Commands are executed by root")
+	})
+
+	t.Run("ServiceRequest including user", func(t *testing.T) {
+		srv := NewServiceRequest("foo").WithUser("nobody")
+
+		assert.Equal(t, "nobody", srv.execUser(), "Commands are executed by the user")
+	})
+}
+
 func Test_ServiceRequest_GetVersion(t *testing.T) {
 	originalElasticAgentVersion := common.ElasticAgentVersion
 
@@ -123,3 +137,19 @@ func Test_ServiceRequest_GetVersion(t *testing.T) {
 		assert.Equal(t, "4.5.6", srv.Version, "Service has version")
 	})
 }
+
+func Test_ServiceRequest_WithOverlays(t *testing.T) {
+	t.Run("ServiceRequest without overlays", func(t *testing.T) {
+		srv := NewServiceRequest("foo")
+
+		assert.Empty(t, srv.Overlays, "Profile has no overlays")
+	})
+
+	t.Run("ServiceRequest including overlays", func(t *testing.T) {
+		base := NewServiceRequest("foo").WithOverlays("bar")
+		srv := base.WithOverlays("baz")
+
+		assert.Equal(t, []string{"bar", "baz"}, srv.Overlays, "Overlays are merged in order")
+		assert.Equal(t, []string{"bar"}, base.Overlays, "Overlays are not shared with the original request")
+	})
+}
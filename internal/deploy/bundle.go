@@ -0,0 +1,200 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/elastic/e2e-testing/internal/config"
+	"github.com/elastic/e2e-testing/internal/io"
+	state "github.com/elastic/e2e-testing/internal/state"
+	log "github.com/sirupsen/logrus"
+	tc "github.com/testcontainers/testcontainers-go"
+	"gopkg.in/yaml.v2"
+)
+
+// bundleManifestFile the name of the file describing the bundle
+const bundleManifestFile = "bundle.yml"
+
+// bundleImagesFile the name of the compose file pinning the images of the services to their digests
+const bundleImagesFile = "docker-compose.images.yml"
+
+// Bundle represents the environment of a run, exported to be reproduced in another Docker host
+type Bundle struct {
+	RunID      string            `yaml:"runID"`
+	Profile    string            `yaml:"profile"`
+	ExportedAt time.Time         `yaml:"exportedAt"`
+	Compose    []string          `yaml:"compose"` // relative to the bundle, starting with the profile
+	Env        map[string]string `yaml:"env"`
+	Images     map[string]string `yaml:"images"` // image of each service, including its digest when available
+}
+
+// ExportBundle exports the running environment of a profile to a directory, including the compose files and
+// environment of the run, and the digests of the images of its containers
+func ExportBundle(ctx context.Context, profile string, dir string) (Bundle, error) {
+	run := state.Recover(profile+"-profile", config.OpDir())
+	if len(run.Compose) == 0 {
+		return Bundle{}, fmt.Errorf("there is no state for the %s profile, is it running?", profile)
+	}
+
+	// compose files can refer to files in other directories, such as the ones of the parent profile
+	composeDir := filepath.Join(config.OpDir(), "compose")
+	err := io.CopyDir(composeDir, filepath.Join(dir, "compose"))
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	bundle := Bundle{
+		RunID:      run.Env[runIDEnvVar],
+		Profile:    profile,
+		ExportedAt: time.Now().UTC(),
+		Compose:    []string{},
+		Env:        run.Env,
+	}
+
+	for _, composeFile := range run.Compose {
+		rel, err := filepath.Rel(config.OpDir(), composeFile)
+		if err != nil {
+			return Bundle{}, err
+		}
+		bundle.Compose = append(bundle.Compose, rel)
+	}
+
+	images, err := getServiceImages(ctx, profile)
+	if err != nil {
+		return Bundle{}, err
+	}
+	bundle.Images = images
+
+	err = writeYAML(filepath.Join(dir, bundleImagesFile), imagesCompose(images))
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	err = writeYAML(filepath.Join(dir, bundleManifestFile), bundle)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	log.WithFields(log.Fields{
+		"dir":     dir,
+		"images":  images,
+		"profile": profile,
+		"runID":   bundle.RunID,
+	}).Info("Environment exported to bundle")
+
+	return bundle, nil
+}
+
+// UpBundle starts the environment exported to a bundle directory, with the images pinned to the exported digests.
+// The state of the profile is updated, so it can be stopped as any other profile
+func UpBundle(ctx context.Context, dir string) (Bundle, error) {
+	bundle := Bundle{}
+
+	bytes, err := io.ReadFile(filepath.Join(dir, bundleManifestFile))
+	if err != nil {
+		return bundle, err
+	}
+
+	err = yaml.Unmarshal(bytes, &bundle)
+	if err != nil {
+		return bundle, err
+	}
+
+	composeFilePaths := []string{}
+	for _, composeFile := range bundle.Compose {
+		composeFilePaths = append(composeFilePaths, filepath.Join(dir, composeFile))
+	}
+	composeFilePaths = append(composeFilePaths, filepath.Join(dir, bundleImagesFile))
+
+	started := time.Now()
+	execError := tc.NewLocalDockerCompose(composeFilePaths, bundle.Profile).
+		WithCommand([]string{"up", "-d"}).
+		WithEnv(bundle.Env).
+		Invoke()
+	if execError.Error != nil {
+		return bundle, fmt.Errorf("could not run the compose files of the bundle: %v - %v", composeFilePaths, execError.Error)
+	}
+
+	state.Update(bundle.Profile+"-profile", config.OpDir(), composeFilePaths, bundle.Env)
+
+	log.WithFields(log.Fields{
+		"dir":         dir,
+		"elapsedTime": time.Since(started),
+		"profile":     bundle.Profile,
+		"runID":       bundle.RunID,
+	}).Info("Environment started from bundle")
+
+	return bundle, nil
+}
+
+// getServiceImages returns the image of each service of a profile, identified by its digest when the image
+// comes from a registry, or by its tag otherwise
+func getServiceImages(ctx context.Context, profile string) (map[string]string, error) {
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	labelFilters := filters.NewArgs()
+	labelFilters.Add("label", composeProjectLabel+"="+profile)
+
+	containers, err := dockerClient.ContainerList(ctx, types.ContainerListOptions{Filters: labelFilters})
+	if err != nil {
+		return nil, err
+	}
+
+	images := map[string]string{}
+	for _, container := range containers {
+		service := container.Labels[composeServiceLabel]
+
+		inspect, _, err := dockerClient.ImageInspectWithRaw(ctx, container.ImageID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(inspect.RepoDigests) == 0 {
+			// images loaded from a file, such as the ones built from a PR, are not in a registry
+			log.WithFields(log.Fields{
+				"image":   container.Image,
+				"service": service,
+			}).Warn("The image does not have a digest, it will be referenced by its tag")
+
+			images[service] = container.Image
+			continue
+		}
+
+		images[service] = inspect.RepoDigests[0]
+	}
+
+	return images, nil
+}
+
+// imagesCompose returns the compose file overriding the images of the services
+func imagesCompose(images map[string]string) map[string]interface{} {
+	services := map[string]interface{}{}
+	for service, image := range images {
+		services[service] = map[string]string{
+			"image": image,
+		}
+	}
+
+	return map[string]interface{}{
+		"version":  "2.4",
+		"services": services,
+	}
+}
+
+func writeYAML(path string, value interface{}) error {
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return io.WriteFile(bytes, path)
+}
@@ -0,0 +1,36 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestImagesCompose(t *testing.T) {
+	images := map[string]string{
+		"elasticsearch": "docker.elastic.co/elasticsearch/elasticsearch@sha256:1a2b3c",
+		"kibana":        "docker.elastic.co/kibana/kibana:8.6.0-SNAPSHOT",
+	}
+
+	bytes, err := yaml.Marshal(imagesCompose(images))
+	assert.Nil(t, err)
+
+	var compose struct {
+		Version  string `yaml:"version"`
+		Services map[string]struct {
+			Image string `yaml:"image"`
+		} `yaml:"services"`
+	}
+	err = yaml.Unmarshal(bytes, &compose)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "2.4", compose.Version)
+	assert.Equal(t, 2, len(compose.Services))
+	assert.Equal(t, images["elasticsearch"], compose.Services["elasticsearch"].Image)
+	assert.Equal(t, images["kibana"], compose.Services["kibana"].Image)
+}
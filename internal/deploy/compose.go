@@ -9,9 +9,12 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/elastic/e2e-testing/internal/config"
 	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/elastic/e2e-testing/internal/metrics"
+	"github.com/elastic/e2e-testing/internal/naming"
 	state "github.com/elastic/e2e-testing/internal/state"
 	"go.elastic.co/apm"
 
@@ -19,6 +22,12 @@ import (
 	tc "github.com/testcontainers/testcontainers-go"
 )
 
+// RunIDLabel the Docker label identifying the run that created a container or a network
+const RunIDLabel = "co.elastic.e2e.run-id"
+
+// runIDEnvVar the variable interpolated by the compose files into the value of the run ID label
+const runIDEnvVar = "e2eRunID"
+
 // ServiceManager manages lifecycle of a service
 type ServiceManager interface {
 	AddServicesToCompose(ctx context.Context, profile ServiceRequest, services []ServiceRequest, env map[string]string) error
@@ -33,6 +42,12 @@ type ServiceManager interface {
 type DockerServiceManager struct {
 }
 
+// composeLogger returns the logger for the Docker Compose executions, which level can be configured
+// with the LOG_LEVEL_COMPOSE env var
+func composeLogger() *log.Logger {
+	return config.Logger(config.ComposeLogger)
+}
+
 // NewServiceManager returns a new service manager
 func NewServiceManager() ServiceManager {
 	return &DockerServiceManager{}
@@ -45,7 +60,7 @@ func (sm *DockerServiceManager) AddServicesToCompose(ctx context.Context, profil
 	})
 	defer span.End()
 
-	log.WithFields(log.Fields{
+	composeLogger().WithFields(log.Fields{
 		"profile":  profile,
 		"services": services,
 	}).Trace("Adding services to compose")
@@ -65,7 +80,9 @@ func (sm *DockerServiceManager) AddServicesToCompose(ctx context.Context, profil
 
 	cmds := []string{"up", "-d"}
 	if len(scaleCmds) > 0 {
-		cmds = append(cmds, "--scale")
+		// scaling adds replicas, so the existing ones must not be recreated with the settings of the new one,
+		// such as its hostname
+		cmds = append(cmds, "--no-recreate", "--scale")
 		cmds = append(cmds, scaleCmds...)
 	}
 
@@ -77,7 +94,9 @@ func (sm *DockerServiceManager) AddServicesToCompose(ctx context.Context, profil
 	return nil
 }
 
-// ExecCommandInService executes a command in a service from a profile
+// ExecCommandInService executes a command in a service from a profile.
+// It stops waiting for the command when the context is done, returning its error, as Docker Compose is not run
+// with the context and it cannot be killed: the command could still be running in the container
 func (sm *DockerServiceManager) ExecCommandInService(ctx context.Context, profile ServiceRequest, image ServiceRequest, serviceName string, cmds []string, env map[string]string, detach bool) error {
 	services := []ServiceRequest{
 		image, // image for the service
@@ -86,13 +105,27 @@ func (sm *DockerServiceManager) ExecCommandInService(ctx context.Context, profil
 	if detach {
 		composeArgs = append(composeArgs, "-d")
 	}
+	if image.User != "" {
+		composeArgs = append(composeArgs, "-u", image.User)
+	}
 	composeArgs = append(composeArgs, "--index", fmt.Sprintf("%d", image.Scale))
 	composeArgs = append(composeArgs, serviceName)
 	composeArgs = append(composeArgs, cmds...)
 
-	err := sm.RunCommand(ctx, profile, services, composeArgs, env)
+	done := make(chan error, 1)
+	go func() {
+		done <- sm.RunCommand(ctx, profile, services, composeArgs, env)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("the command did not finish in the service: %w", ctx.Err())
+	}
+
 	if err != nil {
-		log.WithFields(log.Fields{
+		composeLogger().WithFields(log.Fields{
 			"command": cmds,
 			"error":   err,
 			"service": serviceName,
@@ -113,7 +146,7 @@ func (sm *DockerServiceManager) RemoveServicesFromCompose(ctx context.Context, p
 	span.Context.SetLabel("services", services)
 	defer span.End()
 
-	log.WithFields(log.Fields{
+	composeLogger().WithFields(log.Fields{
 		"profile":  profile,
 		"services": services,
 	}).Trace("Removing services from compose")
@@ -130,14 +163,14 @@ func (sm *DockerServiceManager) RemoveServicesFromCompose(ctx context.Context, p
 
 		err := executeCompose(ctx, profile, services, command, persistedEnv)
 		if err != nil {
-			log.WithFields(log.Fields{
+			composeLogger().WithFields(log.Fields{
 				"command": command,
 				"service": srv,
 				"profile": profile,
 			}).Error("Could not remove service from compose")
 			return err
 		}
-		log.WithFields(log.Fields{
+		composeLogger().WithFields(log.Fields{
 			"profile": profile,
 			"service": srv,
 		}).Debug("Service removed from compose")
@@ -180,7 +213,7 @@ func (sm *DockerServiceManager) StopCompose(ctx context.Context, profile Service
 	}
 	defer state.Destroy(ID, config.OpDir())
 
-	log.WithFields(log.Fields{
+	composeLogger().WithFields(log.Fields{
 		"profile": profile.Name,
 	}).Trace("Docker compose down.")
 
@@ -210,6 +243,17 @@ func executeCompose(ctx context.Context, profile ServiceRequest, services []Serv
 		composeFilePaths = append(composeFilePaths, composeFilePath)
 	}
 
+	// overlays are merged the last, so that they prevail over the profile and the services
+	for _, overlay := range profile.Overlays {
+		overlayFilePath, err := getOverlayFile(profile.GetName(), overlay)
+		if err != nil {
+			return fmt.Errorf("could not get overlay file for profile: %s - %v", overlay, err)
+		}
+		composeFilePaths = append(composeFilePaths, overlayFilePath)
+	}
+
+	env = withRunID(profile, env)
+
 	compose := tc.NewLocalDockerCompose(composeFilePaths, profile.Name)
 	dc := compose.
 		WithCommand(command).
@@ -226,8 +270,10 @@ func executeCompose(ctx context.Context, profile ServiceRequest, services []Serv
 		}
 	}
 
+	started := time.Now()
 	execError := dc.Invoke()
 	err = execError.Error
+	metrics.ObserveDockerOperation("compose-"+command[0], started, err)
 	if err != nil {
 		return fmt.Errorf("could not run compose file: %v - %v", composeFilePaths, err)
 	}
@@ -235,7 +281,7 @@ func executeCompose(ctx context.Context, profile ServiceRequest, services []Serv
 	ID := filepath.Base(filepath.Dir(composeFilePaths[0])) + "-profile"
 	defer state.Update(ID, config.OpDir(), composeFilePaths, env)
 
-	log.WithFields(log.Fields{
+	composeLogger().WithFields(log.Fields{
 		"cmd":              command,
 		"composeFilePaths": composeFilePaths,
 		"env":              env,
@@ -245,6 +291,29 @@ func executeCompose(ctx context.Context, profile ServiceRequest, services []Serv
 	return nil
 }
 
+// withRunID returns a copy of the environment including the run ID for the labels of the containers. The run ID
+// persisted for the profile prevails over the current one, so that the runtime dependencies reused in
+// developer mode are not recreated because of a label change
+func withRunID(profile ServiceRequest, env map[string]string) map[string]string {
+	result := map[string]string{}
+	for k, v := range env {
+		result[k] = v
+	}
+
+	if _, exists := result[runIDEnvVar]; exists {
+		return result
+	}
+
+	run := state.Recover(profile.Name+"-profile", config.OpDir())
+	if runID, exists := run.Env[runIDEnvVar]; exists {
+		result[runIDEnvVar] = runID
+		return result
+	}
+
+	result[runIDEnvVar] = naming.RunID()
+	return result
+}
+
 // getComposeFile returns the path of the compose file, looking up the
 // tool's workdir
 func getComposeFile(isProfile bool, composeName string) (string, error) {
@@ -257,7 +326,7 @@ func getComposeFile(isProfile bool, composeName string) (string, error) {
 	composeFilePath := path.Join(config.OpDir(), "compose", serviceType, composeName, composeFileName)
 	found, err := io.Exists(composeFilePath)
 	if found && err == nil {
-		log.WithFields(log.Fields{
+		composeLogger().WithFields(log.Fields{
 			"composeFilePath": composeFilePath,
 			"type":            serviceType,
 		}).Trace("Compose file found at workdir")
@@ -265,14 +334,14 @@ func getComposeFile(isProfile bool, composeName string) (string, error) {
 		return composeFilePath, nil
 	}
 
-	log.WithFields(log.Fields{
+	composeLogger().WithFields(log.Fields{
 		"composeFilePath": composeFilePath,
 		"error":           err,
 		"type":            serviceType,
 	}).Trace("Compose file not found. Please make sure the file exists at the location")
 
 	if err != nil {
-		log.WithFields(log.Fields{
+		composeLogger().WithFields(log.Fields{
 			"composeFileName": composeFileName,
 			"error":           err,
 			"isProfile":       isProfile,
@@ -284,3 +353,28 @@ func getComposeFile(isProfile bool, composeName string) (string, error) {
 
 	return composeFilePath, nil
 }
+
+// getOverlayFile returns the path of the compose overlay of a profile, looking up the overlays subdir of the
+// profile in the tool's workdir. Absolute paths are used as they are. The relative paths of the overlay are
+// resolved from the directory of the profile, as Docker Compose uses the first file as the project directory
+func getOverlayFile(profileName string, overlay string) (string, error) {
+	overlayFilePath := overlay
+	if !filepath.IsAbs(overlay) {
+		overlayFilePath = path.Join(config.OpDir(), "compose", "profiles", profileName, "overlays", overlay+".yml")
+	}
+
+	found, err := io.Exists(overlayFilePath)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("the overlay does not exist: %s", overlayFilePath)
+	}
+
+	composeLogger().WithFields(log.Fields{
+		"overlayFilePath": overlayFilePath,
+		"profile":         profileName,
+	}).Trace("Compose overlay found at workdir")
+
+	return overlayFilePath, nil
+}
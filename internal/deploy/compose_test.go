@@ -0,0 +1,32 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package deploy
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOverlayFile(t *testing.T) {
+	t.Run("Absolute path of an existing overlay", func(t *testing.T) {
+		overlay := filepath.Join(t.TempDir(), "overlay.yml")
+		err := ioutil.WriteFile(overlay, []byte("version: '2.4'\n"), 0644)
+		assert.Nil(t, err)
+
+		overlayFilePath, err := getOverlayFile("fleet", overlay)
+		assert.Nil(t, err)
+		assert.Equal(t, overlay, overlayFilePath)
+	})
+
+	t.Run("Absolute path of a missing overlay", func(t *testing.T) {
+		overlay := filepath.Join(t.TempDir(), "missing.yml")
+
+		_, err := getOverlayFile("fleet", overlay)
+		assert.NotNil(t, err)
+	})
+}
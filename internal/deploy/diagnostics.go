@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package deploy
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// diagnosticsLogLines the number of lines of the logs of each service included in the diagnostics
+const diagnosticsLogLines = "500"
+
+// CollectDiagnostics writes into the directory the state of the Docker containers, and the processes and
+// latest logs of the services, so that a stuck execution can be investigated once it has been aborted.
+// Services without a container are skipped
+func CollectDiagnostics(ctx context.Context, dir string, services ...ServiceRequest) error {
+	err := io.MkdirAll(dir)
+	if err != nil {
+		return err
+	}
+
+	ps, err := shell.Execute(ctx, ".", "docker", "ps", "--all", "--no-trunc")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "docker-ps.txt"), []byte(ps), 0644)
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services {
+		inspect, err := InspectContainer(service)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"service": service.Name,
+			}).Debug("Could not find the container for the service. Skipping its diagnostics")
+			continue
+		}
+
+		// the processes are only available for running containers
+		top, err := shell.Execute(ctx, ".", "docker", "top", inspect.ID)
+		if err == nil {
+			_ = ioutil.WriteFile(filepath.Join(dir, service.Name+"-processes.txt"), []byte(top), 0644)
+		}
+
+		logs, err := shell.Execute(ctx, ".", "docker", "logs", "--timestamps", "--tail", diagnosticsLogLines, inspect.ID)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"service": service.Name,
+			}).Warn("Could not retrieve the logs of the service for the diagnostics")
+			continue
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, service.Name+".log"), []byte(logs), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"dir": dir,
+	}).Info("Diagnostics collected")
+
+	return nil
+}
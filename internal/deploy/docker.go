@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/elastic/e2e-testing/internal/metrics"
 	"github.com/elastic/e2e-testing/internal/shell"
 	"github.com/elastic/e2e-testing/pkg/downloads"
 	log "github.com/sirupsen/logrus"
@@ -124,10 +126,12 @@ func (c *dockerDeploymentManifest) ExecIn(ctx context.Context, profile ServiceRe
 	defer span.End()
 
 	manifest, _ := c.GetServiceManifest(ctx, service)
-	args := []string{"exec", "-u", "root", "-i", manifest.Name}
+	args := []string{"exec", "-u", service.execUser(), "-i", manifest.Name}
 	args = append(args, cmd...)
 
+	started := time.Now()
 	output, err := shell.Execute(ctx, ".", "docker", args...)
+	metrics.ObserveDockerOperation("exec", started, err)
 	if err != nil {
 		return "", err
 	}
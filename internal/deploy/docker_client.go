@@ -293,6 +293,34 @@ func GetContainerHostname(containerName string) (string, error) {
 	return hostname, nil
 }
 
+// GetImageDigests returns the digest of each image present in the Docker host, by its reference. The images
+// which are not present, or do not come from a registry, such as the ones loaded from a file, have no digest
+func GetImageDigests(ctx context.Context, images []string) map[string]string {
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	digests := map[string]string{}
+	for _, image := range images {
+		inspect, _, err := dockerClient.ImageInspectWithRaw(ctx, image)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"image": image,
+			}).Debug("Could not inspect the image to get its digest")
+			digests[image] = ""
+			continue
+		}
+
+		digest := ""
+		if len(inspect.RepoDigests) > 0 {
+			digest = inspect.RepoDigests[0]
+		}
+		digests[image] = digest
+	}
+
+	return digests
+}
+
 // InspectContainer returns the JSON representation of the inspection of a
 // Docker container, identified by its name
 func InspectContainer(service ServiceRequest) (*types.ContainerJSON, error) {
@@ -325,6 +353,41 @@ func InspectContainer(service ServiceRequest) (*types.ContainerJSON, error) {
 	return &inspect, nil
 }
 
+// GetContainerLogs returns the logs written by the container of a service since the given time,
+// combining its standard output and error
+func GetContainerLogs(ctx context.Context, service ServiceRequest, since time.Time) (string, error) {
+	inspect, err := InspectContainer(service)
+	if err != nil {
+		return "", err
+	}
+
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	reader, err := dockerClient.ContainerLogs(ctx, inspect.ID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      since.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"service": service.Name,
+		}).Error("Could not retrieve the logs of the container")
+		return "", err
+	}
+	defer reader.Close()
+
+	var outBuf bytes.Buffer
+	// containers without a TTY multiplex both streams, so they are written into the same buffer
+	_, err = stdcopy.StdCopy(&outBuf, &outBuf, reader)
+	if err != nil {
+		return "", err
+	}
+
+	return outBuf.String(), nil
+}
+
 // ListContainers returns a list of running containers
 func ListContainers() ([]types.Container, error) {
 	dockerClient := getDockerClient()
@@ -338,6 +401,48 @@ func ListContainers() ([]types.Container, error) {
 	return containers, nil
 }
 
+// ListContainersByRunID returns the containers created by a run, including the stopped ones
+func ListContainersByRunID(runID string) ([]types.Container, error) {
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	containers, err := dockerClient.ContainerList(context.Background(), types.ContainerListOptions{All: true, Filters: runIDFilters(runID)})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"runID": runID,
+		}).Error("Cannot list containers")
+		return []types.Container{}, err
+	}
+
+	return containers, nil
+}
+
+// ListNetworksByRunID returns the networks created by a run
+func ListNetworksByRunID(runID string) ([]types.NetworkResource, error) {
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	networks, err := dockerClient.NetworkList(context.Background(), types.NetworkListOptions{Filters: runIDFilters(runID)})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"runID": runID,
+		}).Error("Cannot list networks")
+		return []types.NetworkResource{}, err
+	}
+
+	return networks, nil
+}
+
+// runIDFilters returns the filters for the Docker resources labelled with the run ID
+func runIDFilters(runID string) filters.Args {
+	labelFilters := filters.NewArgs()
+	labelFilters.Add("label", RunIDLabel+"="+runID)
+
+	return labelFilters
+}
+
 // RemoveContainer removes a container identified by its container name
 func RemoveContainer(containerName string) error {
 	dockerClient := getDockerClient()
@@ -475,6 +580,27 @@ func RemoveDevNetwork() error {
 	return nil
 }
 
+// RemoveNetwork removes a network identified by its ID or name
+func RemoveNetwork(networkID string) error {
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	if err := dockerClient.NetworkRemove(context.Background(), networkID); err != nil {
+		log.WithFields(log.Fields{
+			"error":   err,
+			"network": networkID,
+		}).Warn("Network could not be removed")
+
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"network": networkID,
+	}).Debug("Network has been removed")
+
+	return nil
+}
+
 func getDockerClient() *client.Client {
 	if instance != nil {
 		return instance
@@ -223,7 +223,7 @@ func (ep *EPServiceManager) ExecIn(ctx context.Context, profile ServiceRequest,
 
 	manifest, _ := ep.GetServiceManifest(ctx, service)
 
-	args := []string{"exec", "-u", "root", "-i", manifest.Name}
+	args := []string{"exec", "-u", service.execUser(), "-i", manifest.Name}
 	args = append(args, cmd...)
 
 	output, err := shell.Execute(ctx, ".", "docker", args...)
@@ -0,0 +1,140 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	log "github.com/sirupsen/logrus"
+)
+
+// composeProjectLabel the label set by Docker Compose with the name of the project, which is the profile
+const composeProjectLabel = "com.docker.compose.project"
+
+// composeServiceLabel the label set by Docker Compose with the name of the service
+const composeServiceLabel = "com.docker.compose.service"
+
+// composeContainerNumberLabel the label set by Docker Compose with the index of the container of a scaled service
+const composeContainerNumberLabel = "com.docker.compose.container-number"
+
+// Environment represents the containers created by a test run
+type Environment struct {
+	RunID      string
+	Profile    string
+	Created    time.Time
+	Containers []EnvironmentContainer
+}
+
+// EnvironmentContainer represents a container of an environment
+type EnvironmentContainer struct {
+	Name    string
+	Service string
+	State   string
+	Status  string
+}
+
+// ListEnvironments returns the environments created by the test runs in the Docker host, including the
+// stopped containers, sorted from the oldest to the newest
+func ListEnvironments() ([]Environment, error) {
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	labelFilters := filters.NewArgs()
+	labelFilters.Add("label", RunIDLabel)
+
+	containers, err := dockerClient.ContainerList(context.Background(), types.ContainerListOptions{All: true, Filters: labelFilters})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":  err,
+			"labels": labelFilters,
+		}).Error("Cannot list containers")
+		return []Environment{}, err
+	}
+
+	return groupEnvironments(containers), nil
+}
+
+// groupEnvironments groups the containers by the run that created them, which is created when its
+// first container is
+func groupEnvironments(containers []types.Container) []Environment {
+	environments := map[string]*Environment{}
+	for _, container := range containers {
+		runID := container.Labels[RunIDLabel]
+
+		env, exists := environments[runID]
+		if !exists {
+			env = &Environment{
+				RunID:   runID,
+				Profile: container.Labels[composeProjectLabel],
+			}
+			environments[runID] = env
+		}
+
+		created := time.Unix(container.Created, 0)
+		if env.Created.IsZero() || created.Before(env.Created) {
+			env.Created = created
+		}
+
+		name := ""
+		if len(container.Names) > 0 {
+			// Docker prefixes the names with a slash
+			name = container.Names[0][1:]
+		}
+
+		env.Containers = append(env.Containers, EnvironmentContainer{
+			Name:    name,
+			Service: container.Labels[composeServiceLabel],
+			State:   container.State,
+			Status:  container.Status,
+		})
+	}
+
+	result := []Environment{}
+	for _, env := range environments {
+		sort.Slice(env.Containers, func(i, j int) bool {
+			return env.Containers[i].Name < env.Containers[j].Name
+		})
+		result = append(result, *env)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Before(result[j].Created)
+	})
+
+	return result
+}
+
+// GetServiceContainerName returns the name of the running container of a service in a profile, identified by
+// its index when the service is scaled, starting at 1
+func GetServiceContainerName(profile string, service string, index int) (string, error) {
+	dockerClient := getDockerClient()
+	defer dockerClient.Close()
+
+	labelFilters := filters.NewArgs()
+	labelFilters.Add("label", composeProjectLabel+"="+profile)
+	labelFilters.Add("label", composeServiceLabel+"="+service)
+	labelFilters.Add("label", composeContainerNumberLabel+"="+strconv.Itoa(index))
+
+	containers, err := dockerClient.ContainerList(context.Background(), types.ContainerListOptions{Filters: labelFilters})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":  err,
+			"labels": labelFilters,
+		}).Error("Cannot list containers")
+		return "", err
+	}
+
+	if len(containers) == 0 || len(containers[0].Names) == 0 {
+		return "", fmt.Errorf("there is no running container for the %s service in the %s profile (index %d)", service, profile, index)
+	}
+
+	return containers[0].Names[0][1:], nil
+}
@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLabelledContainer(name string, runID string, service string, created int64, state string) types.Container {
+	return types.Container{
+		Names:   []string{"/" + name},
+		Created: created,
+		State:   state,
+		Labels: map[string]string{
+			RunIDLabel:          runID,
+			composeProjectLabel: "fleet",
+			composeServiceLabel: service,
+		},
+	}
+}
+
+func TestGroupEnvironments(t *testing.T) {
+	t.Run("Containers are grouped by run ID", func(t *testing.T) {
+		containers := []types.Container{
+			newLabelledContainer("fleet_kibana_1", "1a2b3c", "kibana", 200, "running"),
+			newLabelledContainer("fleet_elasticsearch_1", "1a2b3c", "elasticsearch", 100, "running"),
+			newLabelledContainer("fleet_elastic-agent_1", "4d5e6f", "elastic-agent", 50, "exited"),
+		}
+
+		environments := groupEnvironments(containers)
+		assert.Equal(t, 2, len(environments))
+
+		assert.Equal(t, "4d5e6f", environments[0].RunID)
+		assert.Equal(t, 1, len(environments[0].Containers))
+		assert.Equal(t, "exited", environments[0].Containers[0].State)
+
+		assert.Equal(t, "1a2b3c", environments[1].RunID)
+		assert.Equal(t, "fleet", environments[1].Profile)
+		assert.Equal(t, int64(100), environments[1].Created.Unix())
+		assert.Equal(t, 2, len(environments[1].Containers))
+		assert.Equal(t, "fleet_elasticsearch_1", environments[1].Containers[0].Name)
+		assert.Equal(t, "elasticsearch", environments[1].Containers[0].Service)
+	})
+
+	t.Run("No containers return no environments", func(t *testing.T) {
+		environments := groupEnvironments([]types.Container{})
+		assert.Equal(t, 0, len(environments))
+	})
+}
@@ -117,6 +117,10 @@ func (c *kubernetesDeploymentManifest) ExecIn(ctx context.Context, profile Servi
 	span.Context.SetLabel("arguments", cmd)
 	defer span.End()
 
+	if service.User != "" {
+		return "", fmt.Errorf("running commands as the '%s' user is not supported in kubernetes deployments", service.User)
+	}
+
 	kubectl = cluster.Kubectl().WithNamespace(ctx, getNamespaceFromProfile(profile))
 	args := []string{"exec", "deployment/" + service.Name, "--"}
 	for _, arg := range cmd {
@@ -0,0 +1,115 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/io"
+	log "github.com/sirupsen/logrus"
+)
+
+// LogStreamer streams the logs of Docker services into rotating files, following them until it is stopped,
+// so that the context before a failure is always captured
+type LogStreamer struct {
+	dir        string
+	maxSize    int64
+	maxBackups int
+
+	cancel context.CancelFunc
+	files  []*io.RotatingFile
+	wg     sync.WaitGroup
+}
+
+// NewLogStreamer returns a log streamer writing into the directory, rotating the files when they reach
+// the max size in bytes
+func NewLogStreamer(dir string, maxSize int64, maxBackups int) *LogStreamer {
+	return &LogStreamer{
+		dir:        dir,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+}
+
+// Start follows the logs of the services, writing a header with the title in each file (i.e. the name of
+// a scenario). Services without a running container are skipped
+func (ls *LogStreamer) Start(ctx context.Context, title string, services ...ServiceRequest) error {
+	err := io.MkdirAll(ls.dir)
+	if err != nil {
+		return err
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	ls.cancel = cancel
+
+	for _, service := range services {
+		inspect, err := InspectContainer(service)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"service": service.Name,
+			}).Warn("Could not find the container for the service. Its logs won't be streamed")
+			continue
+		}
+
+		file, err := io.NewRotatingFile(filepath.Join(ls.dir, service.Name+".log"), ls.maxSize, ls.maxBackups)
+		if err != nil {
+			ls.Stop()
+			return err
+		}
+		ls.files = append(ls.files, file)
+
+		since := time.Now().UTC().Format(time.RFC3339)
+		_, _ = fmt.Fprintf(file, "=== %s: %s ===\n", since, title)
+
+		// only the logs produced from now on, as the previous ones were streamed already
+		cmd := exec.CommandContext(streamCtx, "docker", "logs", "--follow", "--timestamps", "--since", since, inspect.ID)
+		cmd.Stdout = file
+		cmd.Stderr = file
+
+		err = cmd.Start()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"service": service.Name,
+			}).Warn("Could not stream the logs of the service")
+			continue
+		}
+
+		ls.wg.Add(1)
+		go func() {
+			defer ls.wg.Done()
+			// the command is killed when the streamer is stopped
+			_ = cmd.Wait()
+		}()
+
+		log.WithFields(log.Fields{
+			"dir":     ls.dir,
+			"service": service.Name,
+		}).Trace("Streaming the logs of the service")
+	}
+
+	return nil
+}
+
+// Stop stops following the logs, closing the files
+func (ls *LogStreamer) Stop() {
+	if ls.cancel != nil {
+		ls.cancel()
+	}
+	ls.wg.Wait()
+
+	for _, file := range ls.files {
+		_ = file.Close()
+	}
+
+	ls.cancel = nil
+	ls.files = nil
+}
@@ -6,6 +6,7 @@ package deploy
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"strings"
 
@@ -54,6 +55,10 @@ func (c *remoteDeploymentManifest) ExecIn(ctx context.Context, profile ServiceRe
 	span.Context.SetLabel("arguments", cmd)
 	defer span.End()
 
+	if service.User != "" {
+		return "", fmt.Errorf("running commands as the '%s' user is not supported in remote deployments", service.User)
+	}
+
 	output, err := shell.Execute(ctx, ".", cmd[0], cmd[1:]...)
 	if err != nil {
 		return "", err
@@ -0,0 +1,185 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// maxBuckets the maximum number of values of a field counted by the terms aggregations
+const maxBuckets = 1000
+
+// Bucket the number of documents with a value of a field
+type Bucket struct {
+	Key   string
+	Count int
+}
+
+// CountByField counts the documents matching the query per value of the field, with a terms aggregation
+func CountByField(ctx context.Context, indexName string, query map[string]interface{}, field string) ([]Bucket, error) {
+	return CountByFieldInCluster(ctx, GetElasticSearchEndpoint(), indexName, query, field)
+}
+
+// CountByFieldInCluster counts the documents matching the query per value of the field, with a terms aggregation,
+// in the ES cluster represented by the endpoint. The buckets are sorted by their number of documents
+func CountByFieldInCluster(ctx context.Context, esEndpoint *Endpoint, indexName string, query map[string]interface{}, field string) ([]Bucket, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Count by field", "elasticsearch.count-by-field", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("field", field)
+	span.Context.SetLabel("index", indexName)
+	defer span.End()
+
+	esClient, err := getElasticsearchClientFromHostPort(ctx, esEndpoint.Host, esEndpoint.Port, esEndpoint.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"aggs": map[string]interface{}{
+			"by_field": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": field,
+					"size":  maxBuckets,
+				},
+			},
+		},
+	}
+	if q, exists := query["query"]; exists {
+		body["query"] = q
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+
+	res, err := esClient.Search(
+		esClient.Search.WithIndex(indexName),
+		esClient.Search.WithBody(&buf),
+		esClient.Search.WithSize(0),
+		esClient.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"field": field,
+			"index": indexName,
+		}).Error("Error counting documents by field in Elasticsearch")
+
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error counting the documents in the %s index by %s. Status: %s", indexName, field, res.Status())
+	}
+
+	return parseBuckets(res.Body)
+}
+
+// parseBuckets reads the buckets of the terms aggregation from the body of a search response
+func parseBuckets(body io.Reader) ([]Bucket, error) {
+	var resp struct {
+		Aggregations struct {
+			ByField struct {
+				Buckets []struct {
+					Key         json.RawMessage `json:"key"`
+					KeyAsString string          `json:"key_as_string"`
+					DocCount    int             `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"by_field"`
+		} `json:"aggregations"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	buckets := []Bucket{}
+	for _, b := range resp.Aggregations.ByField.Buckets {
+		// numeric and boolean keys are not quoted, and their string representation is optional
+		key := b.KeyAsString
+		if key == "" {
+			if err := json.Unmarshal(b.Key, &key); err != nil {
+				key = string(b.Key)
+			}
+		}
+
+		buckets = append(buckets, Bucket{Key: key, Count: b.DocCount})
+	}
+
+	return buckets, nil
+}
+
+// AssertNumberOfBuckets returns an error if there are less than a number of different values
+func AssertNumberOfBuckets(buckets []Bucket, minBuckets int) error {
+	if len(buckets) < minBuckets {
+		return fmt.Errorf("there are documents for %d different values, expected at least %d: %v", len(buckets), minBuckets, buckets)
+	}
+
+	return nil
+}
+
+// AssertBucketCounts returns an error if any of the expected values has less documents than expected
+func AssertBucketCounts(buckets []Bucket, expected []Bucket) error {
+	counts := map[string]int{}
+	for _, b := range buckets {
+		counts[b.Key] = b.Count
+	}
+
+	for _, e := range expected {
+		if counts[e.Key] < e.Count {
+			return fmt.Errorf("there are %d documents for the %s value, expected at least %d", counts[e.Key], e.Key, e.Count)
+		}
+	}
+
+	return nil
+}
+
+// WaitForBuckets waits for the counts of the documents matching the query, per value of the field, to satisfy
+// the assertion, returning the last counts
+func WaitForBuckets(ctx context.Context, indexName string, query map[string]interface{}, field string, assertFn func([]Bucket) error, maxTimeout time.Duration) ([]Bucket, error) {
+	exp := utils.GetExponentialBackOffForCheck("document counts by "+field, maxTimeout)
+
+	retryCount := 1
+	buckets := []Bucket{}
+
+	countFn := func() error {
+		current, err := CountByField(ctx, indexName, query, field)
+		if err != nil {
+			retryCount++
+			return err
+		}
+		buckets = current
+
+		if err := assertFn(buckets); err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"field":       field,
+				"index":       indexName,
+				"retry":       retryCount,
+			}).Warn("Waiting for the document counts")
+
+			retryCount++
+			return err
+		}
+
+		return nil
+	}
+
+	err := utils.RetryWithHeartbeat("document counts by "+field+" in the "+indexName+" index", countFn, exp)
+	return buckets, err
+}
@@ -0,0 +1,61 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBuckets(t *testing.T) {
+	body := `{
+		"hits": {"total": {"value": 42}, "hits": []},
+		"aggregations": {
+			"by_field": {
+				"buckets": [
+					{"key": "agent-1", "doc_count": 30},
+					{"key": 1000, "doc_count": 10},
+					{"key": 1614592800000, "key_as_string": "2021-03-01T10:00:00.000Z", "doc_count": 2}
+				]
+			}
+		}
+	}`
+
+	buckets, err := parseBuckets(strings.NewReader(body))
+	assert.Nil(t, err)
+	assert.Equal(t, []Bucket{
+		{Key: "agent-1", Count: 30},
+		{Key: "1000", Count: 10},
+		{Key: "2021-03-01T10:00:00.000Z", Count: 2},
+	}, buckets)
+}
+
+func TestAssertNumberOfBuckets(t *testing.T) {
+	buckets := []Bucket{{Key: "agent-1", Count: 3}, {Key: "agent-2", Count: 1}}
+
+	assert.Nil(t, AssertNumberOfBuckets(buckets, 2))
+	assert.NotNil(t, AssertNumberOfBuckets(buckets, 3))
+}
+
+func TestAssertBucketCounts(t *testing.T) {
+	buckets := []Bucket{{Key: "system.cpu", Count: 3}, {Key: "system.memory", Count: 1}}
+
+	t.Run("Values with enough documents", func(t *testing.T) {
+		err := AssertBucketCounts(buckets, []Bucket{{Key: "system.cpu", Count: 2}, {Key: "system.memory", Count: 1}})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Values with less documents", func(t *testing.T) {
+		err := AssertBucketCounts(buckets, []Bucket{{Key: "system.memory", Count: 2}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing values", func(t *testing.T) {
+		err := AssertBucketCounts(buckets, []Bucket{{Key: "system.load", Count: 1}})
+		assert.NotNil(t, err)
+	})
+}
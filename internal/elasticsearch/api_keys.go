@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// APIKey represents an API key of Elasticsearch, i.e. the ones created by Fleet for the agents
+type APIKey struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Invalidated bool   `json:"invalidated"`
+}
+
+// GetAPIKey retrieves the API key by its ID, including whether it was invalidated
+func GetAPIKey(ctx context.Context, ID string) (APIKey, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Get API key", "elasticsearch.security.get-api-key", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("id", ID)
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	res, err := esClient.Security.GetAPIKey(
+		esClient.Security.GetAPIKey.WithContext(ctx),
+		esClient.Security.GetAPIKey.WithID(ID),
+	)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"id":    ID,
+		}).Error("Could not get the API key from Elasticsearch")
+
+		return APIKey{}, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return APIKey{}, fmt.Errorf("could not get the %s API key. Status: %s", ID, res.Status())
+	}
+
+	keys, err := parseAPIKeys(res.Body)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	for _, key := range keys {
+		if key.ID == ID {
+			return key, nil
+		}
+	}
+
+	return APIKey{}, fmt.Errorf("the %s API key does not exist", ID)
+}
+
+// parseAPIKeys parses the response of the get API key API
+func parseAPIKeys(body io.Reader) ([]APIKey, error) {
+	var resp struct {
+		APIKeys []APIKey `json:"api_keys"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	return resp.APIKeys, nil
+}
@@ -0,0 +1,33 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	t.Run("Invalidated API key", func(t *testing.T) {
+		body := `{"api_keys": [{"id": "VuaCfGcBCdbkQm-e5aOx", "name": "agent-1", "invalidated": true, "username": "fleet"}]}`
+
+		keys, err := parseAPIKeys(strings.NewReader(body))
+		assert.Nil(t, err)
+		assert.Equal(t, []APIKey{{ID: "VuaCfGcBCdbkQm-e5aOx", Name: "agent-1", Invalidated: true}}, keys)
+	})
+
+	t.Run("No API keys", func(t *testing.T) {
+		keys, err := parseAPIKeys(strings.NewReader(`{"api_keys": []}`))
+		assert.Nil(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Invalid response", func(t *testing.T) {
+		_, err := parseAPIKeys(strings.NewReader(`{"api_keys": `))
+		assert.NotNil(t, err)
+	})
+}
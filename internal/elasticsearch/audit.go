@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+)
+
+// AuditEvent represents an event of the audit log of Elasticsearch, which the Docker image
+// writes to the standard output as JSON lines, mixed with the server logs
+type AuditEvent struct {
+	Action      string // i.e. create_apikey, invalidate_apikeys, access_denied
+	APIKeyName  string // name of the API key, for API key creation events
+	Realm       string
+	RequestName string
+	Type        string // i.e. rest, transport, security_config_change
+	User        string
+}
+
+// ParseAuditEvents extracts the audit events from the logs of an Elasticsearch node, skipping any other line
+func ParseAuditEvents(logs string) []AuditEvent {
+	events := []AuditEvent{}
+
+	scanner := bufio.NewScanner(strings.NewReader(logs))
+	// events including the request body can exceed the default size of the buffer
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue
+		}
+
+		if entry["type"] != "audit" {
+			continue
+		}
+
+		events = append(events, AuditEvent{
+			Action:      stringField(entry, "event.action"),
+			APIKeyName:  stringField(entry, "create", "apikey", "name"),
+			Realm:       stringField(entry, "user.realm"),
+			RequestName: stringField(entry, "request.name"),
+			Type:        stringField(entry, "event.type"),
+			User:        stringField(entry, "user.name"),
+		})
+	}
+
+	return events
+}
+
+// FilterAuditEvents returns the events with the action, performed by the user. An empty user matches any user
+func FilterAuditEvents(events []AuditEvent, action string, user string) []AuditEvent {
+	filtered := []AuditEvent{}
+	for _, event := range events {
+		if event.Action != action {
+			continue
+		}
+
+		if user != "" && event.User != user {
+			continue
+		}
+
+		filtered = append(filtered, event)
+	}
+
+	return filtered
+}
+
+// stringField returns the value of a nested field of the event, or an empty string if it is not present.
+// The audit log uses dotted keys for the common fields, and nested objects for the changes in the configuration
+func stringField(entry map[string]interface{}, keys ...string) string {
+	var value interface{} = entry
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+
+		value = m[key]
+	}
+
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const auditLogs = `{"@timestamp":"2022-10-03T10:00:00.000Z", "log.level": "INFO", "message":"started", "ecs.version": "1.2.0","service.name":"ES_ECS"}
+{"type":"audit", "timestamp":"2022-10-03T10:00:01,000+0000", "node.id":"abc", "event.type":"security_config_change", "event.action":"create_apikey", "user.name":"elastic/fleet-server", "user.realm":"_service_account", "request.name":"CreateApiKeyRequest", "create":{"apikey":{"name":"agent-1","expiration":null}}}
+not a JSON line
+{"type":"audit", "timestamp":"2022-10-03T10:00:02,000+0000", "node.id":"abc", "event.type":"security_config_change", "event.action":"invalidate_apikeys", "user.name":"admin", "user.realm":"default_file", "request.name":"InvalidateApiKeyRequest", "invalidate":{"apikeys":{"ids":["key-1"]}}}
+{"type":"audit", "timestamp":"2022-10-03T10:00:03,000+0000", "node.id":"abc", "event.type":"security_config_change", "event.action":"create_apikey", "user.name":"admin", "user.realm":"default_file", "request.name":"CreateApiKeyRequest", "create":{"apikey":{"name":"enrollment-token","expiration":null}}}
+`
+
+func TestParseAuditEvents(t *testing.T) {
+	events := ParseAuditEvents(auditLogs)
+
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, AuditEvent{
+		Action:      "create_apikey",
+		APIKeyName:  "agent-1",
+		Realm:       "_service_account",
+		RequestName: "CreateApiKeyRequest",
+		Type:        "security_config_change",
+		User:        "elastic/fleet-server",
+	}, events[0])
+	assert.Equal(t, "invalidate_apikeys", events[1].Action)
+	assert.Equal(t, "", events[1].APIKeyName)
+}
+
+func TestFilterAuditEvents(t *testing.T) {
+	events := ParseAuditEvents(auditLogs)
+
+	t.Run("Events by any user", func(t *testing.T) {
+		assert.Equal(t, 2, len(FilterAuditEvents(events, "create_apikey", "")))
+	})
+
+	t.Run("Events by the user", func(t *testing.T) {
+		filtered := FilterAuditEvents(events, "create_apikey", "admin")
+
+		assert.Equal(t, 1, len(filtered))
+		assert.Equal(t, "enrollment-token", filtered[0].APIKeyName)
+	})
+
+	t.Run("No events with the action", func(t *testing.T) {
+		assert.Equal(t, 0, len(FilterAuditEvents(events, "change_password", "")))
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"time"
+)
+
+// timestampField the field with the time of the documents in the data streams
+const timestampField = "@timestamp"
+
+// BoolQuery builds a boolean query which documents must match all of its filters, so that the assertions
+// do not have to write the nested maps of the query DSL by hand:
+//
+//	query := elasticsearch.NewBoolQuery().Dataset("linux.memory").Since(startDate).Exists("elastic_agent").Map()
+type BoolQuery struct {
+	filters []interface{}
+}
+
+// NewBoolQuery creates a query without filters, matching all the documents
+func NewBoolQuery() *BoolQuery {
+	return &BoolQuery{
+		filters: []interface{}{},
+	}
+}
+
+// Filter adds a raw clause of the query DSL to the filters of the query
+func (q *BoolQuery) Filter(clause map[string]interface{}) *BoolQuery {
+	q.filters = append(q.filters, clause)
+	return q
+}
+
+// Term filters the documents with the exact value in the field
+func (q *BoolQuery) Term(field string, value interface{}) *BoolQuery {
+	return q.Filter(map[string]interface{}{
+		"term": map[string]interface{}{
+			field: value,
+		},
+	})
+}
+
+// MatchPhrase filters the documents with the phrase in the field
+func (q *BoolQuery) MatchPhrase(field string, phrase string) *BoolQuery {
+	return q.Filter(map[string]interface{}{
+		"match_phrase": map[string]interface{}{
+			field: phrase,
+		},
+	})
+}
+
+// Dataset filters the documents of the dataset of a data stream, i.e. linux.memory
+func (q *BoolQuery) Dataset(dataset string) *BoolQuery {
+	return q.Term("data_stream.dataset", dataset)
+}
+
+// Exists filters the documents with a value in the field
+func (q *BoolQuery) Exists(field string) *BoolQuery {
+	return q.Filter(map[string]interface{}{
+		"exists": map[string]interface{}{
+			"field": field,
+		},
+	})
+}
+
+// Since filters the documents with a timestamp equal or after the date
+func (q *BoolQuery) Since(from time.Time) *BoolQuery {
+	return q.timeRange(map[string]interface{}{
+		"gte": from,
+	})
+}
+
+// Between filters the documents with a timestamp equal or after the first date, and before the second one
+func (q *BoolQuery) Between(from time.Time, to time.Time) *BoolQuery {
+	return q.timeRange(map[string]interface{}{
+		"gte": from,
+		"lt":  to,
+	})
+}
+
+// timeRange filters the timestamp of the documents with the bounds, in the format the dates are serialised to
+func (q *BoolQuery) timeRange(bounds map[string]interface{}) *BoolQuery {
+	bounds["format"] = "strict_date_optional_time"
+
+	return q.Filter(map[string]interface{}{
+		"range": map[string]interface{}{
+			timestampField: bounds,
+		},
+	})
+}
+
+// Map returns the body of a search or count request with the query
+func (q *BoolQuery) Map() map[string]interface{} {
+	if len(q.filters) == 0 {
+		return map[string]interface{}{
+			"query": map[string]interface{}{
+				"match_all": map[string]interface{}{},
+			},
+		}
+	}
+
+	filters := make([]interface{}, len(q.filters))
+	copy(filters, q.filters)
+
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": filters,
+			},
+		},
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoolQuery(t *testing.T) {
+	t.Run("A query without filters matches all the documents", func(t *testing.T) {
+		query := NewBoolQuery().Map()
+
+		assert.Equal(t, map[string]interface{}{
+			"query": map[string]interface{}{
+				"match_all": map[string]interface{}{},
+			},
+		}, query)
+	})
+
+	t.Run("Filters are combined in order", func(t *testing.T) {
+		from := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+		query := NewBoolQuery().Dataset("linux.memory").Since(from).Exists("elastic_agent").MatchPhrase("host.name", "centos").Map()
+
+		assert.Equal(t, map[string]interface{}{
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{
+					"filter": []interface{}{
+						map[string]interface{}{
+							"term": map[string]interface{}{"data_stream.dataset": "linux.memory"},
+						},
+						map[string]interface{}{
+							"range": map[string]interface{}{
+								"@timestamp": map[string]interface{}{
+									"gte":    from,
+									"format": "strict_date_optional_time",
+								},
+							},
+						},
+						map[string]interface{}{
+							"exists": map[string]interface{}{"field": "elastic_agent"},
+						},
+						map[string]interface{}{
+							"match_phrase": map[string]interface{}{"host.name": "centos"},
+						},
+					},
+				},
+			},
+		}, query)
+	})
+
+	t.Run("Time ranges are bounded on both ends", func(t *testing.T) {
+		from := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+		to := from.Add(time.Hour)
+
+		query := NewBoolQuery().Between(from, to).Map()
+
+		filters := query["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"range": map[string]interface{}{
+				"@timestamp": map[string]interface{}{
+					"gte":    from,
+					"lt":     to,
+					"format": "strict_date_optional_time",
+				},
+			},
+		}, filters[0])
+	})
+
+	t.Run("Maps are not modified by later filters", func(t *testing.T) {
+		builder := NewBoolQuery().Term("agent.type", "filebeat")
+		query := builder.Map()
+		builder.Exists("message")
+
+		filters := query["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]interface{})
+		assert.Equal(t, 1, len(filters))
+	})
+}
@@ -16,10 +16,14 @@ import (
 	"time"
 
 	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/auth"
+	"github.com/elastic/e2e-testing/internal/config"
 	curl "github.com/elastic/e2e-testing/internal/curl"
+	"github.com/elastic/e2e-testing/internal/metrics"
 	"github.com/elastic/e2e-testing/internal/shell"
 	"github.com/elastic/e2e-testing/internal/utils"
 	es "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/estransport"
 	log "github.com/sirupsen/logrus"
 	"go.elastic.co/apm"
 	"go.elastic.co/apm/module/apmelasticsearch"
@@ -86,12 +90,12 @@ type Endpoint struct {
 	Scheme      string
 	Host        string
 	Port        int
-	Credentials string
+	Credentials string // used by Fleet Server to connect to Elasticsearch, in the user:password format
 }
 
 // GetElasticSearchEndpoint - Query environment for correct endpoint information
 func GetElasticSearchEndpoint() *Endpoint {
-	creds := fmt.Sprintf("%s:%s", shell.GetEnv("ELASTICSEARCH_USERNAME", "elastic"), shell.GetEnv("ELASTICSEARCH_PASSWORD", "changeme"))
+	creds := auth.ForService(auth.FleetServerService).String()
 	remoteESHost := shell.GetEnv("ELASTICSEARCH_URL", "")
 	if remoteESHost != "" {
 		remoteESHost = utils.RemoveQuotes(remoteESHost)
@@ -119,6 +123,43 @@ func GetElasticSearchEndpoint() *Endpoint {
 	}
 }
 
+// GetSecondaryElasticSearchEndpoint - Query environment for the endpoint of the secondary Elasticsearch cluster,
+// used as an additional output for the agents. It runs with security disabled, so no credentials are needed
+func GetSecondaryElasticSearchEndpoint() *Endpoint {
+	return getEndpointFromEnv("ELASTICSEARCH_SECONDARY_URL", "http://localhost:9201")
+}
+
+// GetMonitoringElasticSearchEndpoint - Query environment for the endpoint of the Elasticsearch cluster dedicated to
+// the monitoring data of the agents. It runs with security disabled, so no credentials are needed
+func GetMonitoringElasticSearchEndpoint() *Endpoint {
+	return getEndpointFromEnv("ELASTICSEARCH_MONITORING_URL", "http://localhost:9202")
+}
+
+// GetRemoteElasticSearchEndpoint - Query environment for the endpoint of the Elasticsearch cluster connected to
+// the main one for cross-cluster search and replication. It shares the users of the main cluster
+func GetRemoteElasticSearchEndpoint() *Endpoint {
+	return getEndpointFromEnv("ELASTICSEARCH_REMOTE_URL", "http://localhost:9203")
+}
+
+// getEndpointFromEnv returns the endpoint, without credentials, for the URL in the environment variable
+func getEndpointFromEnv(envVar string, defaultURL string) *Endpoint {
+	remoteESHost := utils.RemoveQuotes(shell.GetEnv(envVar, defaultURL))
+	u, err := url.Parse(remoteESHost)
+	if err != nil {
+		log.WithField("error", err).Fatal("Could not parse " + envVar)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		log.Fatal("Could not determine host/port from " + envVar)
+	}
+	remoteESHostPort, _ := strconv.Atoi(port)
+	return &Endpoint{
+		Scheme: u.Scheme,
+		Host:   host,
+		Port:   remoteESHostPort,
+	}
+}
+
 // getElasticsearchClient returns a client connected to the running elasticseach, defined
 // at configuration level. Then we will inspect the running container to get its port bindings
 // and from them, get the one related to the Elasticsearch port (9200). As it is bound to a
@@ -135,16 +176,37 @@ func getElasticsearchClient(ctx context.Context) (*es.Client, error) {
 // random port at localhost, we will build the URL with the bound port at localhost.
 //nolint:unused
 func getElasticsearchClientFromHostPort(ctx context.Context, host string, port int, scheme string) (*es.Client, error) {
+	credentials := auth.ForService(auth.ElasticsearchService)
+
 	cfg := es.Config{
 		Addresses: []string{fmt.Sprintf("%s://%s:%d", scheme, host, port)},
-		Username:  shell.GetEnv("ELASTICSEARCH_USERNAME", "admin"),
-		Password:  shell.GetEnv("ELASTICSEARCH_PASSWORD", "changeme"),
+		Username:  credentials.Username,
+		Password:  credentials.Password,
 	}
 
+	transport := http.DefaultTransport
+
 	// avoid using common properties to avoid cyclical references
 	elasticAPMActive := shell.GetEnvBool("ELASTIC_APM_ACTIVE")
 	if elasticAPMActive {
-		cfg.Transport = apmelasticsearch.WrapRoundTripper(http.DefaultTransport)
+		transport = apmelasticsearch.WrapRoundTripper(transport)
+	}
+
+	cfg.Transport = metrics.WrapRoundTripper("elasticsearch", utils.WrapRoundTripperWithRateLimiter(transport))
+	if credentials.ServiceToken != "" {
+		// the client sets basic auth only with a user, so the token is the only authorization
+		cfg.Username = ""
+		cfg.Password = ""
+		cfg.Transport = &serviceTokenRoundTripper{credentials: credentials, next: cfg.Transport}
+	}
+
+	httpLogger := config.Logger(config.HTTPLogger)
+	if httpLogger.IsLevelEnabled(log.DebugLevel) {
+		cfg.Logger = &estransport.TextLogger{
+			Output:             httpLogger.Out,
+			EnableRequestBody:  httpLogger.IsLevelEnabled(log.TraceLevel),
+			EnableResponseBody: httpLogger.IsLevelEnabled(log.TraceLevel),
+		}
 	}
 
 	esClient, err := es.NewClient(cfg)
@@ -160,124 +222,24 @@ func getElasticsearchClientFromHostPort(ctx context.Context, host string, port i
 	return esClient, nil
 }
 
-// SecurityTokenResponse wraps a security token result
-type SecurityTokenResponse struct {
-	AccessToken    string `json:"access_token"`
-	Authentication struct {
-		Realm struct {
-			Name string `json:"name"`
-			Type string `json:"type"`
-		} `json:"authentication_realm"`
-		Type        string `json:"authentication_type"`
-		Email       string `json:"email"`
-		Enabled     bool   `json:"enabled"`
-		FullName    bool   `json:"full_name"`
-		LookupRealm struct {
-			Name string `json:"name"`
-			Type string `json:"type"`
-		} `json:"lookup_realm"`
-		Metadata struct {
-			Reserved string `json:"_reserved"`
-		} `json:"metadata"`
-		Roles    []string `json:"roles"`
-		Username string   `json:"username"`
-	} `json:"authentication"`
-	ExpiresIn int    `json:"expires_in"`
-	Type      string `json:"type"`
-}
-
-// SecurityTokenRequest payload for requesting a security token
-type SecurityTokenRequest struct {
-	GrantType string `json:"grant_type"`
-}
-
-// GetAPIToken retrieves an OAuth API token
-func GetAPIToken(ctx context.Context) (SecurityTokenResponse, error) {
-	span, _ := apm.StartSpanOptions(ctx, "Get API Token", "elasticsearch.security.get-token", apm.SpanOptions{
-		Parent: apm.SpanFromContext(ctx).TraceContext(),
-	})
-	defer span.End()
-
-	result := SecurityTokenResponse{}
-
-	esClient, err := getElasticsearchClient(ctx)
-	if err != nil {
-		return result, err
-	}
-
-	securityToken := SecurityTokenRequest{
-		GrantType: "client_credentials",
-	}
-
-	log.WithFields(log.Fields{
-		"tokenRequest": securityToken,
-	}).Debug("Retrieving Elasticsearch's API token")
-
-	var buf bytes.Buffer
-	err = json.NewEncoder(&buf).Encode(securityToken)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"error": err,
-		}).Error("Error decoding Security Token struct")
-
-		return result, err
-	}
-
-	res, err := esClient.Security.GetToken(&buf)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"error": err,
-		}).Error("Error retrieving security token from Elasticsearch")
-
-		return result, err
-	}
-	defer res.Body.Close()
-
-	if res.IsError() {
-		var e map[string]interface{}
-		if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
-			log.WithFields(log.Fields{
-				"error": err,
-			}).Error("Error parsing error response body from Elasticsearch")
-
-			return result, err
-		}
-
-		err := fmt.Errorf(
-			"error getting response from Elasticsearch. Status: %s, ResponseError: %v",
-			res.Status(), e)
-
-		return result, err
-	}
-
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		log.WithFields(log.Fields{
-			"error": err,
-		}).Error("Error parsing response body from Elasticsearch")
-
-		return result, err
-	}
-
-	log.WithFields(log.Fields{
-		"status": res.Status(),
-		"result": result,
-	}).Debug("API Token retrieved from Elasticsearch")
-
-	return result, nil
-}
-
 // Search provide search interface to ES
 func Search(ctx context.Context, indexName string, query map[string]interface{}) (SearchResult, error) {
+	return SearchInCluster(ctx, GetElasticSearchEndpoint(), indexName, query)
+}
+
+// SearchInCluster provide search interface to the ES cluster represented by the endpoint
+func SearchInCluster(ctx context.Context, esEndpoint *Endpoint, indexName string, query map[string]interface{}) (SearchResult, error) {
 	span, _ := apm.StartSpanOptions(ctx, "Search", "elasticsearch.search", apm.SpanOptions{
 		Parent: apm.SpanFromContext(ctx).TraceContext(),
 	})
+	span.Context.SetLabel("host", esEndpoint.Host)
 	span.Context.SetLabel("index", indexName)
 	span.Context.SetLabel("query", query)
 	defer span.End()
 
 	result := SearchResult{}
 
-	esClient, err := getElasticsearchClient(ctx)
+	esClient, err := getElasticsearchClientFromHostPort(ctx, esEndpoint.Host, esEndpoint.Port, esEndpoint.Scheme)
 	if err != nil {
 		return result, err
 	}
@@ -388,7 +350,7 @@ func WaitForElasticsearch(ctx context.Context, maxTimeoutMinutes time.Duration)
 		return nil
 	}
 
-	err := backoff.Retry(clusterStatus, exp)
+	err := utils.RetryWithHeartbeat("Elasticsearch to be ready", clusterStatus, exp)
 	if err != nil {
 		return false, err
 	}
@@ -455,14 +417,19 @@ func WaitForIndices() (string, error) {
 	exp := utils.GetExponentialBackOff(60 * time.Second)
 
 	esEndpoint := GetElasticSearchEndpoint()
+	credentials := auth.ForService(auth.ElasticsearchService)
 	retryCount := 1
 	body := ""
 
 	catIndices := func() error {
 		r := curl.HTTPRequest{
 			URL:               fmt.Sprintf("%s://%s:%d/_cat/indices?v", esEndpoint.Scheme, esEndpoint.Host, esEndpoint.Port),
-			BasicAuthPassword: shell.GetEnv("ELASTICSEARCH_PASSWORD", "changeme"),
-			BasicAuthUser:     shell.GetEnv("ELASTICSEARCH_USERNAME", "admin"),
+			BasicAuthPassword: credentials.Password,
+			BasicAuthUser:     credentials.Username,
+		}
+		if credentials.ServiceToken != "" {
+			r.BasicAuthUser = ""
+			r.Headers = map[string]string{"Authorization": "Bearer " + credentials.ServiceToken}
 		}
 
 		response, err := curl.Get(r)
@@ -496,18 +463,26 @@ func WaitForIndices() (string, error) {
 // WaitForNumberOfHits waits for an elasticsearch query to return more than a number of hits,
 // returning false if the query does not reach that number in a defined number of time.
 func WaitForNumberOfHits(ctx context.Context, indexName string, query map[string]interface{}, desiredHits int, maxTimeout time.Duration) (SearchResult, error) {
-	exp := utils.GetExponentialBackOff(maxTimeout)
+	return WaitForNumberOfHitsInCluster(ctx, GetElasticSearchEndpoint(), indexName, query, desiredHits, maxTimeout)
+}
+
+// WaitForNumberOfHitsInCluster waits for an elasticsearch query to return more than a number of hits
+// in the ES cluster represented by the endpoint, returning false if the query does not reach that number
+// in a defined number of time.
+func WaitForNumberOfHitsInCluster(ctx context.Context, esEndpoint *Endpoint, indexName string, query map[string]interface{}, desiredHits int, maxTimeout time.Duration) (SearchResult, error) {
+	exp := utils.GetExponentialBackOffForCheck("search hits", maxTimeout)
 
 	retryCount := 1
 	result := SearchResult{}
 
 	numberOfHits := func() error {
-		hits, err := Search(ctx, indexName, query)
+		hits, err := SearchInCluster(ctx, esEndpoint, indexName, query)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"desiredHits": desiredHits,
 				"elapsedTime": exp.GetElapsedTime(),
 				"error":       err,
+				"host":        esEndpoint.Host,
 				"index":       indexName,
 				"retry":       retryCount,
 			}).Warn("There was an error executing the query")
@@ -543,6 +518,6 @@ func WaitForNumberOfHits(ctx context.Context, indexName string, query map[string
 		return nil
 	}
 
-	err := backoff.Retry(numberOfHits, exp)
+	err := utils.RetryWithHeartbeat("hits in the "+indexName+" index", numberOfHits, exp)
 	return result, err
 }
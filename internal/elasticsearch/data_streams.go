@@ -0,0 +1,244 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// DataStream represents a data stream in Elasticsearch, including its backing indices
+type DataStream struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Indices []struct {
+		IndexName string `json:"index_name"`
+		IndexUUID string `json:"index_uuid"`
+	} `json:"indices"`
+	Template string `json:"template"`
+}
+
+// IndexHealth represents the health of an index, as returned by the cluster health API
+type IndexHealth struct {
+	ActivePrimaryShards int    `json:"active_primary_shards"`
+	ActiveShards        int    `json:"active_shards"`
+	NumberOfNodes       int    `json:"number_of_nodes"`
+	Status              string `json:"status"`
+	UnassignedShards    int    `json:"unassigned_shards"`
+}
+
+// GetDataStream retrieves a data stream by its name
+func GetDataStream(ctx context.Context, name string) (DataStream, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Get data stream", "elasticsearch.data-stream.get", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("dataStream", name)
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return DataStream{}, err
+	}
+
+	res, err := esClient.Indices.GetDataStream(esClient.Indices.GetDataStream.WithName(name))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"dataStream": name,
+			"error":      err,
+		}).Error("Could not get data stream using Elasticsearch Go client")
+
+		return DataStream{}, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return DataStream{}, fmt.Errorf("error getting the %s data stream from Elasticsearch. Status: %s", name, res.Status())
+	}
+
+	var resp struct {
+		DataStreams []DataStream `json:"data_streams"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return DataStream{}, err
+	}
+
+	if len(resp.DataStreams) == 0 {
+		return DataStream{}, fmt.Errorf("the %s data stream does not exist", name)
+	}
+
+	return resp.DataStreams[0], nil
+}
+
+// GetIndexHealth retrieves the health of an index
+func GetIndexHealth(ctx context.Context, index string) (IndexHealth, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Get index health", "elasticsearch.index.health", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("index", index)
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return IndexHealth{}, err
+	}
+
+	res, err := esClient.Cluster.Health(esClient.Cluster.Health.WithIndex(index))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"index": index,
+		}).Error("Could not get index health using Elasticsearch Go client")
+
+		return IndexHealth{}, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return IndexHealth{}, fmt.Errorf("error getting the health of the %s index from Elasticsearch. Status: %s", index, res.Status())
+	}
+
+	health := IndexHealth{}
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return IndexHealth{}, err
+	}
+
+	return health, nil
+}
+
+// WaitForDataStreamHealth waits for all the backing indices of a data stream to reach the desired health status
+// (green, yellow or red), returning the health of each backing index. Yellow is enough for green in a single-node cluster
+func WaitForDataStreamHealth(ctx context.Context, name string, desiredStatus string, maxTimeout time.Duration) (map[string]IndexHealth, error) {
+	exp := utils.GetExponentialBackOff(maxTimeout)
+
+	retryCount := 1
+	result := map[string]IndexHealth{}
+
+	dataStreamHealthFn := func() error {
+		dataStream, err := GetDataStream(ctx, name)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"dataStream":  name,
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"retry":       retryCount,
+			}).Warn("The data stream is not available yet")
+
+			retryCount++
+			return err
+		}
+
+		healths := map[string]IndexHealth{}
+		for _, index := range dataStream.Indices {
+			health, err := GetIndexHealth(ctx, index.IndexName)
+			if err != nil {
+				retryCount++
+				return err
+			}
+
+			if !isHealthStatusReached(health, desiredStatus) {
+				err := fmt.Errorf("the %s backing index of the %s data stream is %s, expected %s", index.IndexName, name, health.Status, desiredStatus)
+
+				log.WithFields(log.Fields{
+					"dataStream":       name,
+					"elapsedTime":      exp.GetElapsedTime(),
+					"index":            index.IndexName,
+					"retry":            retryCount,
+					"status":           health.Status,
+					"unassignedShards": health.UnassignedShards,
+				}).Warn(err.Error())
+
+				retryCount++
+				return err
+			}
+
+			healths[index.IndexName] = health
+		}
+
+		result = healths
+
+		log.WithFields(log.Fields{
+			"dataStream":  name,
+			"elapsedTime": exp.GetElapsedTime(),
+			"indices":     len(healths),
+			"retries":     retryCount,
+			"status":      desiredStatus,
+		}).Info("The backing indices of the data stream are in the desired health status")
+
+		return nil
+	}
+
+	err := utils.RetryWithHeartbeat("the "+name+" data stream to be "+desiredStatus, dataStreamHealthFn, exp)
+	return result, err
+}
+
+// isHealthStatusReached checks if the index is in the desired health status. Yellow is accepted for green in
+// single-node clusters, such as the ones of the profiles, as the replicas cannot be allocated in them
+func isHealthStatusReached(health IndexHealth, desiredStatus string) bool {
+	if strings.EqualFold(health.Status, desiredStatus) {
+		return true
+	}
+
+	return strings.EqualFold(desiredStatus, "green") && strings.EqualFold(health.Status, "yellow") && health.NumberOfNodes == 1
+}
+
+// AssertIndicesHavePrimaryShards returns an error if any of the indices does not have the expected number of
+// active primary shards
+func AssertIndicesHavePrimaryShards(healths map[string]IndexHealth, primaryShards int) error {
+	if len(healths) == 0 {
+		return fmt.Errorf("there aren't indices to check")
+	}
+
+	for index, health := range healths {
+		if health.ActivePrimaryShards != primaryShards {
+			return fmt.Errorf("the %s index has %d active primary shards, expected %d", index, health.ActivePrimaryShards, primaryShards)
+		}
+	}
+
+	return nil
+}
+
+// DeleteDataStreams deletes the data streams matching the name, which supports wildcards, including their backing indices
+func DeleteDataStreams(ctx context.Context, name string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Delete data streams", "elasticsearch.data-stream.delete", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("dataStream", name)
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := esClient.Indices.DeleteDataStream([]string{name})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"dataStream": name,
+			"error":      err,
+		}).Error("Could not delete data streams using Elasticsearch Go client")
+
+		return err
+	}
+	defer res.Body.Close()
+
+	// a wildcard matching no data streams is not an error
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("error deleting the %s data streams from Elasticsearch. Status: %s", name, res.Status())
+	}
+
+	log.WithFields(log.Fields{
+		"dataStream": name,
+		"status":     res.Status(),
+	}).Debug("Data streams deleted using Elasticsearch Go client")
+
+	return nil
+}
@@ -0,0 +1,30 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsHealthStatusReached(t *testing.T) {
+	t.Run("Same status", func(t *testing.T) {
+		assert.True(t, isHealthStatusReached(IndexHealth{Status: "green", NumberOfNodes: 3}, "green"))
+		assert.True(t, isHealthStatusReached(IndexHealth{Status: "red", NumberOfNodes: 3}, "red"))
+	})
+
+	t.Run("Yellow is enough for green in a single-node cluster", func(t *testing.T) {
+		assert.True(t, isHealthStatusReached(IndexHealth{Status: "yellow", NumberOfNodes: 1}, "green"))
+	})
+
+	t.Run("Yellow is not enough for green in a multi-node cluster", func(t *testing.T) {
+		assert.False(t, isHealthStatusReached(IndexHealth{Status: "yellow", NumberOfNodes: 3}, "green"))
+	})
+
+	t.Run("Red is never enough for green", func(t *testing.T) {
+		assert.False(t, isHealthStatusReached(IndexHealth{Status: "red", NumberOfNodes: 1}, "green"))
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// ParseQuery converts a JSON query into the body of a search or count request. The JSON could be a whole body,
+// with the query under the "query" key, or just the query clause. An empty JSON matches all the documents
+func ParseQuery(queryJSON string) (map[string]interface{}, error) {
+	if strings.TrimSpace(queryJSON) == "" {
+		return map[string]interface{}{
+			"query": map[string]interface{}{
+				"match_all": map[string]interface{}{},
+			},
+		}, nil
+	}
+
+	parsed := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(queryJSON), &parsed); err != nil {
+		return nil, fmt.Errorf("the query is not a valid JSON object: %v", err)
+	}
+
+	if query, exists := parsed["query"]; exists {
+		return map[string]interface{}{"query": query}, nil
+	}
+
+	return map[string]interface{}{"query": parsed}, nil
+}
+
+// CountInCluster returns the number of documents matching the query in the ES cluster represented by the endpoint.
+// Unlike the hits of a search, the count is not limited by the size of the result. Only the query clause of
+// a search body is sent, as the count API rejects the rest of it, such as the size or the sort
+func CountInCluster(ctx context.Context, esEndpoint *Endpoint, indexName string, query map[string]interface{}) (int, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Count", "elasticsearch.count", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("host", esEndpoint.Host)
+	span.Context.SetLabel("index", indexName)
+	defer span.End()
+
+	esClient, err := getElasticsearchClientFromHostPort(ctx, esEndpoint.Host, esEndpoint.Port, esEndpoint.Scheme)
+	if err != nil {
+		return 0, err
+	}
+
+	body := query
+	if clause, exists := query["query"]; exists {
+		body = map[string]interface{}{"query": clause}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return 0, err
+	}
+
+	res, err := esClient.Count(
+		esClient.Count.WithIndex(indexName),
+		esClient.Count.WithBody(&buf),
+		esClient.Count.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"index": indexName,
+		}).Error("Error counting documents in Elasticsearch")
+
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("error counting the documents in the %s index. Status: %s", indexName, res.Status())
+	}
+
+	var resp struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return 0, err
+	}
+
+	return resp.Count, nil
+}
+
+// WaitForDocs waits for the index to have at least a number of documents matching the JSON query, returning
+// the last count. See ParseQuery for the supported formats of the query
+func WaitForDocs(ctx context.Context, indexName string, queryJSON string, minCount int, maxTimeout time.Duration) (int, error) {
+	query, err := ParseQuery(queryJSON)
+	if err != nil {
+		return 0, err
+	}
+
+	return WaitForDocsInCluster(ctx, GetElasticSearchEndpoint(), indexName, query, minCount, maxTimeout)
+}
+
+// WaitForDocsInCluster waits for the index in the ES cluster represented by the endpoint to have at least
+// a number of documents matching the query, returning the last count
+func WaitForDocsInCluster(ctx context.Context, esEndpoint *Endpoint, indexName string, query map[string]interface{}, minCount int, maxTimeout time.Duration) (int, error) {
+	exp := utils.GetExponentialBackOffForCheck("document count", maxTimeout)
+
+	retryCount := 1
+	count := 0
+
+	countDocsFn := func() error {
+		current, err := CountInCluster(ctx, esEndpoint, indexName, query)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"index":       indexName,
+				"retry":       retryCount,
+			}).Warn("There was an error counting the documents")
+
+			retryCount++
+			return err
+		}
+		count = current
+
+		if count < minCount {
+			log.WithFields(log.Fields{
+				"count":       count,
+				"elapsedTime": exp.GetElapsedTime(),
+				"index":       indexName,
+				"minCount":    minCount,
+				"retry":       retryCount,
+			}).Warn("Waiting for more documents in the index")
+
+			retryCount++
+			return fmt.Errorf("not enough documents in the %s index yet. Current: %d, Desired: %d", indexName, count, minCount)
+		}
+
+		log.WithFields(log.Fields{
+			"count":       count,
+			"elapsedTime": exp.GetElapsedTime(),
+			"index":       indexName,
+			"retries":     retryCount,
+		}).Info("Document count satisfied")
+
+		return nil
+	}
+
+	err := utils.RetryWithHeartbeat("documents in the "+indexName+" index", countDocsFn, exp)
+	return count, err
+}
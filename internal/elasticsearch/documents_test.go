@@ -0,0 +1,48 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Run("An empty query matches all the documents", func(t *testing.T) {
+		query, err := ParseQuery("  ")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"query": map[string]interface{}{
+				"match_all": map[string]interface{}{},
+			},
+		}, query)
+	})
+
+	t.Run("Query clauses are wrapped", func(t *testing.T) {
+		query, err := ParseQuery(`{"term": {"data_stream.dataset": "system.cpu"}}`)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"query": map[string]interface{}{
+				"term": map[string]interface{}{"data_stream.dataset": "system.cpu"},
+			},
+		}, query)
+	})
+
+	t.Run("Only the query of a whole body is kept", func(t *testing.T) {
+		query, err := ParseQuery(`{"size": 0, "query": {"exists": {"field": "agent.id"}}}`)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"query": map[string]interface{}{
+				"exists": map[string]interface{}{"field": "agent.id"},
+			},
+		}, query)
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		_, err := ParseQuery(`{"term": `)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,163 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxReportedDuplicates the maximum number of duplicated events included in the errors of the assertions
+const maxReportedDuplicates = 5
+
+// ingestFields the fields set by Elasticsearch when a document is indexed, which differ between an event
+// and its resent copies
+var ingestFields = []string{"event.ingested"}
+
+// Duplicate an event indexed more than once, with the IDs of its copies
+type Duplicate struct {
+	Fingerprint string
+	IDs         []string
+}
+
+// FindDuplicates returns the events of the search result with the same fingerprint. The fingerprint is computed
+// from the values of the fields, or from the whole document, except the fields set at ingest time, if there
+// are no fields. Events are only compared with the ones in the same search result
+func FindDuplicates(hits SearchResult, fields []string) ([]Duplicate, error) {
+	outerHits, ok := hits["hits"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the search result does not contain hits")
+	}
+	iterableHits, _ := outerHits["hits"].([]interface{})
+
+	ids := map[string][]string{}
+	fingerprints := []string{}
+	for _, hit := range iterableHits {
+		h := hit.(map[string]interface{})
+		source, _ := h["_source"].(map[string]interface{})
+
+		fingerprint, err := fingerprintEvent(source, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := ids[fingerprint]; !exists {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+		id, _ := h["_id"].(string)
+		ids[fingerprint] = append(ids[fingerprint], id)
+	}
+
+	duplicates := []Duplicate{}
+	for _, fingerprint := range fingerprints {
+		if len(ids[fingerprint]) > 1 {
+			duplicates = append(duplicates, Duplicate{Fingerprint: fingerprint, IDs: ids[fingerprint]})
+		}
+	}
+
+	return duplicates, nil
+}
+
+// AssertNoDuplicates returns an error if any of the events of the search result is duplicated,
+// by the fingerprint of the fields. See FindDuplicates
+func AssertNoDuplicates(hits SearchResult, fields []string) error {
+	duplicates, err := FindDuplicates(hits, fields)
+	if err != nil {
+		return err
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	reported := []string{}
+	for i, d := range duplicates {
+		if i == maxReportedDuplicates {
+			reported = append(reported, "...")
+			break
+		}
+		reported = append(reported, strings.Join(d.IDs, ","))
+	}
+
+	return fmt.Errorf("there are %d duplicated events out of %d documents. IDs of the copies: %s", len(duplicates), getHitsCount(hits), strings.Join(reported, " "))
+}
+
+// fingerprintEvent hashes the values of the fields of the event, or the whole event without the ingest fields
+func fingerprintEvent(source map[string]interface{}, fields []string) (string, error) {
+	var values interface{}
+	if len(fields) == 0 {
+		event := source
+		for _, field := range ingestFields {
+			event = withoutField(event, field)
+		}
+		values = event
+	} else {
+		fieldValues := map[string]interface{}{}
+		for _, field := range fields {
+			value, _ := fieldValue(source, field)
+			fieldValues[field] = value
+		}
+		values = fieldValues
+	}
+
+	// maps are serialised with their keys sorted, so equal events have the same representation
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fieldValue returns the value of a field of the document, which name is a path through the nested objects,
+// supporting keys with dots, i.e. both {"event": {"dataset": "x"}} and {"event.dataset": "x"}
+func fieldValue(source map[string]interface{}, field string) (interface{}, bool) {
+	if value, exists := source[field]; exists {
+		return value, true
+	}
+
+	for i := strings.Index(field, "."); i >= 0; i = nextDot(field, i) {
+		object, ok := source[field[:i]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if value, exists := fieldValue(object, field[i+1:]); exists {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// withoutField returns a copy of the document without the field, copying only the objects in its path
+func withoutField(source map[string]interface{}, field string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range source {
+		if key == field {
+			continue
+		}
+
+		if object, ok := value.(map[string]interface{}); ok && strings.HasPrefix(field, key+".") {
+			value = withoutField(object, strings.TrimPrefix(field, key+"."))
+		}
+		result[key] = value
+	}
+
+	return result
+}
+
+func nextDot(field string, i int) int {
+	next := strings.Index(field[i+1:], ".")
+	if next < 0 {
+		return -1
+	}
+
+	return i + 1 + next
+}
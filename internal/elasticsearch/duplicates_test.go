@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func searchResultWithSources(sources map[string]map[string]interface{}, order []string) SearchResult {
+	hits := []interface{}{}
+	for _, id := range order {
+		hits = append(hits, map[string]interface{}{
+			"_id":     id,
+			"_source": sources[id],
+		})
+	}
+
+	return SearchResult{
+		"hits": map[string]interface{}{
+			"hits": hits,
+		},
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	sources := map[string]map[string]interface{}{
+		"a": {
+			"@timestamp": "2021-03-01T10:00:00.000Z",
+			"event":      map[string]interface{}{"dataset": "system.cpu", "ingested": "2021-03-01T10:00:01.000Z"},
+			"message":    "first",
+		},
+		"b": {
+			"@timestamp": "2021-03-01T10:00:00.000Z",
+			"event":      map[string]interface{}{"dataset": "system.cpu", "ingested": "2021-03-01T10:02:00.000Z"},
+			"message":    "first",
+		},
+		"c": {
+			"@timestamp": "2021-03-01T10:00:10.000Z",
+			"event":      map[string]interface{}{"dataset": "system.cpu", "ingested": "2021-03-01T10:00:11.000Z"},
+			"message":    "second",
+		},
+		"d": {
+			"@timestamp":    "2021-03-01T10:00:10.000Z",
+			"event.dataset": "system.cpu",
+			"message":       "third",
+		},
+	}
+	hits := searchResultWithSources(sources, []string{"a", "b", "c", "d"})
+
+	t.Run("Resent events differ in the ingest fields only", func(t *testing.T) {
+		duplicates, err := FindDuplicates(hits, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(duplicates))
+		assert.Equal(t, []string{"a", "b"}, duplicates[0].IDs)
+	})
+
+	t.Run("Events are compared by the values of the fields", func(t *testing.T) {
+		duplicates, err := FindDuplicates(hits, []string{"@timestamp", "event.dataset"})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(duplicates))
+		assert.Equal(t, []string{"a", "b"}, duplicates[0].IDs)
+		assert.Equal(t, []string{"c", "d"}, duplicates[1].IDs)
+	})
+
+	t.Run("The ingest fields are kept in the documents", func(t *testing.T) {
+		_, exists := fieldValue(sources["a"], "event.ingested")
+		assert.True(t, exists)
+	})
+}
+
+func TestAssertNoDuplicates(t *testing.T) {
+	sources := map[string]map[string]interface{}{
+		"a": {"message": "first"},
+		"b": {"message": "second"},
+	}
+
+	assert.Nil(t, AssertNoDuplicates(searchResultWithSources(sources, []string{"a", "b"}), nil))
+	assert.NotNil(t, AssertNoDuplicates(searchResultWithSources(sources, []string{"a", "b", "a"}), nil))
+}
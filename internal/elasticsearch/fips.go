@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// fipsModeSetting the setting enabling the FIPS 140-2 mode of Elasticsearch
+const fipsModeSetting = "xpack.security.fips_mode.enabled"
+
+// IsFIPSModeEnabled returns if all the nodes of the cluster run in FIPS 140-2 mode
+func IsFIPSModeEnabled(ctx context.Context) (bool, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Get nodes settings", "elasticsearch.nodes.settings", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := esClient.Nodes.Info(
+		esClient.Nodes.Info.WithMetric("settings"),
+		esClient.Nodes.Info.WithFlatSettings(true),
+	)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not get the settings of the nodes using Elasticsearch Go client")
+
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return false, fmt.Errorf("error getting the settings of the nodes from Elasticsearch. Status: %s", res.Status())
+	}
+
+	var resp struct {
+		Nodes map[string]struct {
+			Name     string                 `json:"name"`
+			Settings map[string]interface{} `json:"settings"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return false, err
+	}
+
+	if len(resp.Nodes) == 0 {
+		return false, fmt.Errorf("there aren't nodes in the cluster")
+	}
+
+	for _, node := range resp.Nodes {
+		if fmt.Sprintf("%v", node.Settings[fipsModeSetting]) != "true" {
+			log.WithFields(log.Fields{
+				"node": node.Name,
+			}).Debug("The node does not run in FIPS mode")
+
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,284 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// RemoteClusterAlias the alias of the remote cluster in the profiles configured for cross-cluster search and replication
+const RemoteClusterAlias = "remote"
+
+// RemoteClusterInfo represents the connection information of a remote cluster
+type RemoteClusterInfo struct {
+	Connected                bool     `json:"connected"`
+	Mode                     string   `json:"mode"`
+	NumNodesConnected        int      `json:"num_nodes_connected"`
+	Seeds                    []string `json:"seeds"`
+	SkipUnavailable          bool     `json:"skip_unavailable"`
+	InitialConnectionTimeout string   `json:"initial_connect_timeout"`
+}
+
+// ConfigureRemoteCluster registers the remote cluster, reachable at the seeds, with the alias in the Elasticsearch
+// cluster represented by the endpoint, using the persistent settings of the cluster
+func ConfigureRemoteCluster(ctx context.Context, esEndpoint *Endpoint, alias string, seeds []string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Configure remote cluster", "elasticsearch.cluster.put-settings", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("alias", alias)
+	span.Context.SetLabel("host", esEndpoint.Host)
+	defer span.End()
+
+	esClient, err := getElasticsearchClientFromHostPort(ctx, esEndpoint.Host, esEndpoint.Port, esEndpoint.Scheme)
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]interface{}{
+		"persistent": map[string]interface{}{
+			"cluster.remote." + alias + ".seeds":            seeds,
+			"cluster.remote." + alias + ".skip_unavailable": false,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(settings); err != nil {
+		return err
+	}
+
+	res, err := esClient.Cluster.PutSettings(&buf)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"alias": alias,
+			"error": err,
+		}).Error("Could not configure the remote cluster")
+
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not configure the %s remote cluster. Status: %s", alias, res.Status())
+	}
+
+	log.WithFields(log.Fields{
+		"alias": alias,
+		"seeds": seeds,
+	}).Debug("Remote cluster configured")
+
+	return nil
+}
+
+// GetRemoteClusters returns the remote clusters configured in the Elasticsearch cluster represented by the endpoint,
+// keyed by the alias of the remote cluster
+func GetRemoteClusters(ctx context.Context, esEndpoint *Endpoint) (map[string]RemoteClusterInfo, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Remote info", "elasticsearch.cluster.remote-info", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("host", esEndpoint.Host)
+	defer span.End()
+
+	result := map[string]RemoteClusterInfo{}
+
+	esClient, err := getElasticsearchClientFromHostPort(ctx, esEndpoint.Host, esEndpoint.Port, esEndpoint.Scheme)
+	if err != nil {
+		return result, err
+	}
+
+	res, err := esClient.Cluster.RemoteInfo()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Error retrieving remote clusters from Elasticsearch")
+
+		return result, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return result, fmt.Errorf("error getting remote clusters from Elasticsearch. Status: %s", res.Status())
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Error parsing response body from Elasticsearch")
+
+		return result, err
+	}
+
+	return result, nil
+}
+
+// WaitForRemoteClusterConnected waits for the remote cluster, identified by its alias, to be connected
+// to the Elasticsearch cluster represented by the endpoint
+func WaitForRemoteClusterConnected(ctx context.Context, esEndpoint *Endpoint, alias string, maxTimeout time.Duration) error {
+	exp := utils.GetExponentialBackOff(maxTimeout)
+
+	retryCount := 1
+
+	remoteClusterConnectedFn := func() error {
+		remoteClusters, err := GetRemoteClusters(ctx, esEndpoint)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"alias":       alias,
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"retry":       retryCount,
+			}).Warn("Could not retrieve the remote clusters")
+
+			retryCount++
+			return err
+		}
+
+		remoteCluster, exists := remoteClusters[alias]
+		if !exists || !remoteCluster.Connected {
+			err := fmt.Errorf("the %s remote cluster is not connected yet", alias)
+
+			log.WithFields(log.Fields{
+				"alias":       alias,
+				"elapsedTime": exp.GetElapsedTime(),
+				"exists":      exists,
+				"retry":       retryCount,
+			}).Warn(err.Error())
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"alias":       alias,
+			"elapsedTime": exp.GetElapsedTime(),
+			"nodes":       remoteCluster.NumNodesConnected,
+			"retries":     retryCount,
+		}).Info("The remote cluster is connected")
+
+		return nil
+	}
+
+	return backoff.Retry(remoteClusterConnectedFn, exp)
+}
+
+// FollowIndex creates a follower index in the Elasticsearch cluster represented by the endpoint, replicating
+// the leader index from the remote cluster identified by its alias, using Cross-Cluster Replication
+func FollowIndex(ctx context.Context, esEndpoint *Endpoint, alias string, leaderIndex string, followerIndex string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Follow index", "elasticsearch.ccr.follow", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("alias", alias)
+	span.Context.SetLabel("leaderIndex", leaderIndex)
+	span.Context.SetLabel("followerIndex", followerIndex)
+	defer span.End()
+
+	esClient, err := getElasticsearchClientFromHostPort(ctx, esEndpoint.Host, esEndpoint.Port, esEndpoint.Scheme)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"remote_cluster": alias,
+		"leader_index":   leaderIndex,
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return err
+	}
+
+	res, err := esClient.CCR.Follow(followerIndex, &buf)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":         err,
+			"followerIndex": followerIndex,
+			"leaderIndex":   leaderIndex,
+		}).Error("Could not follow the leader index")
+
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not follow the %s:%s index. Status: %s", alias, leaderIndex, res.Status())
+	}
+
+	log.WithFields(log.Fields{
+		"alias":         alias,
+		"followerIndex": followerIndex,
+		"leaderIndex":   leaderIndex,
+	}).Debug("Follower index created")
+
+	return nil
+}
+
+// IndexDocument indexes the document in the index of the Elasticsearch cluster represented by the endpoint,
+// refreshing the index so that the document is searchable right away
+func IndexDocument(ctx context.Context, esEndpoint *Endpoint, index string, document map[string]interface{}) error {
+	span, _ := apm.StartSpanOptions(ctx, "Index document", "elasticsearch.index", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("host", esEndpoint.Host)
+	span.Context.SetLabel("index", index)
+	defer span.End()
+
+	esClient, err := getElasticsearchClientFromHostPort(ctx, esEndpoint.Host, esEndpoint.Port, esEndpoint.Scheme)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(document); err != nil {
+		return err
+	}
+
+	res, err := esClient.Index(index, &buf, esClient.Index.WithRefresh("true"))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not index the document in the %s index. Status: %s", index, res.Status())
+	}
+
+	return nil
+}
+
+// RemoteIndexName returns the name of an index in a remote cluster, to be used in cross-cluster searches
+func RemoteIndexName(alias string, index string) string {
+	return alias + ":" + index
+}
+
+// AssertHitsComeFromCluster returns an error if no hits are present coming from the cluster identified by its
+// alias. Use an empty alias for the local cluster
+func AssertHitsComeFromCluster(hits map[string]interface{}, alias string) error {
+	iterableHits := hits["hits"].(map[string]interface{})["hits"].([]interface{})
+	for _, hit := range iterableHits {
+		index := hit.(map[string]interface{})["_index"].(string)
+
+		if alias == "" && !strings.Contains(index, ":") {
+			return nil
+		}
+
+		if alias != "" && strings.HasPrefix(index, alias+":") {
+			return nil
+		}
+	}
+
+	if alias == "" {
+		return fmt.Errorf("there aren't documents coming from the local cluster")
+	}
+
+	return fmt.Errorf("there aren't documents coming from the %s remote cluster", alias)
+}
@@ -0,0 +1,50 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertHitsComeFromCluster(t *testing.T) {
+	hits := map[string]interface{}{
+		"hits": map[string]interface{}{
+			"hits": []interface{}{
+				map[string]interface{}{"_index": "remote:logs-generic-default"},
+				map[string]interface{}{"_index": "logs-generic-default"},
+			},
+		},
+	}
+
+	t.Run("Hits from the remote cluster", func(t *testing.T) {
+		assert.Nil(t, AssertHitsComeFromCluster(hits, "remote"))
+	})
+
+	t.Run("Hits from the local cluster", func(t *testing.T) {
+		assert.Nil(t, AssertHitsComeFromCluster(hits, ""))
+	})
+
+	t.Run("No hits from another remote cluster", func(t *testing.T) {
+		assert.NotNil(t, AssertHitsComeFromCluster(hits, "other"))
+	})
+
+	t.Run("No hits from the local cluster", func(t *testing.T) {
+		remoteHits := map[string]interface{}{
+			"hits": map[string]interface{}{
+				"hits": []interface{}{
+					map[string]interface{}{"_index": "remote:logs-generic-default"},
+				},
+			},
+		}
+
+		assert.NotNil(t, AssertHitsComeFromCluster(remoteHits, ""))
+	})
+}
+
+func TestRemoteIndexName(t *testing.T) {
+	assert.Equal(t, "remote:logs-generic-default", RemoteIndexName(RemoteClusterAlias, "logs-generic-default"))
+}
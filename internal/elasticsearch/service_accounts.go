@@ -0,0 +1,144 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/elastic/e2e-testing/internal/auth"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// FleetServerServiceAccount the service account used by Fleet Server to connect to Elasticsearch
+const FleetServerServiceAccount = "elastic/fleet-server"
+
+// ServiceAccountToken represents a token of a service account, which value is sent as a bearer token
+type ServiceAccountToken struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CreateServiceAccountToken creates a token for the service account (i.e. elastic/fleet-server). An existing
+// token with the same name is replaced, as its value can't be retrieved again
+func CreateServiceAccountToken(ctx context.Context, serviceAccount string, name string) (ServiceAccountToken, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Create service account token", "elasticsearch.security.create-service-token", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("serviceAccount", serviceAccount)
+	defer span.End()
+
+	statusCode, body, err := performServiceTokenRequest(ctx, http.MethodPost, serviceAccount, name)
+	if err != nil {
+		return ServiceAccountToken{}, err
+	}
+
+	if statusCode == http.StatusConflict {
+		log.WithFields(log.Fields{
+			"name":           name,
+			"serviceAccount": serviceAccount,
+		}).Debug("The service account token already exists. Replacing it")
+
+		err = DeleteServiceAccountToken(ctx, serviceAccount, name)
+		if err != nil {
+			return ServiceAccountToken{}, err
+		}
+
+		statusCode, body, err = performServiceTokenRequest(ctx, http.MethodPost, serviceAccount, name)
+		if err != nil {
+			return ServiceAccountToken{}, err
+		}
+	}
+
+	if statusCode != http.StatusOK {
+		return ServiceAccountToken{}, fmt.Errorf("could not create the %s token of the %s service account. Status: %d", name, serviceAccount, statusCode)
+	}
+
+	var resp struct {
+		Token ServiceAccountToken `json:"token"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ServiceAccountToken{}, err
+	}
+
+	log.WithFields(log.Fields{
+		"name":           resp.Token.Name,
+		"serviceAccount": serviceAccount,
+	}).Debug("Service account token created")
+
+	return resp.Token, nil
+}
+
+// DeleteServiceAccountToken deletes a token of the service account. A token which does not exist is not an error
+func DeleteServiceAccountToken(ctx context.Context, serviceAccount string, name string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Delete service account token", "elasticsearch.security.delete-service-token", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("serviceAccount", serviceAccount)
+	defer span.End()
+
+	statusCode, _, err := performServiceTokenRequest(ctx, http.MethodDelete, serviceAccount, name)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusOK && statusCode != http.StatusNotFound {
+		return fmt.Errorf("could not delete the %s token of the %s service account. Status: %d", name, serviceAccount, statusCode)
+	}
+
+	return nil
+}
+
+// performServiceTokenRequest sends a request to the service account token API, which is not available in the
+// version of the Elasticsearch Go client in use
+func performServiceTokenRequest(ctx context.Context, method string, serviceAccount string, name string) (int, []byte, error) {
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("/_security/service/%s/credential/token/%s", serviceAccount, name), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := esClient.Perform(req.WithContext(ctx))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":          err,
+			"method":         method,
+			"name":           name,
+			"serviceAccount": serviceAccount,
+		}).Error("Could not send the request to the service account token API")
+
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, nil, err
+	}
+
+	return res.StatusCode, body, nil
+}
+
+// serviceTokenRoundTripper authenticates the requests with the service account token of the credentials,
+// instead of basic auth
+type serviceTokenRoundTripper struct {
+	credentials auth.Credentials
+	next        http.RoundTripper
+}
+
+func (rt *serviceTokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	rt.credentials.Apply(req)
+
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/e2e-testing/internal/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceTokenRoundTripper(t *testing.T) {
+	authorization := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &serviceTokenRoundTripper{
+			credentials: auth.Credentials{Username: "admin", Password: "changeme", ServiceToken: "AAEAAWVsYXN0aWM"},
+			next:        http.DefaultTransport,
+		},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "Bearer AAEAAWVsYXN0aWM", authorization)
+	assert.Equal(t, "", req.Header.Get("Authorization"), "the original request is not modified")
+}
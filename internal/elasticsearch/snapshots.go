@@ -0,0 +1,203 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// SnapshotsRepository the name of the shared file system repository used to store the snapshots
+const SnapshotsRepository = "e2e-snapshots"
+
+// SnapshotsLocation the location of the snapshots repository in the Elasticsearch container, which must
+// be included in the "path.repo" setting of the cluster
+const SnapshotsLocation = "/usr/share/elasticsearch/snapshots"
+
+// SnapshotRequest represents the indices and feature states to include in a snapshot, or to restore from it
+type SnapshotRequest struct {
+	Indices            string   `json:"indices"`
+	FeatureStates      []string `json:"feature_states"`
+	IgnoreUnavailable  bool     `json:"ignore_unavailable"`
+	IncludeGlobalState bool     `json:"include_global_state"`
+}
+
+// NewFleetSnapshotRequest returns a request including Fleet's system indices, which store the enrolled agents,
+// actions and enrollment tokens. Regular indices are excluded
+func NewFleetSnapshotRequest() SnapshotRequest {
+	return SnapshotRequest{
+		Indices:            "-*",
+		FeatureStates:      []string{"fleet"},
+		IgnoreUnavailable:  true,
+		IncludeGlobalState: false,
+	}
+}
+
+// CreateSnapshotsRepository registers the shared file system repository for the snapshots, if it does not exist
+func CreateSnapshotsRepository(ctx context.Context) error {
+	span, _ := apm.StartSpanOptions(ctx, "Create snapshots repository", "elasticsearch.snapshot.create-repository", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"type": "fs",
+		"settings": map[string]interface{}{
+			"location": SnapshotsLocation,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return err
+	}
+
+	res, err := esClient.Snapshot.CreateRepository(SnapshotsRepository, &buf)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":      err,
+			"repository": SnapshotsRepository,
+		}).Error("Could not create the snapshots repository")
+
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not create the %s snapshots repository. Status: %s", SnapshotsRepository, res.Status())
+	}
+
+	return nil
+}
+
+// CreateSnapshot takes a snapshot in the snapshots repository, waiting for its completion
+func CreateSnapshot(ctx context.Context, snapshot string, request SnapshotRequest) error {
+	span, _ := apm.StartSpanOptions(ctx, "Create snapshot", "elasticsearch.snapshot.create", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("snapshot", snapshot)
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(request); err != nil {
+		return err
+	}
+
+	res, err := esClient.Snapshot.Create(
+		SnapshotsRepository, snapshot,
+		esClient.Snapshot.Create.WithBody(&buf),
+		esClient.Snapshot.Create.WithWaitForCompletion(true),
+	)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"snapshot": snapshot,
+		}).Error("Could not create the snapshot")
+
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not create the %s snapshot. Status: %s", snapshot, res.Status())
+	}
+
+	log.WithFields(log.Fields{
+		"featureStates": request.FeatureStates,
+		"indices":       request.Indices,
+		"snapshot":      snapshot,
+	}).Debug("Snapshot created")
+
+	return nil
+}
+
+// RestoreSnapshot restores a snapshot from the snapshots repository, waiting for its completion.
+// The indices in the feature states are deleted before being restored, but regular indices
+// must be closed or deleted by the caller
+func RestoreSnapshot(ctx context.Context, snapshot string, request SnapshotRequest) error {
+	span, _ := apm.StartSpanOptions(ctx, "Restore snapshot", "elasticsearch.snapshot.restore", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("snapshot", snapshot)
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(request); err != nil {
+		return err
+	}
+
+	res, err := esClient.Snapshot.Restore(
+		SnapshotsRepository, snapshot,
+		esClient.Snapshot.Restore.WithBody(&buf),
+		esClient.Snapshot.Restore.WithWaitForCompletion(true),
+	)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error":    err,
+			"snapshot": snapshot,
+		}).Error("Could not restore the snapshot")
+
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not restore the %s snapshot. Status: %s", snapshot, res.Status())
+	}
+
+	log.WithFields(log.Fields{
+		"featureStates": request.FeatureStates,
+		"indices":       request.Indices,
+		"snapshot":      snapshot,
+	}).Debug("Snapshot restored")
+
+	return nil
+}
+
+// DeleteSnapshot deletes a snapshot from the snapshots repository
+func DeleteSnapshot(ctx context.Context, snapshot string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Delete snapshot", "elasticsearch.snapshot.delete", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("snapshot", snapshot)
+	defer span.End()
+
+	esClient, err := getElasticsearchClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := esClient.Snapshot.Delete(SnapshotsRepository, []string{snapshot})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("could not delete the %s snapshot. Status: %s", snapshot, res.Status())
+	}
+
+	return nil
+}
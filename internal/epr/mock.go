@@ -0,0 +1,389 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package epr
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// downloadPathRegex the path of the archive of a package, which is /epr/<name>/<name>-<version>.zip
+var downloadPathRegex = regexp.MustCompile(`^/epr/([^/]+)/([^/]+)-([^/]+)\.zip$`)
+
+// packagePathRegex the path of the information of a package, which is /package/<name>/<version>
+var packagePathRegex = regexp.MustCompile(`^/package/([^/]+)/([^/]+)/?$`)
+
+// Package represents a fixture package served by the mock registry, as described by its manifest
+type Package struct {
+	Name        string   `yaml:"name" json:"name"`
+	Title       string   `yaml:"title" json:"title"`
+	Version     string   `yaml:"version" json:"version"`
+	Release     string   `yaml:"release" json:"release,omitempty"`
+	Description string   `yaml:"description" json:"description"`
+	Type        string   `yaml:"type" json:"type"`
+	Categories  []string `yaml:"categories" json:"categories,omitempty"`
+	Download    string   `yaml:"-" json:"download"`
+	Path        string   `yaml:"-" json:"path"`
+
+	dir string
+}
+
+// failure represents a failure simulated by the registry for the requests matching a pattern
+type failure struct {
+	pattern    *regexp.Regexp
+	statusCode int
+	remaining  int
+}
+
+// MockRegistry a lightweight implementation of the Elastic Package Registry, serving the fixture packages
+// of a directory, so that the packages can be installed without the real registry. It can simulate
+// failures of the registry too
+type MockRegistry struct {
+	failures []*failure
+	listener net.Listener
+	mutex    sync.Mutex
+	packages []Package
+	server   *http.Server
+}
+
+// NewMockRegistry returns a registry serving the fixture packages of the directory, which are laid out
+// as <name>/<version>/manifest.yml
+func NewMockRegistry(fixturesDir string) (*MockRegistry, error) {
+	manifests, err := filepath.Glob(filepath.Join(fixturesDir, "*", "*", "manifest.yml"))
+	if err != nil {
+		return nil, err
+	}
+
+	packages := []Package{}
+	for _, manifest := range manifests {
+		bytes, err := ioutil.ReadFile(manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		p := Package{}
+		if err := yaml.Unmarshal(bytes, &p); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the manifest of the %s fixture package", manifest)
+		}
+
+		p.dir = filepath.Dir(manifest)
+		p.Download = fmt.Sprintf("/epr/%s/%s-%s.zip", p.Name, p.Name, p.Version)
+		p.Path = fmt.Sprintf("/package/%s/%s", p.Name, p.Version)
+		packages = append(packages, p)
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return compareVersions(packages[i].Version, packages[j].Version) < 0
+	})
+
+	return &MockRegistry{packages: packages}, nil
+}
+
+// Start serves the registry on the address (i.e. :8480), in background
+func (m *MockRegistry) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "could not listen on %s", addr)
+	}
+
+	m.listener = listener
+	m.server = &http.Server{Handler: m}
+
+	go func() {
+		if err := m.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.WithFields(log.Fields{
+				"addr":  addr,
+				"error": err,
+			}).Error("The mock package registry stopped")
+		}
+	}()
+
+	log.WithFields(log.Fields{
+		"addr":     listener.Addr().String(),
+		"packages": len(m.packages),
+	}).Info("Mock package registry started")
+
+	return nil
+}
+
+// Stop stops serving the registry
+func (m *MockRegistry) Stop(ctx context.Context) error {
+	if m.server == nil {
+		return nil
+	}
+
+	return m.server.Shutdown(ctx)
+}
+
+// Port returns the port where the registry is served, or 0 if it was not started
+func (m *MockRegistry) Port() int {
+	if m.listener == nil {
+		return 0
+	}
+
+	return m.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Packages returns the fixture packages served by the registry
+func (m *MockRegistry) Packages() []Package {
+	return m.packages
+}
+
+// SimulateFailure makes the requests which path matches the pattern fail with the status code, the number of
+// times, or until the failures are cleared if times is zero. An empty pattern matches all the requests
+func (m *MockRegistry) SimulateFailure(pattern string, statusCode int, times int) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.failures = append(m.failures, &failure{pattern: re, statusCode: statusCode, remaining: times})
+
+	return nil
+}
+
+// ClearFailures removes the simulated failures, so that the registry is available again
+func (m *MockRegistry) ClearFailures() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.failures = nil
+}
+
+// ServeHTTP serves the endpoints of the registry used by Fleet
+func (m *MockRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if statusCode, failed := m.simulatedFailure(r.URL.Path); failed {
+		log.WithFields(log.Fields{
+			"path":       r.URL.Path,
+			"statusCode": statusCode,
+		}).Debug("Simulating a failure of the package registry")
+
+		http.Error(w, http.StatusText(statusCode), statusCode)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/":
+		writeJSON(w, map[string]string{"service.name": "package-registry", "version": "mock"})
+	case path == "/search":
+		writeJSON(w, m.search(r.URL.Query().Get("package"), r.URL.Query().Get("all") == "true"))
+	case path == "/categories":
+		writeJSON(w, m.categories())
+	case packagePathRegex.MatchString(path):
+		matches := packagePathRegex.FindStringSubmatch(path)
+		if p, found := m.find(matches[1], matches[2]); found {
+			writeJSON(w, p)
+			return
+		}
+		http.NotFound(w, r)
+	case downloadPathRegex.MatchString(path):
+		matches := downloadPathRegex.FindStringSubmatch(path)
+		p, found := m.find(matches[1], matches[3])
+		if !found || matches[1] != matches[2] {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		if err := writeArchive(w, p); err != nil {
+			log.WithFields(log.Fields{
+				"error":   err,
+				"package": p.Name,
+				"version": p.Version,
+			}).Error("Could not archive the fixture package")
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// simulatedFailure returns the status code of the first failure matching the path, if any
+func (m *MockRegistry) simulatedFailure(path string) (int, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i, f := range m.failures {
+		if !f.pattern.MatchString(path) {
+			continue
+		}
+
+		if f.remaining > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				m.failures = append(m.failures[:i], m.failures[i+1:]...)
+			}
+		}
+
+		return f.statusCode, true
+	}
+
+	return 0, false
+}
+
+// search returns the packages with the name, or all of them if empty. Only the latest version of each
+// package is returned, unless all the versions are requested
+func (m *MockRegistry) search(name string, all bool) []Package {
+	result := []Package{}
+
+	for i, p := range m.packages {
+		if name != "" && p.Name != name {
+			continue
+		}
+
+		// the packages are sorted by name and version, so the latest version is the last one of each name
+		isLatest := i == len(m.packages)-1 || m.packages[i+1].Name != p.Name
+		if all || isLatest {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+func (m *MockRegistry) categories() []map[string]interface{} {
+	counts := map[string]int{}
+	for _, p := range m.search("", false) {
+		for _, category := range p.Categories {
+			counts[category]++
+		}
+	}
+
+	result := []map[string]interface{}{}
+	for category, count := range counts {
+		result = append(result, map[string]interface{}{"id": category, "title": category, "count": count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i]["id"].(string) < result[j]["id"].(string)
+	})
+
+	return result
+}
+
+func (m *MockRegistry) find(name string, version string) (Package, bool) {
+	for _, p := range m.packages {
+		if p.Name == name && p.Version == version {
+			return p, true
+		}
+	}
+
+	return Package{}, false
+}
+
+// writeArchive writes the zip archive of a package, which files are under the <name>-<version> directory
+func writeArchive(w http.ResponseWriter, p Package) error {
+	archive := zip.NewWriter(w)
+
+	err := filepath.Walk(p.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(p.dir, path)
+		if err != nil {
+			return err
+		}
+
+		f, err := archive.Create(p.Name + "-" + p.Version + "/" + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		bytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(bytes)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+		}).Error("Could not write the response of the mock package registry")
+	}
+}
+
+// compareVersions compares two semantic versions by their numeric parts, a pre-release being lower
+// than its release (i.e. 1.0.0-beta1 is lower than 1.0.0)
+func compareVersions(a string, b string) int {
+	aVersion, aPre := splitPreRelease(a)
+	bVersion, bPre := splitPreRelease(b)
+
+	aParts := strings.Split(aVersion, ".")
+	bParts := strings.Split(bVersion, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aNum, bNum := 0, 0
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	return strings.Compare(aPre, bPre)
+}
+
+func splitPreRelease(version string) (string, string) {
+	parts := strings.SplitN(version, "-", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
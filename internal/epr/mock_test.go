@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package epr
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry(t *testing.T) *MockRegistry {
+	dir := t.TempDir()
+
+	for _, version := range []string{"0.1.0", "0.10.0", "0.2.0"} {
+		packageDir := filepath.Join(dir, "e2e_mock", version)
+		assert.Nil(t, os.MkdirAll(filepath.Join(packageDir, "docs"), 0755))
+
+		manifest := "name: e2e_mock\ntitle: E2E Mock\nversion: " + version + "\ntype: integration\ncategories:\n  - custom\n"
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(packageDir, "manifest.yml"), []byte(manifest), 0644))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(packageDir, "docs", "README.md"), []byte("# E2E Mock"), 0644))
+	}
+
+	registry, err := NewMockRegistry(dir)
+	assert.Nil(t, err)
+
+	return registry
+}
+
+func get(registry *MockRegistry, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return rec
+}
+
+func TestMockRegistrySearch(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	t.Run("Only the latest version is returned", func(t *testing.T) {
+		rec := get(registry, "/search?package=e2e_mock")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		packages := []Package{}
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &packages))
+		assert.Equal(t, 1, len(packages))
+		assert.Equal(t, "0.10.0", packages[0].Version)
+		assert.Equal(t, "/epr/e2e_mock/e2e_mock-0.10.0.zip", packages[0].Download)
+	})
+
+	t.Run("All the versions are returned", func(t *testing.T) {
+		rec := get(registry, "/search?package=e2e_mock&all=true")
+
+		packages := []Package{}
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &packages))
+		assert.Equal(t, 3, len(packages))
+	})
+
+	t.Run("Unknown packages are not found", func(t *testing.T) {
+		rec := get(registry, "/search?package=unknown")
+		assert.Equal(t, "[]\n", rec.Body.String())
+	})
+}
+
+func TestMockRegistryDownload(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	rec := get(registry, "/epr/e2e_mock/e2e_mock-0.2.0.zip")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	assert.Nil(t, err)
+
+	files := []string{}
+	for _, f := range archive.File {
+		files = append(files, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"e2e_mock-0.2.0/docs/README.md", "e2e_mock-0.2.0/manifest.yml"}, files)
+
+	assert.Equal(t, http.StatusNotFound, get(registry, "/epr/e2e_mock/e2e_mock-9.9.9.zip").Code)
+}
+
+func TestMockRegistryFailures(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	t.Run("Failures happen the number of times", func(t *testing.T) {
+		assert.Nil(t, registry.SimulateFailure("^/epr/", http.StatusServiceUnavailable, 1))
+
+		assert.Equal(t, http.StatusOK, get(registry, "/search").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, get(registry, "/epr/e2e_mock/e2e_mock-0.2.0.zip").Code)
+		assert.Equal(t, http.StatusOK, get(registry, "/epr/e2e_mock/e2e_mock-0.2.0.zip").Code)
+	})
+
+	t.Run("Failures happen until cleared", func(t *testing.T) {
+		assert.Nil(t, registry.SimulateFailure("", http.StatusInternalServerError, 0))
+
+		assert.Equal(t, http.StatusInternalServerError, get(registry, "/search").Code)
+		assert.Equal(t, http.StatusInternalServerError, get(registry, "/categories").Code)
+
+		registry.ClearFailures()
+		assert.Equal(t, http.StatusOK, get(registry, "/categories").Code)
+	})
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, -1, compareVersions("0.2.0", "0.10.0"))
+	assert.Equal(t, 1, compareVersions("1.0.0", "1.0.0-beta1"))
+	assert.Equal(t, 0, compareVersions("1.0.0", "1.0.0"))
+	assert.Equal(t, -1, compareVersions("1.0.0-beta1", "1.0.0-beta2"))
+}
@@ -0,0 +1,139 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatters
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// QuietFormatterName the name of the quiet formatter, to be used with the --godog.format flag
+const QuietFormatterName = "quiet"
+
+func init() {
+	godog.Format(QuietFormatterName, "Prints only the scenario names, the failures and a summary with durations.", QuietFormatterFunc)
+}
+
+// scenarioResult the outcome of a scenario, printed once the scenario finishes
+type scenarioResult struct {
+	name     string
+	uri      string
+	started  time.Time
+	duration time.Duration
+	failed   bool
+	failure  string
+}
+
+// QuietFormatter is a CI-friendly formatter, which prints one line per scenario with its status and duration,
+// the failed steps, and a final summary
+type QuietFormatter struct {
+	*godog.BaseFmt
+
+	out     io.Writer
+	mutex   sync.Mutex
+	started time.Time
+	current *scenarioResult
+	results []scenarioResult
+}
+
+// QuietFormatterFunc creates a new quiet formatter for the suite
+func QuietFormatterFunc(suite string, out io.Writer) godog.Formatter {
+	return &QuietFormatter{
+		BaseFmt: godog.NewBaseFmt(suite, out),
+		out:     out,
+		started: time.Now(),
+	}
+}
+
+// TestRunStarted starts the timer of the run
+func (f *QuietFormatter) TestRunStarted() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.started = time.Now()
+}
+
+// Pickle is called when a scenario starts, finishing the previous one
+func (f *QuietFormatter) Pickle(scenario *godog.Scenario) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.finishScenario()
+
+	f.current = &scenarioResult{
+		name:    scenario.Name,
+		uri:     scenario.Uri,
+		started: time.Now(),
+	}
+}
+
+// Failed is called when a step fails, recording the failure for the current scenario
+func (f *QuietFormatter) Failed(scenario *godog.Scenario, step *godog.Step, _ *godog.StepDefinition, err error) {
+	f.fail(step, fmt.Sprintf("%v", err))
+}
+
+// Undefined is called when a step has no definition, which makes the scenario fail
+func (f *QuietFormatter) Undefined(scenario *godog.Scenario, step *godog.Step, _ *godog.StepDefinition) {
+	f.fail(step, "step is undefined")
+}
+
+// Summary finishes the last scenario, and prints the failures and the totals of the run
+func (f *QuietFormatter) Summary() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.finishScenario()
+
+	failed := []scenarioResult{}
+	for _, result := range f.results {
+		if result.failed {
+			failed = append(failed, result)
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(f.out, "\n--- Failed scenarios:\n")
+		for _, result := range failed {
+			fmt.Fprintf(f.out, "  %s (%s)\n    %s\n", result.name, result.uri, result.failure)
+		}
+	}
+
+	fmt.Fprintf(f.out, "\n%d scenarios (%d passed, %d failed) in %s\n",
+		len(f.results), len(f.results)-len(failed), len(failed), time.Since(f.started).Round(time.Second))
+}
+
+func (f *QuietFormatter) fail(step *godog.Step, message string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.current == nil || f.current.failed {
+		return
+	}
+
+	f.current.failed = true
+	f.current.failure = fmt.Sprintf("%s: %s", step.Text, message)
+}
+
+// finishScenario prints the line of the current scenario, if any. The mutex must be held by the caller
+func (f *QuietFormatter) finishScenario() {
+	if f.current == nil {
+		return
+	}
+
+	f.current.duration = time.Since(f.current.started)
+
+	status := "PASS"
+	if f.current.failed {
+		status = "FAIL"
+	}
+	fmt.Fprintf(f.out, "%s %s (%s)\n", status, f.current.name, f.current.duration.Round(time.Second))
+
+	f.results = append(f.results, *f.current)
+	f.current = nil
+}
@@ -0,0 +1,38 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatters
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietFormatter(t *testing.T) {
+	var out bytes.Buffer
+	f := QuietFormatterFunc("fleet", &out)
+
+	passing := &godog.Scenario{Name: "Deploying the agent", Uri: "features/fleet_mode.feature"}
+	failing := &godog.Scenario{Name: "Un-enrolling the agent", Uri: "features/fleet_mode.feature"}
+	step := &godog.Step{Text: "the agent is listed in Fleet as \"inactive\""}
+
+	f.TestRunStarted()
+	f.Pickle(passing)
+	f.Pickle(failing)
+	f.Failed(failing, step, nil, errors.New("the agent is online"))
+	f.Summary()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	assert.Equal(t, "PASS Deploying the agent (0s)", lines[0])
+	assert.Equal(t, "FAIL Un-enrolling the agent (0s)", lines[1])
+	assert.Contains(t, out.String(), "--- Failed scenarios:")
+	assert.Contains(t, out.String(), "the agent is listed in Fleet as \"inactive\": the agent is online")
+	assert.Equal(t, "2 scenarios (1 passed, 1 failed) in 0s", lines[len(lines)-1])
+}
@@ -9,11 +9,13 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/elastic/e2e-testing/internal/common"
 	"github.com/elastic/e2e-testing/internal/deploy"
 	"github.com/elastic/e2e-testing/internal/io"
 	"github.com/elastic/e2e-testing/internal/systemd"
+	"github.com/elastic/e2e-testing/internal/utils"
 	"github.com/elastic/e2e-testing/pkg/downloads"
 	log "github.com/sirupsen/logrus"
 	"go.elastic.co/apm"
@@ -30,6 +32,62 @@ func (p *elasticAgentPackage) PkgMetadata() deploy.ServiceInstallerMetadata {
 	return p.metadata
 }
 
+// AttachFn creates the operator of a service for a installer, using the deployment to run its commands
+type AttachFn func(d deploy.Deployment, service deploy.ServiceRequest) deploy.ServiceOperator
+
+// installerKey identifies an installer of the elastic-agent by the OS and architecture of the host, and by
+// the installer type. An empty OS or architecture matches any of them
+type installerKey struct {
+	goos        string
+	arch        string
+	installType string
+}
+
+// installers the installers of the elastic-agent, by platform and installer type
+var installers = map[installerKey]AttachFn{
+	{installType: "deb"}:                 AttachElasticAgentDEBPackage,
+	{installType: "dnf"}:                 AttachElasticAgentDNFPackage,
+	{installType: "docker"}:              AttachElasticAgentDockerPackage,
+	{installType: "rpm"}:                 AttachElasticAgentRPMPackage,
+	{installType: "tar"}:                 AttachElasticAgentTARPackage,
+	{goos: "darwin", installType: "tar"}: AttachElasticAgentTARDarwinPackage,
+	{installType: "zip"}:                 AttachElasticAgentZIPPackage,
+	{installType: "zypper"}:              AttachElasticAgentZypperPackage,
+}
+
+var installersLock sync.RWMutex
+
+// Register adds a installer for the elastic-agent, replacing the existing one for the same OS, architecture
+// and installer type, so that new flavours of the agent can be deployed without changing the suites.
+// Use an empty OS or architecture for the installers supporting any of them
+func Register(goos string, arch string, installType string, attach AttachFn) {
+	installersLock.Lock()
+	defer installersLock.Unlock()
+
+	installers[installerKey{goos: goos, arch: arch, installType: installType}] = attach
+}
+
+// findInstaller returns the most specific installer for the platform and the installer type
+func findInstaller(goos string, arch string, installType string) (AttachFn, bool) {
+	installersLock.RLock()
+	defer installersLock.RUnlock()
+
+	keys := []installerKey{
+		{goos: goos, arch: arch, installType: installType},
+		{goos: goos, installType: installType},
+		{arch: arch, installType: installType},
+		{installType: installType},
+	}
+
+	for _, key := range keys {
+		if attach, exists := installers[key]; exists {
+			return attach, true
+		}
+	}
+
+	return nil, false
+}
+
 // Attach will attach a installer to a deployment allowing
 // the installation of a package to be transparently configured no matter the backend
 func Attach(ctx context.Context, deploy deploy.Deployment, service deploy.ServiceRequest, installType string) (deploy.ServiceOperator, error) {
@@ -43,33 +101,29 @@ func Attach(ctx context.Context, deploy deploy.Deployment, service deploy.Servic
 		"installType": installType,
 	}).Trace("Attaching service for configuration")
 
-	if strings.EqualFold(service.Name, "elastic-agent") {
-		switch installType {
-		case "tar":
-			// Since both Linux and macOS distribute elastic-agent using TAR format we must
-			// determine the runtime to figure out which tar installer to use here
-			if runtime.GOOS == "darwin" && common.Provider == "remote" {
-				install := AttachElasticAgentTARDarwinPackage(deploy, service)
-				return install, nil
-			}
-			install := AttachElasticAgentTARPackage(deploy, service)
-			return install, nil
-		case "zip":
-			install := AttachElasticAgentZIPPackage(deploy, service)
-			return install, nil
-		case "rpm":
-			install := AttachElasticAgentRPMPackage(deploy, service)
-			return install, nil
-		case "deb":
-			install := AttachElasticAgentDEBPackage(deploy, service)
-			return install, nil
-		case "docker":
-			install := AttachElasticAgentDockerPackage(deploy, service)
-			return install, nil
-		}
+	if !strings.EqualFold(service.Name, "elastic-agent") {
+		return nil, nil
+	}
+
+	goos := hostOS()
+	arch := utils.GetArchitecture()
+
+	attach, exists := findInstaller(goos, arch, installType)
+	if !exists {
+		return nil, fmt.Errorf("'%s' is not a supported installer for the elastic-agent in %s/%s", installType, goos, arch)
 	}
 
-	return nil, nil
+	return attach(deploy, service), nil
+}
+
+// hostOS returns the OS of the hosts where the agents are installed: the one running the tests for the remote
+// provider, as the agents are installed in it, and Linux for the rest, as the agents run in containers
+func hostOS() string {
+	if common.Provider == "remote" {
+		return runtime.GOOS
+	}
+
+	return "linux"
 }
 
 // doUpgrade upgrade an elastic-agent package using the 'upgrade' command
@@ -115,6 +169,58 @@ func doUpgrade(ctx context.Context, so deploy.ServiceOperator) error {
 	return nil
 }
 
+// InstallUpgradeArtifact downloads the artifact of the elastic-agent for a version, for the platform and the
+// package type of the installer, and installs it with its checksum in a directory of the host of the agent,
+// returning the source URI the agent is upgraded from. Each version is installed in its own directory, so that
+// the versions the agent cannot download on its own, such as the snapshots, are fetched in the same scenario
+// as the version the agent was installed with
+func InstallUpgradeArtifact(ctx context.Context, so deploy.ServiceOperator, version string) (string, error) {
+	pkgMetadata := so.PkgMetadata()
+
+	span, _ := apm.StartSpanOptions(ctx, "Installing Elastic Agent upgrade artifact", "elastic-agent."+pkgMetadata.PackageType+".install-upgrade-artifact", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("version", version)
+	defer span.End()
+
+	artifact := common.ElasticAgentServiceName
+	_, binaryPath, err := downloads.FetchElasticArtifactForSnapshots(ctx, downloads.UseElasticAgentCISnapshots(), artifact, version, pkgMetadata.Os, pkgMetadata.Arch, pkgMetadata.FileExtension, pkgMetadata.Docker, pkgMetadata.XPack)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"artifact":        artifact,
+			"version":         version,
+			"packageMetadata": pkgMetadata,
+			"error":           err,
+		}).Error("Could not download the binary for the upgrade of the agent")
+		return "", err
+	}
+
+	// the agent looks for the artifact by the name of the version, without the commit of the snapshots
+	version = downloads.GetSnapshotVersion(version)
+	artifactName := fmt.Sprintf("%s-%s-%s-%s.%s", artifact, version, pkgMetadata.Os, pkgMetadata.Arch, pkgMetadata.FileExtension)
+	upgradeDir := common.GetElasticAgentWorkingPath("upgrades", version)
+
+	cmds := [][]string{
+		{"mkdir", "-p", upgradeDir},
+		{"cp", binaryPath, common.GetElasticAgentWorkingPath("upgrades", version, artifactName)},
+		{"sh", "-c", fmt.Sprintf("cd %s && sha512sum %s > %s.sha512", upgradeDir, artifactName, artifactName)},
+	}
+	for _, cmd := range cmds {
+		_, err = so.Exec(ctx, cmd)
+		if err != nil {
+			return "", fmt.Errorf("could not install the artifact of the %s version for the upgrade: %w", version, err)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"artifact": artifactName,
+		"dir":      upgradeDir,
+		"version":  version,
+	}).Debug("Artifact for the upgrade of the agent installed")
+
+	return "file://" + upgradeDir, nil
+}
+
 // createAgentDirectories makes sure the agent directories belong to the root user
 func createAgentDirectories(ctx context.Context, i deploy.ServiceOperator, osArgs []string) error {
 	agentPath := i.PkgMetadata().AgentPath
@@ -156,6 +262,26 @@ func systemCtlLog(ctx context.Context, OS string, execFn func(ctx context.Contex
 	return nil
 }
 
+// systemCtlEnable reloads the units and enables the unit of the artifact, for the distributions which packages do
+// not enable their services on install, such as the SUSE family
+func systemCtlEnable(ctx context.Context, linux string, artifact string, execFn func(ctx context.Context, args []string) (string, error)) error {
+	span, _ := apm.StartSpanOptions(ctx, "Enabling "+artifact+" service", artifact+"."+linux+".enable", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("artifact", artifact)
+	span.Context.SetLabel("linux", linux)
+	defer span.End()
+
+	for _, cmds := range [][]string{systemd.DaemonReloadCmds(), systemd.EnableCmds(artifact)} {
+		_, err := execFn(ctx, cmds)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func systemCtlPostInstall(ctx context.Context, linux string, artifact string, execFn func(ctx context.Context, args []string) (string, error)) error {
 	cmds := systemd.RestartCmds(artifact)
 	span, _ := apm.StartSpanOptions(ctx, "Post-install operations for the "+artifact, artifact+"."+linux+".post-install", apm.SpanOptions{
@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package installer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttach(t *testing.T) {
+	ctx := context.Background()
+	agent := deploy.NewServiceRequest("elastic-agent")
+
+	t.Run("Known installer", func(t *testing.T) {
+		so, err := Attach(ctx, nil, agent, "rpm")
+		assert.Nil(t, err)
+		assert.Equal(t, "rpm", so.PkgMetadata().PackageType)
+	})
+
+	t.Run("RPM installers", func(t *testing.T) {
+		for _, packageManager := range []string{"dnf", "zypper"} {
+			so, err := Attach(ctx, nil, agent, packageManager)
+			assert.Nil(t, err)
+			assert.Equal(t, "rpm", so.PkgMetadata().PackageType)
+			assert.Equal(t, packageManager, so.(*elasticAgentRPMPackage).packageManager)
+		}
+	})
+
+	t.Run("Unknown installer", func(t *testing.T) {
+		so, err := Attach(ctx, nil, agent, "msi")
+		assert.NotNil(t, err)
+		assert.Nil(t, so)
+	})
+
+	t.Run("Other services", func(t *testing.T) {
+		so, err := Attach(ctx, nil, deploy.NewServiceRequest("metricbeat"), "rpm")
+		assert.Nil(t, err)
+		assert.Nil(t, so)
+	})
+
+	t.Run("Registered installer", func(t *testing.T) {
+		Register("", "", "msi", AttachElasticAgentZIPPackage)
+		defer delete(installers, installerKey{installType: "msi"})
+
+		so, err := Attach(ctx, nil, agent, "msi")
+		assert.Nil(t, err)
+		assert.Equal(t, "zip", so.PkgMetadata().PackageType)
+	})
+
+	t.Run("Installer registered for the platform", func(t *testing.T) {
+		Register("linux", utils.GetArchitecture(), "rpm", AttachElasticAgentZIPPackage)
+		defer delete(installers, installerKey{goos: "linux", arch: utils.GetArchitecture(), installType: "rpm"})
+
+		so, err := Attach(ctx, nil, agent, "rpm")
+		assert.Nil(t, err)
+		assert.Equal(t, "zip", so.PkgMetadata().PackageType)
+	})
+
+	t.Run("Installer registered for another platform", func(t *testing.T) {
+		Register("windows", "", "rpm", AttachElasticAgentZIPPackage)
+		defer delete(installers, installerKey{goos: "windows", installType: "rpm"})
+
+		so, err := Attach(ctx, nil, agent, "rpm")
+		assert.Nil(t, err)
+		assert.Equal(t, "rpm", so.PkgMetadata().PackageType)
+	})
+}
@@ -21,10 +21,27 @@ import (
 // elasticAgentRPMPackage implements operations for a RPM installer
 type elasticAgentRPMPackage struct {
 	elasticAgentPackage
+	packageManager string // yum, dnf for Fedora, or zypper for the SUSE family
 }
 
 // AttachElasticAgentRPMPackage creates an instance for the RPM installer
 func AttachElasticAgentRPMPackage(d deploy.Deployment, service deploy.ServiceRequest) deploy.ServiceOperator {
+	return attachElasticAgentRPMPackage(d, service, "yum")
+}
+
+// AttachElasticAgentZypperPackage creates an instance for the RPM installer of the SUSE family, such as SLES and
+// openSUSE, which installs the packages with zypper
+func AttachElasticAgentZypperPackage(d deploy.Deployment, service deploy.ServiceRequest) deploy.ServiceOperator {
+	return attachElasticAgentRPMPackage(d, service, "zypper")
+}
+
+// AttachElasticAgentDNFPackage creates an instance for the RPM installer of the distributions which install the
+// packages with dnf, such as Fedora
+func AttachElasticAgentDNFPackage(d deploy.Deployment, service deploy.ServiceRequest) deploy.ServiceOperator {
+	return attachElasticAgentRPMPackage(d, service, "dnf")
+}
+
+func attachElasticAgentRPMPackage(d deploy.Deployment, service deploy.ServiceRequest, packageManager string) deploy.ServiceOperator {
 	arch := "x86_64"
 	if utils.GetArchitecture() == "arm64" {
 		arch = "aarch64"
@@ -44,9 +61,19 @@ func AttachElasticAgentRPMPackage(d deploy.Deployment, service deploy.ServiceReq
 				Docker:        false,
 			},
 		},
+		packageManager,
 	}
 }
 
+// linux returns the Linux family of the package, as the SUSE family behaves differently from CentOS
+func (i *elasticAgentRPMPackage) linux() string {
+	if i.packageManager == "zypper" {
+		return "sles"
+	}
+
+	return "centos"
+}
+
 // AddFiles will add files into the service environment, default destination is /
 func (i *elasticAgentRPMPackage) AddFiles(ctx context.Context, files []string) error {
 	span, _ := apm.StartSpanOptions(ctx, "Adding files to the Elastic Agent", "elastic-agent.rpm.add-files", apm.SpanOptions{
@@ -94,15 +121,12 @@ func (i *elasticAgentRPMPackage) Enroll(ctx context.Context, token string, extra
 	defer span.End()
 
 	cfg, _ := kibana.NewFleetConfig(token)
-	cmds = append(cmds, cfg.Flags()...)
-	if extraFlags != "" {
-		cmds = append(cmds, extraFlags)
-	}
+	cmds = append(cmds, cfg.EnrollFlags(extraFlags)...)
 
 	output, err := i.Exec(ctx, cmds)
 	log.Trace(output)
 	if err != nil {
-		return fmt.Errorf("failed to install the agent with subcommand: %v", err)
+		return fmt.Errorf("failed to install the agent with subcommand: %w", err)
 	}
 	return nil
 }
@@ -120,6 +144,19 @@ func (i *elasticAgentRPMPackage) InstallCerts(ctx context.Context) error {
 		{"update-ca-trust", "force-enable"},
 		{"update-ca-trust", "extract"},
 	}
+	switch i.packageManager {
+	case "dnf":
+		cmds = [][]string{
+			{"dnf", "install", "ca-certificates", "-y"},
+			{"update-ca-trust", "force-enable"},
+			{"update-ca-trust", "extract"},
+		}
+	case "zypper":
+		cmds = [][]string{
+			{"zypper", "--non-interactive", "install", "ca-certificates"},
+			{"update-ca-certificates"},
+		}
+	}
 	for _, cmd := range cmds {
 		if _, err := i.Exec(ctx, cmd); err != nil {
 			return err
@@ -136,17 +173,30 @@ func (i *elasticAgentRPMPackage) Logs(ctx context.Context) error {
 
 // Postinstall executes operations after installing a RPM package
 func (i *elasticAgentRPMPackage) Postinstall(ctx context.Context) error {
+	artifacts := []string{}
 	for _, bp := range i.service.BackgroundProcesses {
 		if strings.EqualFold(bp, "filebeat") || strings.EqualFold(bp, "metricbeat") {
 			// post-install the dependant binary first
-			err := systemCtlPostInstall(ctx, "centos", bp, i.Exec)
+			artifacts = append(artifacts, bp)
+		}
+	}
+	artifacts = append(artifacts, "elastic-agent")
+
+	for _, artifact := range artifacts {
+		if i.packageManager == "zypper" {
+			err := systemCtlEnable(ctx, i.linux(), artifact, i.Exec)
 			if err != nil {
 				return err
 			}
 		}
+
+		err := systemCtlPostInstall(ctx, i.linux(), artifact, i.Exec)
+		if err != nil {
+			return err
+		}
 	}
 
-	return systemCtlPostInstall(ctx, "centos", "elastic-agent", i.Exec)
+	return nil
 }
 
 // Preinstall executes operations before installing a RPM package
@@ -180,7 +230,16 @@ func (i *elasticAgentRPMPackage) Preinstall(ctx context.Context) error {
 			return err
 		}
 
-		_, err = i.Exec(ctx, []string{"yum", "localinstall", "/" + binaryName, "-y"})
+		installCmd := []string{"yum", "localinstall", "/" + binaryName, "-y"}
+		switch i.packageManager {
+		case "dnf":
+			installCmd = []string{"dnf", "install", "/" + binaryName, "-y"}
+		case "zypper":
+			// the packages are not signed with a key known by zypper
+			installCmd = []string{"zypper", "--non-interactive", "--no-gpg-checks", "install", "/" + binaryName}
+		}
+
+		_, err = i.Exec(ctx, installCmd)
 		if err != nil {
 			return err
 		}
@@ -206,14 +265,14 @@ func (i *elasticAgentRPMPackage) Restart(ctx context.Context) error {
 	for _, bp := range i.service.BackgroundProcesses {
 		if strings.EqualFold(bp, "filebeat") || strings.EqualFold(bp, "metricbeat") {
 			// start the dependant binary first
-			err := systemCtlRestart(ctx, "centos", bp, i.Exec)
+			err := systemCtlRestart(ctx, i.linux(), bp, i.Exec)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	return systemCtlRestart(ctx, "centos", "elastic-agent", i.Exec)
+	return systemCtlRestart(ctx, i.linux(), "elastic-agent", i.Exec)
 }
 
 // Start will start a service
@@ -221,14 +280,14 @@ func (i *elasticAgentRPMPackage) Start(ctx context.Context) error {
 	for _, bp := range i.service.BackgroundProcesses {
 		if strings.EqualFold(bp, "filebeat") || strings.EqualFold(bp, "metricbeat") {
 			// start the dependant binary first
-			err := systemCtlStart(ctx, "centos", bp, i.Exec)
+			err := systemCtlStart(ctx, i.linux(), bp, i.Exec)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	return systemCtlStart(ctx, "centos", "elastic-agent", i.Exec)
+	return systemCtlStart(ctx, i.linux(), "elastic-agent", i.Exec)
 }
 
 // Stop will start a service
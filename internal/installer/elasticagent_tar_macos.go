@@ -92,14 +92,11 @@ func (i *elasticAgentTARDarwinPackage) Enroll(ctx context.Context, token string,
 	defer span.End()
 
 	cfg, _ := kibana.NewFleetConfig(token)
-	cmds = append(cmds, cfg.Flags()...)
-	if extraFlags != "" {
-		cmds = append(cmds, extraFlags)
-	}
+	cmds = append(cmds, cfg.EnrollFlags(extraFlags)...)
 
 	_, err := i.Exec(ctx, cmds)
 	if err != nil {
-		return fmt.Errorf("failed to install the agent with subcommand: %v", err)
+		return fmt.Errorf("failed to install the agent with subcommand: %w", err)
 	}
 	return nil
 }
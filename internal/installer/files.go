@@ -0,0 +1,123 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/deploy"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// ReadFile returns the content of a file in the service environment, without the trailing line breaks
+func ReadFile(ctx context.Context, so deploy.ServiceOperator, path string) (string, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Reading file in the service", "elastic-agent.file.read", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("path", path)
+	defer span.End()
+
+	cmds := []string{"cat", path}
+	if so.PkgMetadata().Os == "windows" {
+		cmds = []string{"powershell.exe", "Get-Content", "-Raw", "-Path", path}
+	}
+
+	content, err := so.Exec(ctx, cmds)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"error": err,
+			"path":  path,
+		}).Error("Could not read the file in the service")
+		return "", err
+	}
+
+	return content, nil
+}
+
+// FileExists returns whether a file, or a directory, exists in the service environment
+func FileExists(ctx context.Context, so deploy.ServiceOperator, path string) (bool, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Checking file exists in the service", "elastic-agent.file.exists", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("path", path)
+	defer span.End()
+
+	if so.PkgMetadata().Os == "windows" {
+		output, err := so.Exec(ctx, []string{"powershell.exe", "Test-Path", "-Path", path})
+		if err != nil {
+			return false, err
+		}
+
+		return strings.EqualFold(strings.TrimSpace(output), "true"), nil
+	}
+
+	_, err := so.Exec(ctx, []string{"test", "-e", path})
+	if err == nil {
+		return true, nil
+	}
+
+	// test exits with 1 when the file does not exist, any other code means it could not be checked
+	if shell.ExitCode(err) == 1 {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// FileStat the permissions and ownership of a file in the service environment
+type FileStat struct {
+	Mode  string // permissions in octal notation, without leading zeros, i.e. 600
+	Owner string
+	Group string
+}
+
+// StatFile returns the permissions and ownership of a file in the service environment, following symlinks,
+// as the installed binaries are usually linked from the system paths
+func StatFile(ctx context.Context, so deploy.ServiceOperator, path string) (FileStat, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Getting permissions of file in the service", "elastic-agent.file.stat", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("path", path)
+	defer span.End()
+
+	if so.PkgMetadata().Os == "windows" {
+		return FileStat{}, fmt.Errorf("the permissions of the files are not supported on windows: %s", path)
+	}
+
+	output, err := so.Exec(ctx, []string{"stat", "-L", "-c", "%a %U %G", path})
+	if err != nil {
+		return FileStat{}, err
+	}
+
+	return parseStat(output)
+}
+
+// parseStat parses the output of the stat command, formatted as "mode owner group"
+func parseStat(output string) (FileStat, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 3 {
+		return FileStat{}, fmt.Errorf("could not parse the permissions of the file: '%s'", output)
+	}
+
+	return FileStat{
+		Mode:  NormalizeMode(fields[0]),
+		Owner: fields[1],
+		Group: fields[2],
+	}, nil
+}
+
+// NormalizeMode removes the leading zeros of permissions in octal notation, so that 0600 and 600 are equal
+func NormalizeMode(mode string) string {
+	normalized := strings.TrimLeft(strings.TrimSpace(mode), "0")
+	if normalized == "" {
+		return "0"
+	}
+
+	return normalized
+}
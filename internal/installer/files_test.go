@@ -0,0 +1,32 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package installer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseStat(t *testing.T) {
+	t.Run("Output of the stat command", func(t *testing.T) {
+		stat, err := parseStat("600 root root\n")
+
+		assert.Nil(t, err)
+		assert.Equal(t, FileStat{Mode: "600", Owner: "root", Group: "root"}, stat)
+	})
+
+	t.Run("Output with unexpected fields", func(t *testing.T) {
+		_, err := parseStat("stat: cannot stat '/foo': No such file or directory")
+
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_NormalizeMode(t *testing.T) {
+	assert.Equal(t, "600", NormalizeMode("0600"))
+	assert.Equal(t, "755", NormalizeMode(" 755 "))
+	assert.Equal(t, "0", NormalizeMode("000"))
+}
@@ -0,0 +1,115 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package io
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is a writer appending to a file, which is rotated when it reaches the max size.
+// The rotated files are renamed with a numeric suffix (file.1, file.2...), the oldest ones being
+// removed when there are more than the max number of backups. It is safe for concurrent use
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file  *os.File
+	mutex sync.Mutex
+	size  int64
+}
+
+// NewRotatingFile opens the file for appending, creating it if needed
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	err := rf.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+// Write writes the bytes to the file, rotating it first if the bytes do not fit in it
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		err := rf.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (rf *RotatingFile) Close() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	return rf.file.Close()
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+	err := rf.file.Close()
+	if err != nil {
+		return err
+	}
+
+	// shift the backups, discarding the oldest one
+	for i := rf.maxBackups - 1; i > 0; i-- {
+		src := rf.backupPath(i)
+		if found, _ := Exists(src); found {
+			err := os.Rename(src, rf.backupPath(i+1))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if rf.maxBackups > 0 {
+		err = os.Rename(rf.path, rf.backupPath(1))
+	} else {
+		err = os.Remove(rf.path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return rf.open()
+}
+
+func (rf *RotatingFile) backupPath(index int) string {
+	return fmt.Sprintf("%s.%d", rf.path, index)
+}
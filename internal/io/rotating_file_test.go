@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package io
+
+import (
+	"path"
+	"testing"
+
+	"github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile(t *testing.T) {
+	defer filet.CleanUp(t)
+
+	tmpDir := filet.TmpDir(t, "")
+	logFile := path.Join(tmpDir, "kibana.log")
+
+	rf, err := NewRotatingFile(logFile, 10, 2)
+	assert.Nil(t, err)
+
+	for _, line := range []string{"line-1\n", "line-2\n", "line-3\n", "line-4\n"} {
+		_, err := rf.Write([]byte(line))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, rf.Close())
+
+	current, _ := ReadFile(logFile)
+	assert.Equal(t, "line-4\n", string(current))
+
+	backup1, _ := ReadFile(logFile + ".1")
+	assert.Equal(t, "line-3\n", string(backup1))
+
+	backup2, _ := ReadFile(logFile + ".2")
+	assert.Equal(t, "line-2\n", string(backup2))
+
+	// the oldest backup is discarded
+	e, _ := Exists(logFile + ".3")
+	assert.False(t, e)
+}
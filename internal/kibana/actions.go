@@ -0,0 +1,177 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// Action types
+const (
+	ActionTypeSettings = "SETTINGS" // changes the settings of an agent, such as its log level
+	ActionTypeUpgrade  = "UPGRADE"
+)
+
+// Action statuses, as reported by Fleet once the agents acknowledge the action, or fail to
+const (
+	ActionStatusComplete   = "COMPLETE"
+	ActionStatusExpired    = "EXPIRED"
+	ActionStatusFailed     = "FAILED"
+	ActionStatusInProgress = "IN_PROGRESS"
+)
+
+// AgentAction represents an action sent by Fleet to an agent
+type AgentAction struct {
+	ID      string                 `json:"id"`
+	AgentID string                 `json:"agent_id,omitempty"`
+	Type    string                 `json:"type"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// ActionStatus represents the progress of an action among the agents it was sent to
+type ActionStatus struct {
+	ActionID         string    `json:"actionId"`
+	Type             string    `json:"type"`
+	Status           string    `json:"status"`
+	NbAgentsActioned int       `json:"nbAgentsActioned"`
+	NbAgentsAck      int       `json:"nbAgentsAck"`
+	NbAgentsFailed   int       `json:"nbAgentsFailed"`
+	CreationTime     time.Time `json:"creationTime"`
+}
+
+// SetAgentLogLevel sends a settings action to the agent, changing its log level (i.e. debug, info, warning, error)
+func (c *Client) SetAgentLogLevel(ctx context.Context, agentID string, logLevel string) (AgentAction, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Setting the log level of the Elastic Agent", "fleet.agent.actions.settings", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("agentID", agentID)
+	span.Context.SetLabel("logLevel", logLevel)
+	defer span.End()
+
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"action": AgentAction{
+			Type: ActionTypeSettings,
+			Data: map[string]interface{}{
+				"log_level": logLevel,
+			},
+		},
+	})
+	if err != nil {
+		return AgentAction{}, errors.Wrap(err, "could not convert agent action (request) to JSON")
+	}
+
+	statusCode, respBody, err := c.post(ctx, fmt.Sprintf("%s/agents/%s/actions", FleetAPI, agentID), reqBody)
+	if err != nil {
+		return AgentAction{}, errors.Wrap(err, "could not send the action to the agent")
+	}
+
+	if statusCode != 200 {
+		log.WithFields(log.Fields{
+			"agentID":    agentID,
+			"body":       string(respBody),
+			"logLevel":   logLevel,
+			"statusCode": statusCode,
+		}).Error("Could not set the log level of the agent")
+
+		return AgentAction{}, fmt.Errorf("could not set the log level of the agent; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Item AgentAction `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return AgentAction{}, errors.Wrap(err, "could not convert agent action (response) to JSON")
+	}
+
+	return resp.Item, nil
+}
+
+// GetActionStatus returns the progress of an action among the agents it was sent to
+func (c *Client) GetActionStatus(ctx context.Context, actionID string) (ActionStatus, error) {
+	statuses, err := c.ListActionStatuses(ctx)
+	if err != nil {
+		return ActionStatus{}, err
+	}
+
+	return findActionStatus(statuses, actionID)
+}
+
+// GetLatestActionStatus returns the progress of the latest action of the type created since the given time,
+// for those actions which ID is not returned when they are requested, such as upgrades
+func (c *Client) GetLatestActionStatus(ctx context.Context, actionType string, since time.Time) (ActionStatus, error) {
+	statuses, err := c.ListActionStatuses(ctx)
+	if err != nil {
+		return ActionStatus{}, err
+	}
+
+	return findLatestActionStatus(statuses, actionType, since)
+}
+
+// ListActionStatuses returns the progress of the most recent actions
+func (c *Client) ListActionStatuses(ctx context.Context) ([]ActionStatus, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Listing the status of the actions", "fleet.agent.actions.status", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	defer span.End()
+
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/agents/action_status", FleetAPI))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the status of the actions")
+	}
+
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not get the status of the actions; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Items []ActionStatus `json:"items"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "could not convert action status (response) to JSON")
+	}
+
+	return resp.Items, nil
+}
+
+// findActionStatus returns the status of the action, or an error if Fleet does not report it
+func findActionStatus(statuses []ActionStatus, actionID string) (ActionStatus, error) {
+	for _, status := range statuses {
+		if status.ActionID == actionID {
+			return status, nil
+		}
+	}
+
+	return ActionStatus{}, fmt.Errorf("the status of the %s action is not reported by Fleet", actionID)
+}
+
+// findLatestActionStatus returns the status of the latest action of the type created since the given time, or an
+// error if Fleet does not report any
+func findLatestActionStatus(statuses []ActionStatus, actionType string, since time.Time) (ActionStatus, error) {
+	latest := ActionStatus{}
+	for _, status := range statuses {
+		if status.Type != actionType || status.CreationTime.Before(since) {
+			continue
+		}
+
+		if latest.ActionID == "" || status.CreationTime.After(latest.CreationTime) {
+			latest = status
+		}
+	}
+
+	if latest.ActionID == "" {
+		return ActionStatus{}, fmt.Errorf("the status of %s actions created since %s is not reported by Fleet", actionType, since.Format(time.RFC3339))
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,53 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindActionStatus(t *testing.T) {
+	statuses := []ActionStatus{
+		{ActionID: "action-1", Type: ActionTypeUpgrade, Status: ActionStatusComplete},
+		{ActionID: "action-2", Type: ActionTypeSettings, Status: ActionStatusInProgress},
+	}
+
+	t.Run("The status of the action is returned", func(t *testing.T) {
+		status, err := findActionStatus(statuses, "action-2")
+		assert.Nil(t, err)
+		assert.Equal(t, ActionTypeSettings, status.Type)
+		assert.Equal(t, ActionStatusInProgress, status.Status)
+	})
+
+	t.Run("An unknown action returns an error", func(t *testing.T) {
+		_, err := findActionStatus(statuses, "action-3")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestFindLatestActionStatus(t *testing.T) {
+	since := time.Date(2022, 10, 3, 10, 0, 0, 0, time.UTC)
+
+	statuses := []ActionStatus{
+		{ActionID: "action-1", Type: ActionTypeUpgrade, CreationTime: since.Add(-time.Minute)},
+		{ActionID: "action-2", Type: ActionTypeUpgrade, CreationTime: since.Add(2 * time.Minute)},
+		{ActionID: "action-3", Type: ActionTypeUpgrade, CreationTime: since.Add(time.Minute)},
+		{ActionID: "action-4", Type: ActionTypeSettings, CreationTime: since.Add(3 * time.Minute)},
+	}
+
+	t.Run("The latest action of the type is returned", func(t *testing.T) {
+		status, err := findLatestActionStatus(statuses, ActionTypeUpgrade, since)
+		assert.Nil(t, err)
+		assert.Equal(t, "action-2", status.ActionID)
+	})
+
+	t.Run("Actions created before are ignored", func(t *testing.T) {
+		_, err := findLatestActionStatus(statuses, ActionTypeUpgrade, since.Add(5*time.Minute))
+		assert.NotNil(t, err)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/elastic/e2e-testing/internal/elasticsearch"
@@ -17,6 +18,9 @@ import (
 	"go.elastic.co/apm"
 )
 
+// agentsPerPage the number of agents requested per page when listing them
+const agentsPerPage = 100
+
 // Agent represents an Elastic Agent enrolled with fleet.
 type Agent struct {
 	ID             string `json:"id"`
@@ -37,11 +41,13 @@ type Agent struct {
 			Agent struct {
 				Version  string `json:"version"`
 				Snapshot bool   `json:"snapshot"`
+				LogLevel string `json:"log_level"`
 			} `json:"agent"`
 		} `json:"elastic"`
 	} `json:"local_metadata"`
-	Status  string                   `json:"status"`
-	Outputs map[string]*PolicyOutput `json:"outputs,omitempty"`
+	Status      string                   `json:"status"`
+	LastCheckin string                   `json:"last_checkin,omitempty"`
+	Outputs     map[string]*PolicyOutput `json:"outputs,omitempty"`
 }
 
 // PolicyOutput holds the needed data to manage the output API keys
@@ -271,43 +277,122 @@ func (c *Client) GetAgentEvents(ctx context.Context, applicationName string, age
 	return fmt.Errorf("no %s events where found for the agent in the %s policy", applicationName, packagePolicyID)
 }
 
-// ListAgents returns the list of agents enrolled with Fleet.
+// ListAgents returns the list of agents enrolled with Fleet, reading all the pages, as Fleet only returns
+// the first one by default
 func (c *Client) ListAgents(ctx context.Context) ([]Agent, error) {
 	span, _ := apm.StartSpanOptions(ctx, "Listing Elastic Agents", "fleet.agents.items", apm.SpanOptions{
 		Parent: apm.SpanFromContext(ctx).TraceContext(),
 	})
 	defer span.End()
 
-	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/agents", FleetAPI))
+	agents := []Agent{}
+	for page := 1; ; page++ {
+		statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/agents?page=%d&perPage=%d", FleetAPI, page, agentsPerPage))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"body":  string(respBody),
+				"error": err,
+			}).Error("Could not get Fleet's online agents")
+			return nil, err
+		}
 
-	if err != nil {
-		log.WithFields(log.Fields{
-			"body":  string(respBody),
-			"error": err,
-		}).Error("Could not get Fleet's online agents")
-		return nil, err
-	}
+		if statusCode != 200 {
+			log.WithFields(log.Fields{
+				"body":       string(respBody),
+				"statusCode": statusCode,
+			}).Error("Could not get Fleet's online agents")
 
-	if statusCode != 200 {
-		log.WithFields(log.Fields{
-			"body":       string(respBody),
-			"error":      err,
-			"statusCode": statusCode,
-		}).Error("Could not get Fleet's online agents")
+			return nil, fmt.Errorf("could not get Fleet's online agents; API status code = %d; response body = %s", statusCode, respBody)
+		}
 
-		return nil, err
-	}
+		var resp struct {
+			Items []Agent `json:"items"`
+			Total int     `json:"total"`
+		}
 
-	var resp struct {
-		Items []Agent `json:"items"`
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return nil, errors.Wrap(err, "could not convert list of agents (response) to JSON")
+		}
+
+		agents = append(agents, resp.Items...)
+		if len(resp.Items) < agentsPerPage || len(agents) >= resp.Total {
+			break
+		}
 	}
 
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, errors.Wrap(err, "could not convert list of agents (response) to JSON")
+	return agents, nil
+}
+
+// ListAgentsByPolicy lists the agents enrolled in a policy, reading all the pages, as the scale
+// scenarios enroll hundreds of them
+func (c *Client) ListAgentsByPolicy(ctx context.Context, policyID string) ([]Agent, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Listing Elastic Agents by policy", "fleet.agents.items-by-policy", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("policyID", policyID)
+	defer span.End()
+
+	kuery := url.QueryEscape(fmt.Sprintf(`policy_id:"%s"`, policyID))
+
+	agents := []Agent{}
+	for page := 1; ; page++ {
+		statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/agents?kuery=%s&page=%d&perPage=%d", FleetAPI, kuery, page, agentsPerPage))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"body":   string(respBody),
+				"error":  err,
+				"policy": policyID,
+			}).Error("Could not get the agents of the policy")
+			return nil, err
+		}
+
+		if statusCode != 200 {
+			log.WithFields(log.Fields{
+				"body":       string(respBody),
+				"policy":     policyID,
+				"statusCode": statusCode,
+			}).Error("Could not get the agents of the policy")
+
+			return nil, fmt.Errorf("could not get the agents of the %s policy. Status code: %d", policyID, statusCode)
+		}
+
+		var resp struct {
+			Items []Agent `json:"items"`
+			Total int     `json:"total"`
+		}
+
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return nil, errors.Wrap(err, "could not convert list of agents (response) to JSON")
+		}
+
+		agents = append(agents, resp.Items...)
+		if len(resp.Items) < agentsPerPage || len(agents) >= resp.Total {
+			break
+		}
 	}
 
-	return resp.Items, nil
+	return agents, nil
+}
 
+// ReassignAgent assigns the agent to a different policy, which the agent applies on its next check-in
+func (c *Client) ReassignAgent(ctx context.Context, agentID string, policyID string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Reassigning Elastic Agent to policy", "fleet.agent.reassign", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("agentID", agentID)
+	span.Context.SetLabel("policyID", policyID)
+	defer span.End()
+
+	reqBody := `{"policy_id": "` + policyID + `"}`
+	statusCode, respBody, err := c.post(ctx, fmt.Sprintf("%s/agents/%s/reassign", FleetAPI, agentID), []byte(reqBody))
+	if err != nil {
+		return err
+	}
+
+	if statusCode != 200 {
+		return fmt.Errorf("could not reassign agent %s to the %s policy; API status code = %d, response body = %s", agentID, policyID, statusCode, respBody)
+	}
+	return nil
 }
 
 // UnEnrollAgent unenrolls agent from fleet
@@ -322,6 +407,17 @@ func (c *Client) UnEnrollAgent(ctx context.Context, hostname string) error {
 		return err
 	}
 
+	return c.UnEnrollAgentByID(ctx, agentID)
+}
+
+// UnEnrollAgentByID unenrolls agent from fleet, revoking its API keys
+func (c *Client) UnEnrollAgentByID(ctx context.Context, agentID string) error {
+	span, _ := apm.StartSpanOptions(ctx, "UnEnrolling Elastic Agent by ID", "fleet.agent.un-enroll-by-id", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("agentID", agentID)
+	defer span.End()
+
 	reqBody := `{"revoke": true}`
 	statusCode, respBody, _ := c.post(ctx, fmt.Sprintf("%s/agents/%s/unenroll", FleetAPI, agentID), []byte(reqBody))
 	if statusCode != 200 {
@@ -330,32 +426,52 @@ func (c *Client) UnEnrollAgent(ctx context.Context, hostname string) error {
 	return nil
 }
 
-// UpgradeAgent upgrades an agent from to version
-func (c *Client) UpgradeAgent(ctx context.Context, hostname string, version string) error {
+// UpgradeAgent upgrades an agent from to version, returning the status code of Fleet's response
+func (c *Client) UpgradeAgent(ctx context.Context, hostname string, version string) (int, error) {
+	return c.UpgradeAgentFromSource(ctx, hostname, version, "")
+}
+
+// UpgradeAgentFromSource upgrades an agent to version, downloading the artifact from the source URI, such as
+// a directory of the host of the agent (file://). An empty source URI means the default one of the agent
+func (c *Client) UpgradeAgentFromSource(ctx context.Context, hostname string, version string, sourceURI string) (int, error) {
 	span, _ := apm.StartSpanOptions(ctx, "Upgrading Elastic Agent by hostname", "fleet.agent.upgrade-by-hostname", apm.SpanOptions{
 		Parent: apm.SpanFromContext(ctx).TraceContext(),
 	})
+	span.Context.SetLabel("sourceURI", sourceURI)
 	defer span.End()
 
 	agentID, err := c.GetAgentIDByHostname(ctx, hostname)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	version = downloads.RemoveCommitFromSnapshot(version)
-	reqBody := `{"version":"` + version + `"}`
+	reqBody := upgradeRequestBody(version, sourceURI)
 
 	statusCode, respBody, err := c.post(ctx, fmt.Sprintf("%s/agents/%s/upgrade", FleetAPI, agentID), []byte(reqBody))
+	if err != nil {
+		return 0, err
+	}
+
+	// Fleet rejects the versions the agent cannot be upgraded to, such as older versions
 	if statusCode != 200 {
 		log.WithFields(log.Fields{
 			"body":           string(respBody),
 			"desiredVersion": version,
-			"error":          err,
 			"statusCode":     statusCode,
 		}).Error("Could not upgrade agent to version")
 
-		return err
+		return statusCode, fmt.Errorf("could not upgrade agent to %s; API status code = %d; response body = %s", version, statusCode, respBody)
+	}
+	return statusCode, nil
+}
+
+// upgradeRequestBody returns the body of the request upgrading an agent to the version, including the source
+// URI of the artifact if not empty
+func upgradeRequestBody(version string, sourceURI string) string {
+	if sourceURI == "" {
+		return `{"version":"` + version + `"}`
 	}
-	return nil
 
+	return `{"version":"` + version + `","source_uri":"` + sourceURI + `"}`
 }
@@ -0,0 +1,23 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradeRequestBody(t *testing.T) {
+	t.Run("The default source of the agent is used without a source URI", func(t *testing.T) {
+		assert.Equal(t, `{"version":"8.4.0-SNAPSHOT"}`, upgradeRequestBody("8.4.0-SNAPSHOT", ""))
+	})
+
+	t.Run("The source URI is sent with the version", func(t *testing.T) {
+		body := upgradeRequestBody("8.4.0-SNAPSHOT", "file:///root/.op/elastic-agent/upgrades/8.4.0-SNAPSHOT")
+
+		assert.Equal(t, `{"version":"8.4.0-SNAPSHOT","source_uri":"file:///root/.op/elastic-agent/upgrades/8.4.0-SNAPSHOT"}`, body)
+	})
+}
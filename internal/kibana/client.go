@@ -11,9 +11,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/Jeffail/gabs/v2"
-	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/auth"
+	"github.com/elastic/e2e-testing/internal/config"
+	"github.com/elastic/e2e-testing/internal/metrics"
+	"github.com/elastic/e2e-testing/internal/utils"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -22,9 +27,9 @@ import (
 
 // Client is responsible for exporting dashboards from Kibana.
 type Client struct {
-	host     string
-	username string
-	password string
+	host             string
+	credentials      auth.Credentials
+	fleetCredentials auth.Credentials
 }
 
 // HTTPHeader representation of a key-value pair to be passed as a HTTP header
@@ -36,13 +41,11 @@ type HTTPHeader struct {
 // NewClient creates a new instance of the client.
 func NewClient() (*Client, error) {
 	host := getBaseURL()
-	username := shell.GetEnv("KIBANA_USERNAME", "admin")
-	password := shell.GetEnv("KIBANA_PASSWORD", "changeme")
 
 	return &Client{
-		host:     host,
-		username: username,
-		password: password,
+		host:             host,
+		credentials:      auth.ForService(auth.KibanaService),
+		fleetCredentials: auth.ForService(auth.FleetService),
 	}, nil
 }
 
@@ -62,6 +65,11 @@ func (c *Client) delete(ctx context.Context, resourcePath string, headers ...HTT
 	return c.sendRequest(ctx, http.MethodDelete, resourcePath, nil, headers...)
 }
 
+// Request sends a request to the Kibana API, for the callers which handle the responses on their own
+func (c *Client) Request(ctx context.Context, method string, resourcePath string, body []byte) (int, []byte, error) {
+	return c.sendRequest(ctx, method, resourcePath, body)
+}
+
 func (c *Client) sendRequest(ctx context.Context, method, resourcePath string, body []byte, headers ...HTTPHeader) (int, []byte, error) {
 	span, _ := apm.StartSpanOptions(ctx, "Sending HTTP request", "http.request."+method, apm.SpanOptions{
 		Parent: apm.SpanFromContext(ctx).TraceContext(),
@@ -84,21 +92,31 @@ func (c *Client) sendRequest(ctx context.Context, method, resourcePath string, b
 
 	u := base.ResolveReference(rel)
 
-	jsonParsed, _ := gabs.ParseJSON([]byte(body))
+	httpLogger := config.Logger(config.HTTPLogger)
 
-	log.WithFields(log.Fields{
+	queryFields := log.Fields{
 		"method":  method,
 		"url":     u,
-		"body":    jsonParsed,
 		"headers": headers,
-	}).Trace("Kibana API Query")
+	}
+	// the bodies are only logged at trace level, as they could be huge
+	if httpLogger.IsLevelEnabled(log.TraceLevel) {
+		queryFields["body"], _ = gabs.ParseJSON(body)
+		httpLogger.WithFields(queryFields).Trace("Kibana API Query")
+	} else {
+		httpLogger.WithFields(queryFields).Debug("Kibana API Query")
+	}
 
 	req, err := http.NewRequest(method, u.String(), reqBody)
 	if err != nil {
 		return 0, nil, errors.Wrapf(err, "could not create %v request to Kibana API resource: %s", method, resourcePath)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	credentials := c.credentials
+	if strings.HasPrefix(resourcePath, FleetAPI) {
+		credentials = c.fleetCredentials
+	}
+	credentials.Apply(req)
 	req.Header.Add("content-type", "application/json")
 	req.Header.Add("kbn-xsrf", fmt.Sprintf("e2e-tests-%s", uuid.New().String()))
 
@@ -106,17 +124,51 @@ func (c *Client) sendRequest(ctx context.Context, method, resourcePath string, b
 		req.Header.Add(header.key, header.value)
 	}
 
-	client := http.Client{}
+	client := http.Client{
+		Transport: metrics.WrapRoundTripper("kibana", utils.WrapRoundTripperWithRateLimiter(http.DefaultTransport)),
+	}
+	started := time.Now()
+	entry := JournalEntry{
+		Timestamp: started.UTC(),
+		Method:    method,
+		URL:       u.RequestURI(),
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
+		entry.Duration = time.Since(started).Seconds()
+		entry.Error = err.Error()
+		recordJournalEntry(entry, body, nil)
+
 		return 0, nil, errors.Wrap(err, "could not send request to Kibana API")
 	}
 
 	defer resp.Body.Close()
-	body, err = ioutil.ReadAll(resp.Body)
+	recordDeprecations(method, u.Path, resp.Header)
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	entry.Duration = time.Since(started).Seconds()
+	entry.StatusCode = resp.StatusCode
 	if err != nil {
+		entry.Error = err.Error()
+		recordJournalEntry(entry, body, nil)
+
 		return resp.StatusCode, nil, errors.Wrap(err, "could not read response body")
 	}
+	recordJournalEntry(entry, body, respBody)
+	validateContract(method, u.Path, body, resp.StatusCode, respBody)
+
+	responseFields := log.Fields{
+		"method":     method,
+		"url":        u,
+		"statusCode": resp.StatusCode,
+	}
+	if httpLogger.IsLevelEnabled(log.TraceLevel) {
+		responseFields["body"] = string(respBody)
+		httpLogger.WithFields(responseFields).Trace("Kibana API Response")
+	} else {
+		httpLogger.WithFields(responseFields).Debug("Kibana API Response")
+	}
 
-	return resp.StatusCode, body, nil
+	return resp.StatusCode, respBody, nil
 }
@@ -0,0 +1,60 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"sync"
+
+	"github.com/elastic/e2e-testing/internal/openapi"
+	log "github.com/sirupsen/logrus"
+)
+
+// contractSpec the Fleet OpenAPI specification the requests and responses are validated against. It is nil
+// if the contract validation is not enabled
+var contractSpec *openapi.Spec
+
+var contractViolations = []string{}
+var contractMutex sync.Mutex
+
+// EnableContractValidation validates the requests sent to the Fleet API, and their responses, against
+// the specification
+func EnableContractValidation(spec *openapi.Spec) {
+	contractMutex.Lock()
+	defer contractMutex.Unlock()
+
+	contractSpec = spec
+}
+
+// ContractViolations returns the violations of the specification found so far, in order
+func ContractViolations() []string {
+	contractMutex.Lock()
+	defer contractMutex.Unlock()
+
+	return append([]string{}, contractViolations...)
+}
+
+// validateContract records the violations of the specification by a request and its response
+func validateContract(method string, path string, request []byte, statusCode int, response []byte) {
+	contractMutex.Lock()
+	defer contractMutex.Unlock()
+
+	if contractSpec == nil {
+		return
+	}
+
+	violations := contractSpec.ValidateRequest(method, path, request)
+	violations = append(violations, contractSpec.ValidateResponse(method, path, statusCode, response)...)
+
+	for _, violation := range violations {
+		log.WithFields(log.Fields{
+			"method":     method,
+			"path":       path,
+			"statusCode": statusCode,
+			"violation":  violation,
+		}).Warn("The Fleet API request does not match the OpenAPI specification")
+	}
+
+	contractViolations = append(contractViolations, violations...)
+}
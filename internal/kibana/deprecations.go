@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/elastic/e2e-testing/internal/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// warningHeaderRegex extracts the text of a Warning header (i.e. 299 Kibana-8.6.0 "the API is deprecated")
+var warningHeaderRegex = regexp.MustCompile(`^\d{3} \S+ "((?:[^"\\]|\\.)*)"`)
+
+// Deprecation represents a deprecation warning sent by Kibana in the responses of an endpoint
+type Deprecation struct {
+	Method   string
+	Endpoint string
+	Message  string
+	Count    int
+}
+
+var deprecations = map[string]*Deprecation{}
+var deprecationsMutex sync.Mutex
+
+// Deprecations returns the deprecation warnings received during the run, sorted by endpoint
+func Deprecations() []Deprecation {
+	deprecationsMutex.Lock()
+	defer deprecationsMutex.Unlock()
+
+	result := []Deprecation{}
+	for _, d := range deprecations {
+		result = append(result, *d)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Endpoint != result[j].Endpoint {
+			return result[i].Endpoint < result[j].Endpoint
+		}
+		if result[i].Method != result[j].Method {
+			return result[i].Method < result[j].Method
+		}
+		return result[i].Message < result[j].Message
+	})
+
+	return result
+}
+
+// recordDeprecations keeps the deprecation warnings in the headers of a response, logging each one the
+// first time it's received, so that the API paths can be updated before they are removed
+func recordDeprecations(method string, path string, header http.Header) {
+	messages := deprecationMessages(header)
+	if len(messages) == 0 {
+		return
+	}
+
+	endpoint := metrics.EndpointOf(path)
+
+	deprecationsMutex.Lock()
+	defer deprecationsMutex.Unlock()
+
+	for _, message := range messages {
+		key := method + " " + endpoint + " " + message
+
+		d, exists := deprecations[key]
+		if !exists {
+			d = &Deprecation{Method: method, Endpoint: endpoint, Message: message}
+			deprecations[key] = d
+
+			log.WithFields(log.Fields{
+				"endpoint": endpoint,
+				"message":  message,
+				"method":   method,
+			}).Warn("Kibana API endpoint is deprecated")
+		}
+		d.Count++
+	}
+}
+
+// deprecationMessages returns the deprecation warnings in the headers: the Warning headers, and the Deprecation
+// header, including the Sunset date if present
+func deprecationMessages(header http.Header) []string {
+	messages := []string{}
+
+	for _, warning := range header.Values("Warning") {
+		matches := warningHeaderRegex.FindStringSubmatch(warning)
+		if len(matches) == 2 {
+			messages = append(messages, strings.ReplaceAll(matches[1], `\"`, `"`))
+		} else {
+			messages = append(messages, warning)
+		}
+	}
+
+	if deprecation := header.Get("Deprecation"); deprecation != "" && deprecation != "false" {
+		message := "deprecated"
+		if deprecation != "true" {
+			message += " since " + deprecation
+		}
+		if sunset := header.Get("Sunset"); sunset != "" {
+			message += ", to be removed on " + sunset
+		}
+		messages = append(messages, message)
+	}
+
+	return messages
+}
@@ -0,0 +1,57 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecationMessages(t *testing.T) {
+	t.Run("Warning headers are parsed", func(t *testing.T) {
+		header := http.Header{}
+		header.Add("Warning", `299 Kibana-8.6.0 "The \"ingest_manager\" API is deprecated"`)
+		header.Add("Warning", "not a standard warning")
+
+		messages := deprecationMessages(header)
+		assert.Equal(t, []string{`The "ingest_manager" API is deprecated`, "not a standard warning"}, messages)
+	})
+
+	t.Run("Deprecation headers include the sunset date", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Deprecation", "true")
+		header.Set("Sunset", "Wed, 11 Nov 2026 23:59:59 GMT")
+
+		messages := deprecationMessages(header)
+		assert.Equal(t, []string{"deprecated, to be removed on Wed, 11 Nov 2026 23:59:59 GMT"}, messages)
+	})
+
+	t.Run("Responses without deprecations return no messages", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+
+		assert.Equal(t, 0, len(deprecationMessages(header)))
+	})
+}
+
+func TestRecordDeprecations(t *testing.T) {
+	header := http.Header{}
+	header.Add("Warning", `299 Kibana-8.6.0 "deprecated"`)
+
+	recordDeprecations("GET", "/api/ingest_manager/agents/5b8a3e9c-2f1d-4c7a-9e6b-0a1b2c3d4e5f", header)
+	recordDeprecations("GET", "/api/ingest_manager/agents/0a1b2c3d-2f1d-4c7a-9e6b-5b8a3e9c4e5f", header)
+
+	found := false
+	for _, d := range Deprecations() {
+		if d.Endpoint == "/api/ingest_manager/agents/{id}" {
+			found = true
+			assert.Equal(t, 2, d.Count)
+			assert.Equal(t, "deprecated", d.Message)
+		}
+	}
+	assert.True(t, found)
+}
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/elastic/e2e-testing/internal/elasticsearch"
 	"github.com/elastic/e2e-testing/internal/shell"
@@ -74,17 +75,36 @@ func NewFleetConfig(token string) (*FleetConfig, error) {
 	return cfg, nil
 }
 
-// Flags bootstrap flags for fleet server
-func (cfg FleetConfig) Flags() []string {
-	flags := []string{
-		"--e", "--force", "--insecure", "--enrollment-token=" + cfg.EnrollmentToken,
-		"--url", cfg.FleetServerURL(),
+// EnrollFlags returns the flags to enroll an agent, followed by the extra flags, which are split by spaces.
+// When the extra flags include the URL of Fleet Server, or the certificate authorities to verify its certificate,
+// they replace the default URL and the insecure mode
+func (cfg FleetConfig) EnrollFlags(extraFlags string) []string {
+	extra := strings.Fields(extraFlags)
+
+	flags := []string{"--e", "--force"}
+	if !hasFlag(extra, "--certificate-authorities") {
+		flags = append(flags, "--insecure")
+	}
+	flags = append(flags, "--enrollment-token="+cfg.EnrollmentToken)
+	if !hasFlag(extra, "--url") {
+		flags = append(flags, "--url", cfg.FleetServerURL())
 	}
 
-	return flags
+	return append(flags, extra...)
 }
 
 // FleetServerURL returns the fleet-server URL in the config
 func (cfg FleetConfig) FleetServerURL() string {
 	return fmt.Sprintf("%s://%s:%d", cfg.FleetServerScheme, cfg.FleetServerURI, cfg.FleetServerPort)
 }
+
+// hasFlag checks if the flag is present in the flags, either followed by its value or in the --flag=value form
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag || strings.HasPrefix(f, flag+"=") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,179 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// FleetServerHost represents the URLs of a Fleet Server, which the agents in the policies using it connect to
+type FleetServerHost struct {
+	ID        string   `json:"id,omitempty"`
+	Name      string   `json:"name"`
+	HostURLs  []string `json:"host_urls"`
+	IsDefault bool     `json:"is_default"`
+	// defined in kibana.yml, so it cannot be changed through the API
+	IsPreconfigured bool `json:"is_preconfigured,omitempty"`
+}
+
+// CreateFleetServerHost registers the URLs of a Fleet Server in Fleet
+func (c *Client) CreateFleetServerHost(ctx context.Context, host FleetServerHost) (FleetServerHost, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Creating Fleet Server host", "fleet.fleet-server-hosts.create", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("name", host.Name)
+	defer span.End()
+
+	reqBody, err := json.Marshal(host)
+	if err != nil {
+		return FleetServerHost{}, errors.Wrap(err, "could not convert Fleet Server host (request) to JSON")
+	}
+
+	statusCode, respBody, err := c.post(ctx, fmt.Sprintf("%s/fleet_server_hosts", FleetAPI), reqBody)
+	if err != nil {
+		return FleetServerHost{}, errors.Wrap(err, "could not create Fleet Server host")
+	}
+
+	if statusCode != 200 {
+		log.WithFields(log.Fields{
+			"body":       string(respBody),
+			"host":       host,
+			"statusCode": statusCode,
+		}).Error("Could not create Fleet Server host")
+
+		return FleetServerHost{}, fmt.Errorf("could not create Fleet Server host; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Item FleetServerHost `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return FleetServerHost{}, errors.Wrap(err, "Unable to convert Fleet Server host to JSON")
+	}
+
+	log.WithFields(log.Fields{
+		"hosts": resp.Item.HostURLs,
+		"id":    resp.Item.ID,
+		"name":  resp.Item.Name,
+	}).Debug("Fleet Server host created")
+
+	return resp.Item, nil
+}
+
+// DeleteFleetServerHost deletes the URLs of a Fleet Server from Fleet
+func (c *Client) DeleteFleetServerHost(ctx context.Context, hostID string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Deleting Fleet Server host", "fleet.fleet-server-hosts.delete", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("hostID", hostID)
+	defer span.End()
+
+	statusCode, respBody, err := c.delete(ctx, fmt.Sprintf("%s/fleet_server_hosts/%s", FleetAPI, hostID))
+	if err != nil {
+		return errors.Wrap(err, "could not delete Fleet Server host")
+	}
+
+	if statusCode != 200 {
+		return fmt.Errorf("could not delete Fleet Server host; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	return nil
+}
+
+// GetDefaultFleetServerHost returns the Fleet Server host used by the policies without a host of their own
+func (c *Client) GetDefaultFleetServerHost(ctx context.Context) (FleetServerHost, error) {
+	hosts, err := c.ListFleetServerHosts(ctx)
+	if err != nil {
+		return FleetServerHost{}, err
+	}
+
+	return findDefaultFleetServerHost(hosts)
+}
+
+// ListFleetServerHosts returns the list of Fleet Server hosts in Fleet
+func (c *Client) ListFleetServerHosts(ctx context.Context) ([]FleetServerHost, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Listing Fleet Server hosts", "fleet.fleet-server-hosts.list", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	defer span.End()
+
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/fleet_server_hosts", FleetAPI))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list Fleet Server hosts")
+	}
+
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not list Fleet Server hosts; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Items []FleetServerHost `json:"items"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "Unable to convert list of Fleet Server hosts to JSON")
+	}
+
+	return resp.Items, nil
+}
+
+// UpdateFleetServerHost updates the URLs of a Fleet Server in Fleet, which changes the policies using it
+func (c *Client) UpdateFleetServerHost(ctx context.Context, host FleetServerHost) (FleetServerHost, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Updating Fleet Server host", "fleet.fleet-server-hosts.update", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("hostID", host.ID)
+	defer span.End()
+
+	if host.IsPreconfigured {
+		return FleetServerHost{}, fmt.Errorf("the %s Fleet Server host is preconfigured in kibana.yml, so it cannot be updated through the API", host.ID)
+	}
+
+	// the ID is part of the path, not of the request
+	hostID := host.ID
+	host.ID = ""
+
+	reqBody, err := json.Marshal(host)
+	if err != nil {
+		return FleetServerHost{}, errors.Wrap(err, "could not convert Fleet Server host (request) to JSON")
+	}
+
+	statusCode, respBody, err := c.put(ctx, fmt.Sprintf("%s/fleet_server_hosts/%s", FleetAPI, hostID), reqBody)
+	if err != nil {
+		return FleetServerHost{}, errors.Wrap(err, "could not update Fleet Server host")
+	}
+
+	if statusCode != 200 {
+		return FleetServerHost{}, fmt.Errorf("could not update Fleet Server host; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Item FleetServerHost `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return FleetServerHost{}, errors.Wrap(err, "Unable to convert Fleet Server host to JSON")
+	}
+
+	return resp.Item, nil
+}
+
+// findDefaultFleetServerHost returns the default Fleet Server host from a list of hosts
+func findDefaultFleetServerHost(hosts []FleetServerHost) (FleetServerHost, error) {
+	for _, host := range hosts {
+		if host.IsDefault {
+			return host, nil
+		}
+	}
+
+	return FleetServerHost{}, fmt.Errorf("there is no default Fleet Server host in Fleet")
+}
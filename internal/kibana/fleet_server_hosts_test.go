@@ -0,0 +1,29 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDefaultFleetServerHost(t *testing.T) {
+	t.Run("The default host is returned", func(t *testing.T) {
+		hosts := []FleetServerHost{
+			{ID: "fleet-server-tls", HostURLs: []string{"https://fleet-server-tls:8220"}},
+			{ID: "fleet-default-fleet-server-host", HostURLs: []string{"http://fleet-server:8220"}, IsDefault: true},
+		}
+
+		host, err := findDefaultFleetServerHost(hosts)
+		assert.Nil(t, err)
+		assert.Equal(t, "fleet-default-fleet-server-host", host.ID)
+	})
+
+	t.Run("No default host", func(t *testing.T) {
+		_, err := findDefaultFleetServerHost([]FleetServerHost{})
+		assert.NotNil(t, err)
+	})
+}
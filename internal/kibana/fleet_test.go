@@ -0,0 +1,35 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrollFlags(t *testing.T) {
+	cfg := FleetConfig{
+		EnrollmentToken:   "token",
+		FleetServerScheme: "http",
+		FleetServerURI:    "fleet-server",
+		FleetServerPort:   8220,
+	}
+
+	t.Run("Default flags enroll in insecure mode", func(t *testing.T) {
+		flags := cfg.EnrollFlags("")
+		assert.Equal(t, []string{"--e", "--force", "--insecure", "--enrollment-token=token", "--url", "http://fleet-server:8220"}, flags)
+	})
+
+	t.Run("Extra flags are split by spaces", func(t *testing.T) {
+		flags := cfg.EnrollFlags("--tag production,linux")
+		assert.Equal(t, []string{"--e", "--force", "--insecure", "--enrollment-token=token", "--url", "http://fleet-server:8220", "--tag", "production,linux"}, flags)
+	})
+
+	t.Run("Certificate authorities and URL replace the defaults", func(t *testing.T) {
+		flags := cfg.EnrollFlags("--url=https://fleet-tls:8220 --certificate-authorities=/ca.crt")
+		assert.Equal(t, []string{"--e", "--force", "--enrollment-token=token", "--url=https://fleet-tls:8220", "--certificate-authorities=/ca.crt"}, flags)
+	})
+}
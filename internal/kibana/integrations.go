@@ -370,6 +370,86 @@ func (c *Client) InstallIntegrationAssets(ctx context.Context, integration Integ
 	return resp.Items.ID, nil
 }
 
+// WaitForIntegrationInstalled waits for Fleet to install the assets of the integration, checking the install status
+// of the package instead of the data streams, as Kibana installs the package asynchronously
+func (c *Client) WaitForIntegrationInstalled(ctx context.Context, integration IntegrationPackage) error {
+	span, _ := apm.StartSpanOptions(ctx, "Waiting for integration to be installed", "fleet.package.wait-for-install", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("package", integration.Name)
+	span.Context.SetLabel("version", integration.Version)
+	defer span.End()
+
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+	exp := utils.GetExponentialBackOff(maxTimeout)
+	retryCount := 1
+
+	installedFn := func() error {
+		status, err := c.getIntegrationInstallStatus(ctx, integration)
+		if err == nil {
+			err = checkIntegrationInstallStatus(integration, status)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"package":     integration.Name,
+				"retry":       retryCount,
+			}).Debug("The integration is not installed yet")
+
+			retryCount++
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"elapsedTime": exp.GetElapsedTime(),
+			"package":     integration.Name,
+			"retries":     retryCount,
+			"version":     integration.Version,
+		}).Debug("The integration is installed")
+		return nil
+	}
+
+	return backoff.Retry(installedFn, exp)
+}
+
+// getIntegrationInstallStatus returns the install status of the version of the integration in Fleet
+func (c *Client) getIntegrationInstallStatus(ctx context.Context, integration IntegrationPackage) (string, error) {
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/epm/packages/%s/%s", FleetAPI, integration.Name, integration.Version))
+	if err != nil {
+		return "", err
+	}
+
+	if statusCode != 200 {
+		return "", fmt.Errorf("could not get the install status of the %s integration; API status code = %d; response body = %s", integration.Name, statusCode, respBody)
+	}
+
+	var resp struct {
+		Item struct {
+			Status string `json:"status"`
+		} `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", errors.Wrap(err, "Unable to convert integration install status to JSON")
+	}
+
+	return resp.Item.Status, nil
+}
+
+// checkIntegrationInstallStatus returns an error while the integration is not installed, which is permanent if
+// its installation failed
+func checkIntegrationInstallStatus(integration IntegrationPackage, status string) error {
+	switch status {
+	case "installed":
+		return nil
+	case "install_failed":
+		return backoff.Permanent(fmt.Errorf("the installation of the %s integration failed", integration.Name))
+	default:
+		return fmt.Errorf("the %s integration is not installed: status = %s", integration.Name, status)
+	}
+}
+
 // IsAgentListedInSecurityApp retrieves the hosts from Endpoint to check if a hostname
 // is listed in the Security App. For that, we will inspect the metadata, and will iterate
 // through the hosts, until we get the proper hostname.
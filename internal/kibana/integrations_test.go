@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIntegrationInstallStatus(t *testing.T) {
+	integration := IntegrationPackage{Name: "system", Version: "1.20.4"}
+
+	t.Run("Installed integration", func(t *testing.T) {
+		err := checkIntegrationInstallStatus(integration, "installed")
+		assert.Nil(t, err)
+	})
+
+	t.Run("Integration being installed", func(t *testing.T) {
+		err := checkIntegrationInstallStatus(integration, "installing")
+		assert.NotNil(t, err)
+
+		_, permanent := err.(*backoff.PermanentError)
+		assert.False(t, permanent, "The installation is retried")
+	})
+
+	t.Run("Failed installation", func(t *testing.T) {
+		err := checkIntegrationInstallStatus(integration, "install_failed")
+		assert.NotNil(t, err)
+
+		_, permanent := err.(*backoff.PermanentError)
+		assert.True(t, permanent, "The installation is not retried")
+	})
+}
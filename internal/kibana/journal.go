@@ -0,0 +1,148 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/io"
+)
+
+// journalMaxBodySize the max size of the bodies which are not JSON, such as HTML error pages, kept in the journal
+const journalMaxBodySize = 4096
+
+// journalRedacted the value replacing the secrets in the journal
+const journalRedacted = "[REDACTED]"
+
+// secretKeysRegex the keys of the JSON documents which values are secrets, such as the enrollment tokens
+var secretKeysRegex = regexp.MustCompile(`(?i)(api_key|password|secret|token)`)
+
+// JournalEntry represents a request sent to the Kibana API and its response, without secrets
+type JournalEntry struct {
+	Timestamp  time.Time   `json:"@timestamp"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Duration   float64     `json:"durationSeconds"`
+	Request    interface{} `json:"request,omitempty"`
+	Response   interface{} `json:"response,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// journal the journal where the requests are recorded. It is nil if no journal was started
+var journal *journalFile
+
+// journalMutex guards the journal, which is replaced between scenarios while requests could be in flight
+var journalMutex sync.Mutex
+
+type journalFile struct {
+	path string
+	file *os.File
+}
+
+// StartJournal records the requests sent to the Kibana API into a file, one JSON document per line, until
+// the journal is stopped. A running journal is stopped first
+func StartJournal(path string) error {
+	err := io.MkdirAll(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	if journal != nil {
+		journal.file.Close()
+	}
+	journal = &journalFile{path: path, file: f}
+
+	return nil
+}
+
+// StopJournal stops recording the requests, returning the path of the journal, or an empty string if
+// there was no journal
+func StopJournal() string {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	if journal == nil {
+		return ""
+	}
+
+	path := journal.path
+	journal.file.Close()
+	journal = nil
+
+	return path
+}
+
+// recordJournalEntry appends the request to the journal, if any, including the sanitized bodies of the request
+// and the response. Failures to record are ignored, as the journal is a troubleshooting aid which must not fail
+// the request
+func recordJournalEntry(entry JournalEntry, request []byte, response []byte) {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	if journal == nil {
+		return
+	}
+
+	entry.Request = sanitizeBody(request)
+	entry.Response = sanitizeBody(response)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	_, _ = journal.file.Write(append(line, '\n'))
+}
+
+// sanitizeBody returns the body to be recorded in the journal, with the values of the secret keys redacted
+// when it's a JSON document, or truncated otherwise
+func sanitizeBody(body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		if len(body) > journalMaxBodySize {
+			return string(body[:journalMaxBodySize]) + "..."
+		}
+		return string(body)
+	}
+
+	return redactSecrets(doc)
+}
+
+func redactSecrets(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if _, isString := child.(string); isString && secretKeysRegex.MatchString(k) {
+				v[k] = journalRedacted
+				continue
+			}
+			v[k] = redactSecrets(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactSecrets(child)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeBody(t *testing.T) {
+	t.Run("Secrets are redacted", func(t *testing.T) {
+		body := []byte(`{"item":{"id":"1","api_key":"c2VjcmV0","policy_id":"p1"},"list":[{"password":"changeme"}]}`)
+
+		sanitized := sanitizeBody(body).(map[string]interface{})
+
+		item := sanitized["item"].(map[string]interface{})
+		assert.Equal(t, journalRedacted, item["api_key"])
+		assert.Equal(t, "p1", item["policy_id"])
+
+		list := sanitized["list"].([]interface{})
+		assert.Equal(t, journalRedacted, list[0].(map[string]interface{})["password"])
+	})
+
+	t.Run("Documents under secret keys are walked", func(t *testing.T) {
+		body := []byte(`{"tokens":{"name":"Test token","api_key":"c2VjcmV0"}}`)
+
+		sanitized := sanitizeBody(body).(map[string]interface{})
+
+		tokens := sanitized["tokens"].(map[string]interface{})
+		assert.Equal(t, "Test token", tokens["name"])
+		assert.Equal(t, journalRedacted, tokens["api_key"])
+	})
+
+	t.Run("Bodies which are not JSON are truncated", func(t *testing.T) {
+		body := []byte(strings.Repeat("a", journalMaxBodySize+10))
+
+		sanitized := sanitizeBody(body).(string)
+		assert.Equal(t, journalMaxBodySize+3, len(sanitized))
+	})
+
+	t.Run("Empty bodies are omitted", func(t *testing.T) {
+		assert.Nil(t, sanitizeBody([]byte{}))
+	})
+}
+
+func TestJournal(t *testing.T) {
+	defer filet.CleanUp(t)
+
+	path := filepath.Join(filet.TmpDir(t, ""), "journals", "scenario.ndjson")
+
+	err := StartJournal(path)
+	assert.Nil(t, err)
+
+	recordJournalEntry(JournalEntry{Method: "GET", URL: "/api/fleet/agents", StatusCode: 200}, nil, []byte(`{"items":[]}`))
+	recordJournalEntry(JournalEntry{Method: "POST", URL: "/api/fleet/agent_policies", StatusCode: 409}, []byte(`{"name":"test-policy"}`), nil)
+
+	assert.Equal(t, path, StopJournal())
+	assert.Equal(t, "", StopJournal())
+
+	// requests sent once the journal is stopped are not recorded
+	recordJournalEntry(JournalEntry{Method: "GET", URL: "/api/status", StatusCode: 200}, nil, nil)
+
+	bytes, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(bytes)), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	entry := JournalEntry{}
+	err = json.Unmarshal([]byte(lines[1]), &entry)
+	assert.Nil(t, err)
+	assert.Equal(t, "POST", entry.Method)
+	assert.Equal(t, 409, entry.StatusCode)
+	assert.Equal(t, "test-policy", entry.Request.(map[string]interface{})["name"])
+	assert.Nil(t, entry.Response)
+}
@@ -0,0 +1,182 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// Output represents a Fleet output, where the agents send the data
+type Output struct {
+	ID                  string   `json:"id,omitempty"`
+	Name                string   `json:"name"`
+	Type                string   `json:"type"` // elasticsearch, logstash
+	Hosts               []string `json:"hosts"`
+	IsDefault           bool     `json:"is_default"`
+	IsDefaultMonitoring bool     `json:"is_default_monitoring"`
+	ConfigYaml          string   `json:"config_yaml,omitempty"`
+	IsPreconfigured     bool     `json:"is_preconfigured,omitempty"` // defined in kibana.yml, so it cannot be changed through the API
+}
+
+// CreateOutput creates a new output in Fleet
+func (c *Client) CreateOutput(ctx context.Context, output Output) (Output, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Creating Fleet output", "fleet.outputs.create", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("type", output.Type)
+	defer span.End()
+
+	reqBody, err := json.Marshal(output)
+	if err != nil {
+		return Output{}, errors.Wrap(err, "could not convert output (request) to JSON")
+	}
+
+	statusCode, respBody, err := c.post(ctx, fmt.Sprintf("%s/outputs", FleetAPI), reqBody)
+	if err != nil {
+		return Output{}, errors.Wrap(err, "could not create Fleet output")
+	}
+
+	if statusCode != 200 {
+		log.WithFields(log.Fields{
+			"body":       string(respBody),
+			"output":     output,
+			"statusCode": statusCode,
+		}).Error("Could not create Fleet output")
+
+		return Output{}, fmt.Errorf("could not create Fleet output; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Item Output `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return Output{}, errors.Wrap(err, "Unable to convert output to JSON")
+	}
+
+	log.WithFields(log.Fields{
+		"hosts": resp.Item.Hosts,
+		"id":    resp.Item.ID,
+		"name":  resp.Item.Name,
+		"type":  resp.Item.Type,
+	}).Debug("Fleet output created")
+
+	return resp.Item, nil
+}
+
+// DeleteOutput deletes an output from Fleet
+func (c *Client) DeleteOutput(ctx context.Context, outputID string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Deleting Fleet output", "fleet.outputs.delete", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("outputID", outputID)
+	defer span.End()
+
+	statusCode, respBody, err := c.delete(ctx, fmt.Sprintf("%s/outputs/%s", FleetAPI, outputID))
+	if err != nil {
+		return errors.Wrap(err, "could not delete Fleet output")
+	}
+
+	if statusCode != 200 {
+		return fmt.Errorf("could not delete Fleet output; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	return nil
+}
+
+// ListOutputs returns the list of outputs in Fleet
+func (c *Client) ListOutputs(ctx context.Context) ([]Output, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Listing Fleet outputs", "fleet.outputs.list", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	defer span.End()
+
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/outputs", FleetAPI))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list Fleet outputs")
+	}
+
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not list Fleet outputs; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Items []Output `json:"items"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "Unable to convert list of outputs to JSON")
+	}
+
+	return resp.Items, nil
+}
+
+// GetDefaultOutput returns the output used by the policies without a data output of their own
+func (c *Client) GetDefaultOutput(ctx context.Context) (Output, error) {
+	outputs, err := c.ListOutputs(ctx)
+	if err != nil {
+		return Output{}, err
+	}
+
+	return findDefaultOutput(outputs)
+}
+
+// UpdateOutput updates an output in Fleet, which changes the policies using it
+func (c *Client) UpdateOutput(ctx context.Context, output Output) (Output, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Updating Fleet output", "fleet.outputs.update", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("outputID", output.ID)
+	defer span.End()
+
+	if output.IsPreconfigured {
+		return Output{}, fmt.Errorf("the %s output is preconfigured in kibana.yml, so it cannot be updated through the API", output.ID)
+	}
+
+	// the ID is part of the path, not of the request
+	outputID := output.ID
+	output.ID = ""
+
+	reqBody, err := json.Marshal(output)
+	if err != nil {
+		return Output{}, errors.Wrap(err, "could not convert output (request) to JSON")
+	}
+
+	statusCode, respBody, err := c.put(ctx, fmt.Sprintf("%s/outputs/%s", FleetAPI, outputID), reqBody)
+	if err != nil {
+		return Output{}, errors.Wrap(err, "could not update Fleet output")
+	}
+
+	if statusCode != 200 {
+		return Output{}, fmt.Errorf("could not update Fleet output; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Item Output `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return Output{}, errors.Wrap(err, "Unable to convert output to JSON")
+	}
+
+	return resp.Item, nil
+}
+
+// findDefaultOutput returns the default output for data from a list of outputs
+func findDefaultOutput(outputs []Output) (Output, error) {
+	for _, output := range outputs {
+		if output.IsDefault {
+			return output, nil
+		}
+	}
+
+	return Output{}, fmt.Errorf("there is no default output in Fleet")
+}
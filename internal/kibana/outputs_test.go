@@ -0,0 +1,29 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDefaultOutput(t *testing.T) {
+	t.Run("The default output is returned", func(t *testing.T) {
+		outputs := []Output{
+			{ID: "logstash", Type: "logstash"},
+			{ID: "fleet-default-output", Type: "elasticsearch", IsDefault: true, IsDefaultMonitoring: true},
+		}
+
+		output, err := findDefaultOutput(outputs)
+		assert.Nil(t, err)
+		assert.Equal(t, "fleet-default-output", output.ID)
+	})
+
+	t.Run("No default output", func(t *testing.T) {
+		_, err := findDefaultOutput([]Output{{ID: "logstash", Type: "logstash"}})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/naming"
+)
+
+// packagePolicySettingsColumns the columns of a table of package policy settings. The dataset and the
+// type are optional: settings without dataset apply to the input, and the type is inferred from the value
+var packagePolicySettingsColumns = []string{"input", "dataset", "variable", "value", "type"}
+
+// PackagePolicySetting represents the value of a variable of an input, or of one of its streams,
+// in a package policy
+type PackagePolicySetting struct {
+	Input    string
+	Dataset  string // the stream of the input, empty for the variables of the input
+	Variable string
+	Value    interface{}
+	Type     string
+}
+
+// ParsePackagePolicySettings converts the rows of a table, the first one being the header, into
+// package policy settings. The header must include the input, variable and value columns
+func ParsePackagePolicySettings(rows [][]string) ([]PackagePolicySetting, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("the table of settings is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		known := false
+		for _, column := range packagePolicySettingsColumns {
+			if name == column {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("the %s column is not valid. Valid columns are: %s", name, strings.Join(packagePolicySettingsColumns, ", "))
+		}
+
+		columns[name] = i
+	}
+
+	for _, required := range []string{"input", "variable", "value"} {
+		if _, exists := columns[required]; !exists {
+			return nil, fmt.Errorf("the table of settings does not have the %s column", required)
+		}
+	}
+
+	cell := func(row []string, column string) string {
+		i, exists := columns[column]
+		if !exists || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	settings := []PackagePolicySetting{}
+	for _, row := range rows[1:] {
+		setting := PackagePolicySetting{
+			Input:    cell(row, "input"),
+			Dataset:  cell(row, "dataset"),
+			Variable: cell(row, "variable"),
+			Type:     cell(row, "type"),
+		}
+		if setting.Input == "" || setting.Variable == "" {
+			return nil, fmt.Errorf("the settings must have an input and a variable: %v", row)
+		}
+
+		setting.Value, setting.Type = parseSettingValue(cell(row, "value"), setting.Type)
+
+		settings = append(settings, setting)
+	}
+
+	return settings, nil
+}
+
+// parseSettingValue converts the value of a setting, inferring its type if it's not set: JSON arrays
+// and objects, booleans and integers are supported, falling back to text
+func parseSettingValue(value string, varType string) (interface{}, string) {
+	if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			if varType == "" {
+				varType = "text"
+			}
+			return v, varType
+		}
+	}
+
+	if (value == "true" || value == "false") && (varType == "" || varType == "bool") {
+		return value == "true", "bool"
+	}
+
+	if i, err := strconv.Atoi(value); err == nil && (varType == "" || varType == "integer") {
+		return i, "integer"
+	}
+
+	if varType == "" {
+		varType = "text"
+	}
+
+	return value, varType
+}
+
+// ApplyPackagePolicySettings sets the variables of the inputs and streams of the package policy, enabling
+// them. Inputs and streams not present in the package policy are added to it
+func ApplyPackagePolicySettings(packageDS *PackageDataStream, settings []PackagePolicySetting) {
+	for _, setting := range settings {
+		input := findOrAddInput(packageDS, setting.Input)
+		input.Enabled = true
+
+		variable := Var{Value: setting.Value, Type: setting.Type}
+
+		if setting.Dataset == "" {
+			if input.Vars == nil {
+				input.Vars = Vars{}
+			}
+			input.Vars[setting.Variable] = variable
+			continue
+		}
+
+		stream := findOrAddStream(input, setting.Dataset)
+		stream.Enabled = true
+		if stream.Vars == nil {
+			stream.Vars = Vars{}
+		}
+		stream.Vars[setting.Variable] = variable
+	}
+}
+
+func findOrAddInput(packageDS *PackageDataStream, inputType string) *Input {
+	for i := range packageDS.Inputs {
+		if packageDS.Inputs[i].Type == inputType {
+			return &packageDS.Inputs[i]
+		}
+	}
+
+	packageDS.Inputs = append(packageDS.Inputs, Input{Type: inputType, Streams: []Stream{}})
+	return &packageDS.Inputs[len(packageDS.Inputs)-1]
+}
+
+func findOrAddStream(input *Input, dataset string) *Stream {
+	for i := range input.Streams {
+		if input.Streams[i].DS.Dataset == dataset {
+			return &input.Streams[i]
+		}
+	}
+
+	// metrics inputs are suffixed with their type, i.e. linux/metrics, the rest of them send logs
+	dsType := "logs"
+	if strings.HasSuffix(input.Type, "/metrics") {
+		dsType = "metrics"
+	}
+
+	input.Streams = append(input.Streams, Stream{
+		ID: naming.Name(input.Type + "-" + dataset),
+		DS: DataStream{
+			Dataset: dataset,
+			Type:    dsType,
+		},
+	})
+	return &input.Streams[len(input.Streams)-1]
+}
@@ -0,0 +1,100 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePackagePolicySettings(t *testing.T) {
+	t.Run("Values are converted to their inferred type", func(t *testing.T) {
+		settings, err := ParsePackagePolicySettings([][]string{
+			{"input", "dataset", "variable", "value"},
+			{"linux/metrics", "linux.memory", "period", "10s"},
+			{"logfile", "log.log", "paths", `["/tmp/a.log", "/tmp/b.log"]`},
+			{"apm", "", "enabled", "true"},
+			{"apm", "", "max_connections", "0"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []PackagePolicySetting{
+			{Input: "linux/metrics", Dataset: "linux.memory", Variable: "period", Value: "10s", Type: "text"},
+			{Input: "logfile", Dataset: "log.log", Variable: "paths", Value: []interface{}{"/tmp/a.log", "/tmp/b.log"}, Type: "text"},
+			{Input: "apm", Variable: "enabled", Value: true, Type: "bool"},
+			{Input: "apm", Variable: "max_connections", Value: 0, Type: "integer"},
+		}, settings)
+	})
+
+	t.Run("Explicit types are kept", func(t *testing.T) {
+		settings, err := ParsePackagePolicySettings([][]string{
+			{"input", "variable", "value", "type"},
+			{"apm", "host", "localhost:8200", "string"},
+			{"apm", "port", "8200", "text"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "string", settings[0].Type)
+		assert.Equal(t, "8200", settings[1].Value)
+		assert.Equal(t, "text", settings[1].Type)
+	})
+
+	t.Run("Unknown columns are not valid", func(t *testing.T) {
+		_, err := ParsePackagePolicySettings([][]string{{"input", "variable", "value", "stream"}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("The value column is required", func(t *testing.T) {
+		_, err := ParsePackagePolicySettings([][]string{{"input", "variable"}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Settings without variable are not valid", func(t *testing.T) {
+		_, err := ParsePackagePolicySettings([][]string{
+			{"input", "variable", "value"},
+			{"apm", "", "foo"},
+		})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestApplyPackagePolicySettings(t *testing.T) {
+	packageDS := PackageDataStream{
+		Inputs: []Input{
+			{
+				Type: "linux/metrics",
+				Streams: []Stream{
+					{
+						ID: "linux/metrics-linux.memory",
+						DS: DataStream{Dataset: "linux.memory", Type: "metrics"},
+						Vars: Vars{
+							"period": {Value: "1s", Type: "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ApplyPackagePolicySettings(&packageDS, []PackagePolicySetting{
+		{Input: "linux/metrics", Dataset: "linux.memory", Variable: "period", Value: "10s", Type: "text"},
+		{Input: "linux/metrics", Dataset: "linux.load", Variable: "period", Value: "5s", Type: "text"},
+		{Input: "logfile", Variable: "tags", Value: []interface{}{"e2e"}, Type: "text"},
+	})
+
+	assert.Len(t, packageDS.Inputs, 2)
+
+	metrics := packageDS.Inputs[0]
+	assert.True(t, metrics.Enabled)
+	assert.Len(t, metrics.Streams, 2)
+	assert.Equal(t, Var{Value: "10s", Type: "text"}, metrics.Streams[0].Vars["period"])
+	assert.True(t, metrics.Streams[1].Enabled)
+	assert.Equal(t, DataStream{Dataset: "linux.load", Type: "metrics"}, metrics.Streams[1].DS)
+	assert.Equal(t, Var{Value: "5s", Type: "text"}, metrics.Streams[1].Vars["period"])
+
+	logfile := packageDS.Inputs[1]
+	assert.Equal(t, "logfile", logfile.Type)
+	assert.True(t, logfile.Enabled)
+	assert.Equal(t, Var{Value: []interface{}{"e2e"}, Type: "text"}, logfile.Vars["tags"])
+}
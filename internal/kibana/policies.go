@@ -8,10 +8,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
-	"github.com/google/uuid"
+	"github.com/elastic/e2e-testing/internal/naming"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"go.elastic.co/apm"
@@ -27,15 +29,23 @@ var FleetServicePolicy = Policy{
 
 // Policy represents an Ingest Manager policy.
 type Policy struct {
-	ID                   string `json:"id,omitempty"`
-	Name                 string `json:"name"`
-	Description          string `json:"description"`
-	Namespace            string `json:"namespace"`
-	IsDefault            bool   `json:"is_default"`
-	IsManaged            bool   `json:"is_managed"`
-	IsDefaultFleetServer bool   `json:"is_default_fleet_server"`
-	AgentsCount          int    `json:"agents"` // Number of agents connected to Policy
-	Status               string `json:"status"`
+	ID                   string   `json:"id,omitempty"`
+	Name                 string   `json:"name"`
+	Description          string   `json:"description"`
+	Namespace            string   `json:"namespace"`
+	IsDefault            bool     `json:"is_default"`
+	IsManaged            bool     `json:"is_managed"`
+	IsDefaultFleetServer bool     `json:"is_default_fleet_server"`
+	AgentsCount          int      `json:"agents"` // Number of agents connected to Policy
+	Status               string   `json:"status"`
+	DataOutputID         string   `json:"data_output_id,omitempty"`
+	MonitoringOutputID   string   `json:"monitoring_output_id,omitempty"`
+	MonitoringEnabled    []string `json:"monitoring_enabled,omitempty"` // logs and/or metrics of the agents
+	Revision             int      `json:"revision,omitempty"`           // increased by Fleet on every change of the policy
+	InactivityTimeout    int      `json:"inactivity_timeout,omitempty"` // seconds without checking in to be inactive
+	UnenrollTimeout      int      `json:"unenroll_timeout,omitempty"`   // seconds without checking in to be unenrolled
+	IsProtected          bool     `json:"is_protected,omitempty"`       // agents require the uninstall token to be removed
+	FleetServerHostID    string   `json:"fleet_server_host_id,omitempty"`
 }
 
 // GetDefaultPolicy gets the default policy or optionally the default fleet policy
@@ -63,6 +73,40 @@ func (c *Client) GetDefaultPolicy(ctx context.Context, fleetServer bool) (Policy
 	return Policy{}, errors.New("Could not obtain default policy")
 }
 
+// GetPolicy returns a policy by its ID
+func (c *Client) GetPolicy(ctx context.Context, policyID string) (Policy, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Getting Elastic Agent policy", "fleet.agent-policies.get", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("policyID", policyID)
+	defer span.End()
+
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/agent_policies/%s", FleetAPI, policyID))
+	if err != nil {
+		log.WithFields(log.Fields{
+			"body":       string(respBody),
+			"error":      err,
+			"policyID":   policyID,
+			"statusCode": statusCode,
+		}).Error("Could not get policy response")
+		return Policy{}, err
+	}
+
+	if statusCode != 200 {
+		return Policy{}, fmt.Errorf("could not get the %s policy; API status code = %d; response body = %s", policyID, statusCode, respBody)
+	}
+
+	var resp struct {
+		Item Policy `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return Policy{}, errors.Wrap(err, "could not convert policy (response) to JSON")
+	}
+
+	return resp.Item, nil
+}
+
 // ListPolicies returns the list of policies
 func (c *Client) ListPolicies(ctx context.Context) ([]Policy, error) {
 	span, _ := apm.StartSpanOptions(ctx, "Listing Elastic Agent policies", "fleet.agent-policies.list", apm.SpanOptions{
@@ -100,6 +144,39 @@ func (c *Client) ListPolicies(ctx context.Context) ([]Policy, error) {
 	return resp.Items, nil
 }
 
+// ListPoliciesByName returns the policies which name matches the regular expression
+func (c *Client) ListPoliciesByName(ctx context.Context, namePattern string) ([]Policy, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Listing Elastic Agent policies by name", "fleet.agent-policies.list-by-name", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("namePattern", namePattern)
+	defer span.End()
+
+	policies, err := c.ListPolicies(ctx)
+	if err != nil {
+		return []Policy{}, err
+	}
+
+	return filterPoliciesByName(policies, namePattern)
+}
+
+// filterPoliciesByName returns the policies which name matches the regular expression
+func filterPoliciesByName(policies []Policy, namePattern string) ([]Policy, error) {
+	re, err := regexp.Compile(namePattern)
+	if err != nil {
+		return []Policy{}, errors.Wrapf(err, "invalid name pattern for policies: %s", namePattern)
+	}
+
+	filtered := []Policy{}
+	for _, policy := range policies {
+		if re.MatchString(policy.Name) {
+			filtered = append(filtered, policy)
+		}
+	}
+
+	return filtered, nil
+}
+
 // DeleteAllPolicies deletes all policies except fleet_server and system
 func (c *Client) DeleteAllPolicies(ctx context.Context) {
 	span, _ := apm.StartSpanOptions(ctx, "Deleting all agent policy", "fleet.package-policies.delete", apm.SpanOptions{
@@ -129,20 +206,45 @@ func (c *Client) DeleteAllPolicies(ctx context.Context) {
 	}
 }
 
+// DeletePolicy deletes an agent policy. Fleet refuses to delete policies with enrolled agents
+func (c *Client) DeletePolicy(ctx context.Context, policyID string) error {
+	span, _ := apm.StartSpanOptions(ctx, "Deleting agent policy", "fleet.agent-policies.delete", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("policyID", policyID)
+	defer span.End()
+
+	reqBody := `{"agentPolicyId": "` + policyID + `"}`
+	statusCode, respBody, err := c.post(ctx, fmt.Sprintf("%s/agent_policies/delete", FleetAPI), []byte(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "could not delete policy")
+	}
+
+	if statusCode != 200 {
+		return fmt.Errorf("could not delete policy; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	return nil
+}
+
 // CreatePolicy creates a new policy for agent to utilize
 func (c *Client) CreatePolicy(ctx context.Context) (Policy, error) {
+	return c.CreatePolicyWithName(ctx, naming.Name("test-policy"))
+}
+
+// CreatePolicyWithName creates a new policy with the given name, which must be unique in Fleet
+func (c *Client) CreatePolicyWithName(ctx context.Context, policyName string) (Policy, error) {
 	span, _ := apm.StartSpanOptions(ctx, "Creating agent policy", "fleet.package-policies.create", apm.SpanOptions{
 		Parent: apm.SpanFromContext(ctx).TraceContext(),
 	})
+	span.Context.SetLabel("policyName", policyName)
 	defer span.End()
 
-	policyUUID := uuid.New().String()
-
 	reqBody := `{
-		"description": "Test policy ` + policyUUID + `",
+		"description": "Test policy ` + policyName + `",
 		"namespace": "default",
 		"monitoring_enabled": ["logs", "metrics"],
-		"name": "test-policy-` + policyUUID + `"
+		"name": "` + policyName + `"
 	}`
 
 	statusCode, respBody, _ := c.post(ctx, fmt.Sprintf("%s/agent_policies", FleetAPI), []byte(reqBody))
@@ -183,6 +285,184 @@ func (c *Client) CreatePolicy(ctx context.Context) (Policy, error) {
 	return resp.Item, nil
 }
 
+// UpdatePolicyOutputs sets the outputs used by the agents in the policy to send data and monitoring data.
+// An empty output ID means the default output in Fleet will be used
+func (c *Client) UpdatePolicyOutputs(ctx context.Context, policy Policy, dataOutputID string, monitoringOutputID string) (Policy, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Updating agent policy outputs", "fleet.agent-policies.update-outputs", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("dataOutputID", dataOutputID)
+	span.Context.SetLabel("monitoringOutputID", monitoringOutputID)
+	defer span.End()
+
+	type policyOutputsRequest struct {
+		Name               string  `json:"name"`
+		Description        string  `json:"description"`
+		Namespace          string  `json:"namespace"`
+		DataOutputID       *string `json:"data_output_id"`
+		MonitoringOutputID *string `json:"monitoring_output_id"`
+	}
+
+	req := policyOutputsRequest{
+		Name:        policy.Name,
+		Description: policy.Description,
+		Namespace:   policy.Namespace,
+	}
+	// a null value resets the output to the default one
+	if dataOutputID != "" {
+		req.DataOutputID = &dataOutputID
+	}
+	if monitoringOutputID != "" {
+		req.MonitoringOutputID = &monitoringOutputID
+	}
+
+	return c.updatePolicy(ctx, policy.ID, "outputs", req)
+}
+
+// UpdatePolicyMonitoring sets the monitoring data (logs and/or metrics) that the agents in the policy collect
+// about themselves. An empty list disables the monitoring of the agents
+func (c *Client) UpdatePolicyMonitoring(ctx context.Context, policy Policy, monitoringEnabled []string) (Policy, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Updating agent policy monitoring", "fleet.agent-policies.update-monitoring", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("monitoringEnabled", strings.Join(monitoringEnabled, ","))
+	defer span.End()
+
+	type policyMonitoringRequest struct {
+		Name              string   `json:"name"`
+		Description       string   `json:"description"`
+		Namespace         string   `json:"namespace"`
+		MonitoringEnabled []string `json:"monitoring_enabled"`
+	}
+
+	req := policyMonitoringRequest{
+		Name:              policy.Name,
+		Description:       policy.Description,
+		Namespace:         policy.Namespace,
+		MonitoringEnabled: monitoringEnabled,
+	}
+	if req.MonitoringEnabled == nil {
+		req.MonitoringEnabled = []string{}
+	}
+
+	return c.updatePolicy(ctx, policy.ID, "monitoring", req)
+}
+
+// UpdatePolicyTimeouts sets the seconds after which Fleet considers the agents in the policy inactive, and
+// unenrolls them, if they do not check in. A zero timeout leaves it unchanged
+func (c *Client) UpdatePolicyTimeouts(ctx context.Context, policy Policy, inactivityTimeout int, unenrollTimeout int) (Policy, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Updating agent policy timeouts", "fleet.agent-policies.update-timeouts", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("inactivityTimeout", inactivityTimeout)
+	span.Context.SetLabel("unenrollTimeout", unenrollTimeout)
+	defer span.End()
+
+	type policyTimeoutsRequest struct {
+		Name              string `json:"name"`
+		Description       string `json:"description"`
+		Namespace         string `json:"namespace"`
+		InactivityTimeout int    `json:"inactivity_timeout,omitempty"`
+		UnenrollTimeout   int    `json:"unenroll_timeout,omitempty"`
+	}
+
+	req := policyTimeoutsRequest{
+		Name:              policy.Name,
+		Description:       policy.Description,
+		Namespace:         policy.Namespace,
+		InactivityTimeout: inactivityTimeout,
+		UnenrollTimeout:   unenrollTimeout,
+	}
+
+	return c.updatePolicy(ctx, policy.ID, "timeouts", req)
+}
+
+// UpdatePolicyProtection enables or disables the tamper protection of the agents in the policy, which
+// prevents uninstalling them without the uninstall token of the policy
+func (c *Client) UpdatePolicyProtection(ctx context.Context, policy Policy, protected bool) (Policy, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Updating agent policy protection", "fleet.agent-policies.update-protection", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("protected", protected)
+	defer span.End()
+
+	type policyProtectionRequest struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Namespace   string `json:"namespace"`
+		IsProtected bool   `json:"is_protected"`
+	}
+
+	req := policyProtectionRequest{
+		Name:        policy.Name,
+		Description: policy.Description,
+		Namespace:   policy.Namespace,
+		IsProtected: protected,
+	}
+
+	return c.updatePolicy(ctx, policy.ID, "protection", req)
+}
+
+// UpdatePolicyFleetServerHost sets the Fleet Server host the agents in the policy connect to
+func (c *Client) UpdatePolicyFleetServerHost(ctx context.Context, policy Policy, fleetServerHostID string) (Policy, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Updating agent policy Fleet Server host", "fleet.agent-policies.update-fleet-server-host", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("fleetServerHostID", fleetServerHostID)
+	defer span.End()
+
+	type policyFleetServerHostRequest struct {
+		Name              string `json:"name"`
+		Description       string `json:"description"`
+		Namespace         string `json:"namespace"`
+		FleetServerHostID string `json:"fleet_server_host_id"`
+	}
+
+	req := policyFleetServerHostRequest{
+		Name:              policy.Name,
+		Description:       policy.Description,
+		Namespace:         policy.Namespace,
+		FleetServerHostID: fleetServerHostID,
+	}
+
+	return c.updatePolicy(ctx, policy.ID, "Fleet Server host", req)
+}
+
+// updatePolicy sends the request to update the policy, which includes the settings described by the subject,
+// returning the updated policy
+func (c *Client) updatePolicy(ctx context.Context, policyID string, subject string, req interface{}) (Policy, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return Policy{}, errors.Wrapf(err, "could not convert policy %s (request) to JSON", subject)
+	}
+
+	statusCode, respBody, err := c.put(ctx, fmt.Sprintf("%s/agent_policies/%s", FleetAPI, policyID), reqBody)
+	if err != nil {
+		return Policy{}, errors.Wrapf(err, "could not update policy %s", subject)
+	}
+
+	if statusCode != 200 {
+		log.WithFields(log.Fields{
+			"body":       string(respBody),
+			"policy":     policyID,
+			"reqBody":    string(reqBody),
+			"statusCode": statusCode,
+		}).Errorf("Could not update policy %s", subject)
+
+		return Policy{}, fmt.Errorf("could not update policy %s; API status code = %d; response body = %s", subject, statusCode, respBody)
+	}
+
+	var resp struct {
+		Item Policy `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return Policy{}, errors.Wrap(err, "Unable to convert updated policy to JSON")
+	}
+
+	return resp.Item, nil
+}
+
 // Var represents a single variable at the package or
 // data stream level, encapsulating the data type of the
 // variable and it's value.
@@ -238,6 +518,60 @@ type Stream struct {
 	Vars    Vars       `json:"vars,omitempty"`
 }
 
+// packagePoliciesPerPage the number of package policies requested per page when listing them
+const packagePoliciesPerPage = 100
+
+// ListPackagePoliciesByPolicy lists the package policies of a policy, filtering them in Fleet and reading all
+// the pages, as the first page of the package policies of all the policies could miss them
+func (c *Client) ListPackagePoliciesByPolicy(ctx context.Context, policyID string) ([]PackageDataStream, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Listing package policies by policy", "fleet.package-policies.items-by-policy", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("policyID", policyID)
+	defer span.End()
+
+	kuery := url.QueryEscape(fmt.Sprintf(`ingest-package-policies.policy_id:"%s"`, policyID))
+
+	packagePolicies := []PackageDataStream{}
+	for page := 1; ; page++ {
+		statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/package_policies?kuery=%s&page=%d&perPage=%d", FleetAPI, kuery, page, packagePoliciesPerPage))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"body":   string(respBody),
+				"error":  err,
+				"policy": policyID,
+			}).Error("Could not get the package policies of the policy")
+			return nil, err
+		}
+
+		if statusCode != 200 {
+			log.WithFields(log.Fields{
+				"body":       string(respBody),
+				"policy":     policyID,
+				"statusCode": statusCode,
+			}).Error("Could not get the package policies of the policy")
+
+			return nil, fmt.Errorf("could not get the package policies of the %s policy; API status code = %d; response body = %s", policyID, statusCode, respBody)
+		}
+
+		var resp struct {
+			Items []PackageDataStream `json:"items"`
+			Total int                 `json:"total"`
+		}
+
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return nil, errors.Wrap(err, "Unable to convert list of package policies to JSON")
+		}
+
+		packagePolicies = append(packagePolicies, resp.Items...)
+		if len(resp.Items) < packagePoliciesPerPage || len(packagePolicies) >= resp.Total {
+			break
+		}
+	}
+
+	return packagePolicies, nil
+}
+
 // ListPackagePolicies return list of package policies
 func (c *Client) ListPackagePolicies(ctx context.Context) ([]PackageDataStream, error) {
 	span, _ := apm.StartSpanOptions(ctx, "Listing package policies", "fleet.package-policies.items", apm.SpanOptions{
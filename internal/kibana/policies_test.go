@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterPoliciesByName(t *testing.T) {
+	policies := []Policy{
+		{ID: "1", Name: "Default policy"},
+		{ID: "2", Name: "test-policy-1a2b3c-1"},
+		{ID: "3", Name: "test-policy-4d5e6f-1"},
+	}
+
+	t.Run("Matching policies are returned", func(t *testing.T) {
+		filtered, err := filterPoliciesByName(policies, "-1a2b3c-[0-9]+")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(filtered))
+		assert.Equal(t, "2", filtered[0].ID)
+	})
+
+	t.Run("No policies are returned when none matches", func(t *testing.T) {
+		filtered, err := filterPoliciesByName(policies, "^Production")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(filtered))
+	})
+
+	t.Run("An invalid pattern returns an error", func(t *testing.T) {
+		_, err := filterPoliciesByName(policies, "(")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,217 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// SavedObjectReference represents a reference to a saved object in Kibana, such as the visualizations and index
+// patterns of a dashboard
+type SavedObjectReference struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// SavedObject represents a saved object in Kibana, including the error if it could not be retrieved
+type SavedObject struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	References []SavedObjectReference `json:"references"`
+	Error      *struct {
+		StatusCode int    `json:"statusCode"`
+		Message    string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// BrokenReference represents a reference from a saved object to another one which does not exist. References
+// without origin point to the saved objects which were expected to exist, such as the assets of a package
+type BrokenReference struct {
+	From    SavedObjectReference
+	To      SavedObjectReference
+	Message string
+}
+
+// String returns a readable representation of the broken reference
+func (br BrokenReference) String() string {
+	if br.From.ID == "" {
+		return fmt.Sprintf("%s %s (%s)", br.To.Type, br.To.ID, br.Message)
+	}
+
+	return fmt.Sprintf("%s %s -> %s %s (%s)", br.From.Type, br.From.ID, br.To.Type, br.To.ID, br.Message)
+}
+
+// GetInstalledKibanaAssets returns the references to the Kibana saved objects installed by an integration
+func (c *Client) GetInstalledKibanaAssets(ctx context.Context, integration IntegrationPackage) ([]SavedObjectReference, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Getting installed Kibana assets", "fleet.package.installed-kibana-assets", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("package", integration.Name)
+	defer span.End()
+
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/epm/packages/%s/%s", FleetAPI, integration.Name, integration.Version))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get integration")
+	}
+
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not get integration; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	// the installation info moved out of the saved object of the package in recent versions of Kibana
+	var resp struct {
+		Item struct {
+			InstallationInfo struct {
+				InstalledKibana []SavedObjectReference `json:"installed_kibana"`
+			} `json:"installationInfo"`
+			SavedObject struct {
+				Attributes struct {
+					InstalledKibana []SavedObjectReference `json:"installed_kibana"`
+				} `json:"attributes"`
+			} `json:"savedObject"`
+		} `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "Unable to convert integration to JSON")
+	}
+
+	if len(resp.Item.InstallationInfo.InstalledKibana) > 0 {
+		return resp.Item.InstallationInfo.InstalledKibana, nil
+	}
+
+	return resp.Item.SavedObject.Attributes.InstalledKibana, nil
+}
+
+// BulkGetSavedObjects retrieves the saved objects, in the same order as the references. The saved objects
+// which could not be retrieved include the error
+func (c *Client) BulkGetSavedObjects(ctx context.Context, refs []SavedObjectReference) ([]SavedObject, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Bulk getting saved objects", "kibana.saved-objects.bulk-get", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("count", len(refs))
+	defer span.End()
+
+	type objectRequest struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+
+	req := []objectRequest{}
+	for _, ref := range refs {
+		req = append(req, objectRequest{ID: ref.ID, Type: ref.Type})
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not convert saved objects (request) to JSON")
+	}
+
+	statusCode, respBody, err := c.post(ctx, "/api/saved_objects/_bulk_get", reqBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get saved objects")
+	}
+
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not get saved objects; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		SavedObjects []SavedObject `json:"saved_objects"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "Unable to convert saved objects to JSON")
+	}
+
+	return resp.SavedObjects, nil
+}
+
+// FindBrokenReferences resolves the references of the saved objects, and the references of the referenced objects,
+// returning the ones pointing to objects which do not exist
+func (c *Client) FindBrokenReferences(ctx context.Context, roots []SavedObjectReference) ([]BrokenReference, error) {
+	broken := []BrokenReference{}
+	visited := map[string]bool{}
+
+	pending := map[SavedObjectReference][]SavedObjectReference{}
+	for _, root := range roots {
+		key := SavedObjectReference{ID: root.ID, Type: root.Type}
+		pending[key] = append(pending[key], SavedObjectReference{})
+	}
+
+	for len(pending) > 0 {
+		refs := []SavedObjectReference{}
+		for ref := range pending {
+			visited[ref.Type+":"+ref.ID] = true
+			refs = append(refs, ref)
+		}
+
+		objects, err := c.BulkGetSavedObjects(ctx, refs)
+		if err != nil {
+			return nil, err
+		}
+
+		referrers := pending
+		pending = map[SavedObjectReference][]SavedObjectReference{}
+
+		for i, object := range objects {
+			if i >= len(refs) {
+				break
+			}
+
+			if object.Error != nil {
+				for _, from := range referrers[refs[i]] {
+					broken = append(broken, BrokenReference{From: from, To: refs[i], Message: object.Error.Message})
+				}
+				continue
+			}
+
+			from := SavedObjectReference{ID: object.ID, Type: object.Type}
+			for _, next := range nextReferences(object, visited) {
+				if !containsReference(pending[next], from) {
+					pending[next] = append(pending[next], from)
+				}
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"broken":  len(broken),
+		"objects": len(visited),
+	}).Debug("References of the saved objects resolved")
+
+	return broken, nil
+}
+
+// nextReferences returns the references of the saved object which were not resolved yet, without their names
+func nextReferences(object SavedObject, visited map[string]bool) []SavedObjectReference {
+	refs := []SavedObjectReference{}
+	for _, ref := range object.References {
+		if visited[ref.Type+":"+ref.ID] {
+			continue
+		}
+
+		refs = append(refs, SavedObjectReference{ID: ref.ID, Type: ref.Type})
+	}
+
+	return refs
+}
+
+func containsReference(refs []SavedObjectReference, ref SavedObjectReference) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+
+	return false
+}
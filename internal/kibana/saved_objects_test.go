@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// savedObjectsServer serves the bulk get API of saved objects from the objects, keyed by type and ID
+func savedObjectsServer(objects map[string]SavedObject) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req []SavedObjectReference
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		resp := struct {
+			SavedObjects []map[string]interface{} `json:"saved_objects"`
+		}{}
+		for _, ref := range req {
+			object, exists := objects[ref.Type+":"+ref.ID]
+			if !exists {
+				resp.SavedObjects = append(resp.SavedObjects, map[string]interface{}{
+					"id":    ref.ID,
+					"type":  ref.Type,
+					"error": map[string]interface{}{"statusCode": 404, "message": "Not Found"},
+				})
+				continue
+			}
+
+			resp.SavedObjects = append(resp.SavedObjects, map[string]interface{}{
+				"id":         object.ID,
+				"type":       object.Type,
+				"references": object.References,
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestFindBrokenReferences(t *testing.T) {
+	server := savedObjectsServer(map[string]SavedObject{
+		"dashboard:system-overview": {
+			ID:   "system-overview",
+			Type: "dashboard",
+			References: []SavedObjectReference{
+				{ID: "system-cpu", Name: "panel_0", Type: "visualization"},
+				{ID: "system-memory", Name: "panel_1", Type: "visualization"},
+			},
+		},
+		"visualization:system-cpu": {
+			ID:   "system-cpu",
+			Type: "visualization",
+			References: []SavedObjectReference{
+				{ID: "metrics-*", Name: "kibanaSavedObjectMeta.searchSourceJSON.index", Type: "index-pattern"},
+			},
+		},
+		"visualization:system-memory": {
+			ID:   "system-memory",
+			Type: "visualization",
+			References: []SavedObjectReference{
+				{ID: "metrics-*", Name: "kibanaSavedObjectMeta.searchSourceJSON.index", Type: "index-pattern"},
+				{ID: "system-overview", Name: "link", Type: "dashboard"},
+			},
+		},
+	})
+	defer server.Close()
+
+	client := &Client{host: server.URL}
+
+	t.Run("References to missing objects are broken", func(t *testing.T) {
+		broken, err := client.FindBrokenReferences(context.Background(), []SavedObjectReference{
+			{ID: "system-overview", Type: "dashboard"},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, broken, 2)
+		for _, b := range broken {
+			assert.Equal(t, SavedObjectReference{ID: "metrics-*", Type: "index-pattern"}, b.To)
+			assert.Equal(t, "visualization", b.From.Type)
+			assert.Equal(t, "Not Found", b.Message)
+		}
+	})
+
+	t.Run("Missing roots are broken", func(t *testing.T) {
+		broken, err := client.FindBrokenReferences(context.Background(), []SavedObjectReference{
+			{ID: "system-network", Type: "dashboard"},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, broken, 1)
+		assert.Equal(t, "dashboard system-network (Not Found)", broken[0].String())
+	})
+}
+
+func TestBrokenReferenceString(t *testing.T) {
+	broken := BrokenReference{
+		From:    SavedObjectReference{ID: "system-cpu", Type: "visualization"},
+		To:      SavedObjectReference{ID: "metrics-*", Type: "index-pattern"},
+		Message: "Not Found",
+	}
+
+	assert.Equal(t, "visualization system-cpu -> index-pattern metrics-* (Not Found)", broken.String())
+}
@@ -8,16 +8,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/Jeffail/gabs/v2"
-	"github.com/cenkalti/backoff/v4"
 	"github.com/elastic/e2e-testing/internal/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"go.elastic.co/apm"
 )
 
+// enrollmentAPIKeysPerPage the page size used when listing the enrollment api keys
+const enrollmentAPIKeysPerPage = 100
+
+// TestTokenNamePrefix the prefix of the name of the enrollment api keys created by the tests
+const TestTokenNamePrefix = "Test token for "
+
 // EnrollmentAPIKey struct for holding enrollment response
 type EnrollmentAPIKey struct {
 	Active   bool   `json:"active"`
@@ -35,7 +42,8 @@ func (c *Client) CreateEnrollmentAPIKey(ctx context.Context, policy Policy) (Enr
 	})
 	defer span.End()
 
-	reqBody := `{"policy_id": "` + policy.ID + `"}`
+	// named after the policy, so that the tokens left behind by previous runs can be found and revoked
+	reqBody := `{"policy_id": "` + policy.ID + `", "name": "` + TestTokenNamePrefix + policy.Name + `"}`
 	statusCode, respBody, _ := c.post(ctx, fmt.Sprintf("%s/enrollment_api_keys", FleetAPI), []byte(reqBody))
 	if statusCode != 200 {
 		jsonParsed, err := gabs.ParseJSON(respBody)
@@ -178,36 +186,135 @@ func (c *Client) ListEnrollmentAPIKeys(ctx context.Context) ([]EnrollmentAPIKey,
 	})
 	defer span.End()
 
-	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/enrollment_api_keys", FleetAPI))
+	// keys created by previous runs accumulate in Kibana, so all the pages must be read
+	keys := []EnrollmentAPIKey{}
+	for page := 1; ; page++ {
+		statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/enrollment_api_keys?page=%d&perPage=%d", FleetAPI, page, enrollmentAPIKeysPerPage))
+
+		if err != nil {
+			log.WithFields(log.Fields{
+				"body":  string(respBody),
+				"error": err,
+			}).Error("Could not get enrollment apis")
+			return []EnrollmentAPIKey{}, err
+		}
+
+		if statusCode != 200 {
+			log.WithFields(log.Fields{
+				"body":       string(respBody),
+				"statusCode": statusCode,
+			}).Error("Could not get enrollment apis")
+
+			return []EnrollmentAPIKey{}, fmt.Errorf("could not get enrollment apis; API status code = %d; response body = %s", statusCode, respBody)
+		}
+
+		var resp struct {
+			Items []EnrollmentAPIKey `json:"items"`
+			Total int                `json:"total"`
+		}
+
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return nil, errors.Wrap(err, "Unable to convert list of enrollment apis to JSON")
+		}
+
+		keys = append(keys, resp.Items...)
+		if len(resp.Items) < enrollmentAPIKeysPerPage || len(keys) >= resp.Total {
+			break
+		}
+	}
+
+	return keys, nil
+}
+
+// ListEnrollmentAPIKeysByName list the enrollment api keys which name matches the regular expression
+func (c *Client) ListEnrollmentAPIKeysByName(ctx context.Context, namePattern string) ([]EnrollmentAPIKey, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Listing enrollment API Keys by name", "fleet.api-keys.list-by-name", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("namePattern", namePattern)
+	defer span.End()
 
+	keys, err := c.ListEnrollmentAPIKeys(ctx)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"body":  string(respBody),
-			"error": err,
-		}).Error("Could not get Integration package")
 		return []EnrollmentAPIKey{}, err
 	}
 
-	if statusCode != 200 {
-		log.WithFields(log.Fields{
-			"body":       string(respBody),
-			"error":      err,
-			"statusCode": statusCode,
-		}).Error("Could not get enrollment apis")
+	return filterEnrollmentAPIKeysByName(keys, namePattern)
+}
 
-		return []EnrollmentAPIKey{}, err
+// RevokeEnrollmentAPIKeysByName deletes the enrollment api keys which name matches the regular expression,
+// returning the number of revoked keys
+func (c *Client) RevokeEnrollmentAPIKeysByName(ctx context.Context, namePattern string) (int, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Revoking enrollment API Keys by name", "fleet.api-keys.revoke-by-name", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("namePattern", namePattern)
+	defer span.End()
+
+	keys, err := c.ListEnrollmentAPIKeysByName(ctx, namePattern)
+	if err != nil {
+		return 0, err
 	}
 
-	var resp struct {
-		Items []EnrollmentAPIKey `json:"items"`
+	revoked := 0
+	for _, key := range keys {
+		err := c.DeleteEnrollmentAPIKey(ctx, key.ID)
+		if err != nil {
+			return revoked, errors.Wrapf(err, "could not revoke the %s enrollment api key", key.Name)
+		}
+		revoked++
 	}
 
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, errors.Wrap(err, "Unable to convert list of enrollment apis to JSON")
+	log.WithFields(log.Fields{
+		"namePattern": namePattern,
+		"revoked":     revoked,
+	}).Debug("Enrollment API keys revoked")
+
+	return revoked, nil
+}
+
+// GetDefaultEnrollmentAPIKey returns the default enrollment api key, which Fleet creates together with the policy
+func (c *Client) GetDefaultEnrollmentAPIKey(ctx context.Context, policy Policy) (EnrollmentAPIKey, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Getting default enrollment API Key", "fleet.api-key.get-default", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("policyID", policy.ID)
+	defer span.End()
+
+	keys, err := c.ListEnrollmentAPIKeys(ctx)
+	if err != nil {
+		return EnrollmentAPIKey{}, err
+	}
+
+	return findDefaultEnrollmentAPIKey(keys, policy.ID)
+}
+
+// findDefaultEnrollmentAPIKey returns the active enrollment api key created by Fleet for the policy, named "Default (...)"
+func findDefaultEnrollmentAPIKey(keys []EnrollmentAPIKey, policyID string) (EnrollmentAPIKey, error) {
+	for _, key := range keys {
+		if key.PolicyID == policyID && key.Active && strings.HasPrefix(key.Name, "Default") {
+			return key, nil
+		}
+	}
+
+	return EnrollmentAPIKey{}, fmt.Errorf("the default enrollment api key for the %s policy was not found", policyID)
+}
+
+// filterEnrollmentAPIKeysByName returns the enrollment api keys which name matches the regular expression
+func filterEnrollmentAPIKeysByName(keys []EnrollmentAPIKey, namePattern string) ([]EnrollmentAPIKey, error) {
+	re, err := regexp.Compile(namePattern)
+	if err != nil {
+		return []EnrollmentAPIKey{}, errors.Wrapf(err, "invalid name pattern for enrollment api keys: %s", namePattern)
 	}
 
-	return resp.Items, nil
+	filtered := []EnrollmentAPIKey{}
+	for _, key := range keys {
+		if re.MatchString(key.Name) {
+			filtered = append(filtered, key)
+		}
+	}
 
+	return filtered, nil
 }
 
 // RecreateFleet this will force recreate the fleet configuration
@@ -256,7 +363,7 @@ func (c *Client) RecreateFleet(ctx context.Context) error {
 	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
 	exp := utils.GetExponentialBackOff(maxTimeout)
 
-	err := backoff.Retry(waitForFleet, exp)
+	err := utils.RetryWithHeartbeat("Fleet to be ready", waitForFleet, exp)
 	if err != nil {
 		return err
 	}
@@ -312,7 +419,7 @@ func (c *Client) WaitForFleet(ctx context.Context) error {
 	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute * 2
 	exp := utils.GetExponentialBackOff(maxTimeout)
 
-	err := backoff.Retry(waitForFleet, exp)
+	err := utils.RetryWithHeartbeat("Fleet to be ready", waitForFleet, exp)
 	if err != nil {
 		return err
 	}
@@ -358,7 +465,7 @@ func (c *Client) WaitForReady(ctx context.Context, maxTimeoutMinutes time.Durati
 		return nil
 	}
 
-	err := backoff.Retry(kibanaStatus, exp)
+	err := utils.RetryWithHeartbeat("Kibana to be ready", kibanaStatus, exp)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,58 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDefaultEnrollmentAPIKey(t *testing.T) {
+	keys := []EnrollmentAPIKey{
+		{ID: "1", Name: "Test token for policy-1", PolicyID: "policy-1", Active: true},
+		{ID: "2", Name: "Default (a4b6f4c0)", PolicyID: "policy-1", Active: false},
+		{ID: "3", Name: "Default (c7d8e9f0)", PolicyID: "policy-1", Active: true},
+		{ID: "4", Name: "Default (b1c2d3e4)", PolicyID: "policy-2", Active: true},
+	}
+
+	t.Run("The active default key of the policy is returned", func(t *testing.T) {
+		key, err := findDefaultEnrollmentAPIKey(keys, "policy-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "3", key.ID)
+	})
+
+	t.Run("An error is returned when the policy has no default key", func(t *testing.T) {
+		_, err := findDefaultEnrollmentAPIKey(keys, "policy-3")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestFilterEnrollmentAPIKeysByName(t *testing.T) {
+	keys := []EnrollmentAPIKey{
+		{ID: "1", Name: "Default (a4b6f4c0)"},
+		{ID: "2", Name: "Test token for policy-1"},
+		{ID: "3", Name: "Test token for policy-2"},
+	}
+
+	t.Run("Matching keys are returned", func(t *testing.T) {
+		filtered, err := filterEnrollmentAPIKeysByName(keys, "^Test token for ")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(filtered))
+		assert.Equal(t, "2", filtered[0].ID)
+		assert.Equal(t, "3", filtered[1].ID)
+	})
+
+	t.Run("No keys are returned when none matches", func(t *testing.T) {
+		filtered, err := filterEnrollmentAPIKeysByName(keys, "^Production")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(filtered))
+	})
+
+	t.Run("An invalid pattern returns an error", func(t *testing.T) {
+		_, err := filterEnrollmentAPIKeysByName(keys, "(")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// UninstallToken represents the token required to uninstall the agents of a protected policy
+type UninstallToken struct {
+	ID        string `json:"id"`
+	PolicyID  string `json:"policy_id"`
+	Token     string `json:"token,omitempty"` // only returned when the token is retrieved by its ID
+	CreatedAt string `json:"created_at"`
+}
+
+// GetUninstallToken returns the decrypted uninstall token of a policy
+func (c *Client) GetUninstallToken(ctx context.Context, policyID string) (string, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Getting uninstall token", "fleet.uninstall-tokens.get", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	span.Context.SetLabel("policyID", policyID)
+	defer span.End()
+
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/uninstall_tokens?policyId=%s", FleetAPI, url.QueryEscape(policyID)))
+	if err != nil {
+		return "", errors.Wrap(err, "could not list uninstall tokens")
+	}
+
+	if statusCode != 200 {
+		return "", fmt.Errorf("could not list uninstall tokens of the %s policy; API status code = %d; response body = %s", policyID, statusCode, respBody)
+	}
+
+	var listResp struct {
+		Items []UninstallToken `json:"items"`
+	}
+
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return "", errors.Wrap(err, "could not convert uninstall tokens (response) to JSON")
+	}
+
+	if len(listResp.Items) == 0 {
+		return "", fmt.Errorf("the %s policy does not have an uninstall token", policyID)
+	}
+
+	tokenID := listResp.Items[0].ID
+
+	statusCode, respBody, err = c.get(ctx, fmt.Sprintf("%s/uninstall_tokens/%s", FleetAPI, tokenID))
+	if err != nil {
+		return "", errors.Wrap(err, "could not get uninstall token")
+	}
+
+	if statusCode != 200 {
+		log.WithFields(log.Fields{
+			"body":       string(respBody),
+			"policyID":   policyID,
+			"statusCode": statusCode,
+			"tokenID":    tokenID,
+		}).Error("Could not get uninstall token")
+
+		return "", fmt.Errorf("could not get the %s uninstall token; API status code = %d; response body = %s", tokenID, statusCode, respBody)
+	}
+
+	var resp struct {
+		Item UninstallToken `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", errors.Wrap(err, "could not convert uninstall token (response) to JSON")
+	}
+
+	if resp.Item.Token == "" {
+		return "", fmt.Errorf("the %s uninstall token of the %s policy is empty", tokenID, policyID)
+	}
+
+	return resp.Item.Token, nil
+}
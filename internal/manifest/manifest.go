@@ -0,0 +1,218 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package manifest
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	goio "io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/elastic/e2e-testing/internal/naming"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// unknown the value of the details of the environment which could not be retrieved
+const unknown = "unknown"
+
+// Manifest describes the environment where a run was executed, so that its failures can be reproduced, and
+// its results compared with the ones of other runs
+type Manifest struct {
+	Suite     string            `json:"suite"`
+	RunID     string            `json:"run_id"`
+	CreatedAt time.Time         `json:"created_at"`
+	Versions  map[string]string `json:"versions"`  // versions of the stack and the agent under test
+	Images    map[string]string `json:"images"`    // digest of each Docker image, by its reference
+	Artifacts []Artifact        `json:"artifacts"` // artifacts of the agent downloaded by the run
+	Tools     map[string]string `json:"tools"`     // versions of the tools running the environment
+	Host      Host              `json:"host"`
+}
+
+// Artifact a downloaded artifact, identified by its URL and checksum
+type Artifact struct {
+	URL    string `json:"url"`
+	SHA512 string `json:"sha512"`
+}
+
+// Host the machine running the suite
+type Host struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Kernel    string `json:"kernel"`
+	GoVersion string `json:"go_version"`
+}
+
+// Probe retrieves the details of the environment which depend on the tools running it, such as Docker.
+// It's supplied by the suites, which know the tools they run the environment with
+type Probe interface {
+	// ToolVersions returns the versions of the tools running the environment, by their name
+	ToolVersions(ctx context.Context) map[string]string
+	// ImageDigests returns the digest of each image, by its reference
+	ImageDigests(ctx context.Context, images []string) map[string]string
+}
+
+// Capture returns the manifest of the current environment, including the digests of the images, which must have
+// been pulled, and the versions of the tools, as retrieved by the probe. The details which cannot be retrieved
+// are unknown
+func Capture(ctx context.Context, suite string, versions map[string]string, images []string, probe Probe) *Manifest {
+	m := &Manifest{
+		Suite:     suite,
+		RunID:     naming.RunID(),
+		CreatedAt: time.Now().UTC(),
+		Versions:  map[string]string{},
+		Images:    map[string]string{},
+		Artifacts: []Artifact{},
+		Tools:     map[string]string{},
+		Host: Host{
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+			Kernel:    unknown,
+			GoVersion: runtime.Version(),
+		},
+	}
+
+	for k, v := range versions {
+		m.Versions[k] = v
+	}
+
+	for tool, version := range probe.ToolVersions(ctx) {
+		if strings.TrimSpace(version) == "" {
+			version = unknown
+		}
+		m.Tools[tool] = strings.TrimSpace(version)
+	}
+
+	if len(images) > 0 {
+		m.Images = probe.ImageDigests(ctx, images)
+	}
+
+	if runtime.GOOS != "windows" {
+		m.Host.Kernel = commandOutput(ctx, "uname", "-sr")
+	}
+
+	return m
+}
+
+// AddArtifacts adds the downloaded artifacts to the manifest, by their URL and local path, calculating their
+// checksums. The checksum files are skipped
+func (m *Manifest) AddArtifacts(artifacts map[string]string) {
+	byURL := map[string]Artifact{}
+	for _, a := range m.Artifacts {
+		byURL[a.URL] = a
+	}
+
+	for artifactURL, artifactPath := range artifacts {
+		if strings.HasSuffix(artifactName(artifactURL), ".sha512") {
+			continue
+		}
+
+		checksum, err := sha512File(artifactPath)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+				"path":  artifactPath,
+				"url":   artifactURL,
+			}).Warn("Could not calculate the checksum of the artifact for the manifest")
+			checksum = unknown
+		}
+
+		byURL[artifactURL] = Artifact{URL: artifactURL, SHA512: checksum}
+	}
+
+	m.Artifacts = []Artifact{}
+	for _, a := range byURL {
+		m.Artifacts = append(m.Artifacts, a)
+	}
+	sort.Slice(m.Artifacts, func(i, j int) bool {
+		return m.Artifacts[i].URL < m.Artifacts[j].URL
+	})
+}
+
+// Labels returns the manifest as flat key-value pairs, such as the environment details of the status endpoint
+func (m *Manifest) Labels() map[string]string {
+	labels := map[string]string{
+		"run_id":          m.RunID,
+		"host.os":         m.Host.OS,
+		"host.arch":       m.Host.Arch,
+		"host.kernel":     m.Host.Kernel,
+		"host.go_version": m.Host.GoVersion,
+	}
+
+	for k, v := range m.Versions {
+		labels["version."+k] = v
+	}
+	for k, v := range m.Images {
+		labels["image."+k] = v
+	}
+	for k, v := range m.Tools {
+		labels["tool."+k] = v
+	}
+	for _, a := range m.Artifacts {
+		labels["artifact."+artifactName(a.URL)] = a.SHA512
+	}
+
+	return labels
+}
+
+// Write stores the manifest as JSON, creating the parent directories of the file
+func (m *Manifest) Write(file string) error {
+	err := io.MkdirAll(filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return io.WriteFile(bytes, file)
+}
+
+// artifactName returns the name of the file of an artifact, without the query of its URL
+func artifactName(artifactURL string) string {
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return path.Base(artifactURL)
+	}
+
+	return path.Base(u.Path)
+}
+
+func commandOutput(ctx context.Context, command string, args ...string) string {
+	output, err := shell.Execute(ctx, ".", command, args...)
+	if err != nil || strings.TrimSpace(output) == "" {
+		return unknown
+	}
+
+	return strings.TrimSpace(output)
+}
+
+func sha512File(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha512.New()
+	_, err = goio.Copy(hash, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,101 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	artifact := filepath.Join(dir, "elastic-agent-8.0.0-linux-x86_64.tar.gz")
+	err := ioutil.WriteFile(artifact, []byte("foo"), 0644)
+	assert.Nil(t, err)
+
+	m := &Manifest{Artifacts: []Artifact{}}
+	m.AddArtifacts(map[string]string{
+		"https://artifacts/elastic-agent-8.0.0-linux-x86_64.tar.gz?token=1":        artifact,
+		"https://artifacts/elastic-agent-8.0.0-linux-x86_64.tar.gz.sha512?token=1": artifact + ".sha512",
+		"https://artifacts/elastic-agent-8.0.0-amd64.deb":                          filepath.Join(dir, "missing.deb"),
+	})
+
+	assert.Equal(t, []Artifact{
+		{URL: "https://artifacts/elastic-agent-8.0.0-amd64.deb", SHA512: unknown},
+		{
+			URL:    "https://artifacts/elastic-agent-8.0.0-linux-x86_64.tar.gz?token=1",
+			SHA512: "f7fbba6e0636f890e56fbbf3283e524c6fa3204ae298382d624741d0dc6638326e282c41be5e4254d8820772c5518a2c5a8c0c7f7eda19594a7eb539453e1ed7",
+		},
+	}, m.Artifacts)
+}
+
+func TestLabels(t *testing.T) {
+	m := &Manifest{
+		RunID:    "e2e-1",
+		Versions: map[string]string{"stack": "8.0.0"},
+		Images:   map[string]string{"docker.elastic.co/kibana/kibana:8.0.0": "docker.elastic.co/kibana/kibana@sha256:abc"},
+		Artifacts: []Artifact{
+			{URL: "https://artifacts/elastic-agent-8.0.0-amd64.deb?token=1", SHA512: "def"},
+		},
+		Tools: map[string]string{"docker": "20.10.12"},
+		Host:  Host{OS: "linux", Arch: "amd64", Kernel: "Linux 5.10", GoVersion: "go1.17"},
+	}
+
+	labels := m.Labels()
+
+	assert.Equal(t, "e2e-1", labels["run_id"])
+	assert.Equal(t, "8.0.0", labels["version.stack"])
+	assert.Equal(t, "docker.elastic.co/kibana/kibana@sha256:abc", labels["image.docker.elastic.co/kibana/kibana:8.0.0"])
+	assert.Equal(t, "def", labels["artifact.elastic-agent-8.0.0-amd64.deb"])
+	assert.Equal(t, "20.10.12", labels["tool.docker"])
+	assert.Equal(t, "Linux 5.10", labels["host.kernel"])
+}
+
+func TestWrite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "manifests", "e2e-1.json")
+	m := &Manifest{Suite: "fleet", RunID: "e2e-1", Versions: map[string]string{"stack": "8.0.0"}}
+
+	err := m.Write(file)
+	assert.Nil(t, err)
+
+	bytes, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+
+	written := Manifest{}
+	err = json.Unmarshal(bytes, &written)
+	assert.Nil(t, err)
+	assert.Equal(t, "fleet", written.Suite)
+	assert.Equal(t, "8.0.0", written.Versions["stack"])
+}
+
+type fakeProbe struct{}
+
+func (p fakeProbe) ToolVersions(ctx context.Context) map[string]string {
+	return map[string]string{"docker": "20.10.12\n", "docker-compose": ""}
+}
+
+func (p fakeProbe) ImageDigests(ctx context.Context, images []string) map[string]string {
+	digests := map[string]string{}
+	for _, image := range images {
+		digests[image] = image + "@sha256:abc"
+	}
+
+	return digests
+}
+
+func TestCapture(t *testing.T) {
+	m := Capture(context.Background(), "fleet", map[string]string{"stack": "8.0.0"}, []string{"docker.elastic.co/kibana/kibana:8.0.0"}, fakeProbe{})
+
+	assert.Equal(t, "fleet", m.Suite)
+	assert.Equal(t, "8.0.0", m.Versions["stack"])
+	assert.Equal(t, "docker.elastic.co/kibana/kibana:8.0.0@sha256:abc", m.Images["docker.elastic.co/kibana/kibana:8.0.0"])
+	assert.Equal(t, "20.10.12", m.Tools["docker"])
+	assert.Equal(t, unknown, m.Tools["docker-compose"])
+}
@@ -0,0 +1,115 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// endpointIDPlaceholder replaces the identifiers in the paths of the requests, so that the requests to the same
+// endpoint are aggregated
+const endpointIDPlaceholder = "{id}"
+
+// identifierRegex matches the path segments which are identifiers: UUIDs, numbers and long hexadecimal values
+var identifierRegex = regexp.MustCompile(`^([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9]+|[0-9a-fA-F]{16,})$`)
+
+// EndpointLatency aggregates the latencies of the requests sent to an endpoint, in seconds
+type EndpointLatency struct {
+	Target   string  `json:"target"`
+	Method   string  `json:"method"`
+	Endpoint string  `json:"endpoint"`
+	Count    int     `json:"count"`
+	Total    float64 `json:"total"`
+	Max      float64 `json:"max"`
+	P50      float64 `json:"p50"`
+	P95      float64 `json:"p95"`
+}
+
+// LatencyTracker keeps the latencies of the requests per endpoint, to summarise them at the end of the run
+type LatencyTracker struct {
+	mutex     sync.Mutex
+	latencies map[string][]float64
+	endpoints map[string]EndpointLatency
+}
+
+// NewLatencyTracker returns an empty tracker
+func NewLatencyTracker() *LatencyTracker {
+	return &LatencyTracker{
+		latencies: map[string][]float64{},
+		endpoints: map[string]EndpointLatency{},
+	}
+}
+
+// HTTPLatencies the latencies of the requests sent to the Kibana and Elasticsearch APIs during the run
+var HTTPLatencies = NewLatencyTracker()
+
+// Record adds the latency of a request to the path of the target (i.e. kibana)
+func (lt *LatencyTracker) Record(target string, method string, path string, seconds float64) {
+	endpoint := EndpointOf(path)
+	key := target + " " + method + " " + endpoint
+
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+
+	if _, exists := lt.endpoints[key]; !exists {
+		lt.endpoints[key] = EndpointLatency{Target: target, Method: method, Endpoint: endpoint}
+	}
+	lt.latencies[key] = append(lt.latencies[key], seconds)
+}
+
+// Summary returns the latencies per endpoint, sorted by the total time spent in them, the highest first
+func (lt *LatencyTracker) Summary() []EndpointLatency {
+	lt.mutex.Lock()
+	defer lt.mutex.Unlock()
+
+	summaries := []EndpointLatency{}
+	for key, values := range lt.latencies {
+		sorted := append([]float64{}, values...)
+		sort.Float64s(sorted)
+
+		s := lt.endpoints[key]
+		s.Count = len(sorted)
+		for _, v := range sorted {
+			s.Total += v
+		}
+		s.Max = sorted[len(sorted)-1]
+		s.P50 = percentile(sorted, 50)
+		s.P95 = percentile(sorted, 95)
+
+		summaries = append(summaries, s)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Total > summaries[j].Total
+	})
+
+	return summaries
+}
+
+// percentile returns the nearest-rank percentile of the sorted values
+func percentile(sorted []float64, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// EndpointOf returns the endpoint of the path, replacing the identifiers with a placeholder
+// (i.e. /api/fleet/agents/{id})
+func EndpointOf(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if identifierRegex.MatchString(segment) {
+			segments[i] = endpointIDPlaceholder
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
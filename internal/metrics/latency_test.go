@@ -0,0 +1,43 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointOf(t *testing.T) {
+	assert.Equal(t, "/api/fleet/agents/{id}", EndpointOf("/api/fleet/agents/5b8a3e9c-2f1d-4c7a-9e6b-0a1b2c3d4e5f"))
+	assert.Equal(t, "/api/fleet/agents/{id}/actions", EndpointOf("/api/fleet/agents/5b8a3e9c-2f1d-4c7a-9e6b-0a1b2c3d4e5f/actions"))
+	assert.Equal(t, "/api/fleet/agent_policies/{id}", EndpointOf("/api/fleet/agent_policies/1234"))
+	assert.Equal(t, "/logs-elastic_agent-default/_search", EndpointOf("/logs-elastic_agent-default/_search"))
+}
+
+func TestLatencyTracker(t *testing.T) {
+	lt := NewLatencyTracker()
+
+	for i := 1; i <= 20; i++ {
+		lt.Record("kibana", "GET", "/api/fleet/agents/5b8a3e9c-2f1d-4c7a-9e6b-0a1b2c3d4e5f", float64(i))
+	}
+	lt.Record("elasticsearch", "POST", "/logs-*/_search", 100)
+
+	summary := lt.Summary()
+	assert.Equal(t, 2, len(summary))
+
+	// sorted by the total time spent in each endpoint
+	assert.Equal(t, "kibana", summary[0].Target)
+	assert.Equal(t, "/api/fleet/agents/{id}", summary[0].Endpoint)
+	assert.Equal(t, 20, summary[0].Count)
+	assert.Equal(t, float64(210), summary[0].Total)
+	assert.Equal(t, float64(20), summary[0].Max)
+	assert.Equal(t, float64(10), summary[0].P50)
+	assert.Equal(t, float64(19), summary[0].P95)
+
+	assert.Equal(t, "elasticsearch", summary[1].Target)
+	assert.Equal(t, 1, summary[1].Count)
+	assert.Equal(t, float64(100), summary[1].P95)
+}
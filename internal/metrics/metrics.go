@@ -0,0 +1,235 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// metric is a family of time series, written in the Prometheus text format
+type metric interface {
+	write(w io.Writer)
+}
+
+var registry = []metric{}
+var registryMutex sync.Mutex
+
+func register(m metric) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registry = append(registry, m)
+}
+
+// Counter is a monotonically increasing value, partitioned by the values of its labels
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter creates and registers a counter
+func NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: map[string]float64{},
+	}
+	register(c)
+
+	return c
+}
+
+// Inc increments the counter for the label values, which must be passed in the order of the labels
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds the value to the counter for the label values
+func (c *Counter) Add(value float64, labelValues ...string) {
+	key := labelsKey(c.labels, labelValues)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.values[key] += value
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %v\n", c.name, key, c.values[key])
+	}
+}
+
+// Histogram samples observations, such as durations, counting them in configurable buckets
+type Histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mutex  sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogram creates and registers a histogram with the upper bounds of the buckets, in increasing order
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*histogramSeries{},
+	}
+	register(h)
+
+	return h
+}
+
+// Observe adds an observation for the label values, which must be passed in the order of the labels
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := labelsKey(h.labels, labelValues)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	s, exists := h.series[key]
+	if !exists {
+		s = &histogramSeries{
+			labelValues: append([]string{}, labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bucketLabels := appendCopy(h.labels, "le")
+
+	for _, key := range keys {
+		s := h.series[key]
+		for i, upperBound := range h.buckets {
+			bucketKey := labelsKey(bucketLabels, appendCopy(s.labelValues, fmt.Sprintf("%v", upperBound)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, bucketKey, s.counts[i])
+		}
+		infKey := labelsKey(bucketLabels, appendCopy(s.labelValues, "+Inf"))
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, infKey, s.count)
+		fmt.Fprintf(w, "%s_sum%s %v\n", h.name, key, s.sum)
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, key, s.count)
+	}
+}
+
+// labelsKey formats the labels as in the Prometheus text format (i.e. {method="GET",code="200"}).
+// Missing label values are empty
+func labelsKey(labels []string, values []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf("%s=%q", label, value)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// appendCopy appends the value to a copy of the slice, leaving the original untouched
+func appendCopy(values []string, value string) []string {
+	result := make([]string, 0, len(values)+1)
+	result = append(result, values...)
+
+	return append(result, value)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Write writes all the registered metrics in the Prometheus text format
+func Write(w io.Writer) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	for _, m := range registry {
+		m.write(w)
+	}
+}
+
+// Handler serves the registered metrics in the Prometheus text format
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	Write(w)
+}
+
+// Serve exposes the metrics at the /metrics path of the address (i.e. localhost:9464), in the background.
+// Errors serving the endpoint are logged, as they must not break the suite
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", Handler)
+
+	go func() {
+		log.WithField("address", addr).Info("Serving the metrics of the test runner")
+
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"address": addr,
+				"error":   err,
+			}).Warn("Could not serve the metrics of the test runner")
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	c := &Counter{name: "test_total", help: "Test counter.", labels: []string{"status"}, values: map[string]float64{}}
+
+	c.Inc("passed")
+	c.Inc("passed")
+	c.Inc("failed")
+
+	var out bytes.Buffer
+	c.write(&out)
+
+	assert.Equal(t, `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{status="failed"} 1
+test_total{status="passed"} 2
+`, out.String())
+}
+
+func TestHistogram(t *testing.T) {
+	h := &Histogram{name: "test_seconds", help: "Test histogram.", labels: []string{"target"}, buckets: []float64{1, 5}, series: map[string]*histogramSeries{}}
+
+	h.Observe(0.5, "kibana")
+	h.Observe(3, "kibana")
+	h.Observe(10, "kibana")
+
+	var out bytes.Buffer
+	h.write(&out)
+
+	assert.Equal(t, `# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{target="kibana",le="1"} 1
+test_seconds_bucket{target="kibana",le="5"} 2
+test_seconds_bucket{target="kibana",le="+Inf"} 3
+test_seconds_sum{target="kibana"} 13.5
+test_seconds_count{target="kibana"} 3
+`, out.String())
+}
+
+func TestLabelsKey(t *testing.T) {
+	assert.Equal(t, "", labelsKey([]string{}, []string{}))
+	assert.Equal(t, `{method="GET",code=""}`, labelsKey([]string{"method", "code"}, []string{"GET"}))
+}
@@ -0,0 +1,71 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// durationBuckets the upper bounds, in seconds, of the buckets for the durations of the operations
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// StepsTotal the steps executed by the test runner, by status (passed, failed, skipped, undefined, pending)
+var StepsTotal = NewCounter("e2e_steps_total", "Steps executed by the test runner, by status.", "status")
+
+// RetriesTotal the failed attempts of the operations retried with backoff
+var RetriesTotal = NewCounter("e2e_retries_total", "Failed attempts of the operations retried with backoff.")
+
+// HTTPRequestDuration the latency of the requests sent to the Kibana and Elasticsearch APIs
+var HTTPRequestDuration = NewHistogram("e2e_http_request_duration_seconds", "Latency of the requests sent to the Kibana and Elasticsearch APIs.", durationBuckets, "target", "method", "code")
+
+// DockerOperationDuration the duration of the Docker and Docker Compose operations, such as compose up or exec
+var DockerOperationDuration = NewHistogram("e2e_docker_operation_duration_seconds", "Duration of the Docker and Docker Compose operations.", durationBuckets, "operation", "result")
+
+// ObserveDockerOperation records the duration of a Docker operation since it started
+func ObserveDockerOperation(operation string, started time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	DockerOperationDuration.Observe(time.Since(started).Seconds(), operation, result)
+}
+
+// instrumentedRoundTripper records the latency of each request
+type instrumentedRoundTripper struct {
+	target string
+	next   http.RoundTripper
+}
+
+// RoundTrip sends the request, recording its latency, also per endpoint
+func (rt *instrumentedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+
+	resp, err := rt.next.RoundTrip(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	elapsed := time.Since(started).Seconds()
+	HTTPRequestDuration.Observe(elapsed, rt.target, req.Method, code)
+	HTTPLatencies.Record(rt.target, req.Method, req.URL.Path, elapsed)
+
+	return resp, err
+}
+
+// WrapRoundTripper returns a round tripper recording the latency of the requests sent to the target (i.e. kibana)
+func WrapRoundTripper(target string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &instrumentedRoundTripper{
+		target: target,
+		next:   next,
+	}
+}
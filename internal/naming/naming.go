@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package naming
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/google/uuid"
+)
+
+// seedLength the length of the random seeds, long enough to make collisions between concurrent runs negligible
+const seedLength = 12
+
+// Generator generates the names of the resources created by a run, such as policies, enrollment tokens
+// or hostnames. Names are derived from the seed of the run and a counter per prefix, so the same
+// sequence of names is produced when a run is replayed with the same seed
+type Generator struct {
+	seed     string
+	counters map[string]int
+	mutex    sync.Mutex
+}
+
+// NewGenerator returns a generator for the seed
+func NewGenerator(seed string) *Generator {
+	return &Generator{
+		seed:     seed,
+		counters: map[string]int{},
+	}
+}
+
+// Seed returns the seed of the generator
+func (g *Generator) Seed() string {
+	return g.seed
+}
+
+// Name returns the next name for the prefix (i.e. test-policy-1a2b3c4d5e6f-1)
+func (g *Generator) Name(prefix string) string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.counters[prefix]++
+	return fmt.Sprintf("%s-%s-%d", prefix, g.seed, g.counters[prefix])
+}
+
+// defaultGenerator the generator of the current run, which seed is random unless the RUN_SEED env var is set
+var defaultGenerator = NewGenerator(shell.GetEnv("RUN_SEED", randomSeed()))
+
+// Seed returns the seed of the current run
+func Seed() string {
+	return defaultGenerator.Seed()
+}
+
+// Name returns the next name for the prefix in the current run
+func Name(prefix string) string {
+	return defaultGenerator.Name(prefix)
+}
+
+// RunID returns the identifier of the current run, which is its seed, so every generated name carries it
+func RunID() string {
+	return defaultGenerator.Seed()
+}
+
+// NamePattern returns the regular expression matching the names generated for a run ID. The pattern is not
+// anchored because Fleet decorates some names, such as the ones of the enrollment tokens
+func NamePattern(runID string) string {
+	return "-" + regexp.QuoteMeta(runID) + "-[0-9]+"
+}
+
+// AnyRunNamePattern returns the regular expression matching the names generated for any run with a random seed,
+// i.e. to find the resources left behind by previous runs
+func AnyRunNamePattern() string {
+	return fmt.Sprintf("-[0-9a-f]{%d}-[0-9]+", seedLength)
+}
+
+func randomSeed() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")[:seedLength]
+}
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package naming
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator(t *testing.T) {
+	t.Run("Names are sequential per prefix", func(t *testing.T) {
+		g := NewGenerator("1a2b3c")
+
+		assert.Equal(t, "test-policy-1a2b3c-1", g.Name("test-policy"))
+		assert.Equal(t, "test-policy-1a2b3c-2", g.Name("test-policy"))
+		assert.Equal(t, "elastic-agent-1a2b3c-1", g.Name("elastic-agent"))
+	})
+
+	t.Run("The same seed produces the same names", func(t *testing.T) {
+		g1 := NewGenerator("1a2b3c")
+		g2 := NewGenerator("1a2b3c")
+
+		assert.Equal(t, g1.Name("test-policy"), g2.Name("test-policy"))
+	})
+
+	t.Run("Different seeds produce different names", func(t *testing.T) {
+		g1 := NewGenerator(randomSeed())
+		g2 := NewGenerator(randomSeed())
+
+		assert.NotEqual(t, g1.Name("test-policy"), g2.Name("test-policy"))
+	})
+}
+
+func TestRandomSeed(t *testing.T) {
+	assert.Len(t, randomSeed(), seedLength)
+}
+
+func TestNamePattern(t *testing.T) {
+	re := regexp.MustCompile(NamePattern("1a2b3c"))
+
+	t.Run("Names of the run match", func(t *testing.T) {
+		g := NewGenerator("1a2b3c")
+
+		assert.True(t, re.MatchString(g.Name("test-policy")))
+		assert.True(t, re.MatchString("Test token for "+g.Name("test-policy")+" (b5d1c7a0)"))
+	})
+
+	t.Run("Names of other runs do not match", func(t *testing.T) {
+		g := NewGenerator("4d5e6f")
+
+		assert.False(t, re.MatchString(g.Name("test-policy")))
+		assert.False(t, re.MatchString("Default (b5d1c7a0)"))
+	})
+}
+
+func TestAnyRunNamePattern(t *testing.T) {
+	re := regexp.MustCompile(AnyRunNamePattern())
+
+	t.Run("Names of runs with a random seed match", func(t *testing.T) {
+		g := NewGenerator(randomSeed())
+
+		assert.True(t, re.MatchString(g.Name("test-policy")))
+		assert.True(t, re.MatchString(g.Name("elastic-agent")))
+	})
+
+	t.Run("Names not generated by a run do not match", func(t *testing.T) {
+		assert.False(t, re.MatchString("Default policy"))
+		assert.False(t, re.MatchString("fleet-server-policy"))
+		assert.False(t, re.MatchString("Default (b5d1c7a0)"))
+	})
+}
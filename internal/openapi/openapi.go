@@ -0,0 +1,171 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/io"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/pkg/errors"
+)
+
+// jsonContentType the content type of the bodies validated against the schemas
+const jsonContentType = "application/json"
+
+// serverURL the URL of the server the requests are routed to. The host and scheme of the servers of the
+// specification are replaced by it, as the requests are validated by their paths
+const serverURL = "http://localhost"
+
+// Spec represents an OpenAPI 3 specification, used to validate the requests sent to an API and its responses
+type Spec struct {
+	basePath string
+	router   routers.Router
+}
+
+// Load reads a specification from a JSON or YAML file
+func Load(path string) (*Spec, error) {
+	bytes, err := io.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(bytes)
+}
+
+func parse(data []byte) (*Spec, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse the OpenAPI specification")
+	}
+
+	spec := &Spec{}
+
+	// the paths are relative to the URL of the first server (i.e. http://localhost:5601/api/fleet)
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			spec.basePath = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+	doc.Servers = openapi3.Servers{{URL: serverURL + spec.basePath}}
+
+	spec.router, err = gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not route the paths of the OpenAPI specification")
+	}
+
+	return spec, nil
+}
+
+// Covers returns true if the path is under the base path of the specification
+func (s *Spec) Covers(path string) bool {
+	path = strings.SplitN(path, "?", 2)[0]
+
+	return path == s.basePath || strings.HasPrefix(path, s.basePath+"/")
+}
+
+// ValidateRequest returns the violations of the specification by a request to the path, including the
+// ones of its JSON body. Paths not covered by the specification are not validated
+func (s *Spec) ValidateRequest(method string, path string, body []byte) []string {
+	if !s.Covers(path) {
+		return []string{}
+	}
+
+	input, err := s.requestInput(method, path, body)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	err = openapi3filter.ValidateRequest(context.Background(), input)
+
+	return violations(method, path, err)
+}
+
+// ValidateResponse returns the violations of the specification by the response to a request to the path.
+// Responses with a status code not described by the operation are violations too
+func (s *Spec) ValidateResponse(method string, path string, statusCode int, body []byte) []string {
+	if !s.Covers(path) {
+		return []string{}
+	}
+
+	input, err := s.requestInput(method, path, nil)
+	if err != nil {
+		// already reported when validating the request
+		return []string{}
+	}
+
+	options := &openapi3filter.Options{
+		ExcludeResponseBody:   len(body) == 0,
+		IncludeResponseStatus: true,
+		MultiError:            true,
+	}
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 statusCode,
+		Header:                 http.Header{"Content-Type": []string{jsonContentType}},
+		Body:                   ioutil.NopCloser(bytes.NewReader(body)),
+		Options:                options,
+	})
+
+	return violations(method, path, err)
+}
+
+// requestInput returns the input to validate a request to the path, which must be described by the specification
+func (s *Spec) requestInput(method string, path string, body []byte) (*openapi3filter.RequestValidationInput, error) {
+	req, err := http.NewRequest(method, serverURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v", method, path, err)
+	}
+	req.Header.Set("Content-Type", jsonContentType)
+
+	route, pathParams, err := s.router.FindRoute(req)
+	if err == routers.ErrMethodNotAllowed {
+		return nil, fmt.Errorf("%s %s: the method is not described", method, path)
+	} else if err != nil {
+		return nil, fmt.Errorf("%s %s: the path is not described", method, path)
+	}
+
+	return &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			// the requests are authenticated by the client, not validated against the security schemes
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			ExcludeRequestBody: len(body) == 0,
+			MultiError:         true,
+		},
+	}, nil
+}
+
+// violations returns the violations in the error of a validation, keeping only the first line of each one,
+// as the errors of the schemas include the schema and the value
+func violations(method string, path string, err error) []string {
+	if err == nil {
+		return []string{}
+	}
+
+	errs := []error{err}
+	if multiError, ok := err.(openapi3.MultiError); ok {
+		errs = multiError
+	}
+
+	result := []string{}
+	for _, e := range errs {
+		result = append(result, fmt.Sprintf("%s %s: %s", method, path, strings.SplitN(e.Error(), "\n", 2)[0]))
+	}
+
+	return result
+}
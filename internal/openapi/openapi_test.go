@@ -0,0 +1,149 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpec = `
+openapi: 3.0.0
+servers:
+  - url: http://localhost:5601/api/fleet
+paths:
+  /agent_policies:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/new_agent_policy'
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  item:
+                    $ref: '#/components/schemas/agent_policy'
+                required:
+                  - item
+  /agent_policies/{agentPolicyId}:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+  /agent_policies/delete:
+    post:
+      responses:
+        '200':
+          description: OK
+components:
+  schemas:
+    new_agent_policy:
+      type: object
+      additionalProperties: false
+      properties:
+        name:
+          type: string
+        namespace:
+          type: string
+        monitoring_enabled:
+          type: array
+          items:
+            type: string
+            enum: [logs, metrics]
+      required:
+        - name
+        - namespace
+    agent_policy:
+      allOf:
+        - $ref: '#/components/schemas/agent_policy_base'
+        - type: object
+          properties:
+            id:
+              type: string
+            revision:
+              type: integer
+          required:
+            - id
+    agent_policy_base:
+      type: object
+      properties:
+        name:
+          type: string
+        namespace:
+          type: string
+      required:
+        - name
+        - namespace
+`
+
+func TestValidateRequest(t *testing.T) {
+	spec, err := parse([]byte(testSpec))
+	assert.Nil(t, err)
+
+	t.Run("A valid request has no violations", func(t *testing.T) {
+		violations := spec.ValidateRequest("POST", "/api/fleet/agent_policies", []byte(`{"name":"test-policy","namespace":"default","monitoring_enabled":["logs"]}`))
+		assert.Equal(t, 0, len(violations))
+	})
+
+	t.Run("Renamed properties are violations", func(t *testing.T) {
+		violations := spec.ValidateRequest("POST", "/api/fleet/agent_policies", []byte(`{"name":"test-policy","config_namespace":"default"}`))
+		assert.Equal(t, 1, len(violations))
+		assert.Contains(t, violations[0], "POST /api/fleet/agent_policies: request body has an error")
+	})
+
+	t.Run("Values outside the enum are violations", func(t *testing.T) {
+		violations := spec.ValidateRequest("POST", "/api/fleet/agent_policies", []byte(`{"name":"test-policy","namespace":"default","monitoring_enabled":["traces"]}`))
+		assert.Equal(t, 1, len(violations))
+		assert.Contains(t, violations[0], "/monitoring_enabled/0")
+	})
+
+	t.Run("Literal segments are preferred over parameters", func(t *testing.T) {
+		violations := spec.ValidateRequest("POST", "/api/fleet/agent_policies/delete", []byte(`{"agentPolicyId":"1"}`))
+		assert.Equal(t, 0, len(violations))
+	})
+
+	t.Run("Undescribed paths and methods are violations", func(t *testing.T) {
+		violations := spec.ValidateRequest("GET", "/api/fleet/enrollment-api-keys", nil)
+		assert.Equal(t, []string{"GET /api/fleet/enrollment-api-keys: the path is not described"}, violations)
+
+		violations = spec.ValidateRequest("DELETE", "/api/fleet/agent_policies/1", nil)
+		assert.Equal(t, []string{"DELETE /api/fleet/agent_policies/1: the method is not described"}, violations)
+	})
+
+	t.Run("Paths out of the specification are not validated", func(t *testing.T) {
+		violations := spec.ValidateRequest("GET", "/api/status", nil)
+		assert.Equal(t, 0, len(violations))
+	})
+}
+
+func TestValidateResponse(t *testing.T) {
+	spec, err := parse([]byte(testSpec))
+	assert.Nil(t, err)
+
+	t.Run("A valid response has no violations", func(t *testing.T) {
+		violations := spec.ValidateResponse("POST", "/api/fleet/agent_policies", 200, []byte(`{"item":{"id":"1","name":"test-policy","namespace":"default","revision":1}}`))
+		assert.Equal(t, 0, len(violations))
+	})
+
+	t.Run("Wrong types are violations", func(t *testing.T) {
+		violations := spec.ValidateResponse("POST", "/api/fleet/agent_policies", 200, []byte(`{"item":{"id":"1","name":"test-policy","namespace":"default","revision":1.5}}`))
+		assert.Equal(t, 1, len(violations))
+		assert.Contains(t, violations[0], "\"/revision\": Value must be an integer")
+	})
+
+	t.Run("Undescribed status codes are violations", func(t *testing.T) {
+		violations := spec.ValidateResponse("GET", "/api/fleet/agent_policies/1", 404, []byte(`{}`))
+		assert.Equal(t, []string{"GET /api/fleet/agent_policies/1: status is not supported"}, violations)
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package outline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// GeneratedExamplesTag the tag of the examples of a scenario outline which rows are generated from the
+// enabled OS images. The examples must only declare the header, which columns are the properties of the images
+const GeneratedExamplesTag = "@os_images"
+
+// Image an OS image where the agents can be deployed, and the installer it uses
+type Image struct {
+	Name      string `yaml:"name"`
+	Installer string `yaml:"installer"`
+	Enabled   bool   `yaml:"enabled"`
+}
+
+// column returns the value of a property of the image, by the name of its column in the examples
+func (i Image) column(name string) (string, error) {
+	switch name {
+	case "os":
+		return i.Name, nil
+	case "installer":
+		return i.Installer, nil
+	}
+
+	return "", fmt.Errorf("the '%s' column of the examples is not valid. Valid columns are: os, installer", name)
+}
+
+// Images the OS images, in the order they are declared
+type Images struct {
+	Images []Image `yaml:"images"`
+}
+
+// Load reads the OS images from a YAML file
+func Load(path string) (*Images, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(bytes)
+}
+
+func parse(bytes []byte) (*Images, error) {
+	images := &Images{}
+	err := yaml.Unmarshal(bytes, images)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images.Images {
+		if image.Name == "" || image.Installer == "" {
+			return nil, fmt.Errorf("the OS images must have a name and an installer: %+v", image)
+		}
+	}
+
+	return images, nil
+}
+
+// Enabled returns the enabled OS images
+func (is *Images) Enabled() []Image {
+	enabled := []Image{}
+	for _, image := range is.Images {
+		if image.Enabled {
+			enabled = append(enabled, image)
+		}
+	}
+
+	return enabled
+}
+
+// Find returns the OS image with the name, whether it's enabled or not
+func (is *Images) Find(name string) (Image, bool) {
+	if is == nil {
+		return Image{}, false
+	}
+
+	for _, image := range is.Images {
+		if image.Name == name {
+			return image, true
+		}
+	}
+
+	return Image{}, false
+}
+
+// Expand adds a row per image to the examples of the feature tagged with the generated examples tag,
+// returning the resulting feature and whether any examples were generated
+func Expand(feature string, images []Image) (string, bool, error) {
+	lines := strings.Split(feature, "\n")
+	result := []string{}
+
+	tagged := false
+	generated := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		result = append(result, line)
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "@"):
+			for _, tag := range strings.Fields(trimmed) {
+				if tag == GeneratedExamplesTag {
+					tagged = true
+				}
+			}
+			continue
+		case !tagged || !strings.HasPrefix(trimmed, "Examples:"):
+			tagged = false
+			continue
+		}
+
+		tagged = false
+
+		// the header is the first row after the examples keyword
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+			i++
+			result = append(result, lines[i])
+		}
+		if i+1 >= len(lines) || !strings.HasPrefix(strings.TrimSpace(lines[i+1]), "|") {
+			return "", false, fmt.Errorf("the examples at line %d do not declare a header", i+1)
+		}
+		i++
+		header := lines[i]
+		result = append(result, header)
+
+		rows, err := rowsFor(header, images)
+		if err != nil {
+			return "", false, err
+		}
+		result = append(result, rows...)
+		generated = true
+	}
+
+	return strings.Join(result, "\n"), generated, nil
+}
+
+// rowsFor returns the rows of the images for the header of the examples, keeping its indentation
+func rowsFor(header string, images []Image) ([]string, error) {
+	indentation := header[:len(header)-len(strings.TrimLeft(header, " \t"))]
+
+	columns := []string{}
+	for _, column := range strings.Split(strings.Trim(strings.TrimSpace(header), "|"), "|") {
+		columns = append(columns, strings.TrimSpace(column))
+	}
+
+	rows := []string{}
+	for _, image := range images {
+		values := []string{}
+		for _, column := range columns {
+			value, err := image.column(column)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+
+		rows = append(rows, indentation+"| "+strings.Join(values, " | ")+" |")
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,95 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package outline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testImages = `
+images:
+  - name: centos
+    installer: rpm
+    enabled: true
+  - name: debian
+    installer: deb
+    enabled: true
+  - name: sles
+    installer: rpm
+    enabled: false
+`
+
+const testFeature = `@fleet_mode
+Feature: Fleet Mode Agent
+
+@deploy-os
+Scenario Outline: Deploying the agent on <os>
+  Given an agent is deployed to Fleet with "<installer>" installer
+  Then the agent is listed in Fleet as "online"
+
+@os_images
+Examples: OS images
+  | os | installer |
+
+@default
+Examples: default
+  | os      | installer |
+  | default | tar       |
+`
+
+func TestImages(t *testing.T) {
+	images, err := parse([]byte(testImages))
+	assert.Nil(t, err)
+
+	t.Run("Only the enabled images are returned", func(t *testing.T) {
+		enabled := images.Enabled()
+		assert.Len(t, enabled, 2)
+		assert.Equal(t, "centos", enabled[0].Name)
+		assert.Equal(t, "debian", enabled[1].Name)
+	})
+
+	t.Run("Disabled images are found", func(t *testing.T) {
+		image, found := images.Find("sles")
+		assert.True(t, found)
+		assert.Equal(t, "rpm", image.Installer)
+	})
+
+	t.Run("Images without installer are not valid", func(t *testing.T) {
+		_, err := parse([]byte("images:\n  - name: centos\n"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestExpand(t *testing.T) {
+	images, _ := parse([]byte(testImages))
+
+	t.Run("Rows are generated for the tagged examples", func(t *testing.T) {
+		feature, generated, err := Expand(testFeature, images.Enabled())
+		assert.Nil(t, err)
+		assert.True(t, generated)
+		assert.Contains(t, feature, "  | os | installer |\n  | centos | rpm |\n  | debian | deb |\n\n@default")
+		assert.Contains(t, feature, "  | default | tar       |\n")
+		assert.NotContains(t, feature, "sles")
+	})
+
+	t.Run("Features without tagged examples are not changed", func(t *testing.T) {
+		feature, generated, err := Expand("Feature: Foo\n\n@default\nExamples: default\n  | os |\n", images.Enabled())
+		assert.Nil(t, err)
+		assert.False(t, generated)
+		assert.Equal(t, "Feature: Foo\n\n@default\nExamples: default\n  | os |\n", feature)
+	})
+
+	t.Run("Unknown columns are not valid", func(t *testing.T) {
+		_, _, err := Expand("@os_images\nExamples: OS\n  | image |\n", images.Enabled())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Examples without header are not valid", func(t *testing.T) {
+		_, _, err := Expand("@os_images\nExamples: OS\n", images.Enabled())
+		assert.NotNil(t, err)
+	})
+}
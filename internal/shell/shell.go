@@ -7,17 +7,56 @@ package shell
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"go.elastic.co/apm"
 )
 
+// CommandError the error of a command which could not be executed, or exited with a non-zero code,
+// including what the command printed
+type CommandError struct {
+	Command string
+	Args    []string
+	Stdout  string
+	Stderr  string
+	Err     error
+	// TimedOut whether the command was killed because its context exceeded its deadline
+	TimedOut bool
+}
+
+// Error returns the error of the execution, followed by the standard error of the command,
+// or its standard output if it printed nothing to the standard error
+func (e *CommandError) Error() string {
+	err := e.Err.Error()
+	if e.TimedOut {
+		err = fmt.Sprintf("the command exceeded its timeout and it was killed (%v)", e.Err)
+	}
+
+	output := strings.TrimSpace(e.Stderr)
+	if output == "" {
+		output = strings.TrimSpace(e.Stdout)
+	}
+
+	if output == "" {
+		return err
+	}
+
+	return fmt.Sprintf("%s: %s", err, output)
+}
+
+// Unwrap returns the error of the execution, i.e. an *exec.ExitError
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
 // CheckInstalledSoftware checks that the required software is present
 func CheckInstalledSoftware(binaries ...string) {
 	log.Tracef("Validating required tools: %v", binaries)
@@ -68,7 +107,8 @@ func ExecuteWithStdin(ctx context.Context, workspace string, stdin io.Reader, co
 		"env":     env,
 	}).Trace("Executing command")
 
-	cmd := exec.Command(command, args[0:]...)
+	// the command is killed if the context is done, i.e. when a scenario exceeds its timeout
+	cmd := exec.CommandContext(ctx, command, args[0:]...)
 
 	cmd.Dir = workspace
 
@@ -99,9 +139,17 @@ func ExecuteWithStdin(ctx context.Context, workspace string, stdin io.Reader, co
 			"env":     env,
 			"error":   err,
 			"stderr":  stderr.String(),
+			"stdout":  out.String(),
 		}).Error("Error executing command")
 
-		return "", err
+		return "", &CommandError{
+			Command:  command,
+			Args:     args,
+			Stdout:   strings.Trim(out.String(), "\n"),
+			Stderr:   strings.Trim(stderr.String(), "\n"),
+			Err:      err,
+			TimedOut: ctx.Err() == context.DeadlineExceeded,
+		}
 	}
 
 	trimmedOutput := strings.Trim(out.String(), "\n")
@@ -113,6 +161,43 @@ func ExecuteWithStdin(ctx context.Context, workspace string, stdin io.Reader, co
 	return trimmedOutput, nil
 }
 
+// ExitCode returns the exit code of the command which execution returned the error, which could wrap it,
+// 0 if there is no error, or -1 if the command did not exit, i.e. it could not be started
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// CommandOutput returns the standard output and error of the command which execution returned the error,
+// which could wrap it. They are empty if the error does not come from the execution of a command
+func CommandOutput(err error) (string, string) {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Stdout, cmdErr.Stderr
+	}
+
+	return "", ""
+}
+
+// TimedOut returns whether the command which execution returned the error, which could wrap it, was killed
+// because it exceeded the deadline of its context
+func TimedOut(err error) bool {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.TimedOut
+	}
+
+	return false
+}
+
 // GetEnv returns an environment variable as string
 func GetEnv(envVar string, defaultValue string) string {
 	value, exists := os.LookupEnv(envVar)
@@ -151,6 +236,18 @@ func GetEnvInteger(envVar string, defaultValue int) int {
 	return defaultValue
 }
 
+// GetEnvDuration returns an environment variable as a duration (i.e. 500ms, 2s), including a default value
+func GetEnvDuration(envVar string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(envVar); exists {
+		v, err := time.ParseDuration(value)
+		if err == nil {
+			return v
+		}
+	}
+
+	return defaultValue
+}
+
 // which checks if software is installed, else it aborts the execution
 func which(binary string) error {
 	path, err := exec.LookPath(binary)
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -68,6 +69,31 @@ func TestGetEnvBool(t *testing.T) {
 	}
 }
 
+func TestGetEnvDuration(t *testing.T) {
+	t.Run("Empty value should return fallback", func(t *testing.T) {
+		defer os.Unsetenv("test.duration")
+
+		val := GetEnvDuration("test.duration", 2*time.Second)
+		assert.Equal(t, 2*time.Second, val)
+	})
+
+	t.Run("Invalid value should return fallback", func(t *testing.T) {
+		os.Setenv("test.duration", "two seconds")
+		defer os.Unsetenv("test.duration")
+
+		val := GetEnvDuration("test.duration", 2*time.Second)
+		assert.Equal(t, 2*time.Second, val)
+	})
+
+	t.Run("Valid value should be parsed", func(t *testing.T) {
+		os.Setenv("test.duration", "250ms")
+		defer os.Unsetenv("test.duration")
+
+		val := GetEnvDuration("test.duration", 2*time.Second)
+		assert.Equal(t, 250*time.Millisecond, val)
+	})
+}
+
 func TestExecuteWithStdin_doesNotLoseEnv(t *testing.T) {
 	os.Setenv("FOO", "foo")
 	defer os.Unsetenv("FOO")
@@ -80,3 +106,73 @@ func TestExecuteWithStdin_doesNotLoseEnv(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, strings.Contains(output, "FOO=foo"), fmt.Sprintf("Environment is %s", output))
 }
+
+func TestExitCode(t *testing.T) {
+	t.Run("No error", func(t *testing.T) {
+		assert.Equal(t, 0, ExitCode(nil))
+	})
+
+	t.Run("Exit code of the command", func(t *testing.T) {
+		_, err := Execute(context.Background(), ".", "sh", "-c", "exit 3")
+		assert.Equal(t, 3, ExitCode(err))
+	})
+
+	t.Run("Exit code of a wrapped error", func(t *testing.T) {
+		_, err := Execute(context.Background(), ".", "sh", "-c", "exit 3")
+		assert.Equal(t, 3, ExitCode(fmt.Errorf("could not enroll: %w", err)))
+	})
+
+	t.Run("Commands not started", func(t *testing.T) {
+		_, err := Execute(context.Background(), ".", "this-command-does-not-exist")
+		assert.Equal(t, -1, ExitCode(err))
+	})
+}
+
+func TestCommandOutput(t *testing.T) {
+	t.Run("Output of a failed command", func(t *testing.T) {
+		_, err := Execute(context.Background(), ".", "sh", "-c", "echo enrolling; echo 'Error: enroll command failed' >&2; exit 1")
+
+		stdout, stderr := CommandOutput(fmt.Errorf("could not enroll: %w", err))
+		assert.Equal(t, "enrolling", stdout)
+		assert.Equal(t, "Error: enroll command failed", stderr)
+		assert.Equal(t, "exit status 1: Error: enroll command failed", err.Error())
+	})
+
+	t.Run("Standard output is included in the error if there is no standard error", func(t *testing.T) {
+		_, err := Execute(context.Background(), ".", "sh", "-c", "echo 'Error: enroll command failed'; exit 1")
+
+		assert.Equal(t, "exit status 1: Error: enroll command failed", err.Error())
+	})
+
+	t.Run("Errors not coming from commands", func(t *testing.T) {
+		stdout, stderr := CommandOutput(fmt.Errorf("not a command"))
+		assert.Equal(t, "", stdout)
+		assert.Equal(t, "", stderr)
+	})
+}
+
+func TestTimedOut(t *testing.T) {
+	t.Run("Command killed when exceeding its timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := Execute(ctx, ".", "sleep", "10")
+
+		assert.True(t, TimedOut(fmt.Errorf("could not enroll: %w", err)))
+		assert.Equal(t, -1, ExitCode(err))
+		assert.Contains(t, err.Error(), "the command exceeded its timeout and it was killed")
+	})
+
+	t.Run("Command failing before its timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, err := Execute(ctx, ".", "sh", "-c", "exit 1")
+
+		assert.False(t, TimedOut(err))
+	})
+
+	t.Run("Errors not coming from commands", func(t *testing.T) {
+		assert.False(t, TimedOut(fmt.Errorf("not a command")))
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/elastic/e2e-testing/internal/kibana"
+	"github.com/elastic/e2e-testing/pkg/downloads"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.elastic.co/apm"
+)
+
+// RequireTagPrefix the prefix of the tags skipping a scenario when the target stack lacks a capability, followed
+// by the name of the capability: ex. @requires:outputs skips the scenario if Fleet does not expose the outputs API
+const RequireTagPrefix = "@requires:"
+
+const (
+	// FleetServerCapability Fleet has a Fleet Server available to enroll the agents
+	FleetServerCapability = "fleet-server"
+	// OutputsCapability Fleet exposes the API to manage the outputs of the policies
+	OutputsCapability = "outputs"
+	// AgentTagsCapability Fleet supports tagging the agents
+	AgentTagsCapability = "agent-tags"
+)
+
+// agentTagsMinVersion the first version of Kibana where the agents can be tagged
+const agentTagsMinVersion = "8.3.0"
+
+// CapabilitySet the features available in the target stack, keyed by the name of the capability
+type CapabilitySet struct {
+	KibanaVersion string
+	available     map[string]bool
+}
+
+// Capabilities probes the target Kibana for the features the scenarios depend on, so that a single set of
+// feature files can run against stacks of different versions and flavours
+func Capabilities(ctx context.Context, client *kibana.Client) (CapabilitySet, error) {
+	span, _ := apm.StartSpanOptions(ctx, "Detecting stack capabilities", "stack.capabilities", apm.SpanOptions{
+		Parent: apm.SpanFromContext(ctx).TraceContext(),
+	})
+	defer span.End()
+
+	version, err := getKibanaVersion(ctx, client)
+	if err != nil {
+		return CapabilitySet{}, err
+	}
+
+	fleetServer, err := hasFleetServer(ctx, client)
+	if err != nil {
+		return CapabilitySet{}, err
+	}
+
+	statusCode, _, err := client.Request(ctx, http.MethodGet, fmt.Sprintf("%s/outputs", kibana.FleetAPI), nil)
+	if err != nil {
+		return CapabilitySet{}, errors.Wrap(err, "could not probe the Fleet outputs API")
+	}
+
+	capabilities := NewCapabilitySet(version, map[string]bool{
+		FleetServerCapability: fleetServer,
+		OutputsCapability:     statusCode == http.StatusOK,
+		AgentTagsCapability:   downloads.IsVersionAtLeast(version, agentTagsMinVersion),
+	})
+
+	log.WithFields(log.Fields{
+		"capabilities":  capabilities.available,
+		"kibanaVersion": version,
+	}).Info("Stack capabilities detected")
+
+	return capabilities, nil
+}
+
+// NewCapabilitySet creates a set of capabilities for a version of Kibana
+func NewCapabilitySet(kibanaVersion string, available map[string]bool) CapabilitySet {
+	capabilities := CapabilitySet{
+		KibanaVersion: kibanaVersion,
+		available:     map[string]bool{},
+	}
+
+	for name, ok := range available {
+		capabilities.available[name] = ok
+	}
+
+	return capabilities
+}
+
+// Has returns if the capability is available in the stack
+func (cs CapabilitySet) Has(capability string) bool {
+	return cs.available[capability]
+}
+
+// MissingReason returns why a scenario with the tags must be skipped because the stack lacks a capability it
+// requires, or an empty string if the scenario must run. An error is returned for unknown capabilities
+func (cs CapabilitySet) MissingReason(tags []string) (string, error) {
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, RequireTagPrefix) {
+			continue
+		}
+
+		capability := strings.TrimPrefix(tag, RequireTagPrefix)
+		available, known := cs.available[capability]
+		if !known {
+			return "", fmt.Errorf("the %s tag is not valid. Supported capabilities: %s", tag, strings.Join(cs.names(), ", "))
+		}
+
+		if !available {
+			return fmt.Sprintf("the stack (Kibana %s) does not support the %s capability", cs.KibanaVersion, capability), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (cs CapabilitySet) names() []string {
+	names := []string{}
+	for name := range cs.available {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func getKibanaVersion(ctx context.Context, client *kibana.Client) (string, error) {
+	statusCode, respBody, err := client.Request(ctx, http.MethodGet, "/api/status", nil)
+	if err != nil {
+		return "", errors.Wrap(err, "could not get Kibana status")
+	}
+
+	if statusCode != 200 {
+		return "", fmt.Errorf("could not get Kibana status; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", errors.Wrap(err, "Unable to convert Kibana status to JSON")
+	}
+
+	return resp.Version.Number, nil
+}
+
+// hasFleetServer checks the Fleet setup, which lists the Fleet Server as a missing requirement until
+// one is available to enroll the agents
+func hasFleetServer(ctx context.Context, client *kibana.Client) (bool, error) {
+	statusCode, respBody, err := client.Request(ctx, http.MethodGet, fmt.Sprintf("%s/agents/setup", kibana.FleetAPI), nil)
+	if err != nil {
+		return false, errors.Wrap(err, "could not get Fleet setup status")
+	}
+
+	if statusCode != 200 {
+		return false, fmt.Errorf("could not get Fleet setup status; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		IsReady             bool     `json:"isReady"`
+		MissingRequirements []string `json:"missing_requirements"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return false, errors.Wrap(err, "Unable to convert Fleet setup status to JSON")
+	}
+
+	for _, requirement := range resp.MissingRequirements {
+		if requirement == "fleet_server" {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,43 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilitySet(t *testing.T) {
+	capabilities := NewCapabilitySet("8.2.3", map[string]bool{
+		FleetServerCapability: true,
+		OutputsCapability:     true,
+		AgentTagsCapability:   false,
+	})
+
+	t.Run("Available capabilities", func(t *testing.T) {
+		assert.True(t, capabilities.Has(FleetServerCapability))
+		assert.False(t, capabilities.Has(AgentTagsCapability))
+		assert.False(t, capabilities.Has("unknown"))
+	})
+
+	t.Run("Scenarios requiring available capabilities run", func(t *testing.T) {
+		reason, err := capabilities.MissingReason([]string{"@fleet_mode", "@requires:outputs", "@requires:fleet-server"})
+		assert.Nil(t, err)
+		assert.Empty(t, reason)
+	})
+
+	t.Run("Scenarios requiring missing capabilities are skipped", func(t *testing.T) {
+		reason, err := capabilities.MissingReason([]string{"@requires:agent-tags"})
+		assert.Nil(t, err)
+		assert.Equal(t, "the stack (Kibana 8.2.3) does not support the agent-tags capability", reason)
+	})
+
+	t.Run("Unknown capabilities are not valid", func(t *testing.T) {
+		_, err := capabilities.MissingReason([]string{"@requires:tags"})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "agent-tags, fleet-server, outputs")
+	})
+}
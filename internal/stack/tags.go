@@ -0,0 +1,68 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/e2e-testing/pkg/downloads"
+)
+
+// SkipTagPrefix the prefix of the tags skipping a scenario depending on the version of the stack, followed by
+// a comparison operator and a version: ex. @skip:<7.10 skips the scenario for the stacks lower than 7.10
+const SkipTagPrefix = "@skip:"
+
+// skipOperators the supported comparison operators, the two-character ones first so they are matched before
+// their one-character prefixes
+var skipOperators = []string{"<=", ">=", "<", ">", "="}
+
+// SkipReason returns why a scenario with the tags must be skipped for the version of the stack, or an empty
+// string if the scenario must run. An error is returned if a skip tag is not well formed
+func SkipReason(tags []string, stackVersion string) (string, error) {
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, SkipTagPrefix) {
+			continue
+		}
+
+		condition := strings.TrimPrefix(tag, SkipTagPrefix)
+
+		operator := ""
+		for _, op := range skipOperators {
+			if strings.HasPrefix(condition, op) {
+				operator = op
+				break
+			}
+		}
+
+		version := strings.TrimPrefix(condition, operator)
+		if operator == "" || version == "" {
+			return "", fmt.Errorf("the %s tag is not valid. Use %s followed by an operator (<, <=, >, >=, =) and a version, i.e. %s<7.10", tag, SkipTagPrefix, SkipTagPrefix)
+		}
+
+		if matchesVersion(stackVersion, operator, version) {
+			return fmt.Sprintf("the %s version of the stack matches the %s tag", stackVersion, tag), nil
+		}
+	}
+
+	return "", nil
+}
+
+func matchesVersion(stackVersion string, operator string, version string) bool {
+	cmp := downloads.CompareVersions(stackVersion, version)
+
+	switch operator {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+
+	return cmp == 0
+}
@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipReason(t *testing.T) {
+	t.Run("Scenarios without skip tags run", func(t *testing.T) {
+		reason, err := SkipReason([]string{"@fleet_mode", "@install"}, "7.9.3")
+		assert.Nil(t, err)
+		assert.Empty(t, reason)
+	})
+
+	t.Run("Scenarios are skipped for lower stack versions", func(t *testing.T) {
+		reason, err := SkipReason([]string{"@install", "@skip:<7.10"}, "7.9.3-SNAPSHOT")
+		assert.Nil(t, err)
+		assert.Equal(t, "the 7.9.3-SNAPSHOT version of the stack matches the @skip:<7.10 tag", reason)
+	})
+
+	t.Run("Scenarios run for greater stack versions", func(t *testing.T) {
+		reason, err := SkipReason([]string{"@skip:<7.10"}, "8.6.0-233dc5d4-SNAPSHOT")
+		assert.Nil(t, err)
+		assert.Empty(t, reason)
+	})
+
+	t.Run("Two-character operators include the version", func(t *testing.T) {
+		reason, err := SkipReason([]string{"@skip:>=8.6"}, "8.6.0")
+		assert.Nil(t, err)
+		assert.NotEmpty(t, reason)
+	})
+
+	t.Run("Tags without operator are not valid", func(t *testing.T) {
+		_, err := SkipReason([]string{"@skip:7.10"}, "7.9.3")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,24 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"strings"
+
+	"github.com/elastic/e2e-testing/pkg/downloads"
+)
+
+// VersionMatches checks if the running version of the stack matches the declared one, which could omit the
+// patch or minor versions: ex. 8.6.2-SNAPSHOT matches 8.6, but not 8.6.1
+func VersionMatches(running string, declared string) bool {
+	runningParts := strings.Split(strings.SplitN(running, "-", 2)[0], ".")
+	declaredParts := strings.Split(strings.SplitN(declared, "-", 2)[0], ".")
+
+	if len(declaredParts) > len(runningParts) {
+		return false
+	}
+
+	return downloads.CompareVersions(strings.Join(runningParts[:len(declaredParts)], "."), strings.Join(declaredParts, ".")) == 0
+}
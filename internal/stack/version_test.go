@@ -0,0 +1,27 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionMatches(t *testing.T) {
+	t.Run("Declared versions can omit the patch version", func(t *testing.T) {
+		assert.True(t, VersionMatches("8.6.2-SNAPSHOT", "8.6"))
+		assert.True(t, VersionMatches("8.6.0-233dc5d4-SNAPSHOT", "8"))
+	})
+
+	t.Run("Full versions must be equal, ignoring the snapshot and commit", func(t *testing.T) {
+		assert.True(t, VersionMatches("8.6.0-233dc5d4-SNAPSHOT", "8.6.0"))
+		assert.False(t, VersionMatches("8.6.2", "8.6.1"))
+	})
+
+	t.Run("Other minor versions do not match", func(t *testing.T) {
+		assert.False(t, VersionMatches("8.5.3", "8.6"))
+	})
+}
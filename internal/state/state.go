@@ -21,6 +21,7 @@ type CurrentRun struct {
 	Profile  Service           // profile of the run (Optional)
 	Env      map[string]string // environment for the run
 	Services []Service         // services in the run
+	Compose  []string          // compose files of the run, starting with the profile
 }
 
 // Service represents a service in a Run
@@ -83,6 +84,7 @@ func Update(id string, workdir string, composeFilePaths []string, env map[string
 		ID:       id,
 		Env:      env,
 		Services: []Service{},
+		Compose:  composeFilePaths,
 	}
 
 	if strings.HasSuffix(id, "-profile") {
@@ -72,6 +72,7 @@ func TestRecover(t *testing.T) {
 	assert.Equal(t, run.ID, ID)
 	assert.Equal(t, run.Profile.Name, "a")
 	assert.Equal(t, len(run.Services), 3)
+	assert.Equal(t, composeFiles, run.Compose)
 
 	env := run.Env
 	value, e := env["foo"]
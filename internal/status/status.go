@@ -0,0 +1,157 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SuiteStatus represents the progress of a running test suite, exposed as JSON by the status endpoint
+type SuiteStatus struct {
+	Suite             string            `json:"suite"`
+	Feature           string            `json:"feature"`
+	Scenario          string            `json:"scenario"`
+	Step              string            `json:"step"`
+	SuiteStartedAt    time.Time         `json:"suite_started_at"`
+	ScenarioStartedAt time.Time         `json:"scenario_started_at"`
+	StepStartedAt     time.Time         `json:"step_started_at"`
+	SuiteElapsed      string            `json:"suite_elapsed"`
+	ScenarioElapsed   string            `json:"scenario_elapsed,omitempty"`
+	StepElapsed       string            `json:"step_elapsed,omitempty"`
+	ScenariosPassed   int               `json:"scenarios_passed"`
+	ScenariosFailed   int               `json:"scenarios_failed"`
+	ScenariosAborted  int               `json:"scenarios_aborted"`
+	Environment       map[string]string `json:"environment"`
+}
+
+var current = SuiteStatus{
+	Environment: map[string]string{},
+}
+var mutex sync.Mutex
+
+// Serve exposes the status of the suite as JSON at the /status path of the address (i.e. localhost:8090),
+// in the background. Errors serving the endpoint are logged, as they must not break the suite
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", handler)
+
+	go func() {
+		log.WithField("address", addr).Info("Serving the status of the suite")
+
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"address": addr,
+				"error":   err,
+			}).Warn("Could not serve the status of the suite")
+		}
+	}()
+}
+
+// SuiteStarted records the start of the suite, including the environment details to expose
+func SuiteStarted(suite string, env map[string]string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	current.Suite = suite
+	current.SuiteStartedAt = time.Now()
+	current.Environment = map[string]string{}
+	for k, v := range env {
+		current.Environment[k] = v
+	}
+}
+
+// ScenarioStarted records the start of a scenario of a feature file
+func ScenarioStarted(feature string, scenario string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	current.Feature = feature
+	current.Scenario = scenario
+	current.ScenarioStartedAt = time.Now()
+	current.Step = ""
+	current.StepStartedAt = time.Time{}
+}
+
+// StepStarted records the start of a step of the current scenario
+func StepStarted(step string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	current.Step = step
+	current.StepStartedAt = time.Now()
+}
+
+// ScenarioFinished records the result of the current scenario
+func ScenarioFinished(err error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err != nil {
+		current.ScenariosFailed++
+	} else {
+		current.ScenariosPassed++
+	}
+
+	resetScenario()
+}
+
+// ScenarioAborted records the current scenario as aborted, i.e. because the run exceeded its deadline
+func ScenarioAborted() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	current.ScenariosAborted++
+
+	resetScenario()
+}
+
+func resetScenario() {
+	current.Scenario = ""
+	current.ScenarioStartedAt = time.Time{}
+	current.Step = ""
+	current.StepStartedAt = time.Time{}
+}
+
+// Get returns a copy of the current status, with the elapsed times computed at the moment of the call
+func Get() SuiteStatus {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	s := current
+	s.Environment = map[string]string{}
+	for k, v := range current.Environment {
+		s.Environment[k] = v
+	}
+
+	s.SuiteElapsed = elapsed(s.SuiteStartedAt)
+	s.ScenarioElapsed = elapsed(s.ScenarioStartedAt)
+	s.StepElapsed = elapsed(s.StepStartedAt)
+
+	return s
+}
+
+func elapsed(since time.Time) string {
+	if since.IsZero() {
+		return ""
+	}
+
+	return time.Since(since).Round(time.Second).String()
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Get())
+}
@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	SuiteStarted("fleet", map[string]string{"stackVersion": "8.6.0-SNAPSHOT"})
+
+	ScenarioStarted("features/fleet_mode.feature", "Deploying the agent")
+	StepStarted("an agent is deployed to Fleet with \"tar\" installer")
+
+	s := Get()
+	assert.Equal(t, "fleet", s.Suite)
+	assert.Equal(t, "features/fleet_mode.feature", s.Feature)
+	assert.Equal(t, "Deploying the agent", s.Scenario)
+	assert.Equal(t, "an agent is deployed to Fleet with \"tar\" installer", s.Step)
+	assert.Equal(t, "0s", s.StepElapsed)
+	assert.Equal(t, "8.6.0-SNAPSHOT", s.Environment["stackVersion"])
+
+	ScenarioFinished(nil)
+	ScenarioStarted("features/fleet_mode.feature", "Un-enrolling the agent")
+	ScenarioFinished(errors.New("the agent is online"))
+
+	s = Get()
+	assert.Equal(t, "", s.Scenario)
+	assert.Equal(t, "", s.ScenarioElapsed)
+	assert.Equal(t, 1, s.ScenariosPassed)
+	assert.Equal(t, 1, s.ScenariosFailed)
+	assert.Equal(t, 0, s.ScenariosAborted)
+
+	ScenarioStarted("features/fleet_mode.feature", "Re-enrolling the agent")
+	ScenarioAborted()
+
+	s = Get()
+	assert.Equal(t, "", s.Scenario)
+	assert.Equal(t, 1, s.ScenariosFailed)
+	assert.Equal(t, 1, s.ScenariosAborted)
+}
+
+func TestHandler(t *testing.T) {
+	SuiteStarted("fleet", map[string]string{})
+
+	t.Run("The status is returned as JSON", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		s := SuiteStatus{}
+		err := json.Unmarshal(rec.Body.Bytes(), &s)
+		assert.Nil(t, err)
+		assert.Equal(t, "fleet", s.Suite)
+	})
+
+	t.Run("Only GET is allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/status", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
@@ -4,6 +4,26 @@
 
 package systemd
 
+// DaemonReloadCmds represents the command and base arguments to reload the units, such as the ones installed by a package
+func DaemonReloadCmds() []string {
+	return []string{"systemctl", "daemon-reload"}
+}
+
+// EnableCmds represents the command and base arguments to enable a unit at boot
+func EnableCmds(unit string) []string {
+	return []string{"systemctl", "enable", unit}
+}
+
+// IsActiveCmds represents the command and base arguments to print whether a unit is active
+func IsActiveCmds(unit string) []string {
+	return []string{"systemctl", "is-active", unit}
+}
+
+// IsEnabledCmds represents the command and base arguments to print whether a unit is enabled at boot
+func IsEnabledCmds(unit string) []string {
+	return []string{"systemctl", "is-enabled", unit}
+}
+
 // LogCmds represents the command and base arguments to retrieve a unit's logs
 func LogCmds(unit string) []string {
 	// -m --merge	     Show entries from all available journals
@@ -0,0 +1,69 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package utils
+
+import (
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// HeartbeatInterval the interval between two progress lines while waiting in a backoff retry, so that
+// long waits do not look hung. Zero disables the heartbeat.
+// It can be overriden by HEARTBEAT_INTERVAL env var (i.e. 1m)
+var HeartbeatInterval = 30 * time.Second
+
+func init() {
+	HeartbeatInterval = shell.GetEnvDuration("HEARTBEAT_INTERVAL", HeartbeatInterval)
+}
+
+// RetryWithHeartbeat retries the operation using the exponential backoff, like backoff.Retry, but logging
+// a progress line with the elapsed and remaining times every heartbeat interval, until the operation
+// succeeds or the backoff gives up
+func RetryWithHeartbeat(description string, operation backoff.Operation, exp *CountingBackOff) error {
+	if HeartbeatInterval <= 0 {
+		return backoff.Retry(operation, exp)
+	}
+
+	started := time.Now()
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				elapsed, remaining := heartbeatProgress(started, exp.MaxElapsedTime)
+
+				log.WithFields(log.Fields{
+					"elapsedTime":   elapsed,
+					"remainingTime": remaining,
+				}).Infof("Still waiting for %s", description)
+			}
+		}
+	}()
+
+	return backoff.Retry(operation, exp)
+}
+
+// heartbeatProgress returns the elapsed time since the wait started, and the remaining time until the max
+// elapsed time, rounded to seconds. The remaining time is zero if there is no max elapsed time
+func heartbeatProgress(started time.Time, maxElapsedTime time.Duration) (time.Duration, time.Duration) {
+	elapsed := time.Since(started).Round(time.Second)
+
+	remaining := time.Duration(0)
+	if maxElapsedTime > elapsed {
+		remaining = maxElapsedTime - elapsed
+	}
+
+	return elapsed, remaining
+}
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package utils
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces out the calls to Wait, so that no more than the configured number of requests per second
+// are allowed. It is safe for concurrent use
+type RateLimiter struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	next     time.Time
+}
+
+// NewRateLimiter returns a rate limiter allowing the number of requests per second. Zero or a negative number
+// means no limit
+func NewRateLimiter(requestsPerSecond int) *RateLimiter {
+	interval := time.Duration(0)
+	if requestsPerSecond > 0 {
+		interval = time.Second / time.Duration(requestsPerSecond)
+	}
+
+	return &RateLimiter{
+		interval: interval,
+	}
+}
+
+// Wait blocks until the next request is allowed
+func (r *RateLimiter) Wait() {
+	if r.interval == 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	now := time.Now()
+	wait := r.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	r.next = now.Add(wait).Add(r.interval)
+	r.mutex.Unlock()
+
+	time.Sleep(wait)
+}
+
+// rateLimitedRoundTripper waits for the rate limiter before sending each request
+type rateLimitedRoundTripper struct {
+	limiter *RateLimiter
+	next    http.RoundTripper
+}
+
+// RoundTrip sends the request once the rate limiter allows it
+func (rt *rateLimitedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.limiter.Wait()
+
+	return rt.next.RoundTrip(req)
+}
+
+// WrapRoundTripperWithRateLimiter returns a round tripper waiting for the API rate limiter before sending each request
+func WrapRoundTripperWithRateLimiter(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &rateLimitedRoundTripper{
+		limiter: APIRateLimiter,
+		next:    next,
+	}
+}
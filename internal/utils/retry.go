@@ -5,9 +5,12 @@
 package utils
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/metrics"
 	"github.com/elastic/e2e-testing/internal/shell"
 )
 
@@ -15,20 +18,83 @@ import (
 // It can be overriden by TIMEOUT_FACTOR env var
 var TimeoutFactor = 3
 
+// PollInterval the initial interval between two checks when doing backoff retries.
+// It can be overriden by POLL_INTERVAL env var (i.e. 2s)
+var PollInterval = 500 * time.Millisecond
+
+// MaxPollInterval the max interval between two checks when doing backoff retries.
+// It can be overriden by POLL_MAX_INTERVAL env var (i.e. 30s)
+var MaxPollInterval = 5 * time.Second
+
+// MaxRequestsPerSecond the max number of requests per second sent to the Kibana and Elasticsearch APIs,
+// to avoid hammering shared instances. Zero means no limit.
+// It can be overriden by API_MAX_REQUESTS_PER_SECOND env var
+var MaxRequestsPerSecond = 0
+
+// APIRateLimiter the rate limiter shared by the clients of the Kibana and Elasticsearch APIs
+var APIRateLimiter = NewRateLimiter(MaxRequestsPerSecond)
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^A-Z0-9]+`)
+
 func init() {
 	TimeoutFactor = shell.GetEnvInteger("TIMEOUT_FACTOR", TimeoutFactor)
+	PollInterval = shell.GetEnvDuration("POLL_INTERVAL", PollInterval)
+	MaxPollInterval = shell.GetEnvDuration("POLL_MAX_INTERVAL", MaxPollInterval)
+	MaxRequestsPerSecond = shell.GetEnvInteger("API_MAX_REQUESTS_PER_SECOND", MaxRequestsPerSecond)
+
+	APIRateLimiter = NewRateLimiter(MaxRequestsPerSecond)
+}
+
+// CountingBackOff an exponential backoff counting the failed attempts of the operations retried with it
+type CountingBackOff struct {
+	*backoff.ExponentialBackOff
+}
+
+// NextBackOff increments the retries metric, as it's called by backoff.Retry after each failed attempt,
+// unless the max elapsed time is reached and the operation is not retried anymore
+func (b *CountingBackOff) NextBackOff() time.Duration {
+	next := b.ExponentialBackOff.NextBackOff()
+	if next != backoff.Stop {
+		metrics.RetriesTotal.Inc()
+	}
+
+	return next
 }
 
 // GetExponentialBackOff returns a preconfigured exponential backoff instance
-func GetExponentialBackOff(elapsedTime time.Duration) *backoff.ExponentialBackOff {
+func GetExponentialBackOff(elapsedTime time.Duration) *CountingBackOff {
+	return newExponentialBackOff(PollInterval, MaxPollInterval, elapsedTime)
+}
+
+// GetExponentialBackOffForCheck returns a preconfigured exponential backoff instance for a specific check,
+// which poll intervals can be overriden with the POLL_INTERVAL_<CHECK> and POLL_MAX_INTERVAL_<CHECK> env vars,
+// where <CHECK> is the name of the check in upper case (i.e. POLL_INTERVAL_AGENT_STATUS=1s)
+func GetExponentialBackOffForCheck(check string, elapsedTime time.Duration) *CountingBackOff {
+	suffix := checkEnvSuffix(check)
+
+	initialInterval := shell.GetEnvDuration("POLL_INTERVAL_"+suffix, PollInterval)
+	maxInterval := shell.GetEnvDuration("POLL_MAX_INTERVAL_"+suffix, MaxPollInterval)
+
+	return newExponentialBackOff(initialInterval, maxInterval, elapsedTime)
+}
+
+// checkEnvSuffix converts the name of a check into the suffix of its env vars (i.e. "agent status" to "AGENT_STATUS")
+func checkEnvSuffix(check string) string {
+	return strings.Trim(nonAlphanumericRegex.ReplaceAllString(strings.ToUpper(check), "_"), "_")
+}
+
+func newExponentialBackOff(initialInterval time.Duration, maxInterval time.Duration, elapsedTime time.Duration) *CountingBackOff {
 	var (
-		initialInterval     = 500 * time.Millisecond
 		randomizationFactor = 0.5
 		multiplier          = 2.0
-		maxInterval         = 5 * time.Second
 		maxElapsedTime      = elapsedTime
 	)
 
+	// the max interval cannot be lower than the initial one
+	if maxInterval < initialInterval {
+		maxInterval = initialInterval
+	}
+
 	exp := backoff.NewExponentialBackOff()
 	exp.InitialInterval = initialInterval
 	exp.RandomizationFactor = randomizationFactor
@@ -36,5 +102,5 @@ func GetExponentialBackOff(elapsedTime time.Duration) *backoff.ExponentialBackOf
 	exp.MaxInterval = maxInterval
 	exp.MaxElapsedTime = maxElapsedTime
 
-	return exp
+	return &CountingBackOff{ExponentialBackOff: exp}
 }
@@ -0,0 +1,124 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/elastic/e2e-testing/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExponentialBackOffForCheck(t *testing.T) {
+	t.Run("Default intervals are used without overrides", func(t *testing.T) {
+		exp := GetExponentialBackOffForCheck("agent status", time.Minute)
+
+		assert.Equal(t, PollInterval, exp.InitialInterval)
+		assert.Equal(t, MaxPollInterval, exp.MaxInterval)
+		assert.Equal(t, time.Minute, exp.MaxElapsedTime)
+	})
+
+	t.Run("Intervals are overriden for the check", func(t *testing.T) {
+		os.Setenv("POLL_INTERVAL_AGENT_STATUS", "100ms")
+		os.Setenv("POLL_MAX_INTERVAL_AGENT_STATUS", "1s")
+		defer os.Unsetenv("POLL_INTERVAL_AGENT_STATUS")
+		defer os.Unsetenv("POLL_MAX_INTERVAL_AGENT_STATUS")
+
+		exp := GetExponentialBackOffForCheck("agent status", time.Minute)
+
+		assert.Equal(t, 100*time.Millisecond, exp.InitialInterval)
+		assert.Equal(t, time.Second, exp.MaxInterval)
+	})
+
+	t.Run("Max interval is never lower than the initial one", func(t *testing.T) {
+		os.Setenv("POLL_INTERVAL_DATA_STREAMS", "10s")
+		os.Setenv("POLL_MAX_INTERVAL_DATA_STREAMS", "1s")
+		defer os.Unsetenv("POLL_INTERVAL_DATA_STREAMS")
+		defer os.Unsetenv("POLL_MAX_INTERVAL_DATA_STREAMS")
+
+		exp := GetExponentialBackOffForCheck("data-streams", time.Minute)
+
+		assert.Equal(t, 10*time.Second, exp.InitialInterval)
+		assert.Equal(t, 10*time.Second, exp.MaxInterval)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("Requests are not delayed without a limit", func(t *testing.T) {
+		limiter := NewRateLimiter(0)
+
+		start := time.Now()
+		for i := 0; i < 10; i++ {
+			limiter.Wait()
+		}
+
+		assert.True(t, time.Since(start) < 100*time.Millisecond)
+	})
+
+	t.Run("Requests are spaced out by the limit", func(t *testing.T) {
+		limiter := NewRateLimiter(20)
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			limiter.Wait()
+		}
+
+		// the first request is not delayed, the next four wait 50ms each
+		assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	})
+}
+
+func TestHeartbeatProgress(t *testing.T) {
+	t.Run("Remaining time is the difference with the max elapsed time", func(t *testing.T) {
+		elapsed, remaining := heartbeatProgress(time.Now().Add(-90*time.Second), 5*time.Minute)
+
+		assert.Equal(t, 90*time.Second, elapsed)
+		assert.Equal(t, 210*time.Second, remaining)
+	})
+
+	t.Run("Remaining time is never negative", func(t *testing.T) {
+		_, remaining := heartbeatProgress(time.Now().Add(-10*time.Minute), 5*time.Minute)
+
+		assert.Equal(t, time.Duration(0), remaining)
+	})
+}
+
+func TestCountingBackOff(t *testing.T) {
+	t.Run("Only the retried attempts are counted", func(t *testing.T) {
+		before := retriesTotal(t)
+
+		attempts := 0
+		exp := newExponentialBackOff(time.Millisecond, time.Millisecond, 20*time.Millisecond)
+		err := backoff.Retry(func() error {
+			attempts++
+			return errors.New("failed")
+		}, exp)
+
+		assert.Error(t, err)
+		assert.Equal(t, float64(attempts-1), retriesTotal(t)-before)
+	})
+}
+
+func retriesTotal(t *testing.T) float64 {
+	var out bytes.Buffer
+	metrics.Write(&out)
+
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "e2e_retries_total ") {
+			value, err := strconv.ParseFloat(strings.TrimPrefix(line, "e2e_retries_total "), 64)
+			assert.Nil(t, err)
+			return value
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,305 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package downloads
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	internalio "github.com/elastic/e2e-testing/internal/io"
+	"github.com/elastic/e2e-testing/internal/shell"
+	"github.com/elastic/e2e-testing/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArtifactsCacheDir is the directory where the downloaded artifacts are kept across runs, so that they are not
+// downloaded again. It can be overriden by ARTIFACTS_CACHE_DIR env var. Using the empty string as a default,
+// which disables the cache
+var ArtifactsCacheDir = ""
+
+// binariesCacheMutex protects the cache of the binaries, as the artifacts can be downloaded in parallel
+var binariesCacheMutex sync.Mutex
+
+func init() {
+	ArtifactsCacheDir = shell.GetEnv("ARTIFACTS_CACHE_DIR", ArtifactsCacheDir)
+}
+
+// cachedBinary returns the location of the binary downloaded from the URL, if any
+func cachedBinary(URL string) (string, bool) {
+	binariesCacheMutex.Lock()
+	defer binariesCacheMutex.Unlock()
+
+	val, ok := binariesCache[URL]
+	return val, ok
+}
+
+// cacheBinary stores the location of the binary downloaded from the URL
+func cacheBinary(URL string, path string) {
+	binariesCacheMutex.Lock()
+	defer binariesCacheMutex.Unlock()
+
+	binariesCache[URL] = path
+}
+
+// artifactCachePath returns the path of the artifact in the cache directory, in a directory named after its
+// SHA-512 checksum, so that an artifact rebuilt with the same URL, such as a SNAPSHOT, is not served from the cache
+func artifactCachePath(checksum string, name string) string {
+	return filepath.Join(ArtifactsCacheDir, checksum[:16], name)
+}
+
+// downloadToCache downloads the artifact and its checksum to the cache directory, unless the artifact with that
+// checksum was downloaded in a previous run, returning their locations. The checksum is always downloaded, as it
+// identifies the artifact in the cache. The artifact is downloaded to a partial file first, so that an interrupted
+// download is resumed the next time, and it is only moved to the cache when its checksum matches
+func downloadToCache(URL string, name string, shaURL string) (string, string, error) {
+	maxTimeout := time.Duration(utils.TimeoutFactor) * time.Minute
+
+	checksum, shaContent, err := fetchChecksum(shaURL, maxTimeout)
+	if err != nil {
+		return "", "", err
+	}
+
+	target := artifactCachePath(checksum, name)
+	shaTarget := target + ".sha512"
+
+	err = internalio.MkdirAll(filepath.Dir(target))
+	if err != nil {
+		return "", "", err
+	}
+
+	err = ioutil.WriteFile(shaTarget, shaContent, 0666)
+	if err != nil {
+		return "", "", err
+	}
+
+	found, err := internalio.Exists(target)
+	if found && err == nil {
+		log.WithFields(log.Fields{
+			"URL":      URL,
+			"checksum": checksum,
+			"path":     target,
+		}).Debug("Retrieving binary from the artifacts cache")
+		return target, shaTarget, nil
+	}
+
+	partial := target + ".part"
+	err = resumeDownload(URL, partial, maxTimeout)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = verifySHA512(partial, checksum)
+	if err != nil {
+		// the partial file is corrupted, so the next download must start over
+		_ = os.Remove(partial)
+		return "", "", err
+	}
+
+	err = os.Rename(partial, target)
+	if err != nil {
+		return "", "", err
+	}
+
+	return target, shaTarget, nil
+}
+
+// fetchChecksum downloads the SHA-512 checksum file, returning the checksum and the content of the file,
+// in the "<checksum>  <file name>" format
+func fetchChecksum(shaURL string, maxTimeout time.Duration) (string, []byte, error) {
+	exp := utils.GetExponentialBackOff(maxTimeout)
+
+	var checksum string
+	var content []byte
+
+	retryCount := 1
+	fetch := func() error {
+		resp, err := http.Get(shaURL)
+		if err != nil {
+			retryCount++
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"retry":       retryCount,
+				"statusCode":  resp.StatusCode,
+				"url":         shaURL,
+			}).Warn("Could not download the checksum")
+
+			retryCount++
+			return fmt.Errorf("could not download %s: status code = %d", shaURL, resp.StatusCode)
+		}
+
+		content, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			retryCount++
+			return err
+		}
+
+		fields := strings.Fields(string(content))
+		if len(fields) == 0 || len(fields[0]) != sha512.Size*2 {
+			return backoff.Permanent(fmt.Errorf("%s is not a SHA-512 checksum file", shaURL))
+		}
+		checksum = strings.ToLower(fields[0])
+
+		return nil
+	}
+
+	err := backoff.Retry(fetch, exp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return checksum, content, nil
+}
+
+// verifySHA512 checks that the SHA-512 checksum of the file is the expected one
+func verifySHA512(path string, expected string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha512.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if actual != expected {
+		return fmt.Errorf("the checksum of %s is %s, but %s was expected", path, actual, expected)
+	}
+
+	return nil
+}
+
+// resumeDownload downloads the URL to the file, appending to its current content if the server supports
+// range requests, and retrying the download from where it was interrupted
+func resumeDownload(URL string, path string, maxTimeout time.Duration) error {
+	exp := utils.GetExponentialBackOff(maxTimeout)
+
+	retryCount := 1
+	download := func() error {
+		offset := int64(0)
+		if info, err := os.Stat(path); err == nil {
+			offset = info.Size()
+		}
+
+		err := downloadFrom(URL, path, offset)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"elapsedTime": exp.GetElapsedTime(),
+				"error":       err,
+				"offset":      offset,
+				"path":        path,
+				"retry":       retryCount,
+				"url":         URL,
+			}).Warn("Could not download the file")
+
+			retryCount++
+
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"elapsedTime": exp.GetElapsedTime(),
+			"offset":      offset,
+			"path":        path,
+			"retries":     retryCount,
+			"url":         URL,
+		}).Trace("File downloaded")
+
+		return nil
+	}
+
+	return backoff.Retry(download, exp)
+}
+
+// downloadFrom downloads the URL to the file, requesting the content after the offset
+func downloadFrom(URL string, path string, offset int64) error {
+	req, err := http.NewRequest(http.MethodGet, URL, nil)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// the server does not support range requests, so the download starts over
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the file was completely downloaded before the interruption, unless it is larger than the remote file
+		var size int64
+		_, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes */%d", &size)
+		if err == nil && size == offset {
+			return nil
+		}
+
+		// the download starts over in the next attempt
+		_ = os.Remove(path)
+		return fmt.Errorf("could not resume the download of %s from %d bytes: its size is %d bytes", URL, offset, size)
+	default:
+		return fmt.Errorf("could not download %s: status code = %d", URL, resp.StatusCode)
+	}
+
+	file, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
+
+// fetchInParallel fetches the URLs at the same time, returning their locations in the same order
+func fetchInParallel(URLs []string, fetch func(URL string) (string, error)) ([]string, error) {
+	locations := make([]string, len(URLs))
+	errs := make([]error, len(URLs))
+
+	var wg sync.WaitGroup
+	for i, URL := range URLs {
+		wg.Add(1)
+		go func(i int, URL string) {
+			defer wg.Done()
+			locations[i], errs[i] = fetch(URL)
+		}(i, URL)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return locations, err
+		}
+	}
+
+	return locations, nil
+}
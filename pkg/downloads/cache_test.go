@@ -0,0 +1,172 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package downloads
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalio "github.com/elastic/e2e-testing/internal/io"
+	"github.com/stretchr/testify/assert"
+)
+
+var artifactContent = []byte("the content of the artifact")
+
+var artifactChecksum = fmt.Sprintf("%x", sha512.Sum512(artifactContent))
+
+func artifactServer(requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha512") {
+			fmt.Fprintf(w, "%s  artifact.tar.gz\n", artifactChecksum)
+			return
+		}
+
+		atomic.AddInt32(requests, 1)
+		http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(artifactContent))
+	}))
+}
+
+func TestArtifactCachePath(t *testing.T) {
+	defer func(dir string) { ArtifactsCacheDir = dir }(ArtifactsCacheDir)
+	ArtifactsCacheDir = "/cache"
+
+	t.Run("The directory is named after the checksum", func(t *testing.T) {
+		location := artifactCachePath(artifactChecksum, "artifact.tar.gz")
+
+		assert.Equal(t, filepath.Join("/cache", artifactChecksum[:16], "artifact.tar.gz"), location)
+	})
+
+	t.Run("Rebuilt artifacts use different directories", func(t *testing.T) {
+		a := artifactCachePath(strings.Repeat("a", 128), "artifact.tar.gz")
+		b := artifactCachePath(strings.Repeat("b", 128), "artifact.tar.gz")
+
+		assert.NotEqual(t, a, b)
+	})
+}
+
+func TestDownloadToCache(t *testing.T) {
+	defer func(dir string) { ArtifactsCacheDir = dir }(ArtifactsCacheDir)
+
+	t.Run("Cached artifact", func(t *testing.T) {
+		ArtifactsCacheDir = t.TempDir()
+
+		var requests int32
+		server := artifactServer(&requests)
+		defer server.Close()
+
+		location, shaLocation, err := downloadToCache(server.URL+"/artifact.tar.gz", "artifact.tar.gz", server.URL+"/artifact.tar.gz.sha512")
+		assert.Nil(t, err)
+		assert.Equal(t, location+".sha512", shaLocation)
+
+		content, err := ioutil.ReadFile(location)
+		assert.Nil(t, err)
+		assert.Equal(t, artifactContent, content)
+
+		again, _, err := downloadToCache(server.URL+"/artifact.tar.gz", "artifact.tar.gz", server.URL+"/artifact.tar.gz.sha512")
+		assert.Nil(t, err)
+		assert.Equal(t, location, again)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Checksum mismatch", func(t *testing.T) {
+		ArtifactsCacheDir = t.TempDir()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, ".sha512") {
+				fmt.Fprintf(w, "%s  artifact.tar.gz\n", strings.Repeat("a", 128))
+				return
+			}
+			w.Write(artifactContent)
+		}))
+		defer server.Close()
+
+		_, _, err := downloadToCache(server.URL+"/artifact.tar.gz", "artifact.tar.gz", server.URL+"/artifact.tar.gz.sha512")
+		assert.NotNil(t, err)
+
+		target := artifactCachePath(strings.Repeat("a", 128), "artifact.tar.gz")
+		for _, path := range []string{target, target + ".part"} {
+			found, _ := internalio.Exists(path)
+			assert.False(t, found, path)
+		}
+	})
+}
+
+func TestResumeDownload(t *testing.T) {
+	var requests int32
+	server := artifactServer(&requests)
+	defer server.Close()
+
+	t.Run("Interrupted download", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "artifact.part")
+		err := ioutil.WriteFile(path, artifactContent[:10], 0666)
+		assert.Nil(t, err)
+
+		err = resumeDownload(server.URL, path, time.Second)
+		assert.Nil(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, artifactContent, content)
+	})
+
+	t.Run("Partial file larger than the remote file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "artifact.part")
+		err := ioutil.WriteFile(path, append(artifactContent, []byte("garbage")...), 0666)
+		assert.Nil(t, err)
+
+		err = resumeDownload(server.URL, path, time.Second)
+		assert.Nil(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, artifactContent, content)
+	})
+
+	t.Run("Completed download", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "artifact.part")
+		err := ioutil.WriteFile(path, artifactContent, 0666)
+		assert.Nil(t, err)
+
+		err = resumeDownload(server.URL, path, time.Second)
+		assert.Nil(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, artifactContent, content)
+	})
+}
+
+func TestFetchInParallel(t *testing.T) {
+	URLs := []string{"a", "b", "c"}
+
+	t.Run("Locations keep the order of the URLs", func(t *testing.T) {
+		locations, err := fetchInParallel(URLs, func(URL string) (string, error) {
+			return "/tmp/" + URL, nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/tmp/a", "/tmp/b", "/tmp/c"}, locations)
+	})
+
+	t.Run("Any error is returned", func(t *testing.T) {
+		_, err := fetchInParallel(URLs, func(URL string) (string, error) {
+			if URL == "b" {
+				return "", fmt.Errorf("could not fetch %s", URL)
+			}
+			return "/tmp/" + URL, nil
+		})
+
+		assert.NotNil(t, err)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -98,6 +99,19 @@ func newElasticVersion(version string) *elasticVersion {
 	}
 }
 
+// DownloadedArtifacts returns the local path of the artifacts downloaded so far, by their URL
+func DownloadedArtifacts() map[string]string {
+	binariesCacheMutex.Lock()
+	defer binariesCacheMutex.Unlock()
+
+	artifacts := map[string]string{}
+	for url, path := range binariesCache {
+		artifacts[url] = path
+	}
+
+	return artifacts
+}
+
 // CheckPRVersion returns a fallback version if the version comes from a commit
 func CheckPRVersion(version string, fallbackVersion string) string {
 	if GithubCommitSha1 != "" {
@@ -252,6 +266,44 @@ func GetVersion(version string) string {
 	return newElasticVersion(version).Version
 }
 
+// CompareVersions compares two versions, without snapshot or commit, by their numeric parts, returning -1 if the
+// first one is lower, 0 if they are equal, and 1 if it is greater: ex. 8.10.0-SNAPSHOT is greater than 8.9
+func CompareVersions(a string, b string) int {
+	aParts := strings.Split(numericVersion(a), ".")
+	bParts := strings.Split(numericVersion(b), ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aNum, bNum := 0, 0
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// numericVersion returns the numeric parts of a version, without snapshot or commit. Unlike GetVersion,
+// aliases are not resolved, so that versions without patch, such as 8.9, are compared as they are
+func numericVersion(version string) string {
+	return strings.SplitN(version, "-", 2)[0]
+}
+
+// IsVersionAtLeast checks if the passed version, without snapshot or commit, is equal to or greater than
+// the minimum version: ex. 8.11.0-SNAPSHOT is at least 8.11.0
+func IsVersionAtLeast(version string, minimum string) bool {
+	return CompareVersions(version, minimum) >= 0
+}
+
 // IsAlias checks if the passed version is an alias: ex. 8.2-SNAPSHOT
 func IsAlias(version string) bool {
 	aliasMatch := versionAliasRegex.FindStringSubmatch(version)
@@ -372,7 +424,7 @@ func FetchProjectBinaryForSnapshots(ctx context.Context, useCISnapshots bool, pr
 		span.Context.SetLabel("project", project)
 		defer span.End()
 
-		if val, ok := binariesCache[URL]; ok {
+		if val, ok := cachedBinary(URL); ok {
 			log.WithFields(log.Fields{
 				"URL":  URL,
 				"path": val,
@@ -380,14 +432,15 @@ func FetchProjectBinaryForSnapshots(ctx context.Context, useCISnapshots bool, pr
 			return val, nil
 		}
 
+		if strings.HasSuffix(URL, ".sha512") {
+			name = fmt.Sprintf("%s.sha512", name)
+		}
+
 		err := utils.DownloadFile(&downloadRequest)
 		if err != nil {
 			return downloadRequest.UnsanitizedFilePath, err
 		}
 
-		if strings.HasSuffix(URL, ".sha512") {
-			name = fmt.Sprintf("%s.sha512", name)
-		}
 		// use artifact name as file name to avoid having URL params in the name
 		sanitizedFilePath := filepath.Join(path.Dir(downloadRequest.UnsanitizedFilePath), name)
 		err = os.Rename(downloadRequest.UnsanitizedFilePath, sanitizedFilePath)
@@ -399,11 +452,38 @@ func FetchProjectBinaryForSnapshots(ctx context.Context, useCISnapshots bool, pr
 			sanitizedFilePath = downloadRequest.UnsanitizedFilePath
 		}
 
-		binariesCache[URL] = sanitizedFilePath
+		cacheBinary(URL, sanitizedFilePath)
 
 		return sanitizedFilePath, nil
 	}
 
+	// the binary and its checksum are downloaded at the same time, or to the artifacts cache, which identifies
+	// the binaries by their checksums. It returns the location of the binary and of its checksum
+	handleDownloadWithChecksum := func(URL string, shaURL string) (string, string, error) {
+		location, found := cachedBinary(URL)
+		shaLocation, shaFound := cachedBinary(shaURL)
+		if found && shaFound {
+			return location, shaLocation, nil
+		}
+
+		if ArtifactsCacheDir != "" {
+			location, shaLocation, err := downloadToCache(URL, artifactName, shaURL)
+			if err != nil {
+				return "", "", err
+			}
+
+			cacheBinary(URL, location)
+			cacheBinary(shaURL, shaLocation)
+			return location, shaLocation, nil
+		}
+
+		locations, err := fetchInParallel([]string{URL, shaURL}, handleDownload)
+		if err != nil {
+			return "", "", err
+		}
+		return locations[0], locations[1], nil
+	}
+
 	var downloadURL, downloadShaURL string
 	var err error
 
@@ -437,11 +517,10 @@ func FetchProjectBinaryForSnapshots(ctx context.Context, useCISnapshots bool, pr
 		if err != nil {
 			return "", err
 		}
-		downloadLocation, err := handleDownload(downloadURL)
 
 		// check if sha file should be downloaded, else return
 		if !downloadSHAFile {
-			return downloadLocation, err
+			return handleDownload(downloadURL)
 		}
 
 		sha512ArtifactName := fmt.Sprintf("%s.sha512", artifactName)
@@ -453,11 +532,13 @@ func FetchProjectBinaryForSnapshots(ctx context.Context, useCISnapshots bool, pr
 			NewBeatsLegacyURLResolver(artifact, sha512ArtifactName, variant),
 		}
 
-		downloadURL, err = getObjectURLFromResolvers(sha512Resolvers, maxTimeout)
+		downloadShaURL, err = getObjectURLFromResolvers(sha512Resolvers, maxTimeout)
 		if err != nil {
 			return "", err
 		}
-		return handleDownload(downloadURL)
+
+		_, shaLocation, err := handleDownloadWithChecksum(downloadURL, downloadShaURL)
+		return shaLocation, err
 	}
 
 	elasticAgentNamespace := project
@@ -474,14 +555,20 @@ func FetchProjectBinaryForSnapshots(ctx context.Context, useCISnapshots bool, pr
 	if err != nil {
 		return "", err
 	}
-	downloadLocation, err := handleDownload(downloadURL)
+	// the checksum is only needed by the artifacts cache when it is not requested
+	if downloadShaURL == "" || (!downloadSHAFile && ArtifactsCacheDir == "") {
+		return handleDownload(downloadURL)
+	}
+
+	location, shaLocation, err := handleDownloadWithChecksum(downloadURL, downloadShaURL)
 	if err != nil {
 		return "", err
 	}
-	if downloadSHAFile && downloadShaURL != "" {
-		downloadLocation, err = handleDownload(downloadShaURL)
+
+	if !downloadSHAFile {
+		return location, nil
 	}
-	return downloadLocation, err
+	return shaLocation, nil
 }
 
 func getBucketSearchNextPageParam(jsonParsed *gabs.Container) string {
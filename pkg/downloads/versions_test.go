@@ -604,6 +604,38 @@ func Test_GetVersion(t *testing.T) {
 	})
 }
 
+func Test_CompareVersions(t *testing.T) {
+	t.Run("Missing parts are zero", func(t *testing.T) {
+		assert.Equal(t, 0, CompareVersions("7.10", "7.10.0"))
+	})
+
+	t.Run("Lower version", func(t *testing.T) {
+		assert.Equal(t, -1, CompareVersions("7.9.3", "7.10"))
+	})
+
+	t.Run("Greater version with snapshot", func(t *testing.T) {
+		assert.Equal(t, 1, CompareVersions("8.10.0-SNAPSHOT", "8.9"))
+	})
+}
+
+func Test_IsVersionAtLeast(t *testing.T) {
+	t.Run("Same version", func(t *testing.T) {
+		assert.True(t, IsVersionAtLeast("8.11.0", "8.11.0"))
+	})
+
+	t.Run("Greater version with git commit", func(t *testing.T) {
+		assert.True(t, IsVersionAtLeast("8.12.0-abcdef-SNAPSHOT", "8.11.0"))
+	})
+
+	t.Run("Greater version compares numbers, not strings", func(t *testing.T) {
+		assert.True(t, IsVersionAtLeast("8.11.0", "8.9.0"))
+	})
+
+	t.Run("Lower version with snapshot", func(t *testing.T) {
+		assert.False(t, IsVersionAtLeast("8.6.0-SNAPSHOT", "8.11.0"))
+	})
+}
+
 func TestProcessBucketSearchPage_CommitFound(t *testing.T) {
 	// retrieving last element in commits.json
 	object := "024b732844d40bdb2bf806480af2b03fcb8fbdbe/elastic-agent/" + versionPrefix + "-darwin-x86_64.tar.gz"